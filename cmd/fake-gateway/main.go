@@ -0,0 +1,54 @@
+// fake-gateway will post a signed payment gateway notification to the callback endpoint of a running loan-svc.
+//
+//	go run ./cmd/fake-gateway -secret local-gateway-secret -reference 8808123456789072 -amount 1916666.67
+package main
+
+import (
+	"context"
+	"crypto/ed25519"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/gunawanwijaya/loan-svc/internal/feature/gateway"
+	"github.com/gunawanwijaya/loan-svc/pkg"
+	"github.com/rs/xid"
+)
+
+func main() {
+	var (
+		url        = flag.String("url", "http://0.0.0.0:8080/gateway/callback", "callback endpoint of loan-svc")
+		secret     = flag.String("secret", "", "shared secret of HMAC-SHA256 signature")
+		privateKey = flag.String("private-key", "", "base64 ed25519 private key of the gateway")
+		n          gateway.Notification
+	)
+	flag.StringVar(&n.TransactionID, "id", xid.New().String(), "gateway transaction ID, repeat to simulate a retry")
+	flag.StringVar(&n.Status, "status", "succeeded", "pending, succeeded or failed")
+	flag.StringVar(&n.PaymentReference, "reference", "", "payment reference of the loan")
+	flag.StringVar(&n.Currency, "currency", "IDR", "ISO 4217 currency code")
+	flag.Float64Var(&n.Amount, "amount", 0, "amount paid")
+	flag.StringVar(&n.Payer, "payer", "", "name of the payer")
+	flag.Parse()
+	if *secret == "" && *privateKey == "" {
+		fmt.Fprintln(os.Stderr, "either -secret or -private-key is required")
+		os.Exit(2)
+	}
+	if n.Status == "succeeded" {
+		n.PaidAt = time.Now().UTC().Truncate(time.Second)
+	}
+
+	fake := gateway.Fake{Secret: []byte(*secret), PrivateKey: ed25519.PrivateKey(pkg.AtoB(*privateKey))}
+	res, err := fake.Notify(context.Background(), *url, n)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	defer res.Body.Close()
+	fmt.Println(res.Status)
+	_, _ = io.Copy(os.Stdout, res.Body)
+	if res.StatusCode/100 != 2 {
+		os.Exit(1)
+	}
+}
@@ -2,8 +2,6 @@ package main
 
 import (
 	"context"
-	"crypto/ed25519"
-	"crypto/rand"
 	"database/sql"
 	"embed"
 	"errors"
@@ -15,8 +13,18 @@ import (
 	"time"
 
 	"github.com/goccy/go-yaml"
+	"github.com/gunawanwijaya/loan-svc/internal/feature/contract"
+	"github.com/gunawanwijaya/loan-svc/internal/feature/document"
+	"github.com/gunawanwijaya/loan-svc/internal/feature/gateway"
 	"github.com/gunawanwijaya/loan-svc/internal/feature/loan"
+	"github.com/gunawanwijaya/loan-svc/internal/feature/outbox"
+	"github.com/gunawanwijaya/loan-svc/internal/feature/payout"
+	"github.com/gunawanwijaya/loan-svc/internal/feature/provision"
+	"github.com/gunawanwijaya/loan-svc/internal/feature/reconciliation"
+	"github.com/gunawanwijaya/loan-svc/internal/feature/wallet"
+	"github.com/gunawanwijaya/loan-svc/internal/feature/webhook"
 	"github.com/gunawanwijaya/loan-svc/internal/repository/datastore"
+	"github.com/gunawanwijaya/loan-svc/internal/repository/keyring"
 	"github.com/gunawanwijaya/loan-svc/internal/service/rest"
 	"github.com/gunawanwijaya/loan-svc/pkg"
 	"github.com/rs/zerolog"
@@ -53,10 +61,20 @@ var (
 
 type Config struct {
 	Feature struct {
-		Loan loan.Configuration `json:"loan"`
+		Loan           loan.Configuration           `json:"loan"`
+		Provision      provision.Configuration      `json:"provision"`
+		Wallet         wallet.Configuration         `json:"wallet"`
+		Reconciliation reconciliation.Configuration `json:"reconciliation"`
+		Payout         payout.Configuration         `json:"payout"`
+		Gateway        gateway.Configuration        `json:"gateway"`
+		Outbox         outbox.Configuration         `json:"outbox"`
+		Webhook        webhook.Configuration        `json:"webhook"`
+		Contract       contract.Configuration       `json:"contract"`
+		Document       document.Configuration       `json:"document"`
 	} `json:"feature"`
 	Repository struct {
 		Datastore datastore.Configuration `json:"datastore"`
+		Keyring   keyring.Configuration   `json:"keyring"`
 	} `json:"repository"`
 	Service struct {
 		REST rest.Configuration `json:"rest"`
@@ -69,6 +87,13 @@ type Secret struct {
 			DSN string `json:"dsn"`
 		} `json:"local"`
 	} `json:"database"`
+	Gateway struct {
+		Secret    string `json:"secret"`     // shared secret of HMAC-SHA256 signature
+		PublicKey string `json:"public_key"` // base64 ed25519 public key of the gateway
+	} `json:"gateway"`
+	Keyring struct {
+		Seeds []string `json:"seeds"` // base64 ed25519 seeds, used when there is no key file
+	} `json:"keyring"`
 }
 
 func main() {
@@ -114,28 +139,169 @@ func main() {
 	pkg.Must(yaml.Unmarshal(configBytes, &config))
 	// log.DebugContext(ctx, "validated", slog.Any("config", config))
 
+	// the secret sealing the key file is only taken from the environment, never committed along with the secret file
+	keyringSecret := pkg.AtoB(os.Getenv("LOAN_SVC_KEYRING_SECRET"))
+	if len(keyringSecret) < 1 {
+		log.ErrorContext(ctx, "empty or malformed $LOAN_SVC_KEYRING_SECRET, a base64 AES-256 key")
+		os.Exit(1)
+	}
+
 	dbSQLite3 := pkg.Must1(sql.Open("sqlite3", secret.Database.Local.DSN))
 	gracefully(func() {
 		dbSQLite3.Close()
 		log.DebugContext(ctx, "gracefully db.Close")
 	})
 
-	pub, key := pkg.Must2(ed25519.GenerateKey(rand.Reader))
+	seeds := make([][]byte, len(secret.Keyring.Seeds))
+	for i, seed := range secret.Keyring.Seeds {
+		seeds[i] = pkg.AtoB(seed)
+	}
+	repoKeyring := pkg.Must1(keyring.New(ctx, config.Repository.Keyring, keyring.Dependency{
+		Secret: keyringSecret,
+		Seeds:  seeds,
+	}))
+
+	keyringCtx, keyringCancel := context.WithCancel(ctx)
+	gracefully(func() {
+		keyringCancel()
+		log.DebugContext(ctx, "gracefully keyring.Cancel")
+	})
+	go repoKeyring.Run(keyringCtx) // rotate the signing key on schedule, retired keys are kept for verification
 
 	repoDatastore := pkg.Must1(datastore.New(ctx, config.Repository.Datastore, datastore.Dependency{
 		DB: struct{ SQLite3 *sql.DB }{
 			SQLite3: dbSQLite3,
 		},
-		PublicKey:  pub,
-		PrivateKey: key,
+		Keyring: repoKeyring,
+	}))
+
+	featDocument := pkg.Must1(document.New(ctx, config.Feature.Document, document.Dependency{
+		Datastore: repoDatastore,
 	}))
 
 	featLoan := pkg.Must1(loan.New(ctx, config.Feature.Loan, loan.Dependency{
 		Datastore: repoDatastore,
+		Keyring:   repoKeyring,
+		Document:  featDocument,
+	}))
+
+	featProvision := pkg.Must1(provision.New(ctx, config.Feature.Provision, provision.Dependency{
+		Datastore: repoDatastore,
+	}))
+
+	featWallet := pkg.Must1(wallet.New(ctx, config.Feature.Wallet, wallet.Dependency{
+		Datastore: repoDatastore,
+	}))
+
+	featReconciliation := pkg.Must1(reconciliation.New(ctx, config.Feature.Reconciliation, reconciliation.Dependency{
+		Datastore: repoDatastore,
+	}))
+
+	featPayout := pkg.Must1(payout.New(ctx, config.Feature.Payout, payout.Dependency{
+		Datastore: repoDatastore,
+	}))
+
+	featGateway := pkg.Must1(gateway.New(ctx, config.Feature.Gateway, gateway.Dependency{
+		Datastore:      repoDatastore,
+		Reconciliation: featReconciliation,
+		Secret:         []byte(secret.Gateway.Secret),
+		PublicKey:      pkg.AtoB(secret.Gateway.PublicKey),
 	}))
 
+	featWebhook := pkg.Must1(webhook.New(ctx, config.Feature.Webhook, webhook.Dependency{
+		Datastore: repoDatastore,
+	}))
+
+	featContract := pkg.Must1(contract.New(ctx, config.Feature.Contract, contract.Dependency{
+		Datastore: repoDatastore,
+	}))
+
+	publisher := outbox.NewInProcess()
+	if config.Feature.Outbox.File != "" {
+		file := pkg.Must1(outbox.NewFile(config.Feature.Outbox.File))
+		gracefully(func() {
+			file.Close()
+			log.DebugContext(ctx, "gracefully outbox.Close")
+		})
+		publisher.Subscribe(file.Publish)
+	} else {
+		publisher.Subscribe(func(ctx context.Context, e outbox.Event) error {
+			log.InfoContext(ctx, "outbox", slog.Int64("sequence", e.Sequence), slog.String("event_type", e.EventType))
+			return nil
+		})
+	}
+	publisher.Subscribe(featWebhook.Enqueue) // a delivery for each matching subscription, posted by the deliverer
+
+	featOutbox := pkg.Must1(outbox.New(ctx, config.Feature.Outbox, outbox.Dependency{
+		Datastore: repoDatastore,
+		Publisher: publisher,
+	}))
+
+	outboxCtx, outboxCancel := context.WithCancel(ctx)
+	gracefully(func() {
+		outboxCancel()
+		log.DebugContext(ctx, "gracefully outbox.Cancel")
+	})
+	go featOutbox.Run(outboxCtx) // relay the domain events written by the mutations, at least once
+
+	webhookCtx, webhookCancel := context.WithCancel(ctx)
+	gracefully(func() {
+		webhookCancel()
+		log.DebugContext(ctx, "gracefully webhook.Cancel")
+	})
+	go featWebhook.Run(webhookCtx) // post the due deliveries, retried with exponential backoff until dead
+
+	accrualCtx, accrualCancel := context.WithCancel(ctx)
+	gracefully(func() {
+		accrualCancel()
+		log.DebugContext(ctx, "gracefully accrual.Cancel")
+	})
+	go func() { // record the daily accrual up to the previous day, backfilling the days missed while stopped
+		tick := time.NewTicker(24 * time.Hour)
+		defer tick.Stop()
+		for {
+			asOf := time.Now().UTC().AddDate(0, 0, -1)
+			if _, err := featLoan.Accrual(accrualCtx, loan.AccrualRequest{AsOf: &asOf, Record: true, Backfill: true}); err != nil {
+				log.ErrorContext(ctx, "accrual", slog.Any("err", err))
+			}
+			select {
+			case <-accrualCtx.Done():
+				return
+			case <-tick.C:
+			}
+		}
+	}()
+
+	checkpointCtx, checkpointCancel := context.WithCancel(ctx)
+	gracefully(func() {
+		checkpointCancel()
+		log.DebugContext(ctx, "gracefully checkpoint.Cancel")
+	})
+	go func() { // sign the head of the hash chains daily, a chain that has not moved is skipped
+		tick := time.NewTicker(24 * time.Hour)
+		defer tick.Stop()
+		for {
+			if _, err := featLoan.Checkpoint(checkpointCtx, loan.CheckpointRequest{}); err != nil {
+				log.ErrorContext(ctx, "checkpoint", slog.Any("err", err))
+			}
+			select {
+			case <-checkpointCtx.Done():
+				return
+			case <-tick.C:
+			}
+		}
+	}()
+
 	svcREST := pkg.Must1(rest.New(ctx, config.Service.REST, rest.Dependency{
-		Loan: featLoan,
+		Loan:           featLoan,
+		Provision:      featProvision,
+		Wallet:         featWallet,
+		Reconciliation: featReconciliation,
+		Payout:         featPayout,
+		Gateway:        featGateway,
+		Webhook:        featWebhook,
+		Contract:       featContract,
+		Document:       featDocument,
 	}))
 
 	scheme := "http://"
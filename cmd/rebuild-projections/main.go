@@ -0,0 +1,73 @@
+// rebuild-projections will replace the loan tables of the datastore by folding the events of each loan, loans
+// projected before the events were recorded are imported as their first event. Run it while the service is stopped.
+//
+//	LOAN_SVC_KEYRING_SECRET=<base64 secret> go run ./cmd/rebuild-projections -dsn file:./local.db -keyring ./local.keyring
+//	LOAN_SVC_KEYRING_SECRET=<base64 secret> go run ./cmd/rebuild-projections -dsn file:./local.db -keyring ./local.keyring -loan <base64 loan_id>
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"log/slog"
+	"os"
+
+	"github.com/gunawanwijaya/loan-svc/internal/feature/loan"
+	"github.com/gunawanwijaya/loan-svc/internal/repository/datastore"
+	"github.com/gunawanwijaya/loan-svc/internal/repository/keyring"
+	"github.com/gunawanwijaya/loan-svc/pkg"
+)
+
+func main() {
+	var (
+		dsn         = flag.String("dsn", "file:./local.db", "data source name of the datastore")
+		keyringFile = flag.String("keyring", "", "key file of the service sealed by $LOAN_SVC_KEYRING_SECRET, required to sign what is written")
+		loanID      = flag.String("loan", "", "base64 loan_id to be rebuilt, all loans when empty")
+	)
+	flag.Parse()
+	if *keyringFile == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	log := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelInfo}))
+	ctx := pkg.Context.PutSlogLogger(context.Background(), log)
+
+	dbSQLite3 := pkg.Must1(sql.Open("sqlite3", *dsn))
+	defer dbSQLite3.Close()
+
+	// a key file created here would sign by a key the service never verifies
+	if _, err := os.Stat(*keyringFile); err != nil {
+		log.ErrorContext(ctx, "rebuild-projections", slog.Any("err", err))
+		os.Exit(1)
+	}
+	repoKeyring := pkg.Must1(keyring.New(ctx, keyring.Configuration{File: *keyringFile}, keyring.Dependency{
+		Secret: pkg.AtoB(os.Getenv("LOAN_SVC_KEYRING_SECRET")),
+	}))
+
+	repoDatastore := pkg.Must1(datastore.New(ctx, datastore.Configuration{}, datastore.Dependency{
+		DB: struct{ SQLite3 *sql.DB }{
+			SQLite3: dbSQLite3,
+		},
+		Keyring: repoKeyring,
+	}))
+
+	featLoan := pkg.Must1(loan.New(ctx, loan.Configuration{}, loan.Dependency{
+		Datastore: repoDatastore,
+	}))
+
+	req := loan.RebuildRequest{}
+	if *loanID != "" {
+		req.LoanID = pkg.AtoB(*loanID)
+	}
+	res, err := featLoan.Rebuild(ctx, req)
+	if err != nil {
+		log.ErrorContext(ctx, "rebuild-projections", slog.Any("err", err))
+		os.Exit(1)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	pkg.Must(enc.Encode(map[string]any{"rebuild": res}))
+}
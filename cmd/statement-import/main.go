@@ -0,0 +1,96 @@
+// statement-import will import bank statements (CSV, MT940 or CAMT.053) into the settled transactions of the
+// datastore, optionally reconciled afterwards.
+//
+//	LOAN_SVC_KEYRING_SECRET=<base64 secret> go run ./cmd/statement-import -dsn file:./local.db -keyring ./local.keyring \
+//		-reconcile statement-20250102.sta statement-20250102.xml
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/gunawanwijaya/loan-svc/internal/feature/reconciliation"
+	"github.com/gunawanwijaya/loan-svc/internal/repository/datastore"
+	"github.com/gunawanwijaya/loan-svc/internal/repository/keyring"
+	"github.com/gunawanwijaya/loan-svc/pkg"
+)
+
+func main() {
+	var (
+		dsn         = flag.String("dsn", "file:./local.db", "data source name of the datastore")
+		keyringFile = flag.String("keyring", "", "key file of the service sealed by $LOAN_SVC_KEYRING_SECRET, required to sign what is written")
+		format      = flag.String("format", "", "statement format of csv, mt940 or camt053, detected from the content when empty")
+		reconcile   = flag.Bool("reconcile", false, "reconcile the unmatched transactions after import")
+		cfg         reconciliation.Configuration
+	)
+	flag.Float64Var(&cfg.AmountTolerance, "amount-tolerance", 1, "absolute difference of amount still considered as match")
+	flag.IntVar(&cfg.DateTolerance, "date-tolerance", 3, "days between value date & due date still considered as match")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] statement...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 || *keyringFile == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	log := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelInfo}))
+	ctx := pkg.Context.PutSlogLogger(context.Background(), log)
+
+	dbSQLite3 := pkg.Must1(sql.Open("sqlite3", *dsn))
+	defer dbSQLite3.Close()
+
+	// a key file created here would sign by a key the service never verifies
+	if _, err := os.Stat(*keyringFile); err != nil {
+		log.ErrorContext(ctx, "statement-import", slog.Any("err", err))
+		os.Exit(1)
+	}
+	repoKeyring := pkg.Must1(keyring.New(ctx, keyring.Configuration{File: *keyringFile}, keyring.Dependency{
+		Secret: pkg.AtoB(os.Getenv("LOAN_SVC_KEYRING_SECRET")),
+	}))
+
+	repoDatastore := pkg.Must1(datastore.New(ctx, datastore.Configuration{}, datastore.Dependency{
+		DB: struct{ SQLite3 *sql.DB }{
+			SQLite3: dbSQLite3,
+		},
+		Keyring: repoKeyring,
+	}))
+
+	featReconciliation := pkg.Must1(reconciliation.New(ctx, cfg, reconciliation.Dependency{
+		Datastore: repoDatastore,
+	}))
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	failed := false
+	for _, name := range flag.Args() {
+		statement, err := os.ReadFile(name)
+		if err == nil {
+			var res reconciliation.ImportResponse
+			if res, err = featReconciliation.Import(ctx, reconciliation.ImportRequest{Format: *format, Statement: statement}); err == nil {
+				pkg.Must(enc.Encode(map[string]any{"statement": name, "res": res}))
+				continue
+			}
+		}
+		log.ErrorContext(ctx, "statement-import", slog.String("statement", name), slog.Any("err", err))
+		failed = true
+	}
+
+	if *reconcile {
+		res, err := featReconciliation.Reconcile(ctx, reconciliation.ReconcileRequest{})
+		if err != nil {
+			log.ErrorContext(ctx, "statement-import", slog.Any("err", err))
+			os.Exit(1)
+		}
+		pkg.Must(enc.Encode(map[string]any{"reconciliation": res}))
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
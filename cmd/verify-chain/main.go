@@ -0,0 +1,74 @@
+// verify-chain will walk the hash chains of the payments & of the loan events, then check each signed checkpoint
+// against the chain, reporting the first broken link of each chain & exiting with 1 when any is found. A checkpoint
+// is verified by the key file, or by the trusted keys for those signed before the keyring.
+//
+//	LOAN_SVC_KEYRING_SECRET=<base64 secret> go run ./cmd/verify-chain -dsn file:./local.db -keyring ./local.keyring
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/gunawanwijaya/loan-svc/internal/repository/datastore"
+	"github.com/gunawanwijaya/loan-svc/internal/repository/keyring"
+	"github.com/gunawanwijaya/loan-svc/pkg"
+)
+
+func main() {
+	var (
+		dsn         = flag.String("dsn", "file:./local.db", "data source name of the datastore")
+		keyringFile = flag.String("keyring", "", "key file of the service sealed by $LOAN_SVC_KEYRING_SECRET, verifying the checkpoints")
+		trusted     = flag.String("trusted", "", "comma separated base64 ed25519 public keys trusted to sign the checkpoints")
+		cfg         datastore.Configuration
+	)
+	flag.Parse()
+	if *trusted != "" {
+		cfg.TrustedKeys = strings.Split(*trusted, ",")
+	}
+
+	log := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelInfo}))
+	ctx := pkg.Context.PutSlogLogger(context.Background(), log)
+
+	dbSQLite3 := pkg.Must1(sql.Open("sqlite3", *dsn))
+	defer dbSQLite3.Close()
+
+	// signs nothing, an ephemeral key is never found on the checkpoints
+	repoKeyring := pkg.Must1(keyring.New(ctx, keyring.Configuration{File: *keyringFile}, keyring.Dependency{
+		Secret: pkg.AtoB(os.Getenv("LOAN_SVC_KEYRING_SECRET")),
+	}))
+
+	repoDatastore := pkg.Must1(datastore.New(ctx, cfg, datastore.Dependency{
+		DB: struct{ SQLite3 *sql.DB }{
+			SQLite3: dbSQLite3,
+		},
+		Keyring: repoKeyring,
+	}))
+
+	res, err := repoDatastore.Query(ctx, datastore.QueryRequest{
+		Chains: &datastore.QueryRequestChains{},
+	})
+	if err != nil {
+		log.ErrorContext(ctx, "verify-chain", slog.Any("err", err))
+		os.Exit(1)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	for _, r := range res.List {
+		link := r.Chains.BrokenLink
+		pkg.Must(enc.Encode(map[string]any{
+			"chain":    link.Chain,
+			"sequence": link.Sequence,
+			"id":       link.ID,
+			"reason":   link.Reason,
+		}))
+	}
+	pkg.Must(enc.Encode(map[string]any{"scanned": res.Chains.Scanned, "checkpoints": res.Chains.Checkpoints, "broken": len(res.List)}))
+	if len(res.List) > 0 {
+		os.Exit(1)
+	}
+}
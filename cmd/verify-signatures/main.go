@@ -0,0 +1,82 @@
+// verify-signatures will scan every signature stored in the datastore & report the rows signed by an unknown key,
+// unsigned or whose signature is invalid, exiting with 1 when any is found. The key IDs are resolved by the key file,
+// signatures made before the keyring carry their public key to be found in the key file or trusted.
+//
+//	LOAN_SVC_KEYRING_SECRET=<base64 secret> go run ./cmd/verify-signatures -dsn file:./local.db -keyring ./local.keyring
+//	go run ./cmd/verify-signatures -dsn file:./local.db -trusted <base64 public key>,<base64 public key>
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/gunawanwijaya/loan-svc/internal/repository/datastore"
+	"github.com/gunawanwijaya/loan-svc/internal/repository/keyring"
+	"github.com/gunawanwijaya/loan-svc/pkg"
+)
+
+func main() {
+	var (
+		dsn         = flag.String("dsn", "file:./local.db", "data source name of the datastore")
+		keyringFile = flag.String("keyring", "", "key file of the service sealed by $LOAN_SVC_KEYRING_SECRET, resolving the key ID of the rows")
+		trusted     = flag.String("trusted", "", "comma separated base64 ed25519 public keys trusted to sign the rows")
+		all         = flag.Bool("all", false, "report the valid signatures as well")
+		cfg         datastore.Configuration
+	)
+	flag.Parse()
+	if *trusted != "" {
+		cfg.TrustedKeys = strings.Split(*trusted, ",")
+	}
+
+	log := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelInfo}))
+	ctx := pkg.Context.PutSlogLogger(context.Background(), log)
+
+	dbSQLite3 := pkg.Must1(sql.Open("sqlite3", *dsn))
+	defer dbSQLite3.Close()
+
+	// signs nothing, an ephemeral key is never found on the rows
+	repoKeyring := pkg.Must1(keyring.New(ctx, keyring.Configuration{File: *keyringFile}, keyring.Dependency{
+		Secret: pkg.AtoB(os.Getenv("LOAN_SVC_KEYRING_SECRET")),
+	}))
+
+	repoDatastore := pkg.Must1(datastore.New(ctx, cfg, datastore.Dependency{
+		DB: struct{ SQLite3 *sql.DB }{
+			SQLite3: dbSQLite3,
+		},
+		Keyring: repoKeyring,
+	}))
+
+	res, err := repoDatastore.Query(ctx, datastore.QueryRequest{
+		Signatures: &datastore.QueryRequestSignatures{WithValid: *all},
+	})
+	if err != nil {
+		log.ErrorContext(ctx, "verify-signatures", slog.Any("err", err))
+		os.Exit(1)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	found := map[string]int64{}
+	for _, r := range res.List {
+		row := r.Signatures.SignedRow
+		found[row.Verification.String()]++
+		pkg.Must(enc.Encode(map[string]any{
+			"table":        row.Table,
+			"rowid":        row.RowID,
+			"column":       row.Column,
+			"signed_at":    row.At,
+			"sign_version": row.SignVersion.String(),
+			"key_id":       row.KeyID,
+			"public_key":   row.PublicKey,
+			"verification": row.Verification.String(),
+		}))
+	}
+	pkg.Must(enc.Encode(map[string]any{"scanned": res.Signatures.Scanned, "found": found}))
+	if int64(len(res.List)) > found[datastore.SignatureValid.String()] {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,118 @@
+//go:generate mockgen -destination contract_mock.go -package contract . Contract
+package contract
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gunawanwijaya/loan-svc/internal/repository/datastore"
+	"github.com/gunawanwijaya/loan-svc/pkg"
+)
+
+type Configuration struct {
+	//
+}
+type Dependency struct {
+	datastore.Datastore
+}
+type Contract interface {
+	RegisterKey(ctx context.Context, req RegisterKeyRequest) (res KeyResponse, err error)
+	Render(ctx context.Context, req RenderRequest) (res ContractResponse, err error)
+	RevokeKey(ctx context.Context, req RevokeKeyRequest) (res KeyResponse, err error)
+	Sign(ctx context.Context, req SignRequest) (res ContractResponse, err error)
+	View(ctx context.Context, req ViewRequest) (res ContractResponse, err error)
+}
+
+func New(ctx context.Context, cfg Configuration, dep Dependency) (_ Contract, err error) {
+	cfg, err = pkg.AsValidator(cfg).Validate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	dep, err = pkg.AsValidator(dep).Validate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &contract{cfg, dep}, nil
+}
+
+type contract struct {
+	Configuration
+	Dependency
+}
+
+// ErrForbidden is returned when the authenticated actor is not the user of the request.
+var ErrForbidden = errors.New("feature/contract: actor is not the user")
+
+// authorize will require the authenticated actor to be the user, identified by the base64 of its user_id as written
+// by the API.
+func authorize(ctx context.Context, userID []byte) error {
+	if actor := pkg.Context.Actor(ctx); actor == "" || actor != pkg.BtoA(userID) {
+		return ErrForbidden
+	}
+	return nil
+}
+
+func (cfg Configuration) Validate(ctx context.Context) (_ Configuration, err error) {
+	return cfg, nil
+}
+
+func (dep Dependency) Validate(ctx context.Context) (_ Dependency, err error) {
+	if dep.Datastore == nil {
+		return dep, fmt.Errorf("feature/contract: uninitialized repository/datastore")
+	}
+	return dep, nil
+}
+
+// ContractResponse is a single contract, multiple contracts are returned in List.
+type ContractResponse struct {
+	List []ContractResponse `json:"list,omitempty"`
+
+	ContractID []byte              `json:"contract_id,omitempty"`
+	LoanID     []byte              `json:"loan_id,omitempty"`
+	UserID     []byte              `json:"user_id,omitempty"` // the party expected to sign
+	RoleAs     string              `json:"role_as,omitempty"`
+	Document   string              `json:"document,omitempty"`
+	Hash       []byte              `json:"hash,omitempty"` // SHA-256 of Document, the message to be signed
+	CreatedAt  *time.Time          `json:"created_at,omitempty"`
+	Signatures []SignatureResponse `json:"signatures,omitempty"`
+}
+
+type SignatureResponse struct {
+	KeyID     []byte     `json:"key_id,omitempty"`
+	Signature []byte     `json:"signature,omitempty"`
+	SignedAt  *time.Time `json:"signed_at,omitempty"`
+	Verified  bool       `json:"verified"` // by an unrevoked key of the party over the hash of the document as stored
+}
+
+// Signed will tell if the contract is signed by the party with a verified signature.
+func (x ContractResponse) Signed() bool {
+	for _, s := range x.Signatures {
+		if s.Verified {
+			return true
+		}
+	}
+	return false
+}
+
+func fromContract(c datastore.Contract) (res ContractResponse) {
+	res = ContractResponse{
+		ContractID: c.ContractID,
+		LoanID:     c.LoanID,
+		UserID:     c.UserID,
+		RoleAs:     c.LoanPartyRoleAs.String(),
+		Document:   string(c.Document),
+		Hash:       c.Hash,
+		CreatedAt:  pkg.Ptr(time.Unix(c.CreatedAt, 0)),
+	}
+	for _, s := range c.Signatures {
+		res.Signatures = append(res.Signatures, SignatureResponse{
+			KeyID:     s.KeyID,
+			Signature: s.Signature,
+			SignedAt:  pkg.Ptr(time.Unix(s.CreatedAt, 0)),
+			Verified:  s.Verified,
+		})
+	}
+	return
+}
@@ -0,0 +1,167 @@
+package contract_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"log/slog"
+	"testing"
+
+	"github.com/gunawanwijaya/loan-svc/internal/feature/contract"
+	"github.com/gunawanwijaya/loan-svc/internal/repository/datastore"
+	"github.com/gunawanwijaya/loan-svc/internal/repository/keyring"
+	"github.com/gunawanwijaya/loan-svc/pkg"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestContract(t *testing.T) {
+	loanID, borrowerID, lenderID := []byte("1234"), []byte("5555"), []byte("1111")
+	// the borrower is the authenticated actor
+	ctx := pkg.Context.PutActor(pkg.Context.PutSlogLogger(context.Background(), slog.Default()), pkg.BtoA(borrowerID))
+	ctrl := gomock.NewController(t)
+	mockDatastore := datastore.NewMockDatastore(ctrl)
+
+	_, err := contract.New(ctx, contract.Configuration{}, contract.Dependency{})
+	require.Error(t, err)
+
+	featContract, err := contract.New(ctx, contract.Configuration{}, contract.Dependency{
+		Datastore: mockDatastore,
+	})
+	require.NoError(t, err)
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	keyID := keyring.KeyID(pub)
+
+	{
+		mockDatastore.EXPECT().
+			Mutation(ctx, gomock.Any()).
+			DoAndReturn(func(ctx context.Context, req datastore.MutationRequest) (datastore.MutationResponse, error) {
+				require.Equal(t, keyID, req.UserKeys.KeyID)
+				require.False(t, req.UserKeys.Revoke)
+				return datastore.MutationResponse{UserKeys: &datastore.MutationResponseUserKeys{UserKey: req.UserKeys.UserKey}}, nil
+			})
+	}
+	resKey, err := featContract.RegisterKey(ctx, contract.RegisterKeyRequest{UserID: borrowerID, PublicKey: pub})
+	require.NoError(t, err)
+	require.Equal(t, keyID, resKey.KeyID)
+
+	_, err = featContract.RegisterKey(ctx, contract.RegisterKeyRequest{UserID: borrowerID, PublicKey: pub[:16]})
+	require.ErrorContains(t, err, "invalid public_key")
+
+	// the key of another user, or without an authenticated actor
+	_, err = featContract.RegisterKey(ctx, contract.RegisterKeyRequest{UserID: lenderID, PublicKey: pub})
+	require.ErrorIs(t, err, contract.ErrForbidden)
+	_, err = featContract.RegisterKey(context.Background(), contract.RegisterKeyRequest{UserID: borrowerID, PublicKey: pub})
+	require.ErrorIs(t, err, contract.ErrForbidden)
+	_, err = featContract.RevokeKey(ctx, contract.RevokeKeyRequest{UserID: lenderID, KeyID: keyID})
+	require.ErrorIs(t, err, contract.ErrForbidden)
+
+	l := datastore.Loan{
+		LoanID:    loanID,
+		LoanState: datastore.StateInvested,
+		APR:       pkg.Ptr(0.12),
+		Parties: []datastore.LoanParty{{
+			UserID:          borrowerID,
+			LoanPartyRoleAs: datastore.RoleAsBorrower,
+			Payments: []datastore.LoanPartyPayment{
+				{PaymentType: datastore.PaymentPrincipalDisbursement, ISO4217: "IDR", Amount: -10_000_000.00},
+				{PaymentType: datastore.PaymentInstallment, ISO4217: "IDR", Amount: 11_500_000.00},
+			},
+		}, {
+			UserID:          lenderID,
+			LoanPartyRoleAs: datastore.RoleAsLender,
+		}},
+	}
+	var rendered datastore.Contract
+	{
+		mockDatastore.EXPECT().
+			Query(ctx, datastore.QueryRequest{Loans: &datastore.QueryRequestLoans{ByLoanID: loanID}}).
+			Return(datastore.QueryResponse{Loans: &datastore.QueryResponseLoans{Loan: l}}, nil).
+			Times(2)
+		mockDatastore.EXPECT().
+			Query(ctx, datastore.QueryRequest{Contracts: &datastore.QueryRequestContracts{ByLoanID: loanID}}).
+			DoAndReturn(func(ctx context.Context, req datastore.QueryRequest) (res datastore.QueryResponse, err error) {
+				if rendered.ContractID != nil {
+					res.List = append(res.List, datastore.QueryResponse{Contracts: &datastore.QueryResponseContracts{Contract: rendered}})
+				}
+				return
+			}).
+			Times(2)
+		mockDatastore.EXPECT().
+			Mutation(ctx, gomock.Any()).
+			DoAndReturn(func(ctx context.Context, req datastore.MutationRequest) (datastore.MutationResponse, error) {
+				c := req.Contracts.Contract
+				require.Equal(t, borrowerID, c.UserID)
+				require.Equal(t, datastore.RoleAsBorrower, c.LoanPartyRoleAs)
+				hash := sha256.Sum256(c.Document)
+				require.Equal(t, hash[:], c.Hash)
+				rendered = *c
+				return datastore.MutationResponse{Contracts: &datastore.MutationResponseContracts{Contract: c}}, nil
+			})
+	}
+	resRender, err := featContract.Render(ctx, contract.RenderRequest{LoanID: loanID, UserID: borrowerID})
+	require.NoError(t, err)
+	require.Contains(t, resRender.Document, "LOAN AGREEMENT (BORROWER)")
+	require.Contains(t, resRender.Document, "12.00%")
+	require.Contains(t, resRender.Document, "installment")
+
+	// the same loan renders the same contract
+	resRender2, err := featContract.Render(ctx, contract.RenderRequest{LoanID: loanID, UserID: borrowerID})
+	require.NoError(t, err)
+	require.Equal(t, resRender.ContractID, resRender2.ContractID)
+
+	{
+		mockDatastore.EXPECT().
+			Query(ctx, datastore.QueryRequest{Loans: &datastore.QueryRequestLoans{ByLoanID: loanID}}).
+			Return(datastore.QueryResponse{Loans: &datastore.QueryResponseLoans{Loan: l}}, nil)
+	}
+	_, err = featContract.Render(ctx, contract.RenderRequest{LoanID: loanID, UserID: []byte("9999")})
+	require.ErrorContains(t, err, "user is not a party of the loan")
+
+	byContractID := datastore.QueryRequest{Contracts: &datastore.QueryRequestContracts{ByContractID: rendered.ContractID}}
+	byKeyID := datastore.QueryRequest{UserKeys: &datastore.QueryRequestUserKeys{ByKeyID: keyID}}
+	{
+		mockDatastore.EXPECT().
+			Query(gomock.Any(), byContractID).
+			Return(datastore.QueryResponse{List: []datastore.QueryResponse{{Contracts: &datastore.QueryResponseContracts{Contract: rendered}}}}, nil).
+			Times(3)
+		mockDatastore.EXPECT().
+			Query(ctx, byKeyID).
+			Return(datastore.QueryResponse{List: []datastore.QueryResponse{{UserKeys: &datastore.QueryResponseUserKeys{UserKey: datastore.UserKey{
+				KeyID:     keyID,
+				UserID:    borrowerID,
+				PublicKey: pub,
+			}}}}}, nil).
+			Times(2)
+		mockDatastore.EXPECT().
+			Mutation(ctx, gomock.Any()).
+			DoAndReturn(func(ctx context.Context, req datastore.MutationRequest) (datastore.MutationResponse, error) {
+				require.Nil(t, req.Contracts.Contract)
+				require.Equal(t, keyID, req.Contracts.Signature.KeyID)
+				return datastore.MutationResponse{Contracts: &datastore.MutationResponseContracts{Signature: req.Contracts.Signature}}, nil
+			})
+	}
+	sign := contract.SignRequest{ContractID: rendered.ContractID, UserID: borrowerID, KeyID: keyID}
+
+	// signed over another document
+	sign.Signature = ed25519.Sign(priv, []byte("another document"))
+	_, err = featContract.Sign(ctx, sign)
+	require.ErrorContains(t, err, "invalid signature")
+
+	// signed by another party
+	lenderCtx := pkg.Context.PutActor(ctx, pkg.BtoA(lenderID))
+	_, err = featContract.Sign(lenderCtx, contract.SignRequest{ContractID: rendered.ContractID, UserID: lenderID, KeyID: keyID, Signature: sign.Signature})
+	require.ErrorContains(t, err, "user is not the party of the contract")
+
+	// submitted on behalf of the party by another actor
+	_, err = featContract.Sign(lenderCtx, sign)
+	require.ErrorIs(t, err, contract.ErrForbidden)
+
+	sign.Signature = ed25519.Sign(priv, resRender.Hash)
+	resSign, err := featContract.Sign(ctx, sign)
+	require.NoError(t, err)
+	require.True(t, resSign.Signed())
+}
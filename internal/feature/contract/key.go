@@ -0,0 +1,109 @@
+package contract
+
+import (
+	"context"
+	"crypto/ed25519"
+	"fmt"
+	"time"
+
+	"github.com/gunawanwijaya/loan-svc/internal/repository/datastore"
+	"github.com/gunawanwijaya/loan-svc/internal/repository/keyring"
+	"github.com/gunawanwijaya/loan-svc/pkg"
+)
+
+// KeyResponse is a key registered by the user.
+type KeyResponse struct {
+	KeyID     []byte     `json:"key_id,omitempty"`
+	UserID    []byte     `json:"user_id,omitempty"`
+	PublicKey []byte     `json:"public_key,omitempty"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+func fromUserKey(k datastore.UserKey) KeyResponse {
+	res := KeyResponse{
+		KeyID:     k.KeyID,
+		UserID:    k.UserID,
+		PublicKey: k.PublicKey,
+		CreatedAt: pkg.Ptr(time.Unix(k.CreatedAt, 0)),
+	}
+	if k.RevokedAt != nil {
+		res.RevokedAt = pkg.Ptr(time.Unix(*k.RevokedAt, 0))
+	}
+	return res
+}
+
+type RegisterKeyRequest struct {
+	UserID    []byte `json:"user_id,omitempty"`
+	PublicKey []byte `json:"public_key,omitempty"` // ed25519
+}
+
+func (x RegisterKeyRequest) Validate(ctx context.Context) (_ RegisterKeyRequest, err error) {
+	if len(x.UserID) < 1 {
+		return x, fmt.Errorf("invalid user_id")
+	}
+	if len(x.PublicKey) != ed25519.PublicKeySize {
+		return x, fmt.Errorf("invalid public_key")
+	}
+	return x, nil
+}
+
+// RegisterKey will register the ed25519 public key of the user to sign the contracts, the key ID is derived from
+// the public key. Only the user registers its own keys.
+func (x *contract) RegisterKey(ctx context.Context, req RegisterKeyRequest) (res KeyResponse, err error) {
+	if req, err = pkg.AsValidator(req).Validate(ctx); err != nil {
+		return
+	}
+	if err = authorize(ctx, req.UserID); err != nil {
+		return
+	}
+	var mut datastore.MutationResponse
+	mut, err = x.Dependency.Datastore.Mutation(ctx, datastore.MutationRequest{
+		UserKeys: &datastore.MutationRequestUserKeys{UserKey: datastore.UserKey{
+			KeyID:     keyring.KeyID(req.PublicKey),
+			UserID:    req.UserID,
+			PublicKey: req.PublicKey,
+		}},
+	})
+	if err == nil {
+		res = fromUserKey(mut.UserKeys.UserKey)
+	}
+	return
+}
+
+type RevokeKeyRequest struct {
+	UserID []byte `json:"user_id,omitempty"`
+	KeyID  []byte `json:"key_id,omitempty"`
+}
+
+func (x RevokeKeyRequest) Validate(ctx context.Context) (_ RevokeKeyRequest, err error) {
+	if len(x.UserID) < 1 {
+		return x, fmt.Errorf("invalid user_id")
+	}
+	if len(x.KeyID) < 1 {
+		return x, fmt.Errorf("invalid key_id")
+	}
+	return x, nil
+}
+
+// RevokeKey will revoke the key of the user, the signatures accepted beforehand stay valid. Only the user revokes its
+// own keys.
+func (x *contract) RevokeKey(ctx context.Context, req RevokeKeyRequest) (res KeyResponse, err error) {
+	if req, err = pkg.AsValidator(req).Validate(ctx); err != nil {
+		return
+	}
+	if err = authorize(ctx, req.UserID); err != nil {
+		return
+	}
+	var mut datastore.MutationResponse
+	mut, err = x.Dependency.Datastore.Mutation(ctx, datastore.MutationRequest{
+		UserKeys: &datastore.MutationRequestUserKeys{
+			UserKey: datastore.UserKey{KeyID: req.KeyID, UserID: req.UserID},
+			Revoke:  true,
+		},
+	})
+	if err == nil {
+		res = fromUserKey(mut.UserKeys.UserKey)
+	}
+	return
+}
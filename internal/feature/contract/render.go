@@ -0,0 +1,134 @@
+package contract
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/gunawanwijaya/loan-svc/internal/feature/document"
+	"github.com/gunawanwijaya/loan-svc/internal/repository/datastore"
+	"github.com/gunawanwijaya/loan-svc/pkg"
+	"github.com/rs/xid"
+)
+
+type RenderRequest struct {
+	LoanID []byte `json:"loan_id,omitempty"`
+	UserID []byte `json:"user_id,omitempty"` // either the borrower or a lender of the loan
+}
+
+func (x RenderRequest) Validate(ctx context.Context) (_ RenderRequest, err error) {
+	if len(x.LoanID) < 1 {
+		return x, fmt.Errorf("invalid loan_id")
+	}
+	if len(x.UserID) < 1 {
+		return x, fmt.Errorf("invalid user_id")
+	}
+	return x, nil
+}
+
+// Render will render the contract of the party from the loan as stored by the latest template of its agreement, the
+// contract already rendered is returned as long as the document is unchanged.
+func (x *contract) Render(ctx context.Context, req RenderRequest) (res ContractResponse, err error) {
+	if req, err = pkg.AsValidator(req).Validate(ctx); err != nil {
+		return
+	}
+	var qry datastore.QueryResponse
+	qry, err = x.Dependency.Datastore.Query(ctx, datastore.QueryRequest{
+		Loans: &datastore.QueryRequestLoans{ByLoanID: req.LoanID},
+	})
+	if err != nil {
+		return
+	}
+	if len(qry.List) == 1 {
+		qry = qry.List[0]
+	}
+	if qry.Loans == nil {
+		err = fmt.Errorf("loan is not found")
+		return
+	}
+	l := qry.Loans.Loan
+	if l.LoanState == datastore.StateDisbursed {
+		err = fmt.Errorf("expected state from [proposed approved invested]")
+		return
+	}
+	var party *datastore.LoanParty
+	for i := range l.Parties {
+		if bytes.Equal(l.Parties[i].UserID, req.UserID) {
+			party = &l.Parties[i]
+		}
+	}
+	if party == nil {
+		err = fmt.Errorf("user is not a party of the loan")
+		return
+	}
+
+	doc, hash, err := Document(l, *party)
+	if err != nil {
+		return
+	}
+	if qry, err = x.Dependency.Datastore.Query(ctx, datastore.QueryRequest{
+		Contracts: &datastore.QueryRequestContracts{ByLoanID: l.LoanID},
+	}); err != nil {
+		return
+	}
+	for _, qry := range qry.List {
+		if c := qry.Contracts.Contract; bytes.Equal(c.UserID, party.UserID) && bytes.Equal(c.Hash, hash) {
+			return fromContract(c), nil
+		}
+	}
+
+	var mut datastore.MutationResponse
+	mut, err = x.Dependency.Datastore.Mutation(ctx, datastore.MutationRequest{
+		Contracts: &datastore.MutationRequestContracts{Contract: &datastore.Contract{
+			ContractID:      xid.New().Bytes(),
+			LoanID:          l.LoanID,
+			UserID:          party.UserID,
+			LoanPartyRoleAs: party.LoanPartyRoleAs,
+			Document:        doc,
+			Hash:            hash,
+		}},
+	})
+	if err == nil {
+		res = fromContract(*mut.Contracts.Contract)
+	}
+	return
+}
+
+// Document will render the contract of the party from the loan by the latest template of its agreement, along with
+// the SHA-256 of the document being signed by the party.
+func Document(l datastore.Loan, party datastore.LoanParty) (doc, hash []byte, err error) {
+	var r document.Rendered
+	if r, err = document.Render(document.KindOf(party.LoanPartyRoleAs), 0, l, party); err != nil {
+		return
+	}
+	h := sha256.Sum256(r.Text)
+	return r.Text, h[:], nil
+}
+
+type ViewRequest struct {
+	LoanID     []byte `json:"loan_id,omitempty"`
+	ContractID []byte `json:"contract_id,omitempty"`
+}
+
+// View will list the contracts of the loan, or the contract of the ID, along with the verification of each signature.
+func (x *contract) View(ctx context.Context, req ViewRequest) (res ContractResponse, err error) {
+	if len(req.LoanID) < 1 && len(req.ContractID) < 1 {
+		err = fmt.Errorf("invalid loan_id")
+		return
+	}
+	var qry datastore.QueryResponse
+	qry, err = x.Dependency.Datastore.Query(ctx, datastore.QueryRequest{
+		Contracts: &datastore.QueryRequestContracts{ByLoanID: req.LoanID, ByContractID: req.ContractID},
+	})
+	if err != nil {
+		return
+	}
+	for _, qry := range qry.List {
+		res.List = append(res.List, fromContract(qry.Contracts.Contract))
+	}
+	if len(res.List) == 1 {
+		res = res.List[0]
+	}
+	return
+}
@@ -0,0 +1,94 @@
+package contract
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"fmt"
+
+	"github.com/gunawanwijaya/loan-svc/internal/repository/datastore"
+	"github.com/gunawanwijaya/loan-svc/pkg"
+)
+
+type SignRequest struct {
+	ContractID []byte `json:"contract_id,omitempty"`
+	UserID     []byte `json:"user_id,omitempty"`
+	KeyID      []byte `json:"key_id,omitempty"`    // a registered key of the user
+	Signature  []byte `json:"signature,omitempty"` // ed25519 signature of the hash of the contract
+}
+
+func (x SignRequest) Validate(ctx context.Context) (_ SignRequest, err error) {
+	if len(x.ContractID) < 1 {
+		return x, fmt.Errorf("invalid contract_id")
+	}
+	if len(x.UserID) < 1 {
+		return x, fmt.Errorf("invalid user_id")
+	}
+	if len(x.KeyID) < 1 {
+		return x, fmt.Errorf("invalid key_id")
+	}
+	if len(x.Signature) != ed25519.SignatureSize {
+		return x, fmt.Errorf("invalid signature")
+	}
+	return x, nil
+}
+
+// Sign will accept the signature of the party over the hash of the contract, made by an unrevoked key of the party
+// & submitted by the party itself.
+func (x *contract) Sign(ctx context.Context, req SignRequest) (res ContractResponse, err error) {
+	if req, err = pkg.AsValidator(req).Validate(ctx); err != nil {
+		return
+	}
+	if err = authorize(ctx, req.UserID); err != nil {
+		return
+	}
+	var qry datastore.QueryResponse
+	if qry, err = x.Dependency.Datastore.Query(ctx, datastore.QueryRequest{
+		Contracts: &datastore.QueryRequestContracts{ByContractID: req.ContractID},
+	}); err != nil {
+		return
+	}
+	if len(qry.List) == 1 {
+		qry = qry.List[0]
+	}
+	if qry.Contracts == nil {
+		err = fmt.Errorf("contract is not found")
+		return
+	}
+	c := qry.Contracts.Contract
+	if !bytes.Equal(c.UserID, req.UserID) {
+		err = fmt.Errorf("user is not the party of the contract")
+		return
+	}
+
+	if qry, err = x.Dependency.Datastore.Query(ctx, datastore.QueryRequest{
+		UserKeys: &datastore.QueryRequestUserKeys{ByKeyID: req.KeyID},
+	}); err != nil {
+		return
+	}
+	if len(qry.List) == 1 {
+		qry = qry.List[0]
+	}
+	if qry.UserKeys == nil || !bytes.Equal(qry.UserKeys.UserID, req.UserID) || qry.UserKeys.RevokedAt != nil {
+		err = fmt.Errorf("key is not registered by the user")
+		return
+	}
+	if !ed25519.Verify(qry.UserKeys.PublicKey, c.Hash, req.Signature) {
+		err = fmt.Errorf("invalid signature")
+		return
+	}
+
+	var mut datastore.MutationResponse
+	if mut, err = x.Dependency.Datastore.Mutation(ctx, datastore.MutationRequest{
+		Contracts: &datastore.MutationRequestContracts{Signature: &datastore.ContractSignature{
+			ContractID: c.ContractID,
+			KeyID:      req.KeyID,
+			Signature:  req.Signature,
+		}},
+	}); err != nil {
+		return
+	}
+	mut.Contracts.Signature.Verified = true
+	c.Signatures = append(c.Signatures, *mut.Contracts.Signature)
+	return fromContract(c), nil
+}
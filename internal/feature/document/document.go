@@ -0,0 +1,99 @@
+//go:generate mockgen -destination document_mock.go -package document . Document
+package document
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/gunawanwijaya/loan-svc/internal/repository/datastore"
+	"github.com/gunawanwijaya/loan-svc/pkg"
+)
+
+type Configuration struct {
+	//
+}
+type Dependency struct {
+	datastore.Datastore
+}
+type Document interface {
+	Generate(ctx context.Context, req GenerateRequest) (res DocumentResponse, err error)
+	Get(ctx context.Context, req GetRequest) (res DocumentResponse, err error)
+	List(ctx context.Context, req ListRequest) (res DocumentResponse, err error)
+}
+
+func New(ctx context.Context, cfg Configuration, dep Dependency) (_ Document, err error) {
+	cfg, err = pkg.AsValidator(cfg).Validate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	dep, err = pkg.AsValidator(dep).Validate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &document{cfg, dep}, nil
+}
+
+type document struct {
+	Configuration
+	Dependency
+}
+
+func (cfg Configuration) Validate(ctx context.Context) (_ Configuration, err error) {
+	return cfg, nil
+}
+
+func (dep Dependency) Validate(ctx context.Context) (_ Dependency, err error) {
+	if dep.Datastore == nil {
+		return dep, fmt.Errorf("feature/document: uninitialized repository/datastore")
+	}
+	return dep, nil
+}
+
+// Link will return the path serving the document of the hash.
+func Link(hash []byte) string {
+	return "/document/" + hex.EncodeToString(hash)
+}
+
+// DocumentResponse is a single document, multiple documents are returned in List.
+type DocumentResponse struct {
+	List []DocumentResponse `json:"list,omitempty"`
+
+	Hash            []byte     `json:"hash,omitempty"` // SHA-256 of the content
+	LoanID          []byte     `json:"loan_id,omitempty"`
+	UserID          []byte     `json:"user_id,omitempty"`
+	Kind            Kind       `json:"kind,omitempty"`
+	TemplateVersion int        `json:"template_version,omitempty"`
+	MediaType       string     `json:"media_type,omitempty"`
+	Link            string     `json:"link,omitempty"`
+	Content         []byte     `json:"-"` // served as is by its link
+	CreatedAt       *time.Time `json:"created_at,omitempty"`
+}
+
+// Find will find the document of the kind & media type among the list.
+func (x DocumentResponse) Find(kind Kind, mediaType string) (res DocumentResponse, ok bool) {
+	if x.Kind == kind && x.MediaType == mediaType {
+		return x, true
+	}
+	for _, d := range x.List {
+		if d.Kind == kind && d.MediaType == mediaType {
+			return d, true
+		}
+	}
+	return res, false
+}
+
+func fromDocument(d datastore.Document) DocumentResponse {
+	return DocumentResponse{
+		Hash:            d.Hash,
+		LoanID:          d.LoanID,
+		UserID:          d.UserID,
+		Kind:            Kind(d.Kind),
+		TemplateVersion: d.TemplateVersion,
+		MediaType:       d.MediaType,
+		Link:            Link(d.Hash),
+		Content:         d.Content,
+		CreatedAt:       pkg.Ptr(time.Unix(d.CreatedAt, 0)),
+	}
+}
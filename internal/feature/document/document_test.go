@@ -0,0 +1,98 @@
+package document_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/gunawanwijaya/loan-svc/internal/feature/document"
+	"github.com/gunawanwijaya/loan-svc/internal/repository/datastore"
+	"github.com/gunawanwijaya/loan-svc/pkg"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestDocument(t *testing.T) {
+	ctx := pkg.Context.PutSlogLogger(context.Background(), slog.Default())
+	ctrl := gomock.NewController(t)
+	mockDatastore := datastore.NewMockDatastore(ctrl)
+
+	_, err := document.New(ctx, document.Configuration{}, document.Dependency{})
+	require.Error(t, err)
+
+	featDocument, err := document.New(ctx, document.Configuration{}, document.Dependency{
+		Datastore: mockDatastore,
+	})
+	require.NoError(t, err)
+
+	loanID, borrowerID, lenderID := []byte("1234"), []byte("5555"), []byte("1111")
+	due := time.Date(2024, 11, 30, 0, 0, 0, 0, time.UTC).Unix()
+	l := datastore.Loan{
+		LoanID:           loanID,
+		LoanState:        datastore.StateInvested,
+		APR:              pkg.Ptr(0.12),
+		PaymentReference: pkg.Ptr("000000000195"),
+		Parties: []datastore.LoanParty{{
+			UserID:          borrowerID,
+			LoanPartyRoleAs: datastore.RoleAsBorrower,
+			Payments: []datastore.LoanPartyPayment{
+				{PaymentType: datastore.PaymentPrincipalDisbursement, ISO4217: "IDR", Amount: -10_000_000.00, Time: due},
+				{PaymentType: datastore.PaymentInstallment, ISO4217: "IDR", Amount: 5_750_000.00, Time: due},
+				{PaymentType: datastore.PaymentInstallment, ISO4217: "IDR", Amount: 5_750_000.00, Time: due},
+			},
+		}, {
+			UserID:          lenderID,
+			LoanPartyRoleAs: datastore.RoleAsLender,
+			Payments: []datastore.LoanPartyPayment{
+				{PaymentType: datastore.PaymentInvestment, ISO4217: "IDR", Amount: 10_000_000.00, Time: due},
+			},
+		}},
+	}
+
+	r, err := document.Render(document.KindRepaymentSchedule, 0, l, l.Parties[0])
+	require.NoError(t, err)
+	require.Equal(t, document.Latest(document.KindRepaymentSchedule), r.Version)
+	require.Contains(t, string(r.Text), "Total             : IDR 11500000")
+	require.NotContains(t, string(r.Text), "principal_disbursement")
+	require.Contains(t, string(r.HTML), "<td>000000000195</td>")
+	r2, err := document.Render(document.KindRepaymentSchedule, r.Version, l, l.Parties[0])
+	require.NoError(t, err)
+	require.Equal(t, r, r2)
+	require.Equal(t, r.PDF(), r2.PDF())
+
+	_, err = document.Render(document.KindRepaymentSchedule, 999, l, l.Parties[0])
+	require.ErrorContains(t, err, "unknown template")
+
+	// the party is escaped in the HTML
+	r, err = document.Render(document.KindLenderAgreement, 0, l, datastore.LoanParty{UserID: []byte("<>?"), LoanPartyRoleAs: datastore.RoleAsLender})
+	require.NoError(t, err)
+	require.Contains(t, string(r.HTML), "PD4/")
+
+	{
+		mockDatastore.EXPECT().
+			Query(ctx, datastore.QueryRequest{Loans: &datastore.QueryRequestLoans{ByLoanID: loanID}}).
+			Return(datastore.QueryResponse{Loans: &datastore.QueryResponseLoans{Loan: l}}, nil)
+		mockDatastore.EXPECT().
+			Mutation(ctx, gomock.Any()).
+			DoAndReturn(func(ctx context.Context, req datastore.MutationRequest) (datastore.MutationResponse, error) {
+				require.Len(t, req.Documents.List, 6) // agreement & schedule of the borrower, agreement of the lender
+				for _, d := range req.Documents.List {
+					hash := sha256.Sum256(d.Content)
+					require.Equal(t, hash[:], d.Hash)
+				}
+				return datastore.MutationResponse{Documents: &datastore.MutationResponseDocuments{List: req.Documents.List}}, nil
+			})
+	}
+	res, err := featDocument.Generate(ctx, document.GenerateRequest{LoanID: loanID})
+	require.NoError(t, err)
+	pdf, ok := res.Find(document.KindLenderAgreement, document.MediaTypePDF)
+	require.True(t, ok)
+	require.Equal(t, lenderID, pdf.UserID)
+	require.Equal(t, document.Link(pdf.Hash), pdf.Link)
+	require.Contains(t, string(pdf.Content), "(LOAN AGREEMENT \\(LENDER\\)) '")
+
+	_, err = featDocument.Get(ctx, document.GetRequest{Hash: []byte("short")})
+	require.ErrorContains(t, err, "invalid hash")
+}
@@ -0,0 +1,132 @@
+package document
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/gunawanwijaya/loan-svc/internal/repository/datastore"
+)
+
+type GenerateRequest struct {
+	LoanID []byte `json:"loan_id,omitempty"`
+}
+
+// Generate will render the documents of the loan by the latest templates, as HTML & PDF: the agreement of each
+// party & the repayment schedule of the borrower. The documents are stored by their hash, a loan rendered again
+// unchanged gives the documents already stored.
+func (x *document) Generate(ctx context.Context, req GenerateRequest) (res DocumentResponse, err error) {
+	if len(req.LoanID) < 1 {
+		err = fmt.Errorf("invalid loan_id")
+		return
+	}
+	var qry datastore.QueryResponse
+	qry, err = x.Dependency.Datastore.Query(ctx, datastore.QueryRequest{
+		Loans: &datastore.QueryRequestLoans{ByLoanID: req.LoanID},
+	})
+	if err != nil {
+		return
+	}
+	if len(qry.List) == 1 {
+		qry = qry.List[0]
+	}
+	if qry.Loans == nil {
+		err = fmt.Errorf("loan is not found")
+		return
+	}
+	l := qry.Loans.Loan
+
+	docs := []datastore.Document{}
+	for _, party := range l.Parties {
+		kinds := []Kind{KindOf(party.LoanPartyRoleAs)}
+		if party.LoanPartyRoleAs == datastore.RoleAsBorrower {
+			kinds = append(kinds, KindRepaymentSchedule)
+		}
+		for _, kind := range kinds {
+			var r Rendered
+			if r, err = Render(kind, 0, l, party); err != nil {
+				return
+			}
+			for _, c := range []struct {
+				mediaType string
+				content   []byte
+			}{{MediaTypeHTML, r.HTML}, {MediaTypePDF, r.PDF()}} {
+				mediaType, content := c.mediaType, c.content
+				hash := sha256.Sum256(content)
+				docs = append(docs, datastore.Document{
+					Hash:            hash[:],
+					LoanID:          l.LoanID,
+					UserID:          party.UserID,
+					Kind:            string(kind),
+					TemplateVersion: r.Version,
+					MediaType:       mediaType,
+					Content:         content,
+				})
+			}
+		}
+	}
+
+	var mut datastore.MutationResponse
+	mut, err = x.Dependency.Datastore.Mutation(ctx, datastore.MutationRequest{
+		Documents: &datastore.MutationRequestDocuments{List: docs},
+	})
+	if err != nil {
+		return
+	}
+	for _, d := range mut.Documents.List {
+		res.List = append(res.List, fromDocument(d))
+	}
+	return
+}
+
+type GetRequest struct {
+	Hash []byte `json:"hash,omitempty"`
+}
+
+// Get will find the document of the hash along with its content.
+func (x *document) Get(ctx context.Context, req GetRequest) (res DocumentResponse, err error) {
+	if len(req.Hash) != sha256.Size {
+		err = fmt.Errorf("invalid hash")
+		return
+	}
+	var qry datastore.QueryResponse
+	qry, err = x.Dependency.Datastore.Query(ctx, datastore.QueryRequest{
+		Documents: &datastore.QueryRequestDocuments{ByHash: req.Hash},
+	})
+	if err != nil {
+		return
+	}
+	if len(qry.List) == 1 {
+		qry = qry.List[0]
+	}
+	if qry.Documents == nil {
+		err = fmt.Errorf("document is not found")
+		return
+	}
+	return fromDocument(qry.Documents.Document), nil
+}
+
+type ListRequest struct {
+	LoanID []byte `json:"loan_id,omitempty"`
+}
+
+// List will list the documents stored for the loan, without their content.
+func (x *document) List(ctx context.Context, req ListRequest) (res DocumentResponse, err error) {
+	if len(req.LoanID) < 1 {
+		err = fmt.Errorf("invalid loan_id")
+		return
+	}
+	var qry datastore.QueryResponse
+	qry, err = x.Dependency.Datastore.Query(ctx, datastore.QueryRequest{
+		Documents: &datastore.QueryRequestDocuments{ByLoanID: req.LoanID},
+	})
+	if err != nil {
+		return
+	}
+	for _, qry := range qry.List {
+		d := fromDocument(qry.Documents.Document)
+		d.Content = nil
+		res.List = append(res.List, d)
+	}
+	return
+}
@@ -0,0 +1,155 @@
+package document
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"regexp"
+	"strconv"
+	"text/template"
+	"time"
+
+	"github.com/gunawanwijaya/loan-svc/internal/repository/datastore"
+	"github.com/gunawanwijaya/loan-svc/pkg"
+)
+
+type Kind string
+
+const (
+	KindBorrowerAgreement Kind = "borrower_agreement"
+	KindLenderAgreement   Kind = "lender_agreement"
+	KindRepaymentSchedule Kind = "repayment_schedule"
+)
+
+const (
+	MediaTypeHTML = "text/html; charset=utf-8"
+	MediaTypePDF  = "application/pdf"
+)
+
+// templates are named <kind>.v<version>.<html|txt>.tmpl, a new version is added as a new file & the previous
+// versions are kept to render the documents again as they were.
+//
+//go:embed templates/*.tmpl
+var templatesFS embed.FS
+
+type templateVersion struct {
+	HTML *htmltemplate.Template
+	Text *template.Template // typeset as PDF, also the plain text being signed as contract
+}
+
+var templates = pkg.Must1(parseTemplates(templatesFS))
+
+func parseTemplates(fsys fs.FS) (map[Kind]map[int]*templateVersion, error) {
+	funcs := map[string]any{
+		"id":   pkg.BtoA,
+		"date": func(t int64) string { return time.Unix(t, 0).UTC().Format(time.DateOnly) },
+		"rate": func(r *float64) string {
+			if r == nil {
+				return "-"
+			}
+			return strconv.FormatFloat(*r*100, 'f', 2, 64) + "%"
+		},
+		"amount": func(a float64) string { return strconv.FormatFloat(a, 'f', -1, 64) },
+		"total": func(payments []datastore.LoanPartyPayment) map[string]float64 {
+			total := map[string]float64{}
+			for _, p := range payments {
+				if p.Amount > 0 {
+					total[p.ISO4217] += p.Amount
+				}
+			}
+			return total
+		},
+	}
+	re := regexp.MustCompile(`^(\w+)\.v(\d+)\.(html|txt)\.tmpl$`)
+	names, err := fs.Glob(fsys, "templates/*.tmpl")
+	if err != nil {
+		return nil, err
+	}
+	res := map[Kind]map[int]*templateVersion{}
+	for _, name := range names {
+		m := re.FindStringSubmatch(name[len("templates/"):])
+		if m == nil {
+			return nil, fmt.Errorf("feature/document: unexpected template %s", name)
+		}
+		kind, version := Kind(m[1]), pkg.Must1(strconv.Atoi(m[2]))
+		if res[kind] == nil {
+			res[kind] = map[int]*templateVersion{}
+		}
+		if res[kind][version] == nil {
+			res[kind][version] = &templateVersion{}
+		}
+		b, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		if m[3] == "html" {
+			res[kind][version].HTML, err = htmltemplate.New(name).Funcs(funcs).Parse(string(b))
+		} else {
+			res[kind][version].Text, err = template.New(name).Funcs(funcs).Parse(string(b))
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	for kind, versions := range res {
+		for version, t := range versions {
+			if t.HTML == nil || t.Text == nil {
+				return nil, fmt.Errorf("feature/document: incomplete template %s v%d", kind, version)
+			}
+		}
+	}
+	return res, nil
+}
+
+// Latest will return the latest version of the template of the kind, 0 when unknown.
+func Latest(kind Kind) (version int) {
+	for v := range templates[kind] {
+		version = max(version, v)
+	}
+	return version
+}
+
+// Rendered is a document rendered from a version of the template.
+type Rendered struct {
+	Kind    Kind
+	Version int
+	HTML    []byte
+	Text    []byte
+}
+
+// PDF will typeset the plain text of the document.
+func (x Rendered) PDF() []byte {
+	return pkg.PDF(string(x.Kind)+" v"+strconv.Itoa(x.Version), string(x.Text))
+}
+
+// Render will render the document of the kind for the party of the loan, by the latest template when version is 0.
+// The same loan always renders the same document.
+func Render(kind Kind, version int, l datastore.Loan, party datastore.LoanParty) (res Rendered, err error) {
+	version = pkg.OrElse(version > 0, version, Latest(kind))
+	t, ok := templates[kind][version]
+	if !ok {
+		return res, fmt.Errorf("unknown template %s v%d", kind, version)
+	}
+	data := struct {
+		Kind    Kind
+		Version int
+		Loan    datastore.Loan
+		Party   datastore.LoanParty
+	}{kind, version, l, party}
+
+	html, text := &bytes.Buffer{}, &bytes.Buffer{}
+	if err = t.HTML.Execute(html, data); err != nil {
+		return
+	}
+	if err = t.Text.Execute(text, data); err != nil {
+		return
+	}
+	return Rendered{Kind: kind, Version: version, HTML: html.Bytes(), Text: text.Bytes()}, nil
+}
+
+// KindOf will tell the agreement of the role.
+func KindOf(role datastore.LoanPartyRoleAs) Kind {
+	return pkg.OrElse(role == datastore.RoleAsLender, KindLenderAgreement, KindBorrowerAgreement)
+}
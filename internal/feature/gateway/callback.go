@@ -0,0 +1,164 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/gunawanwijaya/loan-svc/internal/feature/reconciliation"
+	"github.com/gunawanwijaya/loan-svc/internal/repository/datastore"
+	"github.com/gunawanwijaya/loan-svc/pkg"
+	"github.com/rs/xid"
+)
+
+const sourceGateway = "gateway"
+
+// Notification is the payload posted by the payment gateway, the payment reference is the one given to the
+// borrower upon proposal.
+type Notification struct {
+	TransactionID    string    `json:"transaction_id,omitempty"`
+	Status           string    `json:"status,omitempty"` // pending, succeeded or failed
+	PaymentReference string    `json:"payment_reference,omitempty"`
+	Currency         string    `json:"currency,omitempty"`
+	Amount           float64   `json:"amount,omitempty"`
+	PaidAt           time.Time `json:"paid_at,omitempty"` // required once succeeded
+	Payer            string    `json:"payer,omitempty"`
+}
+
+func (x Notification) Validate(ctx context.Context) (_ Notification, err error) {
+	if x.TransactionID == "" {
+		return x, fmt.Errorf("invalid transaction_id")
+	}
+	status, err := parseStatus(x.Status)
+	if err != nil {
+		return x, err
+	}
+	amount, err := (&pkg.Money{ISO4217: x.Currency, Amount: x.Amount}).Validate(ctx)
+	if err != nil {
+		return x, err
+	}
+	if !pkg.ValidPaymentReference(x.PaymentReference) {
+		return x, fmt.Errorf("invalid payment_reference of [%s]", x.TransactionID)
+	}
+	if amount.Amount <= 0 {
+		return x, fmt.Errorf("invalid amount of [%s]", x.TransactionID)
+	}
+	if status == datastore.GatewaySucceeded && x.PaidAt.IsZero() {
+		return x, fmt.Errorf("invalid paid_at of [%s]", x.TransactionID)
+	}
+	x.Amount = amount.Amount
+	return x, nil
+}
+
+type CallbackRequest struct {
+	Payload   []byte `json:"payload,omitempty"`   // raw body as signed by the gateway
+	Signature string `json:"signature,omitempty"` // value of SignatureHeader
+}
+
+type CallbackResponse struct {
+	Notification Notification             `json:"notification"`
+	Duplicate    bool                     `json:"duplicate"` // already recorded, nothing is changed
+	Matched      []reconciliation.Matched `json:"matched,omitempty"`
+}
+
+// Callback will verify & record the notification once per gateway transaction ID & status. A succeeded payment is
+// imported as a settled transaction & reconciled before being recorded, so that a failed reconciliation is retried
+// by the gateway while a repeated notification is never counted twice.
+//
+// the error is ErrInvalidSignature, ErrInvalidNotification or ErrUnknownReference when retrying the same
+// notification never succeed, any other error is worth retrying.
+func (x *gateway) Callback(ctx context.Context, req CallbackRequest) (res CallbackResponse, err error) {
+	log := pkg.Context.SlogLogger(ctx)
+	if err = x.verify(req.Payload, req.Signature); err != nil {
+		return
+	}
+	if err = json.Unmarshal(req.Payload, &res.Notification); err != nil {
+		err = fmt.Errorf("%w: %w", ErrInvalidNotification, err)
+		return
+	}
+	if res.Notification, err = res.Notification.Validate(ctx); err != nil {
+		err = fmt.Errorf("%w: %w", ErrInvalidNotification, err)
+		return
+	}
+	// legacy references backfilled by the migration are not known by the gateway, those are only reconciled from
+	// the bank statements
+	if !x.Configuration.PaymentReference.Issued(res.Notification.PaymentReference) {
+		err = fmt.Errorf("%w: legacy payment_reference of [%s]", ErrInvalidNotification, res.Notification.TransactionID)
+		return
+	}
+	n := res.Notification
+	status, _ := parseStatus(n.Status)
+
+	var qry datastore.QueryResponse
+	qry, err = x.Dependency.Datastore.Query(ctx, datastore.QueryRequest{
+		Loans: &datastore.QueryRequestLoans{ByPaymentReference: n.PaymentReference},
+	})
+	if err != nil {
+		return
+	}
+	if len(qry.List) < 1 {
+		err = fmt.Errorf("%w [%s]", ErrUnknownReference, n.PaymentReference)
+		return
+	}
+
+	if status == datastore.GatewaySucceeded {
+		var rec reconciliation.ReportResponse
+		rec, err = x.Dependency.Reconciliation.Reconcile(ctx, reconciliation.ReconcileRequest{
+			Source: sourceGateway,
+			Transactions: []reconciliation.Transaction{{
+				BankReference: n.TransactionID,
+				Reference:     n.PaymentReference,
+				Counterparty:  n.Payer,
+				Amount:        &pkg.Money{ISO4217: n.Currency, Amount: n.Amount, Time: n.PaidAt},
+			}},
+			ReportRequest: reconciliation.ReportRequest{From: &n.PaidAt},
+		})
+		if err != nil {
+			return
+		}
+		for _, m := range rec.Matched {
+			if m.BankReference == n.TransactionID {
+				res.Matched = append(res.Matched, m)
+			}
+		}
+	}
+
+	var mut datastore.MutationResponse
+	mut, err = x.Dependency.Datastore.Mutation(ctx, datastore.MutationRequest{
+		Callbacks: &datastore.MutationRequestCallbacks{List: []datastore.GatewayCallback{{
+			CallbackID:           xid.New().Bytes(),
+			GatewayTransactionID: n.TransactionID,
+			GatewayStatus:        status,
+			Reference:            n.PaymentReference,
+			ISO4217:              n.Currency,
+			Amount:               n.Amount,
+			Payload:              req.Payload,
+			Signature:            req.Signature,
+		}}},
+	})
+	if err != nil {
+		return
+	}
+	res.Duplicate = len(mut.Callbacks.List) == 0
+
+	log.DebugContext(ctx, "feature/gateway.Callback",
+		slog.Any("res", res),
+		slog.Any("err", err),
+	)
+	return
+}
+
+func parseStatus(s string) (datastore.GatewayStatus, error) {
+	for _, status := range []datastore.GatewayStatus{
+		datastore.GatewayPending,
+		datastore.GatewaySucceeded,
+		datastore.GatewayFailed,
+	} {
+		if status.String() == s {
+			return status, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown gateway status [%s]", s)
+}
@@ -0,0 +1,57 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// Fake is a local payment gateway posting signed notifications to the callback endpoint, signed by every key given.
+type Fake struct {
+	Secret     []byte             // shared secret of HMAC-SHA256 signature
+	PrivateKey ed25519.PrivateKey // private key of ed25519 signature
+	Client     *http.Client       // default to http.DefaultClient
+}
+
+// Sign will return the signature header value of the payload.
+func (x Fake) Sign(payload []byte) string {
+	signatures := []string{}
+	if len(x.Secret) > 0 {
+		signatures = append(signatures, SignHMAC(x.Secret, payload))
+	}
+	if len(x.PrivateKey) == ed25519.PrivateKeySize {
+		signatures = append(signatures, SignEd25519(x.PrivateKey, payload))
+	}
+	return strings.Join(signatures, ",")
+}
+
+// Request will build the signed callback request of the notification, as sent by Notify.
+func (x Fake) Request(ctx context.Context, url string, n Notification) (*http.Request, error) {
+	payload, err := json.Marshal(n)
+	if err != nil {
+		return nil, err
+	}
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set(SignatureHeader, x.Sign(payload))
+	return r, nil
+}
+
+// Notify will post the notification to the callback endpoint.
+func (x Fake) Notify(ctx context.Context, url string, n Notification) (*http.Response, error) {
+	r, err := x.Request(ctx, url, n)
+	if err != nil {
+		return nil, err
+	}
+	client := x.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return client.Do(r)
+}
@@ -0,0 +1,63 @@
+//go:generate mockgen -destination gateway_mock.go -package gateway . Gateway
+package gateway
+
+import (
+	"context"
+	"crypto/ed25519"
+	"fmt"
+
+	"github.com/gunawanwijaya/loan-svc/internal/feature/reconciliation"
+	"github.com/gunawanwijaya/loan-svc/internal/repository/datastore"
+	"github.com/gunawanwijaya/loan-svc/pkg"
+)
+
+type Configuration struct {
+	PaymentReference pkg.PaymentReference `json:"payment_reference,omitempty"` // as configured for the loan proposal
+}
+type Dependency struct {
+	datastore.Datastore
+	reconciliation.Reconciliation
+
+	Secret    []byte            // shared secret of HMAC-SHA256 signature, may be empty when PublicKey is given
+	PublicKey ed25519.PublicKey // public key of ed25519 signature, may be empty when Secret is given
+}
+type Gateway interface {
+	Callback(ctx context.Context, req CallbackRequest) (res CallbackResponse, err error)
+}
+
+func New(ctx context.Context, cfg Configuration, dep Dependency) (_ Gateway, err error) {
+	cfg, err = pkg.AsValidator(cfg).Validate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	dep, err = pkg.AsValidator(dep).Validate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &gateway{cfg, dep}, nil
+}
+
+type gateway struct {
+	Configuration
+	Dependency
+}
+
+func (cfg Configuration) Validate(ctx context.Context) (_ Configuration, err error) {
+	if cfg.PaymentReference, err = cfg.PaymentReference.Validate(ctx); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+func (dep Dependency) Validate(ctx context.Context) (_ Dependency, err error) {
+	if dep.Datastore == nil {
+		return dep, fmt.Errorf("feature/gateway: uninitialized repository/datastore")
+	}
+	if dep.Reconciliation == nil {
+		return dep, fmt.Errorf("feature/gateway: uninitialized feature/reconciliation")
+	}
+	if len(dep.Secret) < 1 && len(dep.PublicKey) != ed25519.PublicKeySize {
+		return dep, fmt.Errorf("feature/gateway: either secret or public key is required")
+	}
+	return dep, nil
+}
@@ -0,0 +1,164 @@
+package gateway_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gunawanwijaya/loan-svc/internal/feature/gateway"
+	"github.com/gunawanwijaya/loan-svc/internal/feature/reconciliation"
+	"github.com/gunawanwijaya/loan-svc/internal/repository/datastore"
+	"github.com/gunawanwijaya/loan-svc/pkg"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestGateway(t *testing.T) {
+	ctx := pkg.Context.PutSlogLogger(context.Background(), slog.Default())
+	ctrl := gomock.NewController(t)
+	mockDatastore := datastore.NewMockDatastore(ctrl)
+	mockReconciliation := reconciliation.NewMockReconciliation(ctrl)
+
+	_, err := gateway.New(ctx, gateway.Configuration{}, gateway.Dependency{Datastore: mockDatastore, Reconciliation: mockReconciliation})
+	require.Error(t, err) // neither secret nor public key
+
+	secret := []byte("local-gateway-secret")
+	pub, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	featGateway, err := gateway.New(ctx, gateway.Configuration{}, gateway.Dependency{
+		Datastore:      mockDatastore,
+		Reconciliation: mockReconciliation,
+		Secret:         secret,
+		PublicKey:      pub,
+	})
+	require.NoError(t, err)
+
+	paidAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	succeeded := gateway.Notification{
+		TransactionID:    "GW-0001",
+		Status:           "succeeded",
+		PaymentReference: "8808123456789072",
+		Currency:         "IDR",
+		Amount:           100_000,
+		PaidAt:           paidAt,
+		Payer:            "Budi",
+	}
+	payload, err := json.Marshal(succeeded)
+	require.NoError(t, err)
+
+	for _, signature := range []string{
+		"",
+		gateway.SignHMAC([]byte("other secret"), payload),
+		gateway.Fake{Secret: secret}.Sign([]byte(`{"transaction_id":"GW-0001","amount":1}`)),
+		"ed25519=" + pkg.BtoA([]byte("short")),
+	} {
+		_, err = featGateway.Callback(ctx, gateway.CallbackRequest{Payload: payload, Signature: signature})
+		require.ErrorIs(t, err, gateway.ErrInvalidSignature)
+	}
+
+	mockDatastore.EXPECT().
+		Query(ctx, datastore.QueryRequest{Loans: &datastore.QueryRequestLoans{ByPaymentReference: succeeded.PaymentReference}}).
+		Return(datastore.QueryResponse{List: []datastore.QueryResponse{{Loans: &datastore.QueryResponseLoans{}}}}, nil).
+		AnyTimes()
+
+	recorded := func(ctx context.Context, req datastore.MutationRequest) (datastore.MutationResponse, error) {
+		require.Len(t, req.Callbacks.List, 1)
+		require.NotEmpty(t, req.Callbacks.List[0].CallbackID)
+		return datastore.MutationResponse{Callbacks: &datastore.MutationResponseCallbacks{List: req.Callbacks.List}}, nil
+	}
+	{
+		mockReconciliation.EXPECT().
+			Reconcile(ctx, reconciliation.ReconcileRequest{
+				Source: "gateway",
+				Transactions: []reconciliation.Transaction{{
+					BankReference: "GW-0001",
+					Reference:     "8808123456789072",
+					Counterparty:  "Budi",
+					Amount:        &pkg.Money{ISO4217: "IDR", Amount: 100_000, Time: paidAt},
+				}},
+				ReportRequest: reconciliation.ReportRequest{From: &paidAt},
+			}).
+			Return(reconciliation.ReportResponse{Matched: []reconciliation.Matched{
+				{BankReference: "GW-0001", MatchType: "exact"},
+				{BankReference: "BCA-0001", MatchType: "exact"},
+			}}, nil)
+		mockDatastore.EXPECT().
+			Mutation(ctx, gomock.Any()).
+			DoAndReturn(func(ctx context.Context, req datastore.MutationRequest) (datastore.MutationResponse, error) {
+				c := req.Callbacks.List[0]
+				require.Equal(t, "GW-0001", c.GatewayTransactionID)
+				require.Equal(t, datastore.GatewaySucceeded, c.GatewayStatus)
+				require.Equal(t, payload, c.Payload)
+				return recorded(ctx, req)
+			})
+	}
+	res, err := featGateway.Callback(ctx, gateway.CallbackRequest{Payload: payload, Signature: gateway.SignHMAC(secret, payload)})
+	require.NoError(t, err)
+	require.False(t, res.Duplicate)
+	require.Len(t, res.Matched, 1)
+	require.Equal(t, "exact", res.Matched[0].MatchType)
+
+	{ // retried by the gateway
+		mockReconciliation.EXPECT().
+			Reconcile(ctx, gomock.Any()).
+			Return(reconciliation.ReportResponse{}, nil)
+		mockDatastore.EXPECT().
+			Mutation(ctx, gomock.Any()).
+			Return(datastore.MutationResponse{Callbacks: &datastore.MutationResponseCallbacks{}}, nil)
+	}
+	res, err = featGateway.Callback(ctx, gateway.CallbackRequest{Payload: payload, Signature: gateway.SignEd25519(key, payload)})
+	require.NoError(t, err)
+	require.True(t, res.Duplicate)
+
+	{ // pending is only recorded, posted by the fake gateway
+		mockDatastore.EXPECT().
+			Mutation(ctx, gomock.Any()).
+			DoAndReturn(func(ctx context.Context, req datastore.MutationRequest) (datastore.MutationResponse, error) {
+				require.Equal(t, datastore.GatewayPending, req.Callbacks.List[0].GatewayStatus)
+				return recorded(ctx, req)
+			})
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		res, err := featGateway.Callback(ctx, gateway.CallbackRequest{Payload: payload, Signature: r.Header.Get(gateway.SignatureHeader)})
+		require.NoError(t, err)
+		require.Equal(t, "pending", res.Notification.Status)
+	}))
+	defer srv.Close()
+	pending := succeeded
+	pending.Status, pending.PaidAt = "pending", time.Time{}
+	resp, err := gateway.Fake{PrivateKey: key}.Notify(ctx, srv.URL, pending)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	for _, invalid := range []gateway.Notification{
+		{TransactionID: "GW-0001", Status: "succeeded", PaymentReference: "8808123456789072", Currency: "IDR", Amount: 100_000},
+		{TransactionID: "GW-0001", Status: "pending", PaymentReference: "8808123456789073", Currency: "IDR", Amount: 100_000},
+		{TransactionID: "GW-0001", Status: "pending", PaymentReference: "000000000195", Currency: "IDR", Amount: 100_000}, // legacy
+	} {
+		payload, err = json.Marshal(invalid)
+		require.NoError(t, err)
+		_, err = featGateway.Callback(ctx, gateway.CallbackRequest{Payload: payload, Signature: gateway.SignHMAC(secret, payload)})
+		require.ErrorIs(t, err, gateway.ErrInvalidNotification)
+	}
+
+	unknown := pending
+	unknown.PaymentReference = "8808000000000175" // valid check digits, given to no loan
+	{
+		mockDatastore.EXPECT().
+			Query(ctx, datastore.QueryRequest{Loans: &datastore.QueryRequestLoans{ByPaymentReference: unknown.PaymentReference}}).
+			Return(datastore.QueryResponse{}, nil)
+	}
+	payload, err = json.Marshal(unknown)
+	require.NoError(t, err)
+	_, err = featGateway.Callback(ctx, gateway.CallbackRequest{Payload: payload, Signature: gateway.SignHMAC(secret, payload)})
+	require.ErrorIs(t, err, gateway.ErrUnknownReference)
+}
@@ -0,0 +1,69 @@
+package gateway
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	"github.com/gunawanwijaya/loan-svc/pkg"
+)
+
+// SignatureHeader is the HTTP header carrying the signature of the raw notification, as comma separated
+// `<algorithm>=<value>` where algorithm is either hmac-sha256 (hex) or ed25519 (base64).
+const SignatureHeader = "X-Gateway-Signature"
+
+const (
+	algHMACSHA256 = "hmac-sha256"
+	algEd25519    = "ed25519"
+)
+
+var (
+	ErrInvalidSignature    = errors.New("feature/gateway: invalid signature")
+	ErrInvalidNotification = errors.New("feature/gateway: invalid notification")
+	ErrUnknownReference    = errors.New("feature/gateway: unknown payment reference")
+)
+
+// SignHMAC will sign the payload with the shared secret.
+func SignHMAC(secret, payload []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+	return algHMACSHA256 + "=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// SignEd25519 will sign the payload with the private key of the gateway.
+func SignEd25519(key ed25519.PrivateKey, payload []byte) string {
+	return algEd25519 + "=" + pkg.BtoA(ed25519.Sign(key, payload))
+}
+
+// verify will accept the payload when any of the signatures is valid against the configured secret or public key.
+func (x *gateway) verify(payload []byte, signature string) error {
+	for _, s := range strings.Split(signature, ",") {
+		alg, value, _ := strings.Cut(strings.TrimSpace(s), "=")
+		switch alg {
+		case algHMACSHA256:
+			if len(x.Dependency.Secret) < 1 {
+				continue
+			}
+			mac, err := hex.DecodeString(value)
+			if err != nil {
+				continue
+			}
+			expected := hmac.New(sha256.New, x.Dependency.Secret)
+			expected.Write(payload)
+			if hmac.Equal(mac, expected.Sum(nil)) {
+				return nil
+			}
+		case algEd25519:
+			if len(x.Dependency.PublicKey) != ed25519.PublicKeySize {
+				continue
+			}
+			if sig := pkg.AtoB(value); len(sig) == ed25519.SignatureSize && ed25519.Verify(x.Dependency.PublicKey, payload, sig) {
+				return nil
+			}
+		}
+	}
+	return ErrInvalidSignature
+}
@@ -0,0 +1,204 @@
+package loan
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/gunawanwijaya/loan-svc/internal/repository/datastore"
+	"github.com/gunawanwijaya/loan-svc/pkg"
+)
+
+type AccrualRequest struct {
+	LoanID   []byte       `json:"loan_id,omitempty"`   // empty for all disbursed loans
+	AsOf     *time.Time   `json:"as_of,omitempty"`     // default to now
+	DayCount pkg.DayCount `json:"day_count,omitempty"` // default to configuration
+	Record   bool         `json:"-"`                   // write the daily accrual entry of `as_of` date
+	Backfill bool         `json:"-"`                   // with Record, also write every day since the last recorded
+}
+
+type AccrualResponse struct {
+	List []AccrualResponse `json:"list,omitempty"`
+
+	LoanID        []byte       `json:"loan_id,omitempty"`
+	LoanState     string       `json:"loan_state,omitempty"`
+	DayCount      pkg.DayCount `json:"day_count,omitempty"`
+	AnnualRate    float64      `json:"annual_rate"`
+	Accrued       *pkg.Money   `json:"accrued,omitempty"`        // interest accrued since disbursement until as_of
+	Due           *pkg.Money   `json:"due,omitempty"`            // interest portion of installments due until as_of
+	AccruedUnpaid *pkg.Money   `json:"accrued_unpaid,omitempty"` // accrued - due, negative when billed ahead of accrual
+	Daily         *pkg.Money   `json:"daily,omitempty"`          // interest accrued on the day of as_of
+}
+
+// Accrual will accrue the interest daily on the principal using the flat annual rate
+//   - annual rate is the interest rate spread over the number of monthly installment
+//   - interest accrues from the disbursement date until the last installment is due
+//   - each installment consist of interest with the same proportion as interest rate in total repayment
+//
+// when Record is true, the accrual of the `as_of` day is written for finance to reconcile, along with every day
+// since the last recorded accrual date when Backfill is true.
+func (x *loan) Accrual(ctx context.Context, req AccrualRequest) (res AccrualResponse, err error) {
+	log := pkg.Context.SlogLogger(ctx)
+	asOf := time.Now()
+	if req.AsOf != nil {
+		asOf = *req.AsOf
+	}
+	dc := x.Configuration.DayCountConvention
+	if req.DayCount != "" {
+		if dc, err = req.DayCount.Validate(ctx); err != nil {
+			return
+		}
+	}
+
+	qryLoans := &datastore.QueryRequestLoans{ByLoanID: req.LoanID}
+	if len(req.LoanID) < 1 {
+		qryLoans = &datastore.QueryRequestLoans{ByLoanState: datastore.StateDisbursed}
+	}
+	var qry datastore.QueryResponse
+	if qry, err = x.Dependency.Datastore.Query(ctx, datastore.QueryRequest{Loans: qryLoans}); err != nil {
+		return
+	}
+
+	var days []time.Time
+	if req.Record && req.Backfill {
+		if days, err = x.accrualBackfill(ctx, asOf, dc); err != nil {
+			return
+		}
+	}
+
+	entries := []datastore.LoanAccrual{}
+	for _, qry := range qry.List {
+		for _, day := range days {
+			_, entry, err := x.accrue(ctx, qry.Loans.Loan, day, dc)
+			if err != nil {
+				return res, err
+			}
+			if entry != nil {
+				entries = append(entries, *entry)
+			}
+		}
+		accrual, entry, err := x.accrue(ctx, qry.Loans.Loan, asOf, dc)
+		if err != nil {
+			return res, err
+		}
+		res.List = append(res.List, accrual)
+		if entry != nil {
+			entries = append(entries, *entry)
+		}
+	}
+
+	if req.Record && len(entries) > 0 {
+		if _, err = x.Dependency.Datastore.Mutation(ctx, datastore.MutationRequest{
+			Accruals: &datastore.MutationRequestAccruals{List: entries},
+		}); err != nil {
+			return
+		}
+	}
+
+	if len(res.List) == 1 {
+		res = res.List[0]
+	}
+
+	log.DebugContext(ctx, "feature/loan.Accrual",
+		slog.Any("req", req),
+		slog.Any("res", res),
+		slog.Any("err", err),
+	)
+	return
+}
+
+// accrualBackfill will list the days after the last recorded accrual date and before the `as_of` day, nothing is
+// listed when no accrual has ever been recorded.
+func (x *loan) accrualBackfill(ctx context.Context, asOf time.Time, dc pkg.DayCount) (days []time.Time, err error) {
+	var qry datastore.QueryResponse
+	qry, err = x.Dependency.Datastore.Query(ctx, datastore.QueryRequest{
+		Accruals: &datastore.QueryRequestAccruals{ByDayCount: string(dc)},
+	})
+	if err != nil || qry.Accruals == nil || qry.Accruals.LastAccrualDate < 1 {
+		return
+	}
+	asOfDay := asOf.UTC().Truncate(24 * time.Hour)
+	for day := time.Unix(qry.Accruals.LastAccrualDate, 0).UTC().AddDate(0, 0, 1); day.Before(asOfDay); day = day.AddDate(0, 0, 1) {
+		days = append(days, day)
+	}
+	return
+}
+
+func (x *loan) accrue(ctx context.Context, l datastore.Loan, asOf time.Time, dc pkg.DayCount) (res AccrualResponse, entry *datastore.LoanAccrual, err error) {
+	var principal *datastore.LoanPartyPayment
+	var installments []datastore.LoanPartyPayment
+	var maturity time.Time
+	for _, party := range l.Parties {
+		if party.LoanPartyRoleAs != datastore.RoleAsBorrower {
+			continue
+		}
+		for i, payment := range party.Payments {
+			switch payment.PaymentType {
+			case datastore.PaymentPrincipalDisbursement:
+				principal = &party.Payments[i]
+			case datastore.PaymentInstallment:
+				installments = append(installments, payment)
+				maturity = time.Unix(max(maturity.Unix(), payment.Time), 0)
+			}
+		}
+	}
+	if principal == nil || len(installments) < 1 {
+		return res, nil, fmt.Errorf("invalid principal value")
+	}
+
+	money := func(amount float64) *pkg.Money {
+		m := &pkg.Money{ISO4217: principal.ISO4217, Amount: amount, Time: asOf}
+		if v, _ := m.Validate(ctx); v != nil { // error validation should be ignored
+			return v
+		}
+		return m
+	}
+	rate := x.Configuration.InterestRate * 12 / float64(len(installments))
+	share := x.Configuration.InterestRate / (1 + x.Configuration.InterestRate + x.Configuration.ServiceFee)
+	accruedAt := func(t time.Time) float64 {
+		if l.DisbursedAt == nil {
+			return 0 // interest only accrue after the principal is disbursed
+		}
+		start, end := time.Unix(*l.DisbursedAt, 0), t
+		if end.After(maturity) {
+			end = maturity
+		}
+		if !end.After(start) {
+			return 0
+		}
+		return -principal.Amount * rate * dc.YearFraction(start, end)
+	}
+
+	var due float64
+	for _, installment := range installments {
+		if !time.Unix(installment.Time, 0).After(asOf) {
+			due += installment.Amount * share
+		}
+	}
+	day := asOf.UTC().Truncate(24 * time.Hour)
+	accrued := accruedAt(asOf)
+	daily := accruedAt(day.AddDate(0, 0, 1)) - accruedAt(day)
+
+	res = AccrualResponse{
+		LoanID:        l.LoanID,
+		LoanState:     l.LoanState.String(),
+		DayCount:      dc,
+		AnnualRate:    rate,
+		Accrued:       money(accrued),
+		Due:           money(due),
+		AccruedUnpaid: money(accrued - due),
+		Daily:         money(daily),
+	}
+	if daily != 0 {
+		entry = &datastore.LoanAccrual{
+			LoanID:      l.LoanID,
+			AccrualDate: day.Unix(),
+			DayCount:    string(dc),
+			ISO4217:     principal.ISO4217,
+			Amount:      money(daily).Amount,
+			Accrued:     money(accruedAt(day.AddDate(0, 0, 1))).Amount,
+		}
+	}
+	return res, entry, nil
+}
@@ -0,0 +1,108 @@
+package loan
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/gunawanwijaya/loan-svc/internal/repository/datastore"
+)
+
+// Aggregate is a loan rebuilt by folding its events, the loan tables are the projection of the same fold.
+type Aggregate struct {
+	datastore.Loan
+	Version int // version of the last event applied
+}
+
+// Fold will apply the events of a single loan in order of version.
+func Fold(events ...datastore.LoanEvent) (a Aggregate, err error) {
+	for _, e := range events {
+		if err = a.Apply(e); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// Apply will transition the aggregate by a single event, each event only carry what is changed by the transition
+//   - imported & proposed are only accepted as the first event
+//   - approved, invested & disbursed follow the state of the loan
+//   - payment_settled set the settlement of an unsettled payment
+func (a *Aggregate) Apply(e datastore.LoanEvent) (err error) {
+	if e.Version != a.Version+1 {
+		return fmt.Errorf("expected version %d, got %d", a.Version+1, e.Version)
+	}
+	if a.Version > 0 && !bytes.Equal(a.LoanID, e.LoanID) {
+		return fmt.Errorf("different loan_id")
+	}
+
+	var l datastore.Loan
+	switch e.LoanEventType {
+	default:
+		return fmt.Errorf("unknown event %d", e.LoanEventType)
+	case datastore.LoanImported, datastore.LoanProposed:
+		if a.Version != 0 {
+			return fmt.Errorf("%s is only accepted as the first event", e.LoanEventType)
+		}
+		if err = json.Unmarshal(e.Payload, &l); err != nil {
+			return
+		}
+		if e.LoanEventType == datastore.LoanProposed && l.LoanState != datastore.StateProposed {
+			return fmt.Errorf("expected state from [proposed]")
+		}
+		a.Loan = l
+	case datastore.LoanApproved:
+		if err = a.expect(datastore.StateProposed, e.Payload, &l); err != nil {
+			return
+		}
+		a.LoanState = datastore.StateApproved
+		a.ApprovedBy, a.ApprovedDoc, a.ApprovedAt = l.ApprovedBy, l.ApprovedDoc, l.ApprovedAt
+		a.ApprovedSign, a.ApprovedSignVersion = l.ApprovedSign, l.ApprovedSignVersion
+	case datastore.LoanInvested:
+		if err = a.expect(datastore.StateApproved, e.Payload, &l); err != nil {
+			return
+		}
+		a.LoanState = datastore.StateInvested
+		a.Parties = append(a.Parties, l.Parties...)
+	case datastore.LoanDisbursed:
+		if err = a.expect(datastore.StateInvested, e.Payload, &l); err != nil {
+			return
+		}
+		a.LoanState = datastore.StateDisbursed
+		a.DisbursedBy, a.DisbursedDoc, a.DisbursedAt = l.DisbursedBy, l.DisbursedDoc, l.DisbursedAt
+		a.DisbursedSign, a.DisbursedSignVersion = l.DisbursedSign, l.DisbursedSignVersion
+	case datastore.LoanPaymentSettled:
+		var p datastore.LoanPartyPayment
+		if err = json.Unmarshal(e.Payload, &p); err != nil {
+			return
+		}
+		if err = a.settle(p); err != nil {
+			return
+		}
+	}
+	a.Version = e.Version
+	return
+}
+
+func (a *Aggregate) expect(state datastore.LoanState, payload []byte, l *datastore.Loan) error {
+	if a.LoanState != state {
+		return fmt.Errorf("expected state from [%s]", state)
+	}
+	return json.Unmarshal(payload, l)
+}
+
+func (a *Aggregate) settle(p datastore.LoanPartyPayment) error {
+	for i := range a.Parties {
+		for j := range a.Parties[i].Payments {
+			if !bytes.Equal(a.Parties[i].Payments[j].PaymentID, p.PaymentID) {
+				continue
+			}
+			if a.Parties[i].Payments[j].SettledAt != nil {
+				return fmt.Errorf("payment is already settled")
+			}
+			a.Parties[i].Payments[j].SettledAt = p.SettledAt
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid payment_id")
+}
@@ -0,0 +1,34 @@
+package loan
+
+import (
+	"math"
+	"time"
+
+	"github.com/gunawanwijaya/loan-svc/internal/repository/datastore"
+	"github.com/gunawanwijaya/loan-svc/pkg"
+)
+
+type Disclosure struct {
+	APR           float64 `json:"apr"`            // nominal annual rate compounded monthly, fees included
+	EffectiveRate float64 `json:"effective_rate"` // effective annual rate, fees included
+}
+
+// disclose will derive the APR & effective annual rate from the dated cash flows of a borrower schedule,
+// the principal is the negative entry while installments (already including interest & service fee) are positive.
+//
+// effective annual rate is the XIRR of the schedule, while APR is the equivalent nominal rate compounded monthly.
+func disclose(payments []datastore.LoanPartyPayment) (_ *Disclosure, err error) {
+	flows := make([]pkg.CashFlow, len(payments))
+	for i, payment := range payments {
+		flows[i] = pkg.CashFlow{Amount: payment.Amount, Time: time.Unix(payment.Time, 0)}
+	}
+	var ear float64
+	if ear, err = pkg.XIRR(flows...); err != nil {
+		return nil, err
+	}
+	const periodsInYear = 12
+	return &Disclosure{
+		APR:           periodsInYear * (math.Pow(1+ear, 1.0/periodsInYear) - 1),
+		EffectiveRate: ear,
+	}, nil
+}
@@ -0,0 +1,84 @@
+package loan
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/gunawanwijaya/loan-svc/internal/repository/datastore"
+	"github.com/gunawanwijaya/loan-svc/pkg"
+)
+
+type AuditRequest struct {
+	LoanID []byte `json:"loan_id,omitempty"`
+}
+
+type AuditResponse struct {
+	List []AuditResponse `json:"list,omitempty"`
+
+	AuditID    []byte        `json:"audit_id,omitempty"`
+	Operation  string        `json:"operation,omitempty"`
+	Actor      string        `json:"actor,omitempty"`
+	ClientIP   string        `json:"client_ip,omitempty"`
+	RequestID  string        `json:"request_id,omitempty"`
+	Before     *ViewResponse `json:"before,omitempty"` // empty when the loan is created by the operation
+	After      *ViewResponse `json:"after,omitempty"`
+	OccurredAt *time.Time    `json:"occurred_at,omitempty"`
+}
+
+// Audit will list every operation recorded on a loan in order, each with the loan before & after the operation.
+func (x *loan) Audit(ctx context.Context, req AuditRequest) (res AuditResponse, err error) {
+	log := pkg.Context.SlogLogger(ctx)
+	if len(req.LoanID) < 1 {
+		err = fmt.Errorf("invalid loan_id")
+		return
+	}
+
+	var qry datastore.QueryResponse
+	qry, err = x.Dependency.Datastore.Query(ctx, datastore.QueryRequest{
+		AuditLogs: &datastore.QueryRequestAuditLogs{ByLoanID: req.LoanID},
+	})
+	if err != nil {
+		return
+	}
+	for _, q := range qry.List {
+		a := q.AuditLogs.AuditLog
+		r := AuditResponse{
+			AuditID:    a.AuditID,
+			Operation:  a.Operation,
+			Actor:      a.Actor,
+			ClientIP:   a.ClientIP,
+			RequestID:  a.RequestID,
+			OccurredAt: pkg.Ptr(time.Unix(a.CreatedAt, 0)),
+		}
+		if r.Before, err = snapshot(a.Before); err != nil {
+			return
+		}
+		if r.After, err = snapshot(a.After); err != nil {
+			return
+		}
+		res.List = append(res.List, r)
+	}
+
+	log.DebugContext(ctx, "feature/loan.Audit",
+		slog.Any("req", req),
+		slog.Int("len", len(res.List)),
+		slog.Any("err", err),
+	)
+	return
+}
+
+// snapshot will present the audited JSON of a loan, nil when there is none.
+func snapshot(b []byte) (*ViewResponse, error) {
+	if len(b) < 1 {
+		return nil, nil
+	}
+	var l datastore.Loan
+	if err := json.Unmarshal(b, &l); err != nil {
+		return nil, err
+	}
+	v := view(l)
+	return &v, nil
+}
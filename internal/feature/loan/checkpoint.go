@@ -0,0 +1,51 @@
+package loan
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/gunawanwijaya/loan-svc/internal/repository/datastore"
+	"github.com/gunawanwijaya/loan-svc/pkg"
+)
+
+type CheckpointRequest struct{}
+
+type CheckpointResponse struct {
+	List []CheckpointResponse `json:"list,omitempty"`
+
+	CheckpointID []byte     `json:"checkpoint_id,omitempty"`
+	Chain        string     `json:"chain,omitempty"`
+	HeadSequence int64      `json:"head_sequence,omitempty"`
+	HeadHash     []byte     `json:"head_hash,omitempty"`
+	CreatedAt    *time.Time `json:"created_at,omitempty"`
+}
+
+// Checkpoint will sign the head of the payment & loan event chains with the service key, a chain that has not moved
+// since its last checkpoint is skipped.
+func (x *loan) Checkpoint(ctx context.Context, req CheckpointRequest) (res CheckpointResponse, err error) {
+	log := pkg.Context.SlogLogger(ctx)
+
+	var mut datastore.MutationResponse
+	mut, err = x.Dependency.Datastore.Mutation(ctx, datastore.MutationRequest{
+		Checkpoints: &datastore.MutationRequestCheckpoints{},
+	})
+	if err != nil {
+		return
+	}
+	for _, c := range mut.Checkpoints.List {
+		res.List = append(res.List, CheckpointResponse{
+			CheckpointID: c.CheckpointID,
+			Chain:        c.Chain,
+			HeadSequence: c.HeadSequence,
+			HeadHash:     c.HeadHash,
+			CreatedAt:    pkg.Ptr(time.Unix(c.CreatedAt, 0)),
+		})
+	}
+
+	log.DebugContext(ctx, "feature/loan.Checkpoint",
+		slog.Int("len", len(res.List)),
+		slog.Any("err", err),
+	)
+	return
+}
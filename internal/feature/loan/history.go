@@ -0,0 +1,148 @@
+package loan
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/gunawanwijaya/loan-svc/internal/repository/datastore"
+	"github.com/gunawanwijaya/loan-svc/pkg"
+)
+
+type HistoryRequest struct {
+	LoanID []byte `json:"loan_id,omitempty"`
+}
+
+type HistoryResponse struct {
+	LoanID  []byte         `json:"loan_id,omitempty"`
+	Version int            `json:"version,omitempty"`
+	Events  []HistoryEvent `json:"events,omitempty"`
+	Loan    ViewResponse   `json:"loan"` // folded from the events
+}
+
+type HistoryEvent struct {
+	EventID    []byte          `json:"event_id,omitempty"`
+	Version    int             `json:"version,omitempty"`
+	EventType  string          `json:"event_type,omitempty"`
+	LoanState  string          `json:"loan_state,omitempty"` // state of the loan after the event is applied
+	OccurredAt time.Time       `json:"occurred_at"`
+	Payload    json.RawMessage `json:"payload,omitempty"`
+}
+
+// History will fold the events of a loan, reconstructing the loan as of each of them.
+func (x *loan) History(ctx context.Context, req HistoryRequest) (res HistoryResponse, err error) {
+	log := pkg.Context.SlogLogger(ctx)
+	if len(req.LoanID) < 1 {
+		err = fmt.Errorf("invalid loan_id")
+		return
+	}
+
+	var qry datastore.QueryResponse
+	qry, err = x.Dependency.Datastore.Query(ctx, datastore.QueryRequest{
+		LoanEvents: &datastore.QueryRequestLoanEvents{ByLoanID: req.LoanID},
+	})
+	if err != nil {
+		return
+	}
+	if len(qry.List) < 1 {
+		err = fmt.Errorf("loan has no event, rebuild the projection to import it")
+		return
+	}
+
+	var a Aggregate
+	for _, q := range qry.List {
+		e := q.LoanEvents.LoanEvent
+		if err = a.Apply(e); err != nil {
+			return
+		}
+		res.Events = append(res.Events, HistoryEvent{
+			EventID:    e.EventID,
+			Version:    e.Version,
+			EventType:  e.LoanEventType.String(),
+			LoanState:  a.LoanState.String(),
+			OccurredAt: time.Unix(e.CreatedAt, 0),
+			Payload:    e.Payload,
+		})
+	}
+	res.LoanID, res.Version, res.Loan = a.LoanID, a.Version, view(a.Loan)
+
+	log.DebugContext(ctx, "feature/loan.History",
+		slog.Any("req", req),
+		slog.Int("version", res.Version),
+		slog.Any("err", err),
+	)
+	return
+}
+
+type RebuildRequest struct {
+	LoanID []byte `json:"loan_id,omitempty"` // nil for all loans
+}
+
+type RebuildResponse struct {
+	Imported int64 `json:"imported"` // loans without any event, recorded as imported beforehand
+	Rebuilt  int64 `json:"rebuilt"`
+}
+
+// Rebuild will replace the loan tables from scratch by folding the events of each loan, loans projected before the
+// events were recorded are imported first. Mutation running concurrently may be overwritten, run it offline.
+func (x *loan) Rebuild(ctx context.Context, req RebuildRequest) (res RebuildResponse, err error) {
+	log := pkg.Context.SlogLogger(ctx)
+
+	var mut datastore.MutationResponse
+	mut, err = x.Dependency.Datastore.Mutation(ctx, datastore.MutationRequest{
+		LoanEvents: &datastore.MutationRequestLoanEvents{Import: true, ByLoanID: req.LoanID},
+	})
+	if err != nil {
+		return
+	}
+	res.Imported = mut.LoanEvents.Imported
+
+	var qry datastore.QueryResponse
+	qry, err = x.Dependency.Datastore.Query(ctx, datastore.QueryRequest{
+		LoanEvents: &datastore.QueryRequestLoanEvents{ByLoanID: req.LoanID},
+	})
+	if err != nil {
+		return
+	}
+
+	// events are ordered by sequence, interleaving between loans
+	var aggregates []*Aggregate
+	byLoanID := map[string]*Aggregate{}
+	for _, q := range qry.List {
+		e := q.LoanEvents.LoanEvent
+		a, ok := byLoanID[string(e.LoanID)]
+		if !ok {
+			a = &Aggregate{}
+			byLoanID[string(e.LoanID)] = a
+			aggregates = append(aggregates, a)
+		}
+		if err = a.Apply(e); err != nil {
+			err = fmt.Errorf("loan %s: %w", pkg.BtoA(e.LoanID), err)
+			return
+		}
+	}
+	if len(aggregates) < 1 {
+		return
+	}
+
+	projections := make([]datastore.Loan, len(aggregates))
+	for i, a := range aggregates {
+		projections[i] = a.Loan
+	}
+	mut, err = x.Dependency.Datastore.Mutation(ctx, datastore.MutationRequest{
+		LoanEvents: &datastore.MutationRequestLoanEvents{Projections: projections},
+	})
+	if err != nil {
+		return
+	}
+	res.Rebuilt = mut.LoanEvents.Projected
+
+	log.DebugContext(ctx, "feature/loan.Rebuild",
+		slog.Any("req", req),
+		slog.Any("res", res),
+		slog.Any("err", err),
+	)
+	return
+}
@@ -0,0 +1,155 @@
+package loan
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gunawanwijaya/loan-svc/internal/repository/datastore"
+	"github.com/gunawanwijaya/loan-svc/pkg"
+	"github.com/rs/xid"
+)
+
+// memoJournal will record a state transition that moves no money, a journal without postings.
+func memoJournal(loanID []byte, description string) datastore.Journal {
+	return datastore.Journal{
+		JournalID:   xid.New().Bytes(),
+		LoanID:      loanID,
+		Description: fmt.Sprintf("%s for loan [%s]", description, pkg.BtoA(loanID)),
+	}
+}
+
+// investedJournal will record the money of each used lender moved from its wallet into the loan, the cash is held
+// in cash-in-transit until disbursement & owed back to the lender.
+//
+//	Dr lender_wallet          payment (per lender)
+//	    Cr lender_payable     payment (per lender)
+//	Dr cash_in_transit        payment
+//	    Cr cash_at_bank       payment
+func investedJournal(loanID []byte, used []LoanLender) datastore.Journal {
+	j := memoJournal(loanID, "Investment")
+	for _, lender := range used {
+		j.Postings = append(j.Postings, datastore.Posting{
+			LedgerAccount: datastore.AccountLenderWallet,
+			UserID:        lender.LenderID,
+			ISO4217:       lender.Payment.ISO4217,
+			Debit:         lender.Payment.Amount,
+		}, datastore.Posting{
+			LedgerAccount: datastore.AccountLenderPayable,
+			UserID:        lender.LenderID,
+			ISO4217:       lender.Payment.ISO4217,
+			Credit:        lender.Payment.Amount,
+		}, datastore.Posting{
+			LedgerAccount: datastore.AccountCashInTransit,
+			ISO4217:       lender.Payment.ISO4217,
+			Debit:         lender.Payment.Amount,
+		}, datastore.Posting{
+			LedgerAccount: datastore.AccountCashAtBank,
+			ISO4217:       lender.Payment.ISO4217,
+			Credit:        lender.Payment.Amount,
+		})
+	}
+	return j
+}
+
+// disbursedJournal will record the principal leaving cash-in-transit to the borrower, while the borrower
+// owe the total of installments, split into the lender interest & the platform revenue.
+//
+//	Dr borrower_receivable       sum of installments
+//	    Cr cash_in_transit       principal (per lender payment)
+//	    Cr lender_payable        lender interest (per lender)
+//	    Cr platform_fee_revenue  remaining of interest & service fee
+func disbursedJournal(ctx context.Context, l datastore.Loan) (j datastore.Journal, err error) {
+	j = memoJournal(l.LoanID, "Disbursement")
+	var iso4217 string
+	var receivable, credit float64
+	for _, party := range l.Parties {
+		for _, payment := range party.Payments {
+			iso4217 = payment.ISO4217
+			switch payment.PaymentType {
+			case datastore.PaymentInstallment:
+				receivable += payment.Amount
+			case datastore.PaymentInvestment:
+				credit += payment.Amount
+				j.Postings = append(j.Postings, datastore.Posting{
+					LedgerAccount: datastore.AccountCashInTransit,
+					ISO4217:       payment.ISO4217,
+					Credit:        payment.Amount,
+				})
+			case datastore.PaymentRepayment:
+				// repayment to lender consist of its investment (already owed on investment) & the interest
+				interest := -payment.Amount
+				for _, p := range party.Payments {
+					if p.PaymentType == datastore.PaymentInvestment {
+						interest -= p.Amount
+					}
+				}
+				interest = (&pkg.Money{ISO4217: payment.ISO4217, Amount: interest}).Round().Amount
+				credit += interest
+				j.Postings = append(j.Postings, datastore.Posting{
+					LedgerAccount: datastore.AccountLenderPayable,
+					UserID:        party.UserID,
+					ISO4217:       payment.ISO4217,
+					Credit:        interest,
+				})
+			}
+		}
+	}
+	if receivable == 0 {
+		return j, fmt.Errorf("invalid principal value")
+	}
+	receivable = (&pkg.Money{ISO4217: iso4217, Amount: receivable}).Round().Amount
+	revenue := (&pkg.Money{ISO4217: iso4217, Amount: receivable - credit}).Round().Amount
+	if revenue < 0 {
+		return j, fmt.Errorf("lender interest exceed the borrower interest & service fee")
+	}
+	j.Postings = append([]datastore.Posting{{
+		LedgerAccount: datastore.AccountBorrowerReceivable,
+		UserID:        borrowerID(l),
+		ISO4217:       iso4217,
+		Debit:         receivable,
+	}}, append(j.Postings, datastore.Posting{
+		LedgerAccount: datastore.AccountPlatformFeeRevenue,
+		ISO4217:       iso4217,
+		Credit:        revenue,
+	})...)
+	return j.Validate(ctx)
+}
+
+// investedWallets will move the payment of each used lender from available into reserved, while the unused
+// payment is never taken out of available.
+func investedWallets(used []LoanLender) (entries []datastore.WalletEntry) {
+	for _, lender := range used {
+		entries = append(entries, datastore.WalletEntry{
+			UserID:    lender.LenderID,
+			ISO4217:   lender.Payment.ISO4217,
+			Available: -lender.Payment.Amount,
+			Reserved:  lender.Payment.Amount,
+		})
+	}
+	return entries
+}
+
+// disbursedWallets will release the reserved payment of each lender as the money is paid to the borrower.
+func disbursedWallets(l datastore.Loan) (entries []datastore.WalletEntry) {
+	for _, party := range l.Parties {
+		for _, payment := range party.Payments {
+			if payment.PaymentType == datastore.PaymentInvestment {
+				entries = append(entries, datastore.WalletEntry{
+					UserID:   party.UserID,
+					ISO4217:  payment.ISO4217,
+					Reserved: -payment.Amount,
+				})
+			}
+		}
+	}
+	return entries
+}
+
+func borrowerID(l datastore.Loan) []byte {
+	for _, party := range l.Parties {
+		if party.LoanPartyRoleAs == datastore.RoleAsBorrower {
+			return party.UserID
+		}
+	}
+	return nil
+}
@@ -3,24 +3,40 @@ package loan
 
 import (
 	"context"
+	"fmt"
 
+	"github.com/gunawanwijaya/loan-svc/internal/feature/document"
 	"github.com/gunawanwijaya/loan-svc/internal/repository/datastore"
+	"github.com/gunawanwijaya/loan-svc/internal/repository/keyring"
 	"github.com/gunawanwijaya/loan-svc/pkg"
 )
 
 type Configuration struct {
-	LenderInterestRate      float64 `json:"lender_interest_rate,omitempty"`
-	InterestRate            float64 `json:"interest_rate,omitempty"`
-	ServiceFee              float64 `json:"service_fee,omitempty"`
-	NumOfMonthlyInstallment int     `json:"num_of_monthly_installment,omitempty"`
-	MinRateOfInvestment     float64 `json:"min_rate_of_investment,omitempty"`
+	LenderInterestRate      float64              `json:"lender_interest_rate,omitempty"`
+	InterestRate            float64              `json:"interest_rate,omitempty"`
+	ServiceFee              float64              `json:"service_fee,omitempty"`
+	NumOfMonthlyInstallment int                  `json:"num_of_monthly_installment,omitempty"`
+	MinRateOfInvestment     float64              `json:"min_rate_of_investment,omitempty"`
+	DayCountConvention      pkg.DayCount         `json:"day_count_convention,omitempty"`
+	PaymentReference        pkg.PaymentReference `json:"payment_reference,omitempty"` // bank prefix of repayment references
+	LossGraceDays           int                  `json:"loss_grace_days,omitempty"`   // days past the last repayment of a disbursed loan before its unreceived principal is a loss, default to 90
 }
 type Dependency struct {
 	datastore.Datastore
+	keyring.Keyring   // signs the receipts of the mutations, none are given when nil
+	document.Document // generates the documents linked upon approved & disbursed, the links are required when nil
 }
 type Loan interface {
 	View(ctx context.Context, req ViewRequest) (res ViewResponse, err error)
 	Upsert(ctx context.Context, req UpsertRequest) (res UpsertResponse, err error)
+	Quote(ctx context.Context, req QuoteRequest) (res QuoteResponse, err error)
+	Portfolio(ctx context.Context, req PortfolioRequest) (res PortfolioResponse, err error)
+	Accrual(ctx context.Context, req AccrualRequest) (res AccrualResponse, err error)
+	History(ctx context.Context, req HistoryRequest) (res HistoryResponse, err error)
+	Rebuild(ctx context.Context, req RebuildRequest) (res RebuildResponse, err error)
+	Audit(ctx context.Context, req AuditRequest) (res AuditResponse, err error)
+	Checkpoint(ctx context.Context, req CheckpointRequest) (res CheckpointResponse, err error)
+	JWKS(ctx context.Context, req JWKSRequest) (res JWKSResponse, err error)
 }
 
 func New(ctx context.Context, cfg Configuration, dep Dependency) (_ Loan, err error) {
@@ -41,6 +57,17 @@ type loan struct {
 }
 
 func (cfg Configuration) Validate(ctx context.Context) (_ Configuration, err error) {
+	if cfg.DayCountConvention, err = cfg.DayCountConvention.Validate(ctx); err != nil {
+		return cfg, err
+	}
+	if cfg.PaymentReference, err = cfg.PaymentReference.Validate(ctx); err != nil {
+		return cfg, err
+	}
+	if cfg.LossGraceDays < 0 {
+		return cfg, fmt.Errorf("feature/loan: negative loss_grace_days")
+	} else if cfg.LossGraceDays == 0 {
+		cfg.LossGraceDays = 90
+	}
 	return cfg, nil
 }
 
@@ -40,6 +40,126 @@ func (m *MockLoan) EXPECT() *MockLoanMockRecorder {
 	return m.recorder
 }
 
+// Accrual mocks base method.
+func (m *MockLoan) Accrual(ctx context.Context, req AccrualRequest) (AccrualResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Accrual", ctx, req)
+	ret0, _ := ret[0].(AccrualResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Accrual indicates an expected call of Accrual.
+func (mr *MockLoanMockRecorder) Accrual(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Accrual", reflect.TypeOf((*MockLoan)(nil).Accrual), ctx, req)
+}
+
+// Audit mocks base method.
+func (m *MockLoan) Audit(ctx context.Context, req AuditRequest) (AuditResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Audit", ctx, req)
+	ret0, _ := ret[0].(AuditResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Audit indicates an expected call of Audit.
+func (mr *MockLoanMockRecorder) Audit(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Audit", reflect.TypeOf((*MockLoan)(nil).Audit), ctx, req)
+}
+
+// Checkpoint mocks base method.
+func (m *MockLoan) Checkpoint(ctx context.Context, req CheckpointRequest) (CheckpointResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Checkpoint", ctx, req)
+	ret0, _ := ret[0].(CheckpointResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Checkpoint indicates an expected call of Checkpoint.
+func (mr *MockLoanMockRecorder) Checkpoint(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Checkpoint", reflect.TypeOf((*MockLoan)(nil).Checkpoint), ctx, req)
+}
+
+// History mocks base method.
+func (m *MockLoan) History(ctx context.Context, req HistoryRequest) (HistoryResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "History", ctx, req)
+	ret0, _ := ret[0].(HistoryResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// History indicates an expected call of History.
+func (mr *MockLoanMockRecorder) History(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "History", reflect.TypeOf((*MockLoan)(nil).History), ctx, req)
+}
+
+// JWKS mocks base method.
+func (m *MockLoan) JWKS(ctx context.Context, req JWKSRequest) (JWKSResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "JWKS", ctx, req)
+	ret0, _ := ret[0].(JWKSResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// JWKS indicates an expected call of JWKS.
+func (mr *MockLoanMockRecorder) JWKS(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "JWKS", reflect.TypeOf((*MockLoan)(nil).JWKS), ctx, req)
+}
+
+// Portfolio mocks base method.
+func (m *MockLoan) Portfolio(ctx context.Context, req PortfolioRequest) (PortfolioResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Portfolio", ctx, req)
+	ret0, _ := ret[0].(PortfolioResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Portfolio indicates an expected call of Portfolio.
+func (mr *MockLoanMockRecorder) Portfolio(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Portfolio", reflect.TypeOf((*MockLoan)(nil).Portfolio), ctx, req)
+}
+
+// Quote mocks base method.
+func (m *MockLoan) Quote(ctx context.Context, req QuoteRequest) (QuoteResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Quote", ctx, req)
+	ret0, _ := ret[0].(QuoteResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Quote indicates an expected call of Quote.
+func (mr *MockLoanMockRecorder) Quote(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Quote", reflect.TypeOf((*MockLoan)(nil).Quote), ctx, req)
+}
+
+// Rebuild mocks base method.
+func (m *MockLoan) Rebuild(ctx context.Context, req RebuildRequest) (RebuildResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Rebuild", ctx, req)
+	ret0, _ := ret[0].(RebuildResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Rebuild indicates an expected call of Rebuild.
+func (mr *MockLoanMockRecorder) Rebuild(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Rebuild", reflect.TypeOf((*MockLoan)(nil).Rebuild), ctx, req)
+}
+
 // Upsert mocks base method.
 func (m *MockLoan) Upsert(ctx context.Context, req UpsertRequest) (UpsertResponse, error) {
 	m.ctrl.T.Helper()
@@ -1,13 +1,18 @@
 package loan_test
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"log/slog"
 	"testing"
 	"time"
 
+	"github.com/gunawanwijaya/loan-svc/internal/feature/contract"
+	"github.com/gunawanwijaya/loan-svc/internal/feature/document"
 	"github.com/gunawanwijaya/loan-svc/internal/feature/loan"
 	"github.com/gunawanwijaya/loan-svc/internal/repository/datastore"
+	"github.com/gunawanwijaya/loan-svc/internal/repository/keyring"
 	"github.com/gunawanwijaya/loan-svc/pkg"
 	"github.com/rs/xid"
 	"github.com/stretchr/testify/require"
@@ -25,6 +30,7 @@ func TestLoan(t *testing.T) {
 		ServiceFee:              .05, // 05%
 		MinRateOfInvestment:     .05, // 05%
 		NumOfMonthlyInstallment: 12,
+		PaymentReference:        pkg.PaymentReference{Prefix: "8808"},
 	}, loan.Dependency{
 		Datastore: mockDatastore,
 	})
@@ -43,18 +49,28 @@ func TestLoan(t *testing.T) {
 		Details: "factory expansion",
 	}
 
+	var paymentReference string
 	{
+		// a reference taken by another loan is generated again
 		mockDatastore.EXPECT().
 			Mutation(ctx, gomock.Any()).
-			Return(datastore.MutationResponse{Loans: &datastore.MutationResponseLoans{Loan: datastore.Loan{
-				LoanID:    loanID,
-				LoanState: datastore.StateProposed,
-				Parties: []datastore.LoanParty{{
-					LoanPartyID:     loanPartyID1,
-					UserID:          borrowerID,
-					LoanPartyRoleAs: datastore.RoleAsBorrower,
-				}},
-			}}}, nil)
+			Return(datastore.MutationResponse{}, &datastore.PaymentReferenceConflictError{PaymentReference: "8808"})
+		mockDatastore.EXPECT().
+			Mutation(ctx, gomock.Any()).
+			DoAndReturn(func(ctx context.Context, req datastore.MutationRequest) (datastore.MutationResponse, error) {
+				require.NotNil(t, req.Loans.Loan.PaymentReference)
+				paymentReference = *req.Loans.Loan.PaymentReference
+				return datastore.MutationResponse{Loans: &datastore.MutationResponseLoans{Loan: datastore.Loan{
+					LoanID:           loanID,
+					LoanState:        datastore.StateProposed,
+					PaymentReference: &paymentReference,
+					Parties: []datastore.LoanParty{{
+						LoanPartyID:     loanPartyID1,
+						UserID:          borrowerID,
+						LoanPartyRoleAs: datastore.RoleAsBorrower,
+					}},
+				}}}, nil
+			})
 	}
 	resUpsert, err := featLoan.Upsert(ctx, loan.UpsertRequest{Proposed: &loan.ProposedRequest{
 		BorrowerID: borrowerID,
@@ -63,6 +79,10 @@ func TestLoan(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, datastore.StateProposed.String(), resUpsert.LoanState)
 	require.Equal(t, loanID, resUpsert.LoanID)
+	require.Equal(t, paymentReference, resUpsert.PaymentReference)
+	require.Len(t, paymentReference, 16)
+	require.Equal(t, "8808", paymentReference[:4])
+	require.True(t, pkg.ValidPaymentReference(paymentReference))
 
 	{
 		mockDatastore.EXPECT().
@@ -94,21 +114,36 @@ func TestLoan(t *testing.T) {
 					UserID:          borrowerID,
 					LoanPartyRoleAs: datastore.RoleAsBorrower,
 					Payments: []datastore.LoanPartyPayment{{
-						ISO4217: principal.ISO4217,
-						Amount:  -principal.Amount,
-						Time:    principal.Time.Unix(),
-						Details: principal.Details,
+						PaymentType: datastore.PaymentPrincipalDisbursement,
+						ISO4217:     principal.ISO4217,
+						Amount:      -principal.Amount,
+						Time:        principal.Time.Unix(),
+						Details:     principal.Details,
 					}},
 				}},
 			}}}, nil)
 		mockDatastore.EXPECT().
 			Mutation(ctx, gomock.Any()).
-			Return(datastore.MutationResponse{Loans: &datastore.MutationResponseLoans{Loan: datastore.Loan{
-				LoanID:      loanID,
-				LoanState:   datastore.StateInvested,
-				ApprovedBy:  fieldOfficerID,
-				ApprovedDoc: pkg.Ptr("http://google.com"),
-			}}}, nil)
+			DoAndReturn(func(ctx context.Context, req datastore.MutationRequest) (datastore.MutationResponse, error) {
+				require.Len(t, req.Loans.Journals, 1)
+				journal, err := req.Loans.Journals[0].Validate(ctx)
+				require.NoError(t, err)
+				require.Len(t, journal.Postings, 8) // wallet into lender_payable & bank into cash_in_transit of each lender
+				for _, party := range req.Loans.Parties {
+					require.Equal(t, datastore.PaymentInvestment, party.Payments[0].PaymentType)
+					require.Equal(t, datastore.PaymentRepayment, party.Payments[1].PaymentType)
+				}
+				require.Equal(t, []datastore.WalletEntry{
+					{UserID: lenderID1, ISO4217: "IDR", Available: -5_000_000.00, Reserved: 5_000_000.00},
+					{UserID: lenderID2, ISO4217: "IDR", Available: -5_000_000.00, Reserved: 5_000_000.00},
+				}, req.Loans.Wallets)
+				return datastore.MutationResponse{Loans: &datastore.MutationResponseLoans{Loan: datastore.Loan{
+					LoanID:      loanID,
+					LoanState:   datastore.StateInvested,
+					ApprovedBy:  fieldOfficerID,
+					ApprovedDoc: pkg.Ptr("http://google.com"),
+				}}}, nil
+			})
 	}
 	resUpsert, err = featLoan.Upsert(ctx, loan.UpsertRequest{Invested: &loan.InvestedRequest{
 		LoanID: loanID,
@@ -135,36 +170,726 @@ func TestLoan(t *testing.T) {
 	require.Equal(t, loanID, resUpsert.LoanID)
 
 	{
+		lender := func(userID []byte) datastore.LoanParty {
+			return datastore.LoanParty{
+				UserID:          userID,
+				LoanPartyRoleAs: datastore.RoleAsLender,
+				Payments: []datastore.LoanPartyPayment{
+					{PaymentType: datastore.PaymentInvestment, ISO4217: "IDR", Amount: 5_000_000.00},
+					{PaymentType: datastore.PaymentRepayment, ISO4217: "IDR", Amount: -5_050_000.00},
+				},
+			}
+		}
+		installments := []datastore.LoanPartyPayment{{PaymentType: datastore.PaymentPrincipalDisbursement, ISO4217: "IDR", Amount: -principal.Amount}}
+		for range 12 {
+			installments = append(installments, datastore.LoanPartyPayment{PaymentType: datastore.PaymentInstallment, ISO4217: "IDR", Amount: 11_500_000.00 / 12})
+		}
+		invested := datastore.Loan{
+			LoanID:    loanID,
+			LoanState: datastore.StateInvested,
+			Parties: []datastore.LoanParty{{
+				LoanPartyID:     loanPartyID1,
+				UserID:          borrowerID,
+				LoanPartyRoleAs: datastore.RoleAsBorrower,
+				Payments:        installments,
+			}, lender(lenderID1), lender(lenderID2)},
+		}
+		mockDatastore.EXPECT().
+			Query(ctx, datastore.QueryRequest{Loans: &datastore.QueryRequestLoans{ByLoanID: loanID}}).
+			Return(datastore.QueryResponse{Loans: &datastore.QueryResponseLoans{Loan: invested}}, nil).
+			Times(3)
+		_, hash, err := contract.Document(invested, invested.Parties[0])
+		require.NoError(t, err)
+		contracts := func(verified bool, hash []byte) datastore.QueryResponse {
+			return datastore.QueryResponse{List: []datastore.QueryResponse{{Contracts: &datastore.QueryResponseContracts{Contract: datastore.Contract{
+				LoanID:          loanID,
+				UserID:          borrowerID,
+				LoanPartyRoleAs: datastore.RoleAsBorrower,
+				Hash:            hash,
+				Signatures:      []datastore.ContractSignature{{Verified: verified}},
+			}}}}}
+		}
+		gomock.InOrder(
+			mockDatastore.EXPECT().
+				Query(ctx, datastore.QueryRequest{Contracts: &datastore.QueryRequestContracts{ByLoanID: loanID}}).
+				Return(contracts(false, hash), nil),
+			// signed over a contract rendered from another content of the loan
+			mockDatastore.EXPECT().
+				Query(ctx, datastore.QueryRequest{Contracts: &datastore.QueryRequestContracts{ByLoanID: loanID}}).
+				Return(contracts(true, make([]byte, len(hash))), nil),
+			mockDatastore.EXPECT().
+				Query(ctx, datastore.QueryRequest{Contracts: &datastore.QueryRequestContracts{ByLoanID: loanID}}).
+				Return(contracts(true, hash), nil),
+		)
 		mockDatastore.EXPECT().
 			Mutation(ctx, gomock.Any()).
-			Return(datastore.MutationResponse{Loans: &datastore.MutationResponseLoans{Loan: datastore.Loan{
-				LoanID:       loanID,
-				LoanState:    datastore.StateDisbursed,
-				ApprovedBy:   fieldOfficerID,
-				ApprovedDoc:  pkg.Ptr("http://google.com"),
-				DisbursedBy:  fieldOfficerID,
-				DisbursedDoc: pkg.Ptr("http://google.com"),
-			}}}, nil)
+			DoAndReturn(func(ctx context.Context, req datastore.MutationRequest) (datastore.MutationResponse, error) {
+				require.Len(t, req.Loans.Journals, 1)
+				journal, err := req.Loans.Journals[0].Validate(ctx)
+				require.NoError(t, err)
+				balances := map[datastore.LedgerAccount]float64{}
+				for _, p := range journal.Postings {
+					balances[p.LedgerAccount] += p.Debit - p.Credit
+				}
+				require.InDelta(t, 11_500_000.00, balances[datastore.AccountBorrowerReceivable], 1e-6)
+				require.InDelta(t, -10_000_000.00, balances[datastore.AccountCashInTransit], 1e-6)
+				require.InDelta(t, -100_000.00, balances[datastore.AccountLenderPayable], 1e-6)
+				require.InDelta(t, -1_400_000.00, balances[datastore.AccountPlatformFeeRevenue], 1e-6)
+				require.Equal(t, []datastore.WalletEntry{
+					{UserID: lenderID1, ISO4217: "IDR", Reserved: -5_000_000.00},
+					{UserID: lenderID2, ISO4217: "IDR", Reserved: -5_000_000.00},
+				}, req.Loans.Wallets)
+				require.Len(t, req.Loans.Payouts, 1)
+				require.Equal(t, datastore.PayoutPending, req.Loans.Payouts[0].PayoutStatus)
+				require.Equal(t, "IDR", req.Loans.Payouts[0].ISO4217)
+				require.Equal(t, principal.Amount, req.Loans.Payouts[0].Amount)
+				require.Equal(t, "NL91ABNA0417164300", req.Loans.Payouts[0].AccountNumber)
+				return datastore.MutationResponse{Loans: &datastore.MutationResponseLoans{Loan: datastore.Loan{
+					LoanID:       loanID,
+					LoanState:    datastore.StateDisbursed,
+					ApprovedBy:   fieldOfficerID,
+					ApprovedDoc:  pkg.Ptr("http://google.com"),
+					DisbursedBy:  fieldOfficerID,
+					DisbursedDoc: pkg.Ptr("http://google.com"),
+				}}}, nil
+			})
 	}
-	resUpsert, err = featLoan.Upsert(ctx, loan.UpsertRequest{Disbursed: &loan.DisbursedRequest{
-		LoanID:                loanID,
-		BorrowerContract:      pkg.Ptr("http://google.com"),
-		DisbursementOfficerID: fieldOfficerID,
-	}})
+	disbursed := func() (loan.UpsertResponse, error) {
+		return featLoan.Upsert(ctx, loan.UpsertRequest{Disbursed: &loan.DisbursedRequest{
+			LoanID:                loanID,
+			BorrowerContract:      pkg.Ptr("http://google.com"),
+			DisbursementOfficerID: fieldOfficerID,
+			BorrowerAccount:       &loan.BankAccount{AccountName: "Budi", AccountNumber: "NL91 ABNA 0417 1643 00", BankCode: "abnanl2a"},
+		}})
+	}
+	_, err = disbursed()
+	require.ErrorContains(t, err, "borrower contract is not signed")
+	_, err = disbursed()
+	require.ErrorContains(t, err, "borrower contract is not signed")
+	resUpsert, err = disbursed()
 	require.NoError(t, err)
 	require.Equal(t, datastore.StateDisbursed.String(), resUpsert.LoanState)
 	require.Equal(t, loanID, resUpsert.LoanID)
+	require.NotEmpty(t, resUpsert.PayoutID)
 
 	{
 		mockDatastore.EXPECT().
 			Query(ctx, gomock.Any()).
 			Return(datastore.QueryResponse{Loans: &datastore.QueryResponseLoans{Loan: datastore.Loan{
-				LoanID:    loanID,
-				LoanState: datastore.StateDisbursed,
+				LoanID:           loanID,
+				LoanState:        datastore.StateDisbursed,
+				PaymentReference: &paymentReference,
 			}}}, nil)
 	}
 	resView, err := featLoan.View(ctx, loan.ViewRequest{LoanID: resUpsert.LoanID})
 	require.NoError(t, err)
 	require.Equal(t, resUpsert.LoanState, resView.LoanState)
 	require.Equal(t, resUpsert.LoanID, resView.LoanID)
+	require.Equal(t, paymentReference, resView.PaymentReference)
+}
+
+func TestLoanQuote(t *testing.T) {
+	ctx := pkg.Context.PutSlogLogger(context.Background(), slog.Default())
+	ctrl := gomock.NewController(t)
+	mockDatastore := datastore.NewMockDatastore(ctrl)
+
+	featLoan, err := loan.New(ctx, loan.Configuration{
+		LenderInterestRate:      .07, // 07%
+		InterestRate:            .10, // 10%
+		ServiceFee:              .05, // 05%
+		MinRateOfInvestment:     .05, // 05%
+		NumOfMonthlyInstallment: 12,
+	}, loan.Dependency{
+		Datastore: mockDatastore,
+	})
+	require.NoError(t, err)
+
+	loanID := xid.New().Bytes()
+	principal := &pkg.Money{
+		ISO4217: "IDR",
+		Amount:  10_000_000.00,
+		Time:    time.Now(),
+		Details: "factory expansion",
+	}
+
+	// no Mutation is expected, gomock will fail on any unexpected call
+	resQuote, err := featLoan.Quote(ctx, loan.QuoteRequest{Proposed: &loan.ProposedRequest{
+		BorrowerID: []byte("900"),
+		Principal:  principal,
+	}})
+	require.NoError(t, err)
+	require.Len(t, resQuote.ExpectedPayments, 12)
+	require.Equal(t, 1_000_000.00, resQuote.Interest.Amount)
+	require.Equal(t, 500_000.00, resQuote.ServiceFee.Amount)
+	require.InDelta(t, 11_500_000.00, resQuote.TotalRepayment.Amount, 0.001)
+	require.InDelta(t, 0.27, resQuote.Disclosure.APR, 0.01) // flat 15% over 12 months is ~27% APR
+	require.Greater(t, resQuote.Disclosure.EffectiveRate, resQuote.Disclosure.APR)
+
+	// a past-dated principal keep the same disclosure, the installments are anchored at the principal date
+	pastDated := &pkg.Money{
+		ISO4217: "IDR",
+		Amount:  10_000_000.00,
+		Time:    time.Date(2024, 10, 30, 0, 0, 0, 0, time.UTC),
+		Details: "factory expansion",
+	}
+	resPastDated, err := featLoan.Quote(ctx, loan.QuoteRequest{Proposed: &loan.ProposedRequest{
+		BorrowerID: []byte("900"),
+		Principal:  pastDated,
+	}})
+	require.NoError(t, err)
+	require.Equal(t, pastDated.Time.AddDate(0, 1, 0).Unix(), resPastDated.ExpectedPayments[0].Time.Unix())
+	require.Equal(t, pastDated.Time.AddDate(0, 12, 0).Unix(), resPastDated.TotalRepayment.Time.Unix())
+	require.InDelta(t, resQuote.Disclosure.APR, resPastDated.Disclosure.APR, 0.001)
+	require.InDelta(t, resQuote.Disclosure.EffectiveRate, resPastDated.Disclosure.EffectiveRate, 0.001)
+
+	{
+		mockDatastore.EXPECT().
+			Query(ctx, gomock.Any()).
+			Return(datastore.QueryResponse{Loans: &datastore.QueryResponseLoans{Loan: datastore.Loan{
+				LoanID:    loanID,
+				LoanState: datastore.StateApproved,
+				Parties: []datastore.LoanParty{{
+					UserID:          []byte("900"),
+					LoanPartyRoleAs: datastore.RoleAsBorrower,
+					Payments: []datastore.LoanPartyPayment{{
+						PaymentType: datastore.PaymentPrincipalDisbursement,
+						ISO4217:     principal.ISO4217,
+						Amount:      -principal.Amount,
+						Time:        principal.Time.Unix(),
+						Details:     principal.Details,
+					}},
+				}},
+			}}}, nil)
+	}
+	resQuote, err = featLoan.Quote(ctx, loan.QuoteRequest{Invested: &loan.InvestedRequest{
+		LoanID: loanID,
+		Lenders: []loan.LoanLender{{
+			LenderID: []byte("1111"),
+			Payment:  &pkg.Money{ISO4217: "IDR", Amount: 6_000_000.00, Time: time.Now()},
+		}, {
+			LenderID: []byte("1112"),
+			Payment:  &pkg.Money{ISO4217: "IDR", Amount: 6_000_000.00, Time: time.Now()},
+		}},
+	}})
+	require.NoError(t, err)
+	require.Len(t, resQuote.Invested.Used, 2)
+	require.Len(t, resQuote.Invested.Unused, 1)
+	require.Equal(t, 4_000_000.00, resQuote.Invested.Used[1].Payment.Amount)
+	require.Equal(t, -4_280_000.00, resQuote.Invested.Used[1].Repayment.Amount)
+	require.Equal(t, 2_000_000.00, resQuote.Invested.Unused[0].Payment.Amount)
+	require.Equal(t, principal.Amount, resQuote.Principal.Amount)
+}
+
+func TestLoanPortfolio(t *testing.T) {
+	ctx := pkg.Context.PutSlogLogger(context.Background(), slog.Default())
+	ctrl := gomock.NewController(t)
+	mockDatastore := datastore.NewMockDatastore(ctrl)
+
+	featLoan, err := loan.New(ctx, loan.Configuration{}, loan.Dependency{
+		Datastore: mockDatastore,
+	})
+	require.NoError(t, err)
+
+	lenderID := []byte("1111")
+	asOf := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	lenderParty := func(invested, repaid float64, investedAt, repaidAt time.Time) datastore.LoanParty {
+		return datastore.LoanParty{
+			UserID:          lenderID,
+			LoanPartyRoleAs: datastore.RoleAsLender,
+			Payments: []datastore.LoanPartyPayment{
+				{PaymentType: datastore.PaymentInvestment, ISO4217: "IDR", Amount: invested, Time: investedAt.Unix()},
+				{PaymentType: datastore.PaymentRepayment, ISO4217: "IDR", Amount: -repaid, Time: repaidAt.Unix()},
+			},
+		}
+	}
+	// borrowerParty will have 12 installments, the first `settled` of them are settled on its due date
+	borrowerParty := func(disbursedAt time.Time, settled int) datastore.LoanParty {
+		party := datastore.LoanParty{LoanPartyRoleAs: datastore.RoleAsBorrower}
+		for i := range 12 {
+			payment := datastore.LoanPartyPayment{PaymentType: datastore.PaymentInstallment, ISO4217: "IDR", Amount: 1_000_000, Time: disbursedAt.AddDate(0, i+1, 0).Unix()}
+			if i < settled {
+				payment.SettledAt = pkg.Ptr(payment.Time)
+			}
+			party.Payments = append(party.Payments, payment)
+		}
+		return party
+	}
+
+	loans := []datastore.Loan{{
+		// matured & fully settled, all interest earned
+		LoanID:    xid.New().Bytes(),
+		LoanState: datastore.StateDisbursed,
+		Parties: []datastore.LoanParty{
+			borrowerParty(asOf.AddDate(-1, 0, 0), 12),
+			lenderParty(1_000_000, 1_070_000, asOf.AddDate(-1, 0, 0), asOf),
+		},
+	}, {
+		// matured & half settled within the grace, half the interest earned & half the principal overdue
+		LoanID:    xid.New().Bytes(),
+		LoanState: datastore.StateDisbursed,
+		Parties: []datastore.LoanParty{
+			borrowerParty(asOf.AddDate(-1, -1, 0), 6),
+			lenderParty(2_000_000, 2_140_000, asOf.AddDate(-1, 0, 0), asOf.AddDate(0, -1, 0)),
+		},
+	}, {
+		// not yet matured, the single settled installment is earned
+		LoanID:    xid.New().Bytes(),
+		LoanState: datastore.StateDisbursed,
+		Parties: []datastore.LoanParty{
+			borrowerParty(asOf.AddDate(0, -1, 0), 1),
+			lenderParty(3_000_000, 3_210_000, asOf.AddDate(0, -1, 0), asOf.AddDate(0, 11, 0)),
+			// another lender of the same loan, not in the portfolio
+			{UserID: []byte("2222"), LoanPartyRoleAs: datastore.RoleAsLender, Payments: []datastore.LoanPartyPayment{
+				{PaymentType: datastore.PaymentInvestment, ISO4217: "IDR", Amount: 9_000_000, Time: asOf.AddDate(0, -1, 0).Unix()},
+			}},
+		},
+	}, {
+		// matured & a quarter settled past the grace, a quarter of the interest earned & the rest of the principal lost
+		LoanID:    xid.New().Bytes(),
+		LoanState: datastore.StateDisbursed,
+		Parties: []datastore.LoanParty{
+			borrowerParty(asOf.AddDate(-1, -6, 0), 3),
+			lenderParty(4_000_000, 4_280_000, asOf.AddDate(-1, -6, 0), asOf.AddDate(0, -6, 0)),
+		},
+	}, {
+		// matured but never disbursed, nothing is lost
+		LoanID:    xid.New().Bytes(),
+		LoanState: datastore.StateInvested,
+		Parties: []datastore.LoanParty{
+			borrowerParty(asOf.AddDate(-1, -6, 0), 0),
+			lenderParty(5_000_000, 5_350_000, asOf.AddDate(-1, -6, 0), asOf.AddDate(0, -6, 0)),
+		},
+	}}
+	{
+		// a single query along with every party of the loans
+		mockDatastore.EXPECT().
+			Query(ctx, datastore.QueryRequest{Loans: &datastore.QueryRequestLoans{ByLenderID: lenderID}}).
+			DoAndReturn(func(ctx context.Context, req datastore.QueryRequest) (res datastore.QueryResponse, err error) {
+				for _, l := range loans {
+					res.List = append(res.List, datastore.QueryResponse{Loans: &datastore.QueryResponseLoans{Loan: l}})
+				}
+				return
+			})
+	}
+	res, err := featLoan.Portfolio(ctx, loan.PortfolioRequest{LenderID: lenderID, AsOf: &asOf})
+	require.NoError(t, err)
+	require.Equal(t, lenderID, res.LenderID)
+	require.Len(t, res.Loans, 5)
+	require.Equal(t, []bool{false, true, false, false, true}, []bool{
+		res.Loans[0].Overdue, res.Loans[1].Overdue, res.Loans[2].Overdue, res.Loans[3].Overdue, res.Loans[4].Overdue,
+	})
+	require.Equal(t, 15_000_000.00, res.TotalInvested.Amount)
+	require.InDelta(t, 8_750_000.00, res.OutstandingPrincipal.Amount, 0.01)
+	require.InDelta(t, 6_000_000.00, res.OverduePrincipal.Amount, 0.01)
+	require.InDelta(t, 227_500.00, res.InterestEarned.Amount, 0.01)
+	require.InDelta(t, 612_500.00, res.ProjectedInterest.Amount, 0.01)
+	require.InDelta(t, 3_000_000.00, res.RealisedLoss.Amount, 0.01)
+	require.NotNil(t, res.XIRR)
+	require.Less(t, *res.XIRR, 0.0) // the realised loss outweigh all interest
+}
+
+func TestLoanAccrual(t *testing.T) {
+	ctx := pkg.Context.PutSlogLogger(context.Background(), slog.Default())
+	ctrl := gomock.NewController(t)
+	mockDatastore := datastore.NewMockDatastore(ctrl)
+
+	featLoan, err := loan.New(ctx, loan.Configuration{
+		InterestRate:            .10, // 10%
+		ServiceFee:              .05, // 05%
+		NumOfMonthlyInstallment: 12,
+		DayCountConvention:      pkg.DayCountACT360,
+	}, loan.Dependency{
+		Datastore: mockDatastore,
+	})
+	require.NoError(t, err)
+
+	loanID := xid.New().Bytes()
+	disbursedAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	payments := []datastore.LoanPartyPayment{{PaymentType: datastore.PaymentPrincipalDisbursement, ISO4217: "IDR", Amount: -12_000_000.00, Time: disbursedAt.Unix()}}
+	for i := range 12 {
+		payments = append(payments, datastore.LoanPartyPayment{PaymentType: datastore.PaymentInstallment, ISO4217: "IDR", Amount: 1_150_000.00, Time: disbursedAt.AddDate(0, i+1, 0).Unix()})
+	}
+	qry := datastore.QueryResponse{List: []datastore.QueryResponse{{Loans: &datastore.QueryResponseLoans{Loan: datastore.Loan{
+		LoanID:      loanID,
+		LoanState:   datastore.StateDisbursed,
+		DisbursedAt: pkg.Ptr(disbursedAt.Unix()),
+		Parties: []datastore.LoanParty{{
+			LoanPartyRoleAs: datastore.RoleAsBorrower,
+			Payments:        payments,
+		}},
+	}}}}}
+
+	asOf := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC) // 60 days since disbursement
+	{
+		mockDatastore.EXPECT().Query(ctx, gomock.Any()).Return(qry, nil)
+	}
+	res, err := featLoan.Accrual(ctx, loan.AccrualRequest{LoanID: loanID, AsOf: &asOf})
+	require.NoError(t, err)
+	require.Equal(t, pkg.DayCountACT360, res.DayCount)
+	require.InDelta(t, .10, res.AnnualRate, 1e-12)
+	require.Equal(t, 200_000.00, res.Accrued.Amount)
+	require.Equal(t, 200_000.00, res.Due.Amount)
+	require.Equal(t, 0.00, res.AccruedUnpaid.Amount)
+	require.Equal(t, 3_333.33, res.Daily.Amount)
+
+	{
+		mockDatastore.EXPECT().Query(ctx, gomock.Any()).Return(qry, nil)
+		mockDatastore.EXPECT().
+			Mutation(ctx, gomock.Any()).
+			DoAndReturn(func(ctx context.Context, req datastore.MutationRequest) (datastore.MutationResponse, error) {
+				require.NotNil(t, req.Accruals)
+				require.Len(t, req.Accruals.List, 1)
+				require.Equal(t, string(pkg.DayCountACT365), req.Accruals.List[0].DayCount)
+				require.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC).Unix(), req.Accruals.List[0].AccrualDate)
+				require.Equal(t, 3_287.67, req.Accruals.List[0].Amount)
+				require.Equal(t, 200_547.95, req.Accruals.List[0].Accrued)
+				return datastore.MutationResponse{Accruals: &datastore.MutationResponseAccruals{List: req.Accruals.List}}, nil
+			})
+	}
+	res, err = featLoan.Accrual(ctx, loan.AccrualRequest{AsOf: &asOf, DayCount: pkg.DayCountACT365, Record: true})
+	require.NoError(t, err)
+	require.Equal(t, 197_260.27, res.Accrued.Amount)
+	require.Equal(t, -2_739.73, res.AccruedUnpaid.Amount)
+
+	// the days missed since the last recorded accrual are written along with `as_of`
+	{
+		mockDatastore.EXPECT().Query(ctx, datastore.QueryRequest{Loans: &datastore.QueryRequestLoans{ByLoanState: datastore.StateDisbursed}}).Return(qry, nil)
+		mockDatastore.EXPECT().
+			Query(ctx, datastore.QueryRequest{Accruals: &datastore.QueryRequestAccruals{ByDayCount: string(pkg.DayCountACT360)}}).
+			Return(datastore.QueryResponse{Accruals: &datastore.QueryResponseAccruals{
+				LastAccrualDate: time.Date(2024, 2, 27, 0, 0, 0, 0, time.UTC).Unix(),
+			}}, nil)
+		mockDatastore.EXPECT().
+			Mutation(ctx, gomock.Any()).
+			DoAndReturn(func(ctx context.Context, req datastore.MutationRequest) (datastore.MutationResponse, error) {
+				require.Len(t, req.Accruals.List, 3)
+				for i, day := range []int{28, 29} {
+					require.Equal(t, time.Date(2024, 2, day, 0, 0, 0, 0, time.UTC).Unix(), req.Accruals.List[i].AccrualDate)
+				}
+				require.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC).Unix(), req.Accruals.List[2].AccrualDate)
+				require.Equal(t, 3_333.33, req.Accruals.List[0].Amount)
+				return datastore.MutationResponse{Accruals: &datastore.MutationResponseAccruals{List: req.Accruals.List}}, nil
+			})
+	}
+	_, err = featLoan.Accrual(ctx, loan.AccrualRequest{AsOf: &asOf, Record: true, Backfill: true})
+	require.NoError(t, err)
+}
+
+func TestLoanHistory(t *testing.T) {
+	ctx := pkg.Context.PutSlogLogger(context.Background(), slog.Default())
+	ctrl := gomock.NewController(t)
+	mockDatastore := datastore.NewMockDatastore(ctrl)
+
+	featLoan, err := loan.New(ctx, loan.Configuration{}, loan.Dependency{
+		Datastore: mockDatastore,
+	})
+	require.NoError(t, err)
+
+	loanID := xid.New().Bytes()
+	paymentID := xid.New().Bytes()
+	fieldOfficerID := []byte("777")
+	settledAt := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC).Unix()
+	event := func(version int, eventType datastore.LoanEventType, payload any) datastore.QueryResponse {
+		return datastore.QueryResponse{LoanEvents: &datastore.QueryResponseLoanEvents{LoanEvent: datastore.LoanEvent{
+			LoanID:        loanID,
+			Version:       version,
+			LoanEventType: eventType,
+			Payload:       pkg.Must1(json.Marshal(payload)),
+		}}}
+	}
+	events := []datastore.QueryResponse{
+		event(1, datastore.LoanProposed, datastore.Loan{LoanID: loanID, LoanState: datastore.StateProposed, Parties: []datastore.LoanParty{{
+			UserID:          []byte("900"),
+			LoanPartyRoleAs: datastore.RoleAsBorrower,
+			Payments:        []datastore.LoanPartyPayment{{PaymentID: paymentID, ISO4217: "IDR", Amount: 1_150_000.00}},
+		}}}),
+		event(2, datastore.LoanApproved, datastore.Loan{LoanID: loanID, LoanState: datastore.StateApproved, ApprovedBy: fieldOfficerID}),
+		event(3, datastore.LoanInvested, datastore.Loan{LoanID: loanID, LoanState: datastore.StateInvested, Parties: []datastore.LoanParty{{
+			UserID:          []byte("1111"),
+			LoanPartyRoleAs: datastore.RoleAsLender,
+		}}}),
+		event(4, datastore.LoanDisbursed, datastore.Loan{LoanID: loanID, LoanState: datastore.StateDisbursed, DisbursedBy: fieldOfficerID}),
+		event(5, datastore.LoanPaymentSettled, datastore.LoanPartyPayment{PaymentID: paymentID, SettledAt: &settledAt}),
+	}
+
+	{
+		mockDatastore.EXPECT().
+			Query(ctx, datastore.QueryRequest{LoanEvents: &datastore.QueryRequestLoanEvents{ByLoanID: loanID}}).
+			Return(datastore.QueryResponse{List: events}, nil)
+	}
+	res, err := featLoan.History(ctx, loan.HistoryRequest{LoanID: loanID})
+	require.NoError(t, err)
+	require.Equal(t, 5, res.Version)
+	require.Len(t, res.Events, 5)
+	require.Equal(t, "approved", res.Events[1].LoanState)
+	require.Equal(t, "payment_settled", res.Events[4].EventType)
+	require.Equal(t, "disbursed", res.Loan.LoanState)
+	require.Equal(t, []byte("900"), res.Loan.BorrowerID)
+	require.Len(t, res.Loan.Lenders, 1)
+
+	a, err := loan.Fold(events[0].LoanEvents.LoanEvent, events[1].LoanEvents.LoanEvent)
+	require.NoError(t, err)
+	require.Equal(t, fieldOfficerID, a.ApprovedBy)
+	require.ErrorContains(t, a.Apply(events[3].LoanEvents.LoanEvent), "expected version 3")
+	require.ErrorContains(t, a.Apply(event(3, datastore.LoanDisbursed, datastore.Loan{}).LoanEvents.LoanEvent), "expected state from [invested]")
+	var list []datastore.LoanEvent
+	for _, e := range events {
+		list = append(list, e.LoanEvents.LoanEvent)
+	}
+	list = append(list, event(6, datastore.LoanPaymentSettled, datastore.LoanPartyPayment{PaymentID: paymentID, SettledAt: &settledAt}).LoanEvents.LoanEvent)
+	_, err = loan.Fold(list...)
+	require.ErrorContains(t, err, "already settled")
+
+	{
+		mockDatastore.EXPECT().
+			Mutation(ctx, datastore.MutationRequest{LoanEvents: &datastore.MutationRequestLoanEvents{Import: true}}).
+			Return(datastore.MutationResponse{LoanEvents: &datastore.MutationResponseLoanEvents{Imported: 1}}, nil)
+		mockDatastore.EXPECT().
+			Query(ctx, datastore.QueryRequest{LoanEvents: &datastore.QueryRequestLoanEvents{}}).
+			Return(datastore.QueryResponse{List: events}, nil)
+		mockDatastore.EXPECT().
+			Mutation(ctx, gomock.Any()).
+			DoAndReturn(func(ctx context.Context, req datastore.MutationRequest) (datastore.MutationResponse, error) {
+				require.NotNil(t, req.LoanEvents)
+				require.Len(t, req.LoanEvents.Projections, 1)
+				require.Equal(t, datastore.StateDisbursed, req.LoanEvents.Projections[0].LoanState)
+				require.Equal(t, &settledAt, req.LoanEvents.Projections[0].Parties[0].Payments[0].SettledAt)
+				return datastore.MutationResponse{LoanEvents: &datastore.MutationResponseLoanEvents{Projected: 1}}, nil
+			})
+	}
+	resRebuild, err := featLoan.Rebuild(ctx, loan.RebuildRequest{})
+	require.NoError(t, err)
+	require.Equal(t, loan.RebuildResponse{Imported: 1, Rebuilt: 1}, resRebuild)
+}
+
+func TestLoanViewAsOf(t *testing.T) {
+	ctx := pkg.Context.PutSlogLogger(context.Background(), slog.Default())
+	ctrl := gomock.NewController(t)
+	mockDatastore := datastore.NewMockDatastore(ctrl)
+
+	featLoan, err := loan.New(ctx, loan.Configuration{}, loan.Dependency{
+		Datastore: mockDatastore,
+	})
+	require.NoError(t, err)
+
+	loanID := xid.New().Bytes()
+	day := func(d int) int64 { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC).Unix() }
+	installment := func(d int, settledAt *int64) datastore.LoanPartyPayment {
+		return datastore.LoanPartyPayment{PaymentID: xid.New().Bytes(), PaymentType: datastore.PaymentInstallment, ISO4217: "IDR", Amount: 1_150_000.00, Time: day(d), SettledAt: settledAt, CreatedAt: day(1), Verified: true}
+	}
+	qry := datastore.QueryResponse{List: []datastore.QueryResponse{{Loans: &datastore.QueryResponseLoans{Loan: datastore.Loan{
+		LoanID:      loanID,
+		LoanState:   datastore.StateDisbursed,
+		ApprovedBy:  []byte("777"),
+		ApprovedAt:  pkg.Ptr(day(2)),
+		DisbursedBy: []byte("777"),
+		DisbursedAt: pkg.Ptr(day(4)),
+		CreatedAt:   day(1),
+		Verified:    true,
+		Parties: []datastore.LoanParty{{
+			UserID:          []byte("900"),
+			LoanPartyRoleAs: datastore.RoleAsBorrower,
+			Payments:        []datastore.LoanPartyPayment{installment(20, pkg.Ptr(day(20))), installment(30, nil)},
+			CreatedAt:       day(1),
+			Verified:        true,
+		}, {
+			UserID:          []byte("1111"),
+			LoanPartyRoleAs: datastore.RoleAsLender,
+			CreatedAt:       day(3),
+			Verified:        true,
+		}},
+	}}}}}
+	view := func(d int) (loan.ViewResponse, error) {
+		mockDatastore.EXPECT().Query(ctx, gomock.Any()).Return(qry, nil)
+		asOf := time.Unix(day(d), 0)
+		return featLoan.View(ctx, loan.ViewRequest{LoanID: loanID, AsOf: &asOf})
+	}
+
+	_, err = view(0)
+	require.ErrorContains(t, err, "not created")
+
+	for d, state := range map[int]string{1: "proposed", 2: "approved", 3: "invested", 4: "disbursed"} {
+		res, err := view(d)
+		require.NoError(t, err)
+		require.Equal(t, state, res.LoanState, d)
+		require.Equal(t, d >= 3, len(res.Lenders) == 1, d)
+	}
+
+	res, err := view(10)
+	require.NoError(t, err)
+	require.Len(t, res.Outstanding, 2)
+	res, err = view(25)
+	require.NoError(t, err)
+	require.Len(t, res.Outstanding, 1)
+	require.Equal(t, time.Unix(day(30), 0), res.Outstanding[0].Time)
+	require.Equal(t, pkg.Ptr(true), res.Verified)
+
+	tampered := qry.List[0].Loans.Loan.Parties[0].Payments[1]
+	qry.List[0].Loans.Loan.Parties[0].Payments[1].Verified = false
+	res, err = view(25)
+	require.NoError(t, err)
+	require.Equal(t, pkg.Ptr(false), res.Verified)
+	require.Equal(t, []string{"loan_party_payments/" + pkg.BtoA(tampered.PaymentID)}, res.Unverified)
+
+	// valued on the due date yet reconciled a week later, outstanding until the reconciliation is recorded
+	qry.List[0].Loans.Loan.Parties[0].Payments[0].SettlementRecordedAt = pkg.Ptr(day(27))
+	res, err = view(25)
+	require.NoError(t, err)
+	require.Len(t, res.Outstanding, 2)
+	res, err = view(28)
+	require.NoError(t, err)
+	require.Len(t, res.Outstanding, 1)
+}
+
+func TestLoanAudit(t *testing.T) {
+	ctx := pkg.Context.PutSlogLogger(context.Background(), slog.Default())
+	ctrl := gomock.NewController(t)
+	mockDatastore := datastore.NewMockDatastore(ctrl)
+
+	featLoan, err := loan.New(ctx, loan.Configuration{}, loan.Dependency{
+		Datastore: mockDatastore,
+	})
+	require.NoError(t, err)
+
+	loanID := xid.New().Bytes()
+	proposed := pkg.Must1(json.Marshal(datastore.Loan{LoanID: loanID, LoanState: datastore.StateProposed}))
+	approved := pkg.Must1(json.Marshal(datastore.Loan{LoanID: loanID, LoanState: datastore.StateApproved}))
+	{
+		mockDatastore.EXPECT().
+			Query(ctx, datastore.QueryRequest{AuditLogs: &datastore.QueryRequestAuditLogs{ByLoanID: loanID}}).
+			Return(datastore.QueryResponse{List: []datastore.QueryResponse{
+				{AuditLogs: &datastore.QueryResponseAuditLogs{AuditLog: datastore.AuditLog{Operation: "loans.proposed", LoanID: loanID, Actor: "900", After: proposed}}},
+				{AuditLogs: &datastore.QueryResponseAuditLogs{AuditLog: datastore.AuditLog{Operation: "loans.approved", LoanID: loanID, Actor: "777", Before: proposed, After: approved}}},
+			}}, nil)
+	}
+	res, err := featLoan.Audit(ctx, loan.AuditRequest{LoanID: loanID})
+	require.NoError(t, err)
+	require.Len(t, res.List, 2)
+	require.Nil(t, res.List[0].Before)
+	require.Equal(t, "proposed", res.List[0].After.LoanState)
+	require.Equal(t, "777", res.List[1].Actor)
+	require.Equal(t, "proposed", res.List[1].Before.LoanState)
+	require.Equal(t, "approved", res.List[1].After.LoanState)
+
+	_, err = featLoan.Audit(ctx, loan.AuditRequest{})
+	require.ErrorContains(t, err, "invalid loan_id")
+}
+
+func TestLoanCheckpoint(t *testing.T) {
+	ctx := pkg.Context.PutSlogLogger(context.Background(), slog.Default())
+	ctrl := gomock.NewController(t)
+	mockDatastore := datastore.NewMockDatastore(ctrl)
+
+	featLoan, err := loan.New(ctx, loan.Configuration{}, loan.Dependency{
+		Datastore: mockDatastore,
+	})
+	require.NoError(t, err)
+
+	now := time.Now().Unix()
+	{
+		mockDatastore.EXPECT().
+			Mutation(ctx, datastore.MutationRequest{Checkpoints: &datastore.MutationRequestCheckpoints{}}).
+			Return(datastore.MutationResponse{Checkpoints: &datastore.MutationResponseCheckpoints{List: []datastore.ChainCheckpoint{
+				{Chain: datastore.ChainPayments, HeadSequence: 12, HeadHash: []byte("hash"), CreatedAt: now},
+			}}}, nil)
+	}
+	res, err := featLoan.Checkpoint(ctx, loan.CheckpointRequest{})
+	require.NoError(t, err)
+	require.Len(t, res.List, 1)
+	require.Equal(t, datastore.ChainPayments, res.List[0].Chain)
+	require.Equal(t, int64(12), res.List[0].HeadSequence)
+	require.Equal(t, now, res.List[0].CreatedAt.Unix())
+}
+
+func TestLoanReceipt(t *testing.T) {
+	ctx := pkg.Context.PutSlogLogger(context.Background(), slog.Default())
+	ctrl := gomock.NewController(t)
+	mockDatastore := datastore.NewMockDatastore(ctrl)
+	repoKeyring, err := keyring.New(ctx, keyring.Configuration{}, keyring.Dependency{})
+	require.NoError(t, err)
+
+	featLoan, err := loan.New(ctx, loan.Configuration{}, loan.Dependency{
+		Datastore: mockDatastore,
+		Keyring:   repoKeyring,
+	})
+	require.NoError(t, err)
+
+	loanID, fieldOfficerID := xid.New().Bytes(), []byte("777")
+	{
+		mockDatastore.EXPECT().
+			Mutation(ctx, gomock.Any()).
+			Return(datastore.MutationResponse{Loans: &datastore.MutationResponseLoans{Loan: datastore.Loan{
+				LoanID:    loanID,
+				LoanState: datastore.StateApproved,
+			}}}, nil)
+	}
+	res, err := featLoan.Upsert(ctx, loan.UpsertRequest{Approved: &loan.ApprovedRequest{
+		LoanID:           loanID,
+		ApprovedDocument: pkg.Ptr("http://google.com"),
+		FieldOfficerID:   fieldOfficerID,
+	}})
+	require.NoError(t, err)
+	require.NotNil(t, res.Receipt)
+
+	jwks, err := featLoan.JWKS(ctx, loan.JWKSRequest{})
+	require.NoError(t, err)
+	require.Len(t, jwks.Keys, 1)
+	require.NoError(t, pkg.VerifyDetachedJWS(res.Receipt.JWS, res.Receipt.Payload, jwks.JWKS))
+
+	var payload loan.ReceiptPayload
+	require.NoError(t, json.Unmarshal(res.Receipt.Payload, &payload))
+	require.Equal(t, loanID, payload.LoanID)
+	require.Equal(t, "approved", payload.LoanState)
+	require.Equal(t, fieldOfficerID, payload.Request.Approved.FieldOfficerID)
+	require.NotZero(t, payload.IssuedAt)
+
+	// the receipt is bound to its payload
+	var errVerify *pkg.VerifyJWSError
+	forged := bytes.Replace(res.Receipt.Payload, []byte(`"approved"`), []byte(`"disbursed"`), 1)
+	require.ErrorAs(t, pkg.VerifyDetachedJWS(res.Receipt.JWS, forged, jwks.JWKS), &errVerify)
+
+	// rotated, the retired key is still published to verify the receipt
+	_, err = repoKeyring.Rotate(ctx, keyring.RotateRequest{Force: true})
+	require.NoError(t, err)
+	jwks, err = featLoan.JWKS(ctx, loan.JWKSRequest{})
+	require.NoError(t, err)
+	require.Len(t, jwks.Keys, 2)
+	require.NoError(t, pkg.VerifyDetachedJWS(res.Receipt.JWS, res.Receipt.Payload, jwks.JWKS))
+}
+
+func TestLoanDocument(t *testing.T) {
+	ctx := pkg.Context.PutSlogLogger(context.Background(), slog.Default())
+	ctrl := gomock.NewController(t)
+	mockDatastore := datastore.NewMockDatastore(ctrl)
+	mockDocument := document.NewMockDocument(ctrl)
+
+	loanID, fieldOfficerID := xid.New().Bytes(), []byte("777")
+	approved := loan.UpsertRequest{Approved: &loan.ApprovedRequest{LoanID: loanID, FieldOfficerID: fieldOfficerID}}
+
+	// the link is required without document generation
+	featLoan, err := loan.New(ctx, loan.Configuration{}, loan.Dependency{Datastore: mockDatastore})
+	require.NoError(t, err)
+	_, err = featLoan.Upsert(ctx, approved)
+	require.ErrorContains(t, err, "invalid approved_document")
+
+	featLoan, err = loan.New(ctx, loan.Configuration{}, loan.Dependency{Datastore: mockDatastore, Document: mockDocument})
+	require.NoError(t, err)
+	link := document.Link(make([]byte, 32))
+	{
+		mockDocument.EXPECT().
+			Generate(ctx, document.GenerateRequest{LoanID: loanID}).
+			Return(document.DocumentResponse{List: []document.DocumentResponse{
+				{Kind: document.KindRepaymentSchedule, MediaType: document.MediaTypeHTML, Link: "/document/html"},
+				{Kind: document.KindRepaymentSchedule, MediaType: document.MediaTypePDF, Link: link},
+			}}, nil)
+		mockDatastore.EXPECT().
+			Mutation(ctx, gomock.Any()).
+			DoAndReturn(func(ctx context.Context, req datastore.MutationRequest) (datastore.MutationResponse, error) {
+				require.Equal(t, link, *req.Loans.Loan.ApprovedDoc)
+				return datastore.MutationResponse{Loans: &datastore.MutationResponseLoans{Loan: req.Loans.Loan}}, nil
+			})
+	}
+	res, err := featLoan.Upsert(ctx, approved)
+	require.NoError(t, err)
+	require.Equal(t, datastore.StateApproved.String(), res.LoanState)
 }
@@ -0,0 +1,71 @@
+package loan
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gunawanwijaya/loan-svc/internal/repository/datastore"
+	"github.com/gunawanwijaya/loan-svc/pkg"
+	"github.com/rs/xid"
+)
+
+type BankAccount struct {
+	AccountName   string `json:"account_name,omitempty"`
+	AccountNumber string `json:"account_number,omitempty"` // IBAN or domestic account number
+	BankCode      string `json:"bank_code,omitempty"`      // BIC or domestic bank code
+}
+
+func (x *BankAccount) Validate(ctx context.Context) (_ *BankAccount, err error) {
+	if x == nil {
+		return nil, fmt.Errorf("invalid borrower_account")
+	}
+	x.AccountName = strings.TrimSpace(x.AccountName)
+	x.AccountNumber = strings.ToUpper(strings.ReplaceAll(x.AccountNumber, " ", ""))
+	x.BankCode = strings.ToUpper(strings.TrimSpace(x.BankCode))
+	if x.AccountName == "" {
+		return nil, fmt.Errorf("invalid account_name")
+	}
+	if x.AccountNumber == "" {
+		return nil, fmt.Errorf("invalid account_number")
+	}
+	if x.BankCode == "" {
+		return nil, fmt.Errorf("invalid bank_code")
+	}
+	return x, nil
+}
+
+// disbursedPayout will instruct the payment of the whole principal to the borrower account, there are no upfront
+// fees: the service fee is paid within the installments.
+func disbursedPayout(ctx context.Context, l datastore.Loan, account *BankAccount) (p datastore.PayoutInstruction, err error) {
+	var principal *datastore.LoanPartyPayment
+	for _, party := range l.Parties {
+		if party.LoanPartyRoleAs != datastore.RoleAsBorrower {
+			continue
+		}
+		for i, payment := range party.Payments {
+			if payment.PaymentType == datastore.PaymentPrincipalDisbursement {
+				principal = &party.Payments[i]
+			}
+		}
+	}
+	if principal == nil {
+		return p, fmt.Errorf("invalid principal value")
+	}
+	p.Amount = (&pkg.Money{ISO4217: principal.ISO4217, Amount: -principal.Amount}).Round().Amount
+	if p.Amount <= 0 {
+		return p, fmt.Errorf("invalid principal value")
+	}
+	p.InstructionID = xid.New().Bytes()
+	p.LoanID = l.LoanID
+	p.PaymentID = principal.PaymentID
+	p.ISO4217 = principal.ISO4217
+	p.BankAccount = datastore.BankAccount{
+		AccountName:   account.AccountName,
+		AccountNumber: account.AccountNumber,
+		BankCode:      account.BankCode,
+	}
+	p.Reference = fmt.Sprintf("Disbursement %s loan %s", pkg.BtoA(principal.PaymentID), pkg.BtoA(l.LoanID))
+	p.PayoutStatus = datastore.PayoutPending
+	return p, nil
+}
@@ -0,0 +1,214 @@
+package loan
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/gunawanwijaya/loan-svc/internal/repository/datastore"
+	"github.com/gunawanwijaya/loan-svc/pkg"
+)
+
+type PortfolioRequest struct {
+	LenderID []byte     `json:"lender_id,omitempty"`
+	AsOf     *time.Time `json:"as_of,omitempty"` // default to now
+}
+
+// PortfolioResponse is the lender portfolio of a single currency, multiple currencies are returned in List.
+type PortfolioResponse struct {
+	List []PortfolioResponse `json:"list,omitempty"`
+
+	LenderID             []byte     `json:"lender_id,omitempty"`
+	TotalInvested        *pkg.Money `json:"total_invested,omitempty"`
+	OutstandingPrincipal *pkg.Money `json:"outstanding_principal,omitempty"`
+	InterestEarned       *pkg.Money `json:"interest_earned,omitempty"`
+	OverduePrincipal     *pkg.Money `json:"overdue_principal,omitempty"` // part of the outstanding principal past its last repayment
+	ProjectedInterest    *pkg.Money `json:"projected_interest,omitempty"`
+	RealisedLoss         *pkg.Money `json:"realised_loss,omitempty"`
+	XIRR                 *float64   `json:"xirr,omitempty"` // money-weighted annual return of all dated payments
+
+	Loans []PortfolioLoan `json:"loans,omitempty"`
+}
+
+type PortfolioLoan struct {
+	LoanID    []byte       `json:"loan_id,omitempty"`
+	LoanState string       `json:"loan_state,omitempty"`
+	Matured   bool         `json:"matured"`
+	Overdue   bool         `json:"overdue"` // matured with unreceived principal, not yet a loss
+	Payments  []*pkg.Money `json:"payments,omitempty"`
+}
+
+// Portfolio will aggregate all lender parties of a user across loans, each party is a position where
+//   - positive payments are the investment & negative payments are the repayment to the lender
+//   - the repayment is received pro-rata to the borrower installments settled at `as_of`, each received amount
+//     split between principal & interest in the same proportion as the repayment
+//   - received interest is earned, regardless of the position being matured
+//   - a position is matured once all of its repayment are due at `as_of`, its unreceived principal is a loss only
+//     once the loan is disbursed & the last repayment is past by the loss_grace_days, otherwise it is overdue
+//   - position not yet matured or overdue counted its unreceived principal & interest as outstanding & projected
+func (x *loan) Portfolio(ctx context.Context, req PortfolioRequest) (res PortfolioResponse, err error) {
+	log := pkg.Context.SlogLogger(ctx)
+	if len(req.LenderID) < 1 {
+		err = fmt.Errorf("invalid lender_id")
+		return
+	}
+	asOf := time.Now()
+	if req.AsOf != nil {
+		asOf = *req.AsOf
+	}
+
+	var qry datastore.QueryResponse
+	qry, err = x.Dependency.Datastore.Query(ctx, datastore.QueryRequest{
+		Loans: &datastore.QueryRequestLoans{
+			ByLenderID: req.LenderID,
+		},
+	})
+	if err != nil {
+		return
+	}
+
+	currencies := []string{}
+	byCurrency := map[string]*PortfolioResponse{}
+	flows := map[string][]pkg.CashFlow{}
+	for _, qry := range qry.List {
+		// the loan is listed along with all of its parties, the borrower installments are settled for every position
+		settlements := portfolioSettlements(qry.Loans.Loan, asOf)
+		for _, party := range qry.Loans.Loan.Parties {
+			if party.LoanPartyRoleAs != datastore.RoleAsLender || !bytes.Equal(party.UserID, req.LenderID) || len(party.Payments) < 1 {
+				continue
+			}
+			iso4217 := party.Payments[0].ISO4217
+			p, ok := byCurrency[iso4217]
+			if !ok {
+				zero := func() *pkg.Money { return &pkg.Money{ISO4217: iso4217, Time: asOf} }
+				p = &PortfolioResponse{
+					LenderID:             req.LenderID,
+					TotalInvested:        zero(),
+					OutstandingPrincipal: zero(),
+					OverduePrincipal:     zero(),
+					InterestEarned:       zero(),
+					ProjectedInterest:    zero(),
+					RealisedLoss:         zero(),
+				}
+				byCurrency[iso4217] = p
+				currencies = append(currencies, iso4217)
+			}
+
+			var invested, repaid float64
+			var repaidAt time.Time
+			position := PortfolioLoan{
+				LoanID:    qry.Loans.Loan.LoanID,
+				LoanState: qry.Loans.Loan.LoanState.String(),
+				Matured:   true,
+			}
+			for _, payment := range party.Payments {
+				if payment.ISO4217 != iso4217 {
+					err = fmt.Errorf("different currency")
+					return
+				}
+				switch payment.PaymentType {
+				case datastore.PaymentInvestment:
+					invested += payment.Amount
+					// lender perspective, investment is money going out
+					flows[iso4217] = append(flows[iso4217], pkg.CashFlow{Amount: -payment.Amount, Time: time.Unix(payment.Time, 0)})
+				case datastore.PaymentRepayment, datastore.PaymentRefund:
+					repaid -= payment.Amount
+					if t := time.Unix(payment.Time, 0); t.After(repaidAt) {
+						repaidAt = t
+					}
+					position.Matured = position.Matured && !time.Unix(payment.Time, 0).After(asOf)
+				}
+				position.Payments = append(position.Payments, &pkg.Money{
+					ISO4217: payment.ISO4217,
+					Amount:  payment.Amount,
+					Details: payment.Details,
+					Time:    time.Unix(payment.Time, 0),
+				})
+			}
+
+			var received float64
+			for _, settlement := range settlements {
+				// lender perspective, repayment is money coming in as the borrower settle its installments
+				flows[iso4217] = append(flows[iso4217], pkg.CashFlow{Amount: repaid * settlement.Amount, Time: settlement.Time})
+				received += repaid * settlement.Amount
+			}
+			var principalReceived float64
+			if repaid > 0 {
+				principalReceived = received * invested / repaid
+			}
+
+			p.TotalInvested.Amount += invested
+			p.InterestEarned.Amount += received - principalReceived
+			lost := qry.Loans.Loan.LoanState == datastore.StateDisbursed &&
+				!asOf.Before(repaidAt.AddDate(0, 0, x.Configuration.LossGraceDays))
+			// fully received once the fractions of the settled installments sum up to the whole, rounding aside
+			position.Overdue = position.Matured && !lost && invested-principalReceived > invested*1e-9
+			switch {
+			case !position.Matured || position.Overdue:
+				p.OutstandingPrincipal.Amount += invested - principalReceived
+				p.ProjectedInterest.Amount += max(repaid-invested, 0) - (received - principalReceived)
+				if position.Overdue {
+					p.OverduePrincipal.Amount += invested - principalReceived
+				}
+				if repaid > received {
+					// still expected, the overdue ones from `as_of`
+					flows[iso4217] = append(flows[iso4217], pkg.CashFlow{Amount: repaid - received, Time: pkg.OrElse(position.Overdue, asOf, repaidAt)})
+				}
+			default:
+				p.RealisedLoss.Amount += max(invested-principalReceived, 0)
+			}
+			p.Loans = append(p.Loans, position)
+		}
+	}
+
+	slices.Sort(currencies)
+	for _, iso4217 := range currencies {
+		p := byCurrency[iso4217]
+		if xirr, err := pkg.XIRR(flows[iso4217]...); err == nil {
+			p.XIRR = &xirr
+		} else {
+			log.DebugContext(ctx, "feature/loan.Portfolio xirr", slog.Any("err", err))
+		}
+		res.List = append(res.List, *p)
+	}
+
+	if len(res.List) == 1 {
+		res = res.List[0]
+	} else {
+		res.LenderID = req.LenderID
+	}
+
+	log.DebugContext(ctx, "feature/loan.Portfolio",
+		slog.Any("req", req),
+		slog.Any("res", res),
+		slog.Any("err", err),
+	)
+	return
+}
+
+// portfolioSettlements will list the borrower installments of the loan settled at `as_of`, each amount is the
+// fraction of the whole installments being settled, dated at its settlement.
+func portfolioSettlements(l datastore.Loan, asOf time.Time) (settlements []pkg.CashFlow) {
+	var total float64
+	for _, party := range l.Parties {
+		if party.LoanPartyRoleAs != datastore.RoleAsBorrower {
+			continue
+		}
+		for _, payment := range party.Payments {
+			if payment.PaymentType != datastore.PaymentInstallment {
+				continue
+			}
+			total += payment.Amount
+			if payment.SettledAt != nil && *payment.SettledAt <= asOf.Unix() {
+				settlements = append(settlements, pkg.CashFlow{Amount: payment.Amount, Time: time.Unix(*payment.SettledAt, 0)})
+			}
+		}
+	}
+	for i := range settlements {
+		settlements[i].Amount /= total
+	}
+	return
+}
@@ -0,0 +1,95 @@
+package loan
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/gunawanwijaya/loan-svc/internal/repository/datastore"
+	"github.com/gunawanwijaya/loan-svc/pkg"
+)
+
+type QuoteRequest struct {
+	Proposed *ProposedRequest `json:"proposed,omitempty"`
+	Invested *InvestedRequest `json:"invested,omitempty"`
+}
+
+type QuoteResponse struct {
+	Principal        *pkg.Money   `json:"principal,omitempty"`
+	Interest         *pkg.Money   `json:"interest,omitempty"`
+	ServiceFee       *pkg.Money   `json:"service_fee,omitempty"`
+	TotalRepayment   *pkg.Money   `json:"total_repayment,omitempty"`
+	ExpectedPayments []*pkg.Money `json:"expected_payments,omitempty"`
+	Disclosure       *Disclosure  `json:"disclosure,omitempty"`
+
+	Invested *InvestedResponse `json:"invested,omitempty"`
+}
+
+// Quote will simulate the proposed or invested flow without persisting anything, the same schedule &
+// investment logic from Upsert is used so that the quote is exactly what the borrower or lenders will get.
+func (x *loan) Quote(ctx context.Context, req QuoteRequest) (res QuoteResponse, err error) {
+	log := pkg.Context.SlogLogger(ctx)
+	switch {
+	default:
+		err = fmt.Errorf("empty quote request")
+	case req.Proposed != nil:
+		log.DebugContext(ctx, "feature/loan.Quote proposed")
+		var p *ProposedRequest
+		if p, err = pkg.AsValidator(req.Proposed).Validate(ctx); err == nil {
+			res, err = x.quoteProposed(ctx, p)
+		}
+	case req.Invested != nil:
+		log.DebugContext(ctx, "feature/loan.Quote invested")
+		var i *InvestedRequest
+		if i, err = pkg.AsValidator(req.Invested).Validate(ctx); err == nil {
+			res, err = x.quoteInvested(ctx, i)
+		}
+	}
+	log.DebugContext(ctx, "feature/loan.Quote",
+		slog.Any("req", req),
+		slog.Any("res", res),
+		slog.Any("err", err),
+	)
+	return
+}
+
+func (x *loan) quoteProposed(ctx context.Context, p *ProposedRequest) (res QuoteResponse, err error) {
+	payments, err := x.proposedPayments(nil, p.Principal)
+	if err != nil {
+		return
+	}
+	if res.Interest, _, err = p.Principal.Take(x.Configuration.InterestRate); err != nil {
+		return
+	}
+	if res.ServiceFee, _, err = p.Principal.Take(x.Configuration.ServiceFee); err != nil {
+		return
+	}
+	if res.Disclosure, err = disclose(payments); err != nil {
+		return
+	}
+	res.Principal = p.Principal
+	res.TotalRepayment = &pkg.Money{ISO4217: p.Principal.ISO4217, Details: "Total repayment"}
+	for _, payment := range payments {
+		if payment.PaymentType != datastore.PaymentInstallment {
+			continue
+		}
+		res.TotalRepayment.Amount += payment.Amount
+		res.TotalRepayment.Time = time.Unix(payment.Time, 0)
+		res.ExpectedPayments = append(res.ExpectedPayments, &pkg.Money{
+			ISO4217: payment.ISO4217,
+			Amount:  payment.Amount,
+			Details: payment.Details,
+			Time:    time.Unix(payment.Time, 0),
+		})
+	}
+	return
+}
+
+func (x *loan) quoteInvested(ctx context.Context, i *InvestedRequest) (res QuoteResponse, err error) {
+	if res.Principal, err = x.investedPrincipal(ctx, i.LoanID); err != nil {
+		return
+	}
+	res.Invested, err = x.investedLenders(i.LoanID, res.Principal, i.Lenders)
+	return
+}
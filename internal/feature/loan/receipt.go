@@ -0,0 +1,83 @@
+package loan
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/gunawanwijaya/loan-svc/internal/repository/keyring"
+	"github.com/gunawanwijaya/loan-svc/pkg"
+)
+
+// Receipt is the proof of a mutation being accepted, a detached JWS of EdDSA over the payload sent along. It is
+// verified offline by pkg.VerifyDetachedJWS against the JWK Set of the service, over the payload as received.
+type Receipt struct {
+	JWS     string          `json:"jws"`     // header..signature, the payload is left out
+	Payload json.RawMessage `json:"payload"` // ReceiptPayload as signed
+}
+
+// ReceiptPayload is the content of a receipt.
+type ReceiptPayload struct {
+	Request   UpsertRequest `json:"request"` // as validated by the service
+	LoanID    []byte        `json:"loan_id"`
+	LoanState string        `json:"loan_state"` // resulting state of the loan
+	IssuedAt  int64         `json:"iat"`        // Unix timestamp of the server
+}
+
+// receipt will sign the validated request along with the resulting loan state, nil without keyring. The mutation is
+// already committed, a receipt failing to be made is logged instead of failing the mutation.
+func (x *loan) receipt(ctx context.Context, req UpsertRequest, res UpsertResponse) *Receipt {
+	log := pkg.Context.SlogLogger(ctx)
+	if x.Dependency.Keyring == nil {
+		return nil
+	}
+	payload, err := json.Marshal(ReceiptPayload{
+		Request:   req,
+		LoanID:    res.LoanID,
+		LoanState: res.LoanState,
+		IssuedAt:  time.Now().Unix(),
+	})
+	if err != nil {
+		log.ErrorContext(ctx, "feature/loan.receipt", slog.Any("err", err))
+		return nil
+	}
+
+	// the kid is signed within the header, signed again in case the key is rotated in between
+	for {
+		keys := x.Dependency.Keyring.Keys()
+		keyID := keys[len(keys)-1].KeyID
+		var signedBy []byte
+		jws := pkg.DetachedJWS(base64.RawURLEncoding.EncodeToString(keyID), payload, func(input []byte) []byte {
+			sig := x.Dependency.Keyring.Sign(input)
+			signedBy = sig[:keyring.KeyIDSize]
+			return sig[keyring.KeyIDSize:]
+		})
+		if bytes.Equal(signedBy, keyID) {
+			return &Receipt{JWS: jws, Payload: payload}
+		}
+	}
+}
+
+type JWKSRequest struct{}
+
+type JWKSResponse struct {
+	pkg.JWKS
+}
+
+// JWKS will publish every public key of the keyring verifying the receipts, retired keys are kept to verify what
+// they signed.
+func (x *loan) JWKS(ctx context.Context, req JWKSRequest) (res JWKSResponse, err error) {
+	if x.Dependency.Keyring == nil {
+		err = fmt.Errorf("receipts are not signed")
+		return
+	}
+	res.Keys = []pkg.JWK{}
+	for _, k := range x.Dependency.Keyring.Keys() {
+		res.Keys = append(res.Keys, pkg.NewJWK(k.KeyID, k.PublicKey))
+	}
+	return
+}
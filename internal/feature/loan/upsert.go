@@ -1,12 +1,16 @@
 package loan
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"slices"
 	"time"
 
+	"github.com/gunawanwijaya/loan-svc/internal/feature/contract"
+	"github.com/gunawanwijaya/loan-svc/internal/feature/document"
 	"github.com/gunawanwijaya/loan-svc/internal/repository/datastore"
 	"github.com/gunawanwijaya/loan-svc/pkg"
 	"github.com/rs/xid"
@@ -24,9 +28,12 @@ type UpsertRequest struct {
 type UpsertResponse struct {
 	List []UpsertResponse `json:"list,omitempty"`
 
-	LoanID    []byte            `json:"loan_id,omitempty"`
-	LoanState string            `json:"loan_state,omitempty"`
-	Invested  *InvestedResponse `json:"invested,omitempty"`
+	LoanID           []byte            `json:"loan_id,omitempty"`
+	LoanState        string            `json:"loan_state,omitempty"`
+	PaymentReference string            `json:"payment_reference,omitempty"` // given upon proposal for repayment
+	Invested         *InvestedResponse `json:"invested,omitempty"`
+	PayoutID         []byte            `json:"payout_id,omitempty"` // instruction created upon disbursement
+	Receipt          *Receipt          `json:"receipt,omitempty"`   // proof of the mutation being accepted, nil without keyring
 }
 
 func (x *loan) Upsert(ctx context.Context, req UpsertRequest) (res UpsertResponse, err error) {
@@ -36,25 +43,33 @@ func (x *loan) Upsert(ctx context.Context, req UpsertRequest) (res UpsertRespons
 		log.DebugContext(ctx, "feature/loan.Upsert proposed")
 		var p *ProposedRequest
 		if p, err = pkg.AsValidator(req.Proposed).Validate(ctx); err == nil {
-			return x.upsertProposed(ctx, p)
+			if res, err = x.upsertProposed(ctx, p); err == nil {
+				res.Receipt = x.receipt(ctx, UpsertRequest{Proposed: p}, res)
+			}
 		}
 	case req.Approved != nil:
 		log.DebugContext(ctx, "feature/loan.Upsert approved")
 		var a *ApprovedRequest
 		if a, err = pkg.AsValidator(req.Approved).Validate(ctx); err == nil {
-			return x.upsertApproved(ctx, a)
+			if res, err = x.upsertApproved(ctx, a); err == nil {
+				res.Receipt = x.receipt(ctx, UpsertRequest{Approved: a}, res)
+			}
 		}
 	case req.Invested != nil:
 		log.DebugContext(ctx, "feature/loan.Upsert invested")
 		var i *InvestedRequest
 		if i, err = pkg.AsValidator(req.Invested).Validate(ctx); err == nil {
-			return x.upsertInvested(ctx, i)
+			if res, err = x.upsertInvested(ctx, i); err == nil {
+				res.Receipt = x.receipt(ctx, UpsertRequest{Invested: i}, res)
+			}
 		}
 	case req.Disbursed != nil:
 		log.DebugContext(ctx, "feature/loan.Upsert disbursed")
 		var d *DisbursedRequest
 		if d, err = pkg.AsValidator(req.Disbursed).Validate(ctx); err == nil {
-			return x.upsertDisbursed(ctx, d)
+			if res, err = x.upsertDisbursed(ctx, d); err == nil {
+				res.Receipt = x.receipt(ctx, UpsertRequest{Disbursed: d}, res)
+			}
 		}
 	}
 	log.DebugContext(ctx, "feature/loan.Upsert",
@@ -70,80 +85,125 @@ func (x *loan) Upsert(ctx context.Context, req UpsertRequest) (res UpsertRespons
 //   - 10% interest rate
 //   - total repayment expected is principal + 5% + 10%
 //   - total repayment then converted into 12 times installment evenly spread out
+//
+// the loan is given a payment reference prefixed by the configured bank, to be quoted by the borrower on repayment.
 func (x *loan) upsertProposed(ctx context.Context, p *ProposedRequest) (res UpsertResponse, err error) {
 	loanID := xid.New()
 	loanPartyID := xid.New()
-	interest, _, err := p.Principal.Take(x.Configuration.InterestRate)
-	service, _, err := p.Principal.Take(x.Configuration.ServiceFee)
-	repayment, err := p.Principal.Sum(interest, service)
-
-	split := x.Configuration.NumOfMonthlyInstallment
-	installments, i := []*pkg.Money{}, split
-	installment, err := repayment.Sum(nil)
-	installmentTime := time.Now().AddDate(0, 1, 0)
-	payments := []datastore.LoanPartyPayment{{
-		ISO4217: p.Principal.ISO4217,
-		Amount:  -p.Principal.Amount,
-		Time:    p.Principal.Time.Unix(),
-		Details: p.Principal.Details,
-	}}
-
-	// log := pkg.Context.SlogLogger(ctx)
-	// log.DebugContext(ctx, "",
-	// 	slog.Any("interest", interest),
-	// 	slog.Any("service", service),
-	// 	slog.Any("repayment", repayment),
-	// 	slog.Any("payments", payments),
-	// 	slog.Any("split", split),
-	// )
-
-	for range make([]struct{}, split, split) {
-		var take *pkg.Money
-		take, installment, err = installment.Take(1.0 / float64(i))
-		// log.DebugContext(ctx, "",
-		// 	slog.Any("take", take),
-		// 	slog.Any("installment", installment),
-		// 	slog.Any("err", err),
-		// )
-		take.Time = installmentTime
-		installments = append(installments, take)
-		payments = append(payments, datastore.LoanPartyPayment{
-			ISO4217: take.ISO4217,
-			Amount:  take.Amount,
-			Time:    take.Time.Unix(),
-			Details: fmt.Sprintf("Payment #%d of %d for loan [%s]", split-i+1, split, pkg.BtoA(loanID.Bytes())),
-		})
-		installmentTime = installmentTime.AddDate(0, 1, 0)
-		i--
+	payments, err := x.proposedPayments(loanID.Bytes(), p.Principal)
+	if err != nil {
+		return
 	}
-
+	disclosure, err := disclose(payments)
+	if err != nil {
+		return
+	}
+	// the reference is random, a reference taken by another loan is regenerated for a limited number of attempts
+	const referenceAttempts = 5
+	var reference string
 	var mut datastore.MutationResponse
-	mut, err = x.Dependency.Datastore.Mutation(ctx, datastore.MutationRequest{
-		Loans: &datastore.MutationRequestLoans{
-			Loan: datastore.Loan{
-				LoanID:    loanID.Bytes(), // new loanID
-				LoanState: datastore.StateProposed,
-				Parties: []datastore.LoanParty{
-					{
-						LoanPartyID:     loanPartyID.Bytes(), // new loanPartyID
-						UserID:          p.BorrowerID,
-						LoanPartyRoleAs: datastore.RoleAsBorrower,
-						Payments:        payments,
+	for range referenceAttempts {
+		if reference, err = x.Configuration.PaymentReference.Generate(); err != nil {
+			return
+		}
+		mut, err = x.Dependency.Datastore.Mutation(ctx, datastore.MutationRequest{
+			Loans: &datastore.MutationRequestLoans{
+				Loan: datastore.Loan{
+					LoanID:           loanID.Bytes(), // new loanID
+					LoanState:        datastore.StateProposed,
+					APR:              &disclosure.APR,
+					EffectiveRate:    &disclosure.EffectiveRate,
+					PaymentReference: &reference,
+					Parties: []datastore.LoanParty{
+						{
+							LoanPartyID:     loanPartyID.Bytes(), // new loanPartyID
+							UserID:          p.BorrowerID,
+							LoanPartyRoleAs: datastore.RoleAsBorrower,
+							Payments:        payments,
+						},
 					},
 				},
+				Journals: []datastore.Journal{memoJournal(loanID.Bytes(), "Proposal")},
 			},
-		},
-	})
+		})
+		var conflict *datastore.PaymentReferenceConflictError
+		if !errors.As(err, &conflict) {
+			break
+		}
+	}
 
 	if err == nil {
 		res.LoanID = mut.Loans.LoanID
 		res.LoanState = mut.Loans.LoanState.String()
+		res.PaymentReference = reference
 	}
 	return
 }
 
+// proposedPayments will build the borrower payments, the principal as the first negative entry followed by
+// the monthly installments, loanID is only used on the installment details and may be empty.
+func (x *loan) proposedPayments(loanID []byte, principal *pkg.Money) (payments []datastore.LoanPartyPayment, err error) {
+	interest, _, err := principal.Take(x.Configuration.InterestRate)
+	if err != nil {
+		return nil, err
+	}
+	service, _, err := principal.Take(x.Configuration.ServiceFee)
+	if err != nil {
+		return nil, err
+	}
+	repayment, err := principal.Sum(interest, service)
+	if err != nil {
+		return nil, err
+	}
+
+	split := x.Configuration.NumOfMonthlyInstallment
+	i := split
+	installment, err := repayment.Sum(nil)
+
+	// installments are anchored at the principal date, so the disclosure is computed over the same dates being
+	// stored regardless of the proposal being past-dated, a missing principal date is taken as now.
+	principalTime := principal.Time
+	if principalTime.IsZero() {
+		principalTime = time.Now()
+	}
+	payments = []datastore.LoanPartyPayment{{
+		PaymentID:   xid.New().Bytes(),
+		PaymentType: datastore.PaymentPrincipalDisbursement,
+		ISO4217:     principal.ISO4217,
+		Amount:      -principal.Amount,
+		Time:        principalTime.Unix(),
+		Details:     principal.Details,
+	}}
+
+	for range make([]struct{}, split, split) {
+		var take *pkg.Money
+		if take, installment, err = installment.Take(1.0 / float64(i)); err != nil {
+			return nil, err
+		}
+		details := fmt.Sprintf("Payment #%d of %d", split-i+1, split)
+		if len(loanID) > 0 {
+			details += fmt.Sprintf(" for loan [%s]", pkg.BtoA(loanID))
+		}
+		payments = append(payments, datastore.LoanPartyPayment{
+			PaymentID:   xid.New().Bytes(),
+			PaymentType: datastore.PaymentInstallment,
+			ISO4217:     take.ISO4217,
+			Amount:      take.Amount,
+			Time:        principalTime.AddDate(0, split-i+1, 0).Unix(),
+			Details:     details,
+		})
+		i--
+	}
+	return payments, nil
+}
+
 // upsertApproved
 func (x *loan) upsertApproved(ctx context.Context, a *ApprovedRequest) (res UpsertResponse, err error) {
+	if a.ApprovedDocument == nil {
+		if a.ApprovedDocument, err = x.generated(ctx, a.LoanID, document.KindRepaymentSchedule, "approved_document"); err != nil {
+			return
+		}
+	}
 	var mut datastore.MutationResponse
 	mut, err = x.Dependency.Datastore.Mutation(ctx, datastore.MutationRequest{
 		Loans: &datastore.MutationRequestLoans{
@@ -153,6 +213,7 @@ func (x *loan) upsertApproved(ctx context.Context, a *ApprovedRequest) (res Upse
 				ApprovedBy:  a.FieldOfficerID,
 				ApprovedDoc: a.ApprovedDocument,
 			},
+			Journals: []datastore.Journal{memoJournal(a.LoanID, "Approval")},
 		},
 	})
 	if err == nil {
@@ -167,14 +228,72 @@ func (x *loan) upsertApproved(ctx context.Context, a *ApprovedRequest) (res Upse
 //
 // less than 100% principal will failed the request, while equal or more than 100% will succeed.
 //
+// the used payment of each lender is taken from its wallet in the same transaction, the request is rejected when
+// the available balance is insufficient, while the unused payment stay available.
+//
 // lenders slices will be splitted into `used` & `unused` investment eventually covering all the principal value.
 func (x *loan) upsertInvested(ctx context.Context, i *InvestedRequest) (res UpsertResponse, err error) {
-	var qry datastore.QueryResponse
 	var mut datastore.MutationResponse
 	var principal *pkg.Money
+	if principal, err = x.investedPrincipal(ctx, i.LoanID); err != nil {
+		return
+	}
+	if res.Invested, err = x.investedLenders(i.LoanID, principal, i.Lenders); err != nil {
+		return
+	}
+
+	l := len(res.Invested.Used)
+	parties := make([]datastore.LoanParty, l, l)
+	for i, used := range res.Invested.Used {
+		loanPartyID := xid.New()
+		parties[i] = datastore.LoanParty{
+			LoanPartyID:     loanPartyID.Bytes(),
+			UserID:          used.LenderID,
+			LoanPartyRoleAs: datastore.RoleAsLender,
+			Payments: []datastore.LoanPartyPayment{{
+				PaymentID:   xid.New().Bytes(),
+				PaymentType: datastore.PaymentInvestment,
+				ISO4217:     used.Payment.ISO4217,
+				Amount:      used.Payment.Amount,
+				Time:        used.Payment.Time.Unix(),
+				Details:     used.Payment.Details,
+			}, {
+				PaymentID:   xid.New().Bytes(),
+				PaymentType: datastore.PaymentRepayment,
+				ISO4217:     used.Repayment.ISO4217,
+				Amount:      used.Repayment.Amount,
+				Time:        used.Repayment.Time.Unix(),
+				Details:     used.Repayment.Details,
+			}},
+		}
+	}
+
+	mut, err = x.Dependency.Datastore.Mutation(ctx, datastore.MutationRequest{
+		Loans: &datastore.MutationRequestLoans{
+			Loan: datastore.Loan{
+				LoanID:    i.LoanID,
+				LoanState: datastore.StateInvested,
+				Parties:   parties,
+			},
+			Journals: []datastore.Journal{investedJournal(i.LoanID, res.Invested.Used)},
+			Wallets:  investedWallets(res.Invested.Used),
+		},
+	})
+	if err == nil {
+		res.LoanID = mut.Loans.LoanID
+		res.LoanState = mut.Loans.LoanState.String()
+	} else {
+		res.Invested = nil
+	}
+	return
+}
+
+// investedPrincipal will query the loan and return the principal of an [approved] loan.
+func (x *loan) investedPrincipal(ctx context.Context, loanID []byte) (principal *pkg.Money, err error) {
+	var qry datastore.QueryResponse
 	qry, err = x.Dependency.Datastore.Query(ctx, datastore.QueryRequest{
 		Loans: &datastore.QueryRequestLoans{
-			ByLoanID: i.LoanID,
+			ByLoanID: loanID,
 		},
 	})
 	if err != nil {
@@ -182,7 +301,7 @@ func (x *loan) upsertInvested(ctx context.Context, i *InvestedRequest) (res Upse
 	}
 
 	log := pkg.Context.SlogLogger(ctx)
-	log.DebugContext(ctx, "upsertInvested",
+	log.DebugContext(ctx, "investedPrincipal",
 		slog.Any("qry", qry),
 		slog.Any("err", err),
 	)
@@ -191,7 +310,7 @@ func (x *loan) upsertInvested(ctx context.Context, i *InvestedRequest) (res Upse
 		qry = qry.List[0]
 	}
 
-	if qry.Loans.Loan.LoanState != datastore.StateApproved {
+	if qry.Loans == nil || qry.Loans.Loan.LoanState != datastore.StateApproved {
 		err = fmt.Errorf("expected state from [approved]")
 		return
 	}
@@ -201,7 +320,7 @@ func (x *loan) upsertInvested(ctx context.Context, i *InvestedRequest) (res Upse
 			continue
 		}
 		for _, payment := range party.Payments {
-			if payment.Amount < 0 {
+			if payment.PaymentType == datastore.PaymentPrincipalDisbursement {
 				principal = &pkg.Money{}
 				principal.Amount = -payment.Amount
 				principal.Details = payment.Details
@@ -216,124 +335,121 @@ func (x *loan) upsertInvested(ctx context.Context, i *InvestedRequest) (res Upse
 		err = fmt.Errorf("invalid principal value")
 		return
 	}
-	log.DebugContext(ctx, "upsertInvested",
+	log.DebugContext(ctx, "investedPrincipal",
 		slog.Any("principal", principal),
 	)
+	return
+}
 
+// investedLenders will split the lenders into `used` & `unused` investment, the principal is left untouched.
+func (x *loan) investedLenders(loanID []byte, principal *pkg.Money, lenders []LoanLender) (res *InvestedResponse, err error) {
 	var min, max *pkg.Money
 	var covered bool
 	min, max, err = principal.Take(x.Configuration.MinRateOfInvestment)
 	if err != nil {
 		return
 	}
-	log.DebugContext(ctx, "upsertInvested",
-		slog.Any("min", min),
-		slog.Any("max", max),
-		slog.Any("err", err),
-	)
+	remaining := *principal
+
+	repayment := func(payment *pkg.Money) (*pkg.Money, error) {
+		interest, _, err := payment.Take(x.Configuration.LenderInterestRate)
+		if err != nil {
+			return nil, err
+		}
+		repayment, err := payment.Sum(interest)
+		if err != nil {
+			return nil, err
+		}
+		repayment.Amount *= -1 // repayment to lenders is negative
+		repayment.Time = repayment.Time.AddDate(0, x.Configuration.NumOfMonthlyInstallment, 0)
+		repayment.Details = fmt.Sprintf("Repayment for loan [%s]", pkg.BtoA(loanID))
+		return repayment, nil
+	}
 
-	res.Invested = &InvestedResponse{}
-	for _, lender := range i.Lenders {
+	res = &InvestedResponse{}
+	for _, lender := range lenders {
 		if covered {
 			break
 		}
-		if lender.Payment.ISO4217 != principal.ISO4217 {
-			res.Invested = nil
-			err = fmt.Errorf("different currency")
-			return
+		if lender.Payment.ISO4217 != remaining.ISO4217 {
+			return nil, fmt.Errorf("different currency")
 		}
 
-		if lender.Payment.Amount >= min.Amount && lender.Payment.Amount <= max.Amount && lender.Payment.Amount < principal.Amount {
+		if lender.Payment.Amount >= min.Amount && lender.Payment.Amount <= max.Amount && lender.Payment.Amount < remaining.Amount {
 			// between 5% - 95% principal / remaining covered
-			principal.Amount -= lender.Payment.Amount
-
-			var interest *pkg.Money
-			interest, _, err = lender.Payment.Take(x.Configuration.LenderInterestRate)
-			lender.Repayment, err = lender.Payment.Sum(interest)
-			lender.Repayment.Amount *= -1 // repayment to lenders is negative
-			lender.Repayment.Time = lender.Repayment.Time.AddDate(0, x.Configuration.NumOfMonthlyInstallment, 0)
-			lender.Repayment.Details = fmt.Sprintf("Repayment for loan [%s]", pkg.BtoA(i.LoanID))
-
-			res.Invested.Used = append(res.Invested.Used, lender)
-			covered = (principal.Amount == 0)
-		} else if lender.Payment.Amount > principal.Amount {
+			remaining.Amount -= lender.Payment.Amount
+			if lender.Repayment, err = repayment(lender.Payment); err != nil {
+				return nil, err
+			}
+			res.Used = append(res.Used, lender)
+			covered = (remaining.Amount == 0)
+		} else if lender.Payment.Amount > remaining.Amount {
 			// more than principal amount
 			usedLender, unusedLender := lender, lender
-			var interest *pkg.Money
-			usedLender.Payment, unusedLender.Payment, err = lender.Payment.Take(principal.Amount / lender.Payment.Amount)
-
-			interest, _, err = usedLender.Payment.Take(x.Configuration.LenderInterestRate)
-			usedLender.Repayment, err = usedLender.Payment.Sum(interest)
-			usedLender.Repayment.Amount *= -1 // repayment to lenders is negative
-			usedLender.Repayment.Time = usedLender.Repayment.Time.AddDate(0, x.Configuration.NumOfMonthlyInstallment, 0)
-			usedLender.Repayment.Details = fmt.Sprintf("Repayment for loan [%s]", pkg.BtoA(i.LoanID))
-
-			res.Invested.Used = append(res.Invested.Used, usedLender)
-			res.Invested.Unused = append(res.Invested.Unused, unusedLender)
+			usedLender.Payment, unusedLender.Payment, err = lender.Payment.Take(remaining.Amount / lender.Payment.Amount)
+			if err != nil {
+				return nil, err
+			}
+			if usedLender.Repayment, err = repayment(usedLender.Payment); err != nil {
+				return nil, err
+			}
+			res.Used = append(res.Used, usedLender)
+			res.Unused = append(res.Unused, unusedLender)
 			covered = true
-		} else if lender.Payment.Amount == principal.Amount {
+		} else if lender.Payment.Amount == remaining.Amount {
 			// exact amount of principal amount
-			principal.Amount -= lender.Payment.Amount
-
-			var interest *pkg.Money
-			interest, _, err = lender.Payment.Take(x.Configuration.LenderInterestRate)
-			lender.Repayment, err = lender.Payment.Sum(interest)
-			lender.Repayment.Amount *= -1 // repayment to lenders is negative
-			lender.Repayment.Time = lender.Repayment.Time.AddDate(0, x.Configuration.NumOfMonthlyInstallment, 0)
-			lender.Repayment.Details = fmt.Sprintf("Repayment for loan [%s]", pkg.BtoA(i.LoanID))
-
-			res.Invested.Used = append(res.Invested.Used, lender)
+			remaining.Amount -= lender.Payment.Amount
+			if lender.Repayment, err = repayment(lender.Payment); err != nil {
+				return nil, err
+			}
+			res.Used = append(res.Used, lender)
 			covered = true
 		} else {
-			res.Invested.Unused = append(res.Invested.Unused, lender)
+			res.Unused = append(res.Unused, lender)
 		}
 	}
 	if !covered {
-		err = fmt.Errorf("principal is not fully covered, missing %s", principal)
-		return
-	}
-
-	l := len(res.Invested.Used)
-	parties := make([]datastore.LoanParty, l, l)
-	for i, used := range res.Invested.Used {
-		loanPartyID := xid.New()
-		parties[i] = datastore.LoanParty{
-			LoanPartyID:     loanPartyID.Bytes(),
-			UserID:          used.LenderID,
-			LoanPartyRoleAs: datastore.RoleAsLender,
-			Payments: []datastore.LoanPartyPayment{{
-				ISO4217: used.Payment.ISO4217,
-				Amount:  used.Payment.Amount,
-				Time:    used.Payment.Time.Unix(),
-				Details: used.Payment.Details,
-			}, {
-				ISO4217: used.Repayment.ISO4217,
-				Amount:  used.Repayment.Amount,
-				Time:    used.Repayment.Time.Unix(),
-				Details: used.Repayment.Details,
-			}},
-		}
+		return nil, fmt.Errorf("principal is not fully covered, missing %s", &remaining)
 	}
+	return res, nil
+}
 
-	_ = mut
-	mut, err = x.Dependency.Datastore.Mutation(ctx, datastore.MutationRequest{
-		Loans: &datastore.MutationRequestLoans{
-			Loan: datastore.Loan{
-				LoanID:    i.LoanID,
-				LoanState: datastore.StateInvested,
-				Parties:   parties,
-			},
+// upsertDisbursed will query the [invested] loan to post the journal of the disbursement, along with the payout
+// instruction of the net principal to the borrower account.
+func (x *loan) upsertDisbursed(ctx context.Context, d *DisbursedRequest) (res UpsertResponse, err error) {
+	var qry datastore.QueryResponse
+	qry, err = x.Dependency.Datastore.Query(ctx, datastore.QueryRequest{
+		Loans: &datastore.QueryRequestLoans{
+			ByLoanID: d.LoanID,
 		},
 	})
-	if err == nil {
-		res.LoanID = mut.Loans.LoanID
-		res.LoanState = mut.Loans.LoanState.String()
+	if err != nil {
+		return
+	}
+	if len(qry.List) == 1 {
+		qry = qry.List[0]
+	}
+	if qry.Loans == nil || qry.Loans.Loan.LoanState != datastore.StateInvested {
+		err = fmt.Errorf("expected state from [invested]")
+		return
+	}
+	if err = x.borrowerSigned(ctx, qry.Loans.Loan); err != nil {
+		return
+	}
+	if d.BorrowerContract == nil {
+		if d.BorrowerContract, err = x.generated(ctx, d.LoanID, document.KindBorrowerAgreement, "borrower_contract"); err != nil {
+			return
+		}
+	}
+	var journal datastore.Journal
+	if journal, err = disbursedJournal(ctx, qry.Loans.Loan); err != nil {
+		return
+	}
+	var payout datastore.PayoutInstruction
+	if payout, err = disbursedPayout(ctx, qry.Loans.Loan, d.BorrowerAccount); err != nil {
+		return
 	}
-	return
-}
 
-// upsertDisbursed
-func (x *loan) upsertDisbursed(ctx context.Context, d *DisbursedRequest) (res UpsertResponse, err error) {
 	var mut datastore.MutationResponse
 	mut, err = x.Dependency.Datastore.Mutation(ctx, datastore.MutationRequest{
 		Loans: &datastore.MutationRequestLoans{
@@ -343,15 +459,69 @@ func (x *loan) upsertDisbursed(ctx context.Context, d *DisbursedRequest) (res Up
 				DisbursedBy:  d.DisbursementOfficerID,
 				DisbursedDoc: d.BorrowerContract,
 			},
+			Journals: []datastore.Journal{journal},
+			Wallets:  disbursedWallets(qry.Loans.Loan),
+			Payouts:  []datastore.PayoutInstruction{payout},
 		},
 	})
 	if err == nil {
 		res.LoanID = mut.Loans.LoanID
 		res.LoanState = mut.Loans.LoanState.String()
+		res.PayoutID = payout.InstructionID
 	}
 	return
 }
 
+// generated will generate the documents of the loan to link the PDF of the kind, the link is required without
+// document generation.
+func (x *loan) generated(ctx context.Context, loanID []byte, kind document.Kind, field string) (link *string, err error) {
+	if x.Dependency.Document == nil {
+		return nil, fmt.Errorf("invalid %s", field)
+	}
+	var gen document.DocumentResponse
+	if gen, err = x.Dependency.Document.Generate(ctx, document.GenerateRequest{LoanID: loanID}); err != nil {
+		return
+	}
+	doc, ok := gen.Find(kind, document.MediaTypePDF)
+	if !ok {
+		return nil, fmt.Errorf("%s is not generated", kind)
+	}
+	return &doc.Link, nil
+}
+
+// borrowerSigned will require the contract of the borrower, rendered again from the loan as stored, to be signed by
+// a verified signature of the borrower over its hash. A contract signed before the loan or the template is changed
+// is not taken.
+func (x *loan) borrowerSigned(ctx context.Context, l datastore.Loan) (err error) {
+	var qry datastore.QueryResponse
+	if qry, err = x.Dependency.Datastore.Query(ctx, datastore.QueryRequest{
+		Contracts: &datastore.QueryRequestContracts{ByLoanID: l.LoanID},
+	}); err != nil {
+		return
+	}
+	for _, p := range l.Parties {
+		if p.LoanPartyRoleAs != datastore.RoleAsBorrower {
+			continue
+		}
+		var hash []byte
+		if _, hash, err = contract.Document(l, p); err != nil {
+			return
+		}
+		for _, qry := range qry.List {
+			if qry.Contracts == nil || !bytes.Equal(qry.Contracts.UserID, p.UserID) ||
+				qry.Contracts.LoanPartyRoleAs != datastore.RoleAsBorrower || !bytes.Equal(qry.Contracts.Hash, hash) {
+				continue
+			}
+			for _, s := range qry.Contracts.Signatures {
+				if s.Verified {
+					return nil
+				}
+			}
+		}
+	}
+	return fmt.Errorf("borrower contract is not signed")
+}
+
 type ProposedRequest struct {
 	BorrowerID []byte     `json:"borrower_id,omitempty"`
 	Principal  *pkg.Money `json:"principal,omitempty"`
@@ -373,7 +543,7 @@ type ProposedResponse struct {
 
 type ApprovedRequest struct {
 	LoanID           []byte  `json:"loan_id,omitempty"`
-	ApprovedDocument *string `json:"approved_document,omitempty"`
+	ApprovedDocument *string `json:"approved_document,omitempty"` // linked to the generated repayment schedule when empty
 	FieldOfficerID   []byte  `json:"field_officer_id,omitempty"`
 }
 
@@ -381,7 +551,7 @@ func (x *ApprovedRequest) Validate(ctx context.Context) (_ *ApprovedRequest, err
 	if len(x.LoanID) < 1 {
 		return nil, fmt.Errorf("invalid loan_id")
 	}
-	if x.ApprovedDocument == nil || len(*x.ApprovedDocument) < 1 {
+	if x.ApprovedDocument != nil && len(*x.ApprovedDocument) < 1 {
 		return nil, fmt.Errorf("invalid approved_document")
 	}
 	if len(x.FieldOfficerID) < 1 {
@@ -426,21 +596,25 @@ func (x *InvestedRequest) Validate(ctx context.Context) (_ *InvestedRequest, err
 }
 
 type DisbursedRequest struct {
-	LoanID                []byte  `json:"loan_id,omitempty"`
-	BorrowerContract      *string `json:"borrower_contract,omitempty"`
-	DisbursementOfficerID []byte  `json:"disbursement_officer_id,omitempty"`
+	LoanID                []byte       `json:"loan_id,omitempty"`
+	BorrowerContract      *string      `json:"borrower_contract,omitempty"` // linked to the generated borrower agreement when empty
+	DisbursementOfficerID []byte       `json:"disbursement_officer_id,omitempty"`
+	BorrowerAccount       *BankAccount `json:"borrower_account,omitempty"`
 }
 
 func (x *DisbursedRequest) Validate(ctx context.Context) (_ *DisbursedRequest, err error) {
 	if len(x.LoanID) < 1 {
 		return nil, fmt.Errorf("invalid loan_id")
 	}
-	if x.BorrowerContract == nil || len(*x.BorrowerContract) < 1 {
+	if x.BorrowerContract != nil && len(*x.BorrowerContract) < 1 {
 		return nil, fmt.Errorf("invalid borrower_contract")
 	}
 	if len(x.DisbursementOfficerID) < 1 {
 		return nil, fmt.Errorf("invalid disbursement_officer_id")
 	}
+	if x.BorrowerAccount, err = x.BorrowerAccount.Validate(ctx); err != nil {
+		return nil, err
+	}
 	return x, nil
 }
 
@@ -2,6 +2,7 @@ package loan
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 	"time"
 
@@ -12,7 +13,8 @@ import (
 type ViewRequest struct {
 	List []ViewRequest `json:"list,omitempty"`
 
-	LoanID []byte `json:"loan_id,omitempty"`
+	LoanID []byte     `json:"loan_id,omitempty"`
+	AsOf   *time.Time `json:"as_of,omitempty"` // reconstruct the loan as it was at this time, default to now
 	// LenderID   []byte `json:"lender_id,omitempty"`
 	// BorrowerID []byte `json:"borrower_id,omitempty"`
 }
@@ -23,7 +25,12 @@ type ViewResponse struct {
 	LoanID           []byte       `json:"loan_id,omitempty"`
 	LoanState        string       `json:"loan_state,omitempty"`
 	BorrowerID       []byte       `json:"borrower_id,omitempty"`
+	PaymentReference string       `json:"payment_reference,omitempty"` // to be quoted by the borrower on every repayment
 	ExpectedPayments []*pkg.Money `json:"expected_payments,omitempty"`
+	Outstanding      []*pkg.Money `json:"outstanding_payments,omitempty"` // installments not yet settled once disbursed
+	Disclosure       *Disclosure  `json:"disclosure,omitempty"`
+	Verified         *bool        `json:"verified,omitempty"`   // every row of the loan carry a valid signature
+	Unverified       []string     `json:"unverified,omitempty"` // rows without a valid signature, as table/id
 
 	Lenders []struct {
 		LenderID []byte       `json:"lender_id,omitempty"`
@@ -48,46 +55,23 @@ func (x *loan) View(ctx context.Context, req ViewRequest) (res ViewResponse, err
 		qry.List = append(qry.List, qry)
 	}
 
-	l := len(qry.List)
-	res.List = make([]ViewResponse, l, l)
-	for i, qry := range qry.List {
-		res.List[i].LoanID = qry.Loans.Loan.LoanID
-		res.List[i].LoanState = qry.Loans.Loan.LoanState.String()
-		for _, party := range qry.Loans.Loan.Parties {
-			switch party.LoanPartyRoleAs {
-			case datastore.RoleAsBorrower:
-				l := len(party.Payments)
-				payments := make([]*pkg.Money, l, l)
-				for i, payment := range party.Payments {
-					payments[i] = &pkg.Money{
-						ISO4217: payment.ISO4217,
-						Amount:  payment.Amount,
-						Details: payment.Details,
-						Time:    time.Unix(payment.Time, 0),
-					}
-				}
-				res.List[i].BorrowerID = party.UserID
-				res.List[i].ExpectedPayments = payments
-			case datastore.RoleAsLender:
-				l := len(party.Payments)
-				payments := make([]*pkg.Money, l, l)
-				for i, payment := range party.Payments {
-					payments[i] = &pkg.Money{
-						ISO4217: payment.ISO4217,
-						Amount:  payment.Amount,
-						Details: payment.Details,
-						Time:    time.Unix(payment.Time, 0),
-					}
-				}
-				res.List[i].Lenders = append(res.List[i].Lenders, struct {
-					LenderID []byte       "json:\"lender_id,omitempty\""
-					Payments []*pkg.Money "json:\"payments,omitempty\""
-				}{
-					LenderID: party.UserID,
-					Payments: payments,
-				})
+	res.List = make([]ViewResponse, 0, len(qry.List))
+	for _, qry := range qry.List {
+		l := qry.Loans.Loan
+		if req.AsOf != nil {
+			var ok bool
+			if l, ok = asOf(l, req.AsOf.Unix()); !ok {
+				continue
 			}
 		}
+		v := view(l)
+		v.Unverified = unverified(l)
+		v.Verified = pkg.Ptr(len(v.Unverified) < 1)
+		res.List = append(res.List, v)
+	}
+	if req.AsOf != nil && len(req.LoanID) > 0 && len(res.List) < 1 {
+		err = fmt.Errorf("loan is not created as of %s", req.AsOf.Format(time.RFC3339))
+		return
 	}
 
 	if len(res.List) == 1 {
@@ -103,3 +87,131 @@ func (x *loan) View(ctx context.Context, req ViewRequest) (res ViewResponse, err
 
 	return
 }
+
+// view will present a single loan, either queried from the projection or folded from its events.
+func view(l datastore.Loan) (res ViewResponse) {
+	res.LoanID = l.LoanID
+	res.LoanState = l.LoanState.String()
+	if l.PaymentReference != nil {
+		res.PaymentReference = *l.PaymentReference
+	}
+	if l.APR != nil && l.EffectiveRate != nil {
+		res.Disclosure = &Disclosure{
+			APR:           *l.APR,
+			EffectiveRate: *l.EffectiveRate,
+		}
+	}
+	for _, party := range l.Parties {
+		switch party.LoanPartyRoleAs {
+		case datastore.RoleAsBorrower:
+			n := len(party.Payments)
+			payments := make([]*pkg.Money, n, n)
+			for i, payment := range party.Payments {
+				payments[i] = &pkg.Money{
+					ISO4217: payment.ISO4217,
+					Amount:  payment.Amount,
+					Details: payment.Details,
+					Time:    time.Unix(payment.Time, 0),
+				}
+			}
+			res.BorrowerID = party.UserID
+			res.ExpectedPayments = payments
+			if l.LoanState == datastore.StateDisbursed {
+				for i, payment := range party.Payments {
+					if payment.PaymentType == datastore.PaymentInstallment && payment.SettledAt == nil {
+						res.Outstanding = append(res.Outstanding, payments[i])
+					}
+				}
+			}
+		case datastore.RoleAsLender:
+			n := len(party.Payments)
+			payments := make([]*pkg.Money, n, n)
+			for i, payment := range party.Payments {
+				payments[i] = &pkg.Money{
+					ISO4217: payment.ISO4217,
+					Amount:  payment.Amount,
+					Details: payment.Details,
+					Time:    time.Unix(payment.Time, 0),
+				}
+			}
+			res.Lenders = append(res.Lenders, struct {
+				LenderID []byte       "json:\"lender_id,omitempty\""
+				Payments []*pkg.Money "json:\"payments,omitempty\""
+			}{
+				LenderID: party.UserID,
+				Payments: payments,
+			})
+		}
+	}
+	return
+}
+
+// unverified will name the rows of the loan whose signature is not valid, as verified on query.
+func unverified(l datastore.Loan) (rows []string) {
+	if !l.Verified {
+		rows = append(rows, "loans/"+pkg.BtoA(l.LoanID))
+	}
+	for _, party := range l.Parties {
+		if !party.Verified {
+			rows = append(rows, "loan_parties/"+pkg.BtoA(party.LoanPartyID))
+		}
+		for _, payment := range party.Payments {
+			if !payment.Verified {
+				rows = append(rows, "loan_party_payments/"+pkg.BtoA(payment.PaymentID))
+			}
+		}
+	}
+	return
+}
+
+// asOf will reconstruct the loan at the given Unix timestamp from its recorded timestamps, false when the loan is
+// not created yet
+//   - approval & disbursement recorded after are removed
+//   - parties joined after are removed, the loan is invested once any lender has joined
+//   - settlement of payments recorded after are removed, by the time of the reconciliation rather than the value
+//     date which is kept for display
+func asOf(l datastore.Loan, at int64) (_ datastore.Loan, ok bool) {
+	if l.CreatedAt > at {
+		return l, false
+	}
+	l.LoanState = datastore.StateProposed
+	if l.ApprovedAt != nil && *l.ApprovedAt <= at {
+		l.LoanState = datastore.StateApproved
+	} else {
+		l.ApprovedBy, l.ApprovedDoc, l.ApprovedAt, l.ApprovedSign = nil, nil, nil, nil
+	}
+
+	parties := make([]datastore.LoanParty, 0, len(l.Parties))
+	for _, party := range l.Parties {
+		if party.CreatedAt > at {
+			continue
+		}
+		if party.LoanPartyRoleAs == datastore.RoleAsLender && l.LoanState == datastore.StateApproved {
+			l.LoanState = datastore.StateInvested
+		}
+		payments := make([]datastore.LoanPartyPayment, 0, len(party.Payments))
+		for _, payment := range party.Payments {
+			if payment.CreatedAt > at {
+				continue
+			}
+			recordedAt := payment.SettledAt // settled before the reconciliation was recorded
+			if payment.SettlementRecordedAt != nil {
+				recordedAt = payment.SettlementRecordedAt
+			}
+			if recordedAt != nil && *recordedAt > at {
+				payment.SettledAt, payment.SettlementRecordedAt = nil, nil
+			}
+			payments = append(payments, payment)
+		}
+		party.Payments = payments
+		parties = append(parties, party)
+	}
+	l.Parties = parties
+
+	if l.DisbursedAt != nil && *l.DisbursedAt <= at && l.LoanState == datastore.StateInvested {
+		l.LoanState = datastore.StateDisbursed
+	} else {
+		l.DisbursedBy, l.DisbursedDoc, l.DisbursedAt, l.DisbursedSign = nil, nil, nil, nil
+	}
+	return l, true
+}
@@ -0,0 +1,65 @@
+//go:generate mockgen -destination outbox_mock.go -package outbox . Outbox
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gunawanwijaya/loan-svc/internal/repository/datastore"
+	"github.com/gunawanwijaya/loan-svc/pkg"
+)
+
+type Configuration struct {
+	BatchSize int           `json:"batch_size,omitempty"` // maximum events relayed at once, default to 100
+	Interval  time.Duration `json:"interval,omitempty"`   // pause of the relay once every event is published, default to 5s
+	File      string        `json:"file,omitempty"`       // publish as JSON lines into the file, in-process when empty
+}
+type Dependency struct {
+	datastore.Datastore
+	Publisher
+}
+type Outbox interface {
+	Relay(ctx context.Context, req RelayRequest) (res RelayResponse, err error)
+	Run(ctx context.Context)
+}
+
+func New(ctx context.Context, cfg Configuration, dep Dependency) (_ Outbox, err error) {
+	cfg, err = pkg.AsValidator(cfg).Validate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	dep, err = pkg.AsValidator(dep).Validate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &outbox{cfg, dep}, nil
+}
+
+type outbox struct {
+	Configuration
+	Dependency
+}
+
+func (cfg Configuration) Validate(ctx context.Context) (_ Configuration, err error) {
+	if cfg.BatchSize < 0 || cfg.Interval < 0 {
+		return cfg, fmt.Errorf("feature/outbox: negative batch size or interval")
+	}
+	if cfg.BatchSize == 0 {
+		cfg.BatchSize = 100
+	}
+	if cfg.Interval == 0 {
+		cfg.Interval = 5 * time.Second
+	}
+	return cfg, nil
+}
+
+func (dep Dependency) Validate(ctx context.Context) (_ Dependency, err error) {
+	if dep.Datastore == nil {
+		return dep, fmt.Errorf("feature/outbox: uninitialized repository/datastore")
+	}
+	if dep.Publisher == nil {
+		return dep, fmt.Errorf("feature/outbox: uninitialized publisher")
+	}
+	return dep, nil
+}
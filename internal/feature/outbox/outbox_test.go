@@ -0,0 +1,132 @@
+package outbox_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gunawanwijaya/loan-svc/internal/feature/outbox"
+	"github.com/gunawanwijaya/loan-svc/internal/repository/datastore"
+	"github.com/gunawanwijaya/loan-svc/pkg"
+	"github.com/rs/xid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestOutbox(t *testing.T) {
+	ctx := pkg.Context.PutSlogLogger(context.Background(), slog.Default())
+	ctrl := gomock.NewController(t)
+	mockDatastore := datastore.NewMockDatastore(ctrl)
+
+	_, err := outbox.New(ctx, outbox.Configuration{}, outbox.Dependency{Datastore: mockDatastore})
+	require.Error(t, err) // no publisher
+
+	inProcess := outbox.NewInProcess()
+	received := []string{}
+	failOnce := map[string]bool{"loan.approved": true}
+	inProcess.Subscribe(func(ctx context.Context, e outbox.Event) error {
+		received = append(received, e.EventType)
+		return nil
+	})
+	inProcess.Subscribe(func(ctx context.Context, e outbox.Event) error {
+		if failOnce[e.EventType] {
+			failOnce[e.EventType] = false
+			return errors.New("unavailable")
+		}
+		return nil
+	})
+	featOutbox, err := outbox.New(ctx, outbox.Configuration{BatchSize: 3}, outbox.Dependency{
+		Datastore: mockDatastore,
+		Publisher: inProcess,
+	})
+	require.NoError(t, err)
+
+	loanID := xid.New().Bytes()
+	events := []datastore.OutboxEvent{}
+	for i, state := range []string{"proposed", "approved", "invested"} {
+		events = append(events, datastore.OutboxEvent{
+			Sequence:    int64(i + 1),
+			EventID:     xid.New().Bytes(),
+			EventType:   "loan." + state,
+			AggregateID: loanID,
+			Payload:     []byte(`{"loan_state":"` + state + `"}`),
+			CreatedAt:   1735689600,
+		})
+	}
+	list := func(events ...datastore.OutboxEvent) (res datastore.QueryResponse) {
+		for _, e := range events {
+			res.List = append(res.List, datastore.QueryResponse{Outbox: &datastore.QueryResponseOutbox{OutboxEvent: e}})
+		}
+		return res
+	}
+	marked := func(events ...datastore.OutboxEvent) datastore.MutationRequest {
+		return datastore.MutationRequest{Outbox: &datastore.MutationRequestOutbox{List: events}}
+	}
+
+	{ // the second event fails, the third is not overtaking it
+		mockDatastore.EXPECT().
+			Query(ctx, datastore.QueryRequest{Outbox: &datastore.QueryRequestOutbox{ByLimit: 3}}).
+			Return(list(events...), nil)
+		mockDatastore.EXPECT().
+			Mutation(ctx, marked(events[0])).
+			Return(datastore.MutationResponse{Outbox: &datastore.MutationResponseOutbox{Published: 1}}, nil)
+	}
+	res, err := featOutbox.Relay(ctx, outbox.RelayRequest{})
+	require.Error(t, err)
+	require.True(t, res.More)
+	require.Len(t, res.Published, 1)
+	require.Equal(t, int64(1), res.Published[0].Sequence)
+	require.JSONEq(t, `{"loan_state":"proposed"}`, string(res.Published[0].Payload))
+	require.Equal(t, []string{"loan.proposed", "loan.approved"}, received)
+
+	{ // retried, the first subscriber receives loan.approved twice
+		mockDatastore.EXPECT().
+			Query(ctx, datastore.QueryRequest{Outbox: &datastore.QueryRequestOutbox{ByLimit: 10}}).
+			Return(list(events[1:]...), nil)
+		mockDatastore.EXPECT().
+			Mutation(ctx, marked(events[1:]...)).
+			Return(datastore.MutationResponse{Outbox: &datastore.MutationResponseOutbox{Published: 2}}, nil)
+	}
+	res, err = featOutbox.Relay(ctx, outbox.RelayRequest{BatchSize: 10})
+	require.NoError(t, err)
+	require.False(t, res.More)
+	require.Len(t, res.Published, 2)
+	require.Equal(t, []string{"loan.proposed", "loan.approved", "loan.approved", "loan.invested"}, received)
+
+	{ // marking fails after publishing, the events are published again by the next relay
+		mockDatastore.EXPECT().
+			Query(ctx, gomock.Any()).
+			Return(list(events[2]), nil)
+		mockDatastore.EXPECT().
+			Mutation(ctx, gomock.Any()).
+			Return(datastore.MutationResponse{}, errors.New("database is locked"))
+	}
+	res, err = featOutbox.Relay(ctx, outbox.RelayRequest{})
+	require.Error(t, err)
+	require.Empty(t, res.Published)
+
+	name := filepath.Join(t.TempDir(), "outbox.jsonl")
+	file, err := outbox.NewFile(name)
+	require.NoError(t, err)
+	for _, e := range events {
+		require.NoError(t, file.Publish(ctx, outbox.Event{Sequence: e.Sequence, EventID: e.EventID, EventType: e.EventType, Payload: e.Payload}))
+	}
+	require.NoError(t, file.Close())
+	f, err := os.Open(name)
+	require.NoError(t, err)
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	lines := 0
+	for ; scanner.Scan(); lines++ {
+		var e outbox.Event
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &e))
+		require.Equal(t, events[lines].EventID, e.EventID)
+		require.Equal(t, events[lines].EventType, e.EventType)
+	}
+	require.Equal(t, 3, lines)
+}
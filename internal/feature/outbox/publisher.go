@@ -0,0 +1,86 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+	"time"
+)
+
+// Event is a domain event as handed to the publisher, the same event may be published more than once so the
+// consumer should deduplicate by EventID.
+type Event struct {
+	Sequence    int64           `json:"sequence"`
+	EventID     []byte          `json:"event_id"`
+	EventType   string          `json:"event_type"`
+	AggregateID []byte          `json:"aggregate_id"`
+	Payload     json.RawMessage `json:"payload"`
+	OccurredAt  time.Time       `json:"occurred_at"`
+}
+
+// Publisher will deliver the event, the event is published again by the next relay when an error is returned.
+type Publisher interface {
+	Publish(ctx context.Context, e Event) error
+}
+
+// PublisherFunc is an adapter of ordinary function as Publisher.
+type PublisherFunc func(ctx context.Context, e Event) error
+
+func (fn PublisherFunc) Publish(ctx context.Context, e Event) error { return fn(ctx, e) }
+
+// InProcess will publish to the subscribers within the same process, in order of subscription.
+type InProcess struct {
+	mu          sync.RWMutex
+	subscribers []PublisherFunc
+}
+
+func NewInProcess() *InProcess { return &InProcess{} }
+
+// Subscribe will register the subscriber of every event published afterwards.
+func (x *InProcess) Subscribe(fn PublisherFunc) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.subscribers = append(x.subscribers, fn)
+}
+
+// Publish will hand the event to every subscriber, a failure of any of them cause the event to be published again
+// to all of them.
+func (x *InProcess) Publish(ctx context.Context, e Event) (err error) {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	for _, fn := range x.subscribers {
+		err = errors.Join(err, fn(ctx, e))
+	}
+	return err
+}
+
+// File will append the events as JSON lines, each line is synced before the event is considered as published.
+type File struct {
+	mu sync.Mutex
+	f  *os.File
+}
+
+func NewFile(name string) (*File, error) {
+	f, err := os.OpenFile(name, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &File{f: f}, nil
+}
+
+func (x *File) Publish(ctx context.Context, e Event) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if _, err = x.f.Write(append(b, '\n')); err != nil {
+		return err
+	}
+	return x.f.Sync()
+}
+
+func (x *File) Close() error { return x.f.Close() }
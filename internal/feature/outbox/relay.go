@@ -0,0 +1,97 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/gunawanwijaya/loan-svc/internal/repository/datastore"
+	"github.com/gunawanwijaya/loan-svc/pkg"
+)
+
+type RelayRequest struct {
+	BatchSize int `json:"batch_size,omitempty"` // default to the configured batch size
+}
+
+type RelayResponse struct {
+	Published []Event `json:"published,omitempty"`
+	More      bool    `json:"more"` // the batch is full, more events may be waiting
+}
+
+// Relay will publish the unpublished events in order & stop at the first failure, so that no event overtake an
+// earlier one. An event is marked as published only after being published, an event published right before a crash
+// is published again, a.k.a. at-least-once.
+func (x *outbox) Relay(ctx context.Context, req RelayRequest) (res RelayResponse, err error) {
+	log := pkg.Context.SlogLogger(ctx)
+	batchSize := pkg.OrElse(req.BatchSize > 0, req.BatchSize, x.Configuration.BatchSize)
+
+	var qry datastore.QueryResponse
+	qry, err = x.Dependency.Datastore.Query(ctx, datastore.QueryRequest{
+		Outbox: &datastore.QueryRequestOutbox{ByLimit: batchSize},
+	})
+	if err != nil {
+		return
+	}
+	res.More = len(qry.List) >= batchSize
+
+	published := []datastore.OutboxEvent{}
+	for _, q := range qry.List {
+		e := q.Outbox.OutboxEvent
+		if err = x.Dependency.Publisher.Publish(ctx, event(e)); err != nil {
+			res.More = true
+			break
+		}
+		published = append(published, e)
+	}
+	if len(published) > 0 {
+		// a failure here is the crash between publish & mark, the published events are published again
+		if _, mutErr := x.Dependency.Datastore.Mutation(ctx, datastore.MutationRequest{
+			Outbox: &datastore.MutationRequestOutbox{List: published},
+		}); mutErr != nil {
+			err = errors.Join(err, mutErr)
+			return
+		}
+	}
+	for _, e := range published {
+		res.Published = append(res.Published, event(e))
+	}
+
+	log.DebugContext(ctx, "feature/outbox.Relay",
+		slog.Int("published", len(published)),
+		slog.Bool("more", res.More),
+		slog.Any("err", err),
+	)
+	return
+}
+
+// Run will relay repeatedly until the context is done, pausing for the configured interval once every event is
+// published or the relay fails.
+func (x *outbox) Run(ctx context.Context) {
+	log := pkg.Context.SlogLogger(ctx)
+	for {
+		res, err := x.Relay(ctx, RelayRequest{})
+		if err != nil {
+			log.ErrorContext(ctx, "feature/outbox.Run", slog.Any("err", err))
+		}
+		if err == nil && res.More {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(x.Configuration.Interval):
+		}
+	}
+}
+
+func event(e datastore.OutboxEvent) Event {
+	return Event{
+		Sequence:    e.Sequence,
+		EventID:     e.EventID,
+		EventType:   e.EventType,
+		AggregateID: e.AggregateID,
+		Payload:     e.Payload,
+		OccurredAt:  time.Unix(e.CreatedAt, 0).UTC(),
+	}
+}
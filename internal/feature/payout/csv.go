@@ -0,0 +1,46 @@
+package payout
+
+import (
+	"bytes"
+	"encoding/csv"
+	"time"
+
+	"github.com/gunawanwijaya/loan-svc/internal/repository/datastore"
+	"github.com/gunawanwijaya/loan-svc/pkg"
+)
+
+// writeCSV will write one row per payout instruction, a simpler alternative of pain001 for bank portals.
+func writeCSV(executionDate time.Time, payouts []datastore.PayoutInstruction) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	w := csv.NewWriter(buf)
+	if err := w.Write([]string{
+		"instruction_id",
+		"end_to_end_id",
+		"execution_date",
+		"account_name",
+		"account_number",
+		"bank_code",
+		"currency",
+		"amount",
+		"reference",
+	}); err != nil {
+		return nil, err
+	}
+	for _, p := range payouts {
+		if err := w.Write([]string{
+			pkg.BtoA(p.InstructionID),
+			pkg.BtoA(p.PaymentID),
+			executionDate.Format(time.DateOnly),
+			p.AccountName,
+			p.AccountNumber,
+			p.BankCode,
+			p.ISO4217,
+			decimal(p.Amount),
+			p.Reference,
+		}); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
@@ -0,0 +1,101 @@
+package payout
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/gunawanwijaya/loan-svc/internal/repository/datastore"
+	"github.com/gunawanwijaya/loan-svc/pkg"
+	"github.com/rs/xid"
+)
+
+const (
+	FormatPain001 = "pain001" // ISO 20022 pain.001.001.03 customer credit transfer initiation
+	FormatCSV     = "csv"
+)
+
+type ExportRequest struct {
+	Format        string     `json:"format,omitempty"`         // default to pain001
+	ExecutionDate *time.Time `json:"execution_date,omitempty"` // requested execution date, default to today
+}
+
+type ExportResponse struct {
+	BatchID     []byte        `json:"batch_id,omitempty"`
+	Format      string        `json:"format,omitempty"`
+	Filename    string        `json:"filename,omitempty"`
+	ContentType string        `json:"content_type,omitempty"`
+	File        []byte        `json:"file,omitempty"`
+	List        []Instruction `json:"list,omitempty"`
+}
+
+// Export will write all pending payout instructions into a single payment file, the instructions are marked as
+// exported under the batch once the file is written. Nothing is exported when there is no pending instruction.
+func (x *payout) Export(ctx context.Context, req ExportRequest) (res ExportResponse, err error) {
+	log := pkg.Context.SlogLogger(ctx)
+	res.Format = req.Format
+	if res.Format == "" {
+		res.Format = FormatPain001
+	}
+	executionDate := time.Now().UTC()
+	if req.ExecutionDate != nil {
+		executionDate = req.ExecutionDate.UTC()
+	}
+
+	var qry datastore.QueryResponse
+	qry, err = x.Dependency.Datastore.Query(ctx, datastore.QueryRequest{
+		Payouts: &datastore.QueryRequestPayouts{ByPayoutStatus: datastore.PayoutPending},
+	})
+	if err != nil {
+		return
+	}
+	if len(qry.List) == 0 {
+		err = fmt.Errorf("no pending payout instruction")
+		return
+	}
+	payouts := make([]datastore.PayoutInstruction, len(qry.List))
+	for i, q := range qry.List {
+		payouts[i] = q.Payouts.PayoutInstruction
+	}
+
+	batchID := xid.New()
+	switch res.Format {
+	case FormatPain001:
+		res.ContentType, res.Filename = "application/xml", fmt.Sprintf("payout-%s.xml", batchID)
+		res.File, err = x.pain001(batchID.String(), executionDate, payouts)
+	case FormatCSV:
+		res.ContentType, res.Filename = "text/csv", fmt.Sprintf("payout-%s.csv", batchID)
+		res.File, err = writeCSV(executionDate, payouts)
+	default:
+		err = fmt.Errorf("unknown payout format [%s]", req.Format)
+	}
+	if err != nil {
+		return
+	}
+
+	mut := &datastore.MutationRequestPayouts{BatchID: batchID.Bytes()}
+	for _, p := range payouts {
+		mut.List = append(mut.List, datastore.PayoutInstruction{
+			InstructionID: p.InstructionID,
+			PayoutStatus:  datastore.PayoutExported,
+		})
+	}
+	if _, err = x.Dependency.Datastore.Mutation(ctx, datastore.MutationRequest{Payouts: mut}); err != nil {
+		res = ExportResponse{}
+		return
+	}
+	res.BatchID = batchID.Bytes()
+	for _, p := range payouts {
+		p.PayoutStatus, p.BatchID = datastore.PayoutExported, res.BatchID
+		res.List = append(res.List, instruction(p))
+	}
+
+	log.DebugContext(ctx, "feature/payout.Export",
+		slog.Any("req", req),
+		slog.String("filename", res.Filename),
+		slog.Int("len", len(res.List)),
+		slog.Any("err", err),
+	)
+	return
+}
@@ -0,0 +1,182 @@
+package payout
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/gunawanwijaya/loan-svc/internal/repository/datastore"
+	"github.com/gunawanwijaya/loan-svc/pkg"
+)
+
+// Instruction is the payment of the net principal to the borrower account, following the status lifecycle
+//
+//	pending -> exported -> confirmed
+//	              |
+//	              +-----> failed -> pending
+type Instruction struct {
+	InstructionID   []byte       `json:"instruction_id,omitempty"`
+	LoanID          []byte       `json:"loan_id,omitempty"`
+	PaymentID       []byte       `json:"payment_id,omitempty"`
+	Amount          *pkg.Money   `json:"amount,omitempty"` // the whole principal, there are no upfront fees
+	BorrowerAccount *BankAccount `json:"borrower_account,omitempty"`
+	Reference       string       `json:"reference,omitempty"`
+	Status          string       `json:"status,omitempty"`
+	StatusReason    string       `json:"status_reason,omitempty"`
+	BatchID         []byte       `json:"batch_id,omitempty"`
+	CreatedAt       time.Time    `json:"created_at"`
+	UpdatedAt       time.Time    `json:"updated_at"`
+}
+
+type BankAccount struct {
+	AccountName   string `json:"account_name,omitempty"`
+	AccountNumber string `json:"account_number,omitempty"`
+	BankCode      string `json:"bank_code,omitempty"`
+}
+
+type ListRequest struct {
+	Status  string `json:"status,omitempty"` // empty for all status
+	LoanID  []byte `json:"loan_id,omitempty"`
+	BatchID []byte `json:"batch_id,omitempty"`
+}
+
+type ListResponse struct {
+	List []Instruction `json:"list,omitempty"`
+}
+
+// List will return the payout instructions ordered by creation.
+func (x *payout) List(ctx context.Context, req ListRequest) (res ListResponse, err error) {
+	log := pkg.Context.SlogLogger(ctx)
+	var status datastore.PayoutStatus
+	if req.Status != "" {
+		if status, err = parseStatus(req.Status); err != nil {
+			return
+		}
+	}
+	var qry datastore.QueryResponse
+	qry, err = x.Dependency.Datastore.Query(ctx, datastore.QueryRequest{
+		Payouts: &datastore.QueryRequestPayouts{
+			ByPayoutStatus: status,
+			ByLoanID:       req.LoanID,
+			ByBatchID:      req.BatchID,
+		},
+	})
+	if err != nil {
+		return
+	}
+	for _, q := range qry.List {
+		res.List = append(res.List, instruction(q.Payouts.PayoutInstruction))
+	}
+
+	log.DebugContext(ctx, "feature/payout.List",
+		slog.Any("req", req),
+		slog.Int("len", len(res.List)),
+		slog.Any("err", err),
+	)
+	return
+}
+
+type UpdateRequest struct {
+	List []UpdateRequest `json:"list,omitempty"`
+
+	InstructionID []byte `json:"instruction_id,omitempty"`
+	Status        string `json:"status,omitempty"` // confirmed or failed once exported, pending again once failed
+	Reason        string `json:"reason,omitempty"`
+}
+
+func (x *UpdateRequest) Validate(ctx context.Context) (_ *UpdateRequest, err error) {
+	if len(x.InstructionID) < 1 {
+		return nil, fmt.Errorf("invalid instruction_id")
+	}
+	status, err := parseStatus(x.Status)
+	if err != nil {
+		return nil, err
+	}
+	if status == datastore.PayoutExported {
+		return nil, fmt.Errorf("status [exported] is only set by export")
+	}
+	if status == datastore.PayoutFailed && x.Reason == "" {
+		return nil, fmt.Errorf("invalid reason of failed payout")
+	}
+	return x, nil
+}
+
+// Update will move the status of the payout instructions, either all or none of them are moved.
+func (x *payout) Update(ctx context.Context, req UpdateRequest) (res ListResponse, err error) {
+	log := pkg.Context.SlogLogger(ctx)
+	list := req.List
+	if len(list) == 0 {
+		list = []UpdateRequest{req}
+	}
+	mut := &datastore.MutationRequestPayouts{}
+	for i := range list {
+		var u *UpdateRequest
+		if u, err = pkg.AsValidator(&list[i]).Validate(ctx); err != nil {
+			return
+		}
+		status, _ := parseStatus(u.Status)
+		mut.List = append(mut.List, datastore.PayoutInstruction{
+			InstructionID: u.InstructionID,
+			PayoutStatus:  status,
+			StatusReason:  u.Reason,
+		})
+	}
+	if _, err = x.Dependency.Datastore.Mutation(ctx, datastore.MutationRequest{Payouts: mut}); err != nil {
+		return
+	}
+	updated := map[string]bool{}
+	for _, u := range list {
+		updated[string(u.InstructionID)] = true
+	}
+	var all ListResponse
+	if all, err = x.List(ctx, ListRequest{}); err != nil {
+		return
+	}
+	for _, i := range all.List {
+		if updated[string(i.InstructionID)] {
+			res.List = append(res.List, i)
+		}
+	}
+
+	log.DebugContext(ctx, "feature/payout.Update",
+		slog.Any("req", req),
+		slog.Any("res", res),
+		slog.Any("err", err),
+	)
+	return
+}
+
+func parseStatus(s string) (datastore.PayoutStatus, error) {
+	for _, status := range []datastore.PayoutStatus{
+		datastore.PayoutPending,
+		datastore.PayoutExported,
+		datastore.PayoutConfirmed,
+		datastore.PayoutFailed,
+	} {
+		if status.String() == s {
+			return status, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown payout status [%s]", s)
+}
+
+func instruction(p datastore.PayoutInstruction) Instruction {
+	return Instruction{
+		InstructionID: p.InstructionID,
+		LoanID:        p.LoanID,
+		PaymentID:     p.PaymentID,
+		Amount:        &pkg.Money{ISO4217: p.ISO4217, Amount: p.Amount},
+		BorrowerAccount: &BankAccount{
+			AccountName:   p.AccountName,
+			AccountNumber: p.AccountNumber,
+			BankCode:      p.BankCode,
+		},
+		Reference:    p.Reference,
+		Status:       p.PayoutStatus.String(),
+		StatusReason: p.StatusReason,
+		BatchID:      p.BatchID,
+		CreatedAt:    time.Unix(p.CreatedAt, 0),
+		UpdatedAt:    time.Unix(p.UpdatedAt, 0),
+	}
+}
@@ -0,0 +1,140 @@
+package payout
+
+import (
+	"encoding/xml"
+	"math"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/gunawanwijaya/loan-svc/internal/repository/datastore"
+	"github.com/gunawanwijaya/loan-svc/pkg"
+)
+
+var (
+	reIBAN = regexp.MustCompile(`^[A-Z]{2}[0-9]{2}[A-Z0-9]{11,30}$`)
+	reBIC  = regexp.MustCompile(`^[A-Z]{6}[A-Z0-9]{2}([A-Z0-9]{3})?$`)
+)
+
+type pain001Document struct {
+	XMLName xml.Name `xml:"urn:iso:std:iso:20022:tech:xsd:pain.001.001.03 Document"`
+	GrpHdr  struct {
+		MsgId    string       `xml:"MsgId"`
+		CreDtTm  string       `xml:"CreDtTm"`
+		NbOfTxs  int          `xml:"NbOfTxs"`
+		CtrlSum  string       `xml:"CtrlSum"`
+		InitgPty pain001Party `xml:"InitgPty"`
+	} `xml:"CstmrCdtTrfInitn>GrpHdr"`
+	PmtInf []pain001PaymentInformation `xml:"CstmrCdtTrfInitn>PmtInf"`
+}
+
+type pain001PaymentInformation struct {
+	PmtInfId    string                  `xml:"PmtInfId"`
+	PmtMtd      string                  `xml:"PmtMtd"`
+	NbOfTxs     int                     `xml:"NbOfTxs"`
+	CtrlSum     string                  `xml:"CtrlSum"`
+	ReqdExctnDt string                  `xml:"ReqdExctnDt"`
+	Dbtr        pain001Party            `xml:"Dbtr"`
+	DbtrAcct    pain001Account          `xml:"DbtrAcct"`
+	DbtrAgt     pain001Agent            `xml:"DbtrAgt"`
+	CdtTrfTxInf []pain001CreditTransfer `xml:"CdtTrfTxInf"`
+}
+
+type pain001CreditTransfer struct {
+	PmtId struct {
+		InstrId    string `xml:"InstrId"`
+		EndToEndId string `xml:"EndToEndId"`
+	} `xml:"PmtId"`
+	Amt struct {
+		Ccy   string `xml:"Ccy,attr"`
+		Value string `xml:",chardata"`
+	} `xml:"Amt>InstdAmt"`
+	CdtrAgt  pain001Agent   `xml:"CdtrAgt"`
+	Cdtr     pain001Party   `xml:"Cdtr"`
+	CdtrAcct pain001Account `xml:"CdtrAcct"`
+	Ustrd    string         `xml:"RmtInf>Ustrd"`
+}
+
+type pain001Party struct {
+	Nm string `xml:"Nm"`
+}
+
+type pain001Account struct {
+	IBAN string `xml:"Id>IBAN,omitempty"`
+	Othr string `xml:"Id>Othr>Id,omitempty"`
+}
+
+type pain001Agent struct {
+	BIC   string `xml:"FinInstnId>BIC,omitempty"`
+	MmbId string `xml:"FinInstnId>ClrSysMmbId>MmbId,omitempty"`
+}
+
+// pain001 will write one payment information per currency, debited from the configured debtor. The end to end ID
+// is the principal disbursement payment, so that the bank statement is matched back by reconciliation.
+func (x *payout) pain001(batchID string, executionDate time.Time, payouts []datastore.PayoutInstruction) ([]byte, error) {
+	doc := pain001Document{}
+	doc.GrpHdr.MsgId = batchID
+	doc.GrpHdr.CreDtTm = time.Now().UTC().Format("2006-01-02T15:04:05")
+	doc.GrpHdr.InitgPty.Nm = x.Configuration.Debtor.Name
+
+	var total float64
+	byCurrency := map[string]int{}
+	for _, p := range payouts {
+		i, ok := byCurrency[p.ISO4217]
+		if !ok {
+			i = len(doc.PmtInf)
+			byCurrency[p.ISO4217] = i
+			doc.PmtInf = append(doc.PmtInf, pain001PaymentInformation{
+				PmtInfId:    batchID + "-" + p.ISO4217,
+				PmtMtd:      "TRF",
+				ReqdExctnDt: executionDate.Format(time.DateOnly),
+				Dbtr:        pain001Party{Nm: x.Configuration.Debtor.Name},
+				DbtrAcct:    account(x.Configuration.Debtor.AccountNumber),
+				DbtrAgt:     agent(x.Configuration.Debtor.BankCode),
+			})
+		}
+		t := pain001CreditTransfer{
+			CdtrAgt:  agent(p.BankCode),
+			Cdtr:     pain001Party{Nm: p.AccountName},
+			CdtrAcct: account(p.AccountNumber),
+			Ustrd:    p.Reference,
+		}
+		t.PmtId.InstrId = pkg.BtoA(p.InstructionID)
+		t.PmtId.EndToEndId = pkg.BtoA(p.PaymentID)
+		t.Amt.Ccy, t.Amt.Value = p.ISO4217, decimal(p.Amount)
+
+		pmtInf := &doc.PmtInf[i]
+		pmtInf.CdtTrfTxInf = append(pmtInf.CdtTrfTxInf, t)
+		pmtInf.NbOfTxs++
+		sum, _ := strconv.ParseFloat(pmtInf.CtrlSum, 64)
+		pmtInf.CtrlSum = decimal(sum + p.Amount)
+		total += p.Amount
+	}
+	doc.GrpHdr.NbOfTxs = len(payouts)
+	doc.GrpHdr.CtrlSum = decimal(total)
+
+	b, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), b...), nil
+}
+
+func account(number string) pain001Account {
+	if reIBAN.MatchString(number) {
+		return pain001Account{IBAN: number}
+	}
+	return pain001Account{Othr: number}
+}
+
+func agent(code string) pain001Agent {
+	if reBIC.MatchString(code) {
+		return pain001Agent{BIC: code}
+	}
+	return pain001Agent{MmbId: code}
+}
+
+// decimal will format the amount with at most 5 fraction digits as allowed by ISO 20022.
+func decimal(amount float64) string {
+	return strconv.FormatFloat(math.Round(amount*1e5)/1e5, 'f', -1, 64)
+}
@@ -0,0 +1,58 @@
+//go:generate mockgen -destination payout_mock.go -package payout . Payout
+package payout
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gunawanwijaya/loan-svc/internal/repository/datastore"
+	"github.com/gunawanwijaya/loan-svc/pkg"
+)
+
+type Configuration struct {
+	Debtor Debtor `json:"debtor,omitempty"` // platform account paying the borrower
+}
+type Debtor struct {
+	Name          string `json:"name,omitempty"`
+	AccountNumber string `json:"account_number,omitempty"` // IBAN or domestic account number
+	BankCode      string `json:"bank_code,omitempty"`      // BIC or domestic bank code
+}
+type Dependency struct {
+	datastore.Datastore
+}
+type Payout interface {
+	Export(ctx context.Context, req ExportRequest) (res ExportResponse, err error)
+	List(ctx context.Context, req ListRequest) (res ListResponse, err error)
+	Update(ctx context.Context, req UpdateRequest) (res ListResponse, err error)
+}
+
+func New(ctx context.Context, cfg Configuration, dep Dependency) (_ Payout, err error) {
+	cfg, err = pkg.AsValidator(cfg).Validate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	dep, err = pkg.AsValidator(dep).Validate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &payout{cfg, dep}, nil
+}
+
+type payout struct {
+	Configuration
+	Dependency
+}
+
+func (cfg Configuration) Validate(ctx context.Context) (_ Configuration, err error) {
+	if cfg.Debtor.Name == "" || cfg.Debtor.AccountNumber == "" || cfg.Debtor.BankCode == "" {
+		return cfg, fmt.Errorf("feature/payout: incomplete debtor")
+	}
+	return cfg, nil
+}
+
+func (dep Dependency) Validate(ctx context.Context) (_ Dependency, err error) {
+	if dep.Datastore == nil {
+		return dep, fmt.Errorf("feature/payout: uninitialized repository/datastore")
+	}
+	return dep, nil
+}
@@ -0,0 +1,170 @@
+package payout_test
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/xml"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gunawanwijaya/loan-svc/internal/feature/payout"
+	"github.com/gunawanwijaya/loan-svc/internal/repository/datastore"
+	"github.com/gunawanwijaya/loan-svc/pkg"
+	"github.com/rs/xid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestPayout(t *testing.T) {
+	ctx := pkg.Context.PutSlogLogger(context.Background(), slog.Default())
+	ctrl := gomock.NewController(t)
+	mockDatastore := datastore.NewMockDatastore(ctrl)
+
+	_, err := payout.New(ctx, payout.Configuration{}, payout.Dependency{Datastore: mockDatastore})
+	require.Error(t, err)
+
+	featPayout, err := payout.New(ctx, payout.Configuration{Debtor: payout.Debtor{
+		Name:          "Loan Platform",
+		AccountNumber: "DE89370400440532013000",
+		BankCode:      "COBADEFFXXX",
+	}}, payout.Dependency{
+		Datastore: mockDatastore,
+	})
+	require.NoError(t, err)
+
+	pending := func(iso4217 string, amount float64, accountNumber, bankCode string) datastore.PayoutInstruction {
+		return datastore.PayoutInstruction{
+			InstructionID: xid.New().Bytes(),
+			LoanID:        xid.New().Bytes(),
+			PaymentID:     xid.New().Bytes(),
+			ISO4217:       iso4217,
+			Amount:        amount,
+			BankAccount:   datastore.BankAccount{AccountName: "Budi & Sons", AccountNumber: accountNumber, BankCode: bankCode},
+			Reference:     "Disbursement",
+			PayoutStatus:  datastore.PayoutPending,
+		}
+	}
+	payouts := []datastore.PayoutInstruction{
+		pending("EUR", 1_000.10, "NL91ABNA0417164300", "ABNANL2A"),
+		pending("IDR", 10_000_000, "1234567890", "014"),
+		pending("EUR", 2_000.20, "FR1420041010050500013M02606", "PSSTFRPPPAR"),
+	}
+	list := func(payouts ...datastore.PayoutInstruction) (res datastore.QueryResponse) {
+		for _, p := range payouts {
+			res.List = append(res.List, datastore.QueryResponse{Payouts: &datastore.QueryResponsePayouts{PayoutInstruction: p}})
+		}
+		return res
+	}
+	exported := func(ctx context.Context, req datastore.MutationRequest) (datastore.MutationResponse, error) {
+		require.NotEmpty(t, req.Payouts.BatchID)
+		require.Len(t, req.Payouts.List, len(payouts))
+		for i, p := range req.Payouts.List {
+			require.Equal(t, payouts[i].InstructionID, p.InstructionID)
+			require.Equal(t, datastore.PayoutExported, p.PayoutStatus)
+		}
+		return datastore.MutationResponse{Payouts: &datastore.MutationResponsePayouts{List: req.Payouts.List}}, nil
+	}
+
+	executionDate := time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)
+	{
+		mockDatastore.EXPECT().
+			Query(ctx, datastore.QueryRequest{Payouts: &datastore.QueryRequestPayouts{ByPayoutStatus: datastore.PayoutPending}}).
+			Return(list(payouts...), nil)
+		mockDatastore.EXPECT().
+			Mutation(ctx, gomock.Any()).
+			DoAndReturn(exported)
+	}
+	res, err := featPayout.Export(ctx, payout.ExportRequest{ExecutionDate: &executionDate})
+	require.NoError(t, err)
+	require.Equal(t, payout.FormatPain001, res.Format)
+	require.Equal(t, "application/xml", res.ContentType)
+	require.Len(t, res.List, 3)
+	require.Equal(t, "exported", res.List[0].Status)
+
+	var doc struct {
+		NbOfTxs int    `xml:"CstmrCdtTrfInitn>GrpHdr>NbOfTxs"`
+		CtrlSum string `xml:"CstmrCdtTrfInitn>GrpHdr>CtrlSum"`
+		PmtInf  []struct {
+			NbOfTxs     int    `xml:"NbOfTxs"`
+			CtrlSum     string `xml:"CtrlSum"`
+			ReqdExctnDt string `xml:"ReqdExctnDt"`
+			DbtrIBAN    string `xml:"DbtrAcct>Id>IBAN"`
+			CdtTrfTxInf []struct {
+				EndToEndId string `xml:"PmtId>EndToEndId"`
+				Amt        struct {
+					Ccy   string `xml:"Ccy,attr"`
+					Value string `xml:",chardata"`
+				} `xml:"Amt>InstdAmt"`
+				BIC   string `xml:"CdtrAgt>FinInstnId>BIC"`
+				MmbId string `xml:"CdtrAgt>FinInstnId>ClrSysMmbId>MmbId"`
+				Nm    string `xml:"Cdtr>Nm"`
+				IBAN  string `xml:"CdtrAcct>Id>IBAN"`
+				Othr  string `xml:"CdtrAcct>Id>Othr>Id"`
+			} `xml:"CdtTrfTxInf"`
+		} `xml:"CstmrCdtTrfInitn>PmtInf"`
+	}
+	require.NoError(t, xml.Unmarshal(res.File, &doc))
+	require.Equal(t, 3, doc.NbOfTxs)
+	require.Equal(t, "10003000.3", doc.CtrlSum)
+	require.Len(t, doc.PmtInf, 2) // EUR & IDR
+	require.Equal(t, 2, doc.PmtInf[0].NbOfTxs)
+	require.Equal(t, "3000.3", doc.PmtInf[0].CtrlSum)
+	require.Equal(t, "2025-01-02", doc.PmtInf[0].ReqdExctnDt)
+	require.Equal(t, "DE89370400440532013000", doc.PmtInf[0].DbtrIBAN)
+	require.Equal(t, pkg.BtoA(payouts[0].PaymentID), doc.PmtInf[0].CdtTrfTxInf[0].EndToEndId)
+	require.Equal(t, "EUR", doc.PmtInf[0].CdtTrfTxInf[0].Amt.Ccy)
+	require.Equal(t, "1000.1", doc.PmtInf[0].CdtTrfTxInf[0].Amt.Value)
+	require.Equal(t, "ABNANL2A", doc.PmtInf[0].CdtTrfTxInf[0].BIC)
+	require.Equal(t, "NL91ABNA0417164300", doc.PmtInf[0].CdtTrfTxInf[0].IBAN)
+	require.Equal(t, "Budi & Sons", doc.PmtInf[0].CdtTrfTxInf[0].Nm)
+	require.Equal(t, "014", doc.PmtInf[1].CdtTrfTxInf[0].MmbId)
+	require.Equal(t, "1234567890", doc.PmtInf[1].CdtTrfTxInf[0].Othr)
+
+	{
+		mockDatastore.EXPECT().
+			Query(ctx, gomock.Any()).
+			Return(list(payouts...), nil)
+		mockDatastore.EXPECT().
+			Mutation(ctx, gomock.Any()).
+			DoAndReturn(exported)
+	}
+	res, err = featPayout.Export(ctx, payout.ExportRequest{Format: payout.FormatCSV, ExecutionDate: &executionDate})
+	require.NoError(t, err)
+	records, err := csv.NewReader(strings.NewReader(string(res.File))).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 4)
+	require.Equal(t, []string{"2025-01-02", "Budi & Sons", "1234567890", "014", "IDR", "10000000"}, records[2][2:8])
+
+	{
+		mockDatastore.EXPECT().
+			Query(ctx, gomock.Any()).
+			Return(datastore.QueryResponse{}, nil)
+	}
+	_, err = featPayout.Export(ctx, payout.ExportRequest{})
+	require.Error(t, err) // nothing is pending
+
+	_, err = featPayout.Update(ctx, payout.UpdateRequest{InstructionID: payouts[0].InstructionID, Status: "exported"})
+	require.Error(t, err)
+	_, err = featPayout.Update(ctx, payout.UpdateRequest{InstructionID: payouts[0].InstructionID, Status: "failed"})
+	require.Error(t, err) // missing reason
+
+	{
+		mockDatastore.EXPECT().
+			Mutation(ctx, datastore.MutationRequest{Payouts: &datastore.MutationRequestPayouts{List: []datastore.PayoutInstruction{
+				{InstructionID: payouts[0].InstructionID, PayoutStatus: datastore.PayoutFailed, StatusReason: "account closed"},
+			}}}).
+			Return(datastore.MutationResponse{Payouts: &datastore.MutationResponsePayouts{}}, nil)
+		failed := payouts[0]
+		failed.PayoutStatus, failed.StatusReason = datastore.PayoutFailed, "account closed"
+		mockDatastore.EXPECT().
+			Query(ctx, datastore.QueryRequest{Payouts: &datastore.QueryRequestPayouts{}}).
+			Return(list(failed, payouts[1], payouts[2]), nil)
+	}
+	updated, err := featPayout.Update(ctx, payout.UpdateRequest{InstructionID: payouts[0].InstructionID, Status: "failed", Reason: "account closed"})
+	require.NoError(t, err)
+	require.Len(t, updated.List, 1)
+	require.Equal(t, "failed", updated.List[0].Status)
+	require.Equal(t, "account closed", updated.List[0].StatusReason)
+}
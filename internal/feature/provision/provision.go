@@ -0,0 +1,77 @@
+//go:generate mockgen -destination provision_mock.go -package provision . Provision
+package provision
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/gunawanwijaya/loan-svc/internal/repository/datastore"
+	"github.com/gunawanwijaya/loan-svc/pkg"
+)
+
+type Configuration struct {
+	Buckets []Bucket `json:"buckets,omitempty"`
+}
+
+// Bucket is the probability of default & loss given default applied to loans with days past due starting from
+// MinDaysPastDue until the next bucket.
+type Bucket struct {
+	MinDaysPastDue       int     `json:"min_days_past_due"`
+	Stage                int     `json:"stage"`
+	ProbabilityOfDefault float64 `json:"probability_of_default"`
+	LossGivenDefault     float64 `json:"loss_given_default"`
+}
+type Dependency struct {
+	datastore.Datastore
+}
+type Provision interface {
+	Snapshot(ctx context.Context, req SnapshotRequest) (res SnapshotResponse, err error)
+}
+
+func New(ctx context.Context, cfg Configuration, dep Dependency) (_ Provision, err error) {
+	cfg, err = pkg.AsValidator(cfg).Validate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	dep, err = pkg.AsValidator(dep).Validate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &provision{cfg, dep}, nil
+}
+
+type provision struct {
+	Configuration
+	Dependency
+}
+
+func (cfg Configuration) Validate(ctx context.Context) (_ Configuration, err error) {
+	if len(cfg.Buckets) < 1 {
+		return cfg, fmt.Errorf("feature/provision: empty buckets")
+	}
+	cfg.Buckets = slices.Clone(cfg.Buckets)
+	slices.SortFunc(cfg.Buckets, func(a, b Bucket) int { return a.MinDaysPastDue - b.MinDaysPastDue })
+	if cfg.Buckets[0].MinDaysPastDue != 0 {
+		return cfg, fmt.Errorf("feature/provision: first bucket should start from 0 days past due")
+	}
+	for i, b := range cfg.Buckets {
+		if i > 0 && b.MinDaysPastDue == cfg.Buckets[i-1].MinDaysPastDue {
+			return cfg, fmt.Errorf("feature/provision: duplicate bucket of %d days past due", b.MinDaysPastDue)
+		}
+		if b.Stage < 1 || b.Stage > 3 {
+			return cfg, fmt.Errorf("feature/provision: invalid stage %d", b.Stage)
+		}
+		if b.ProbabilityOfDefault < 0 || b.ProbabilityOfDefault > 1 || b.LossGivenDefault < 0 || b.LossGivenDefault > 1 {
+			return cfg, fmt.Errorf("feature/provision: probability & loss should be ranged between 0 & 1")
+		}
+	}
+	return cfg, nil
+}
+
+func (dep Dependency) Validate(ctx context.Context) (_ Dependency, err error) {
+	if dep.Datastore == nil {
+		return dep, fmt.Errorf("feature/provision: uninitialized repository/datastore")
+	}
+	return dep, nil
+}
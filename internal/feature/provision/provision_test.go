@@ -0,0 +1,142 @@
+package provision_test
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/gunawanwijaya/loan-svc/internal/feature/provision"
+	"github.com/gunawanwijaya/loan-svc/internal/repository/datastore"
+	"github.com/gunawanwijaya/loan-svc/pkg"
+	"github.com/rs/xid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestProvision(t *testing.T) {
+	ctx := pkg.Context.PutSlogLogger(context.Background(), slog.Default())
+	ctrl := gomock.NewController(t)
+	mockDatastore := datastore.NewMockDatastore(ctrl)
+
+	_, err := provision.New(ctx, provision.Configuration{}, provision.Dependency{Datastore: mockDatastore})
+	require.Error(t, err)
+
+	featProvision, err := provision.New(ctx, provision.Configuration{
+		Buckets: []provision.Bucket{
+			{MinDaysPastDue: 91, Stage: 3, ProbabilityOfDefault: 1.0, LossGivenDefault: .50},
+			{MinDaysPastDue: 0, Stage: 1, ProbabilityOfDefault: .02, LossGivenDefault: .50},
+			{MinDaysPastDue: 31, Stage: 2, ProbabilityOfDefault: .20, LossGivenDefault: .50},
+		},
+	}, provision.Dependency{
+		Datastore: mockDatastore,
+	})
+	require.NoError(t, err)
+
+	asOf := time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)
+	lenderID1, lenderID2 := []byte("1111"), []byte("1112")
+	newLoan := func(firstDue time.Time, settled int) datastore.Loan {
+		payments := []datastore.LoanPartyPayment{{PaymentType: datastore.PaymentPrincipalDisbursement, ISO4217: "IDR", Amount: -1_000_000.00, Time: firstDue.AddDate(0, -1, 0).Unix()}}
+		for i := range 4 {
+			payment := datastore.LoanPartyPayment{PaymentType: datastore.PaymentInstallment, ISO4217: "IDR", Amount: 300_000.00, Time: firstDue.AddDate(0, i, 0).Unix()}
+			if i < settled {
+				payment.SettledAt = pkg.Ptr(payment.Time)
+			}
+			payments = append(payments, payment)
+		}
+		return datastore.Loan{
+			LoanID:      xid.New().Bytes(),
+			LoanState:   datastore.StateDisbursed,
+			DisbursedAt: pkg.Ptr(payments[0].Time),
+			Parties: []datastore.LoanParty{{
+				LoanPartyRoleAs: datastore.RoleAsBorrower,
+				Payments:        payments,
+			}, {
+				UserID:          lenderID1,
+				LoanPartyRoleAs: datastore.RoleAsLender,
+				Payments: []datastore.LoanPartyPayment{
+					{PaymentType: datastore.PaymentInvestment, ISO4217: "IDR", Amount: 750_000.00},
+					{PaymentType: datastore.PaymentRepayment, ISO4217: "IDR", Amount: -800_000.00},
+				},
+			}, {
+				UserID:          lenderID2,
+				LoanPartyRoleAs: datastore.RoleAsLender,
+				Payments: []datastore.LoanPartyPayment{
+					{PaymentType: datastore.PaymentInvestment, ISO4217: "IDR", Amount: 250_000.00},
+					{PaymentType: datastore.PaymentRepayment, ISO4217: "IDR", Amount: -270_000.00},
+				},
+			}},
+		}
+	}
+	performing := newLoan(asOf.AddDate(0, 0, 10), 0)                            // nothing is due yet
+	underperforming := newLoan(time.Date(2024, 9, 30, 0, 0, 0, 0, time.UTC), 2) // 2 settled, 3rd installment 31 days past due
+	impaired := newLoan(asOf.AddDate(0, -4, 0), 0)                              // nothing settled, 1st installment 122 days past due
+	disbursedLater := newLoan(asOf.AddDate(0, 1, 1), 0)                         // disbursed after said date, left out
+
+	{
+		mockDatastore.EXPECT().
+			Query(ctx, datastore.QueryRequest{Provisions: &datastore.QueryRequestProvisions{ByAsOf: asOf.Unix()}}).
+			Return(datastore.QueryResponse{}, nil)
+		mockDatastore.EXPECT().
+			Query(ctx, datastore.QueryRequest{Loans: &datastore.QueryRequestLoans{ByLoanState: datastore.StateDisbursed}}).
+			Return(datastore.QueryResponse{List: []datastore.QueryResponse{
+				{Loans: &datastore.QueryResponseLoans{Loan: performing}},
+				{Loans: &datastore.QueryResponseLoans{Loan: underperforming}},
+				{Loans: &datastore.QueryResponseLoans{Loan: impaired}},
+				{Loans: &datastore.QueryResponseLoans{Loan: disbursedLater}},
+			}}, nil)
+		mockDatastore.EXPECT().
+			Mutation(ctx, gomock.Any()).
+			DoAndReturn(func(ctx context.Context, req datastore.MutationRequest) (datastore.MutationResponse, error) {
+				require.NotNil(t, req.Provisions)
+				require.NotEmpty(t, req.Provisions.SnapshotID)
+				require.Equal(t, asOf.Unix(), req.Provisions.AsOf)
+				require.Len(t, req.Provisions.Loans, 3)
+				require.Len(t, req.Provisions.Lenders, 6)
+				return datastore.MutationResponse{Provisions: &datastore.MutationResponseProvisions{
+					ProvisionSnapshot: req.Provisions.ProvisionSnapshot,
+				}}, nil
+			})
+	}
+	res, err := featProvision.Snapshot(ctx, provision.SnapshotRequest{AsOf: pkg.Ptr(asOf.Add(13 * time.Hour)), Record: true})
+	require.NoError(t, err)
+	require.NotEmpty(t, res.SnapshotID)
+	require.Equal(t, asOf, res.AsOf)
+
+	require.Len(t, res.Loans, 3)
+	require.Equal(t, 0, res.Loans[0].DaysPastDue)
+	require.Equal(t, 1, res.Loans[0].Stage)
+	require.Equal(t, 1_000_000.00, res.Loans[0].ExposureAtDefault.Amount)
+	require.Equal(t, 10_000.00, res.Loans[0].ExpectedCreditLoss.Amount)
+	require.Equal(t, 31, res.Loans[1].DaysPastDue)
+	require.Equal(t, 2, res.Loans[1].Stage)
+	require.Equal(t, 500_000.00, res.Loans[1].ExposureAtDefault.Amount)
+	require.Equal(t, 50_000.00, res.Loans[1].ExpectedCreditLoss.Amount)
+	require.Equal(t, 122, res.Loans[2].DaysPastDue)
+	require.Equal(t, 3, res.Loans[2].Stage)
+	require.Equal(t, 500_000.00, res.Loans[2].ExpectedCreditLoss.Amount)
+
+	require.Len(t, res.Portfolio, 3)
+	require.Len(t, res.Lenders, 2)
+	require.Equal(t, lenderID1, res.Lenders[0].LenderID)
+	require.Equal(t, 1_875_000.00, res.Lenders[0].ExposureAtDefault.Amount)
+	require.Equal(t, 420_000.00, res.Lenders[0].ExpectedCreditLoss.Amount)
+	require.Equal(t, 140_000.00, res.Lenders[1].ExpectedCreditLoss.Amount)
+
+	{
+		mockDatastore.EXPECT().
+			Query(ctx, datastore.QueryRequest{Provisions: &datastore.QueryRequestProvisions{ByAsOf: asOf.Unix()}}).
+			Return(datastore.QueryResponse{Provisions: &datastore.QueryResponseProvisions{ProvisionSnapshot: datastore.ProvisionSnapshot{
+				SnapshotID: res.SnapshotID,
+				AsOf:       asOf.Unix(),
+				Loans: []datastore.ProvisionLoan{{
+					LoanID: impaired.LoanID, ISO4217: "IDR", DaysPastDue: 122, Stage: 3,
+					ProbabilityOfDefault: 1.0, LossGivenDefault: .50, ExposureAtDefault: 1_000_000.00, ExpectedCreditLoss: 500_000.00,
+				}},
+			}}}, nil)
+	}
+	res, err = featProvision.Snapshot(ctx, provision.SnapshotRequest{AsOf: &asOf, Record: true}) // persisted snapshot is reproduced as is
+	require.NoError(t, err)
+	require.Len(t, res.Loans, 1)
+	require.Equal(t, 500_000.00, res.Portfolio[0].ExpectedCreditLoss.Amount)
+}
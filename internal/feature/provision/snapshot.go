@@ -0,0 +1,245 @@
+package provision
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/gunawanwijaya/loan-svc/internal/repository/datastore"
+	"github.com/gunawanwijaya/loan-svc/pkg"
+	"github.com/rs/xid"
+)
+
+type SnapshotRequest struct {
+	AsOf   *time.Time `json:"as_of,omitempty"`  // default to today, only the date in UTC is used
+	Record bool       `json:"record,omitempty"` // persist the snapshot when there is none on said date
+}
+
+type SnapshotResponse struct {
+	SnapshotID []byte    `json:"snapshot_id,omitempty"` // empty when the snapshot is not persisted
+	AsOf       time.Time `json:"as_of"`
+
+	Portfolio []SnapshotStage  `json:"portfolio,omitempty"`
+	Loans     []SnapshotLoan   `json:"loans,omitempty"`
+	Lenders   []SnapshotLender `json:"lenders,omitempty"`
+}
+
+type SnapshotStage struct {
+	Stage              int        `json:"stage"`
+	NumOfLoans         int        `json:"num_of_loans"`
+	ExposureAtDefault  *pkg.Money `json:"exposure_at_default,omitempty"`
+	ExpectedCreditLoss *pkg.Money `json:"expected_credit_loss,omitempty"`
+}
+
+type SnapshotLoan struct {
+	LoanID               []byte     `json:"loan_id,omitempty"`
+	DaysPastDue          int        `json:"days_past_due"`
+	Stage                int        `json:"stage"`
+	ProbabilityOfDefault float64    `json:"probability_of_default"`
+	LossGivenDefault     float64    `json:"loss_given_default"`
+	ExposureAtDefault    *pkg.Money `json:"exposure_at_default,omitempty"`
+	ExpectedCreditLoss   *pkg.Money `json:"expected_credit_loss,omitempty"`
+}
+
+type SnapshotLender struct {
+	LenderID           []byte     `json:"lender_id,omitempty"`
+	ExposureAtDefault  *pkg.Money `json:"exposure_at_default,omitempty"`
+	ExpectedCreditLoss *pkg.Money `json:"expected_credit_loss,omitempty"`
+}
+
+// Snapshot will return the persisted snapshot of said date when exists so that figures are reproducible,
+// otherwise the expected credit loss is calculated from all loans disbursed at said date
+//   - days past due counted from the oldest installment not settled at said date
+//   - exposure at default is the principal portion of installments not settled at said date
+//   - stage, probability of default & loss given default taken from the bucket of days past due
+//   - expected credit loss of each loan is shared to its lenders proportional to their investment
+func (x *provision) Snapshot(ctx context.Context, req SnapshotRequest) (res SnapshotResponse, err error) {
+	log := pkg.Context.SlogLogger(ctx)
+	asOf := time.Now()
+	if req.AsOf != nil {
+		asOf = *req.AsOf
+	}
+	asOf = asOf.UTC().Truncate(24 * time.Hour)
+
+	var qry datastore.QueryResponse
+	qry, err = x.Dependency.Datastore.Query(ctx, datastore.QueryRequest{
+		Provisions: &datastore.QueryRequestProvisions{ByAsOf: asOf.Unix()},
+	})
+	if err != nil {
+		return
+	}
+	if qry.Provisions != nil {
+		return fromSnapshot(qry.Provisions.ProvisionSnapshot), nil
+	}
+
+	qry, err = x.Dependency.Datastore.Query(ctx, datastore.QueryRequest{
+		Loans: &datastore.QueryRequestLoans{ByLoanState: datastore.StateDisbursed},
+	})
+	if err != nil {
+		return
+	}
+
+	snapshot := datastore.ProvisionSnapshot{AsOf: asOf.Unix()}
+	for _, qry := range qry.List {
+		if disbursedAt := qry.Loans.Loan.DisbursedAt; disbursedAt == nil || *disbursedAt >= asOf.AddDate(0, 0, 1).Unix() {
+			continue // disbursed after said date
+		}
+		var l *datastore.ProvisionLoan
+		var lenders []datastore.ProvisionLender
+		if l, lenders, err = x.provision(ctx, qry.Loans.Loan, asOf); err != nil {
+			return
+		}
+		snapshot.Loans = append(snapshot.Loans, *l)
+		snapshot.Lenders = append(snapshot.Lenders, lenders...)
+	}
+
+	if req.Record {
+		snapshot.SnapshotID = xid.New().Bytes()
+		var mut datastore.MutationResponse
+		mut, err = x.Dependency.Datastore.Mutation(ctx, datastore.MutationRequest{
+			Provisions: &datastore.MutationRequestProvisions{ProvisionSnapshot: snapshot},
+		})
+		if err != nil {
+			return
+		}
+		snapshot = mut.Provisions.ProvisionSnapshot
+	}
+	res = fromSnapshot(snapshot)
+
+	log.DebugContext(ctx, "feature/provision.Snapshot",
+		slog.Any("req", req),
+		slog.Any("res", res),
+		slog.Any("err", err),
+	)
+	return
+}
+
+func (x *provision) provision(ctx context.Context, l datastore.Loan, asOf time.Time) (_ *datastore.ProvisionLoan, _ []datastore.ProvisionLender, err error) {
+	var principal, scheduled, outstanding, invested float64
+	var iso4217 string
+	var pastDueSince *time.Time
+	type lender struct {
+		userID   []byte
+		invested float64
+	}
+	lenders := []lender{}
+	for _, party := range l.Parties {
+		for _, payment := range party.Payments {
+			switch payment.PaymentType {
+			case datastore.PaymentPrincipalDisbursement:
+				principal, iso4217 = -payment.Amount, payment.ISO4217
+			case datastore.PaymentInstallment:
+				scheduled += payment.Amount
+				if payment.SettledAt != nil && *payment.SettledAt < asOf.AddDate(0, 0, 1).Unix() {
+					continue // settled at said date
+				}
+				outstanding += payment.Amount
+				if due := time.Unix(payment.Time, 0); due.Before(asOf) && (pastDueSince == nil || due.Before(*pastDueSince)) {
+					pastDueSince = &due
+				}
+			case datastore.PaymentInvestment:
+				invested += payment.Amount
+				if i := len(lenders) - 1; i >= 0 && bytes.Equal(lenders[i].userID, party.UserID) {
+					lenders[i].invested += payment.Amount
+				} else {
+					lenders = append(lenders, lender{party.UserID, payment.Amount})
+				}
+			}
+		}
+	}
+	if principal == 0 || scheduled == 0 {
+		return nil, nil, fmt.Errorf("invalid principal value")
+	}
+
+	var dpd int
+	if pastDueSince != nil {
+		dpd = int(asOf.Sub(pastDueSince.UTC().Truncate(24*time.Hour)).Hours() / 24)
+	}
+	bucket := x.Configuration.Buckets[0]
+	for _, b := range x.Configuration.Buckets {
+		if b.MinDaysPastDue <= dpd {
+			bucket = b
+		}
+	}
+	ead := (&pkg.Money{ISO4217: iso4217, Amount: principal * outstanding / scheduled}).Round().Amount
+	ecl := (&pkg.Money{ISO4217: iso4217, Amount: bucket.ProbabilityOfDefault * bucket.LossGivenDefault * ead}).Round().Amount
+
+	res := &datastore.ProvisionLoan{
+		LoanID:               l.LoanID,
+		ISO4217:              iso4217,
+		DaysPastDue:          dpd,
+		Stage:                bucket.Stage,
+		ProbabilityOfDefault: bucket.ProbabilityOfDefault,
+		LossGivenDefault:     bucket.LossGivenDefault,
+		ExposureAtDefault:    ead,
+		ExpectedCreditLoss:   ecl,
+	}
+	resLenders := make([]datastore.ProvisionLender, len(lenders))
+	for i, lender := range lenders {
+		share := lender.invested / invested
+		resLenders[i] = datastore.ProvisionLender{
+			UserID:             lender.userID,
+			LoanID:             l.LoanID,
+			ISO4217:            iso4217,
+			Share:              share,
+			ExposureAtDefault:  (&pkg.Money{ISO4217: iso4217, Amount: ead * share}).Round().Amount,
+			ExpectedCreditLoss: (&pkg.Money{ISO4217: iso4217, Amount: ecl * share}).Round().Amount,
+		}
+	}
+	return res, resLenders, nil
+}
+
+// fromSnapshot will map the snapshot into response & aggregate it per stage & per lender of each currency.
+func fromSnapshot(snapshot datastore.ProvisionSnapshot) (res SnapshotResponse) {
+	res.SnapshotID = snapshot.SnapshotID
+	res.AsOf = time.Unix(snapshot.AsOf, 0).UTC()
+
+	stages := map[string]*SnapshotStage{}
+	for _, l := range snapshot.Loans {
+		res.Loans = append(res.Loans, SnapshotLoan{
+			LoanID:               l.LoanID,
+			DaysPastDue:          l.DaysPastDue,
+			Stage:                l.Stage,
+			ProbabilityOfDefault: l.ProbabilityOfDefault,
+			LossGivenDefault:     l.LossGivenDefault,
+			ExposureAtDefault:    &pkg.Money{ISO4217: l.ISO4217, Amount: l.ExposureAtDefault, Time: res.AsOf},
+			ExpectedCreditLoss:   &pkg.Money{ISO4217: l.ISO4217, Amount: l.ExpectedCreditLoss, Time: res.AsOf},
+		})
+		key := fmt.Sprint(l.ISO4217, l.Stage)
+		if _, ok := stages[key]; !ok {
+			stages[key] = &SnapshotStage{
+				Stage:              l.Stage,
+				ExposureAtDefault:  &pkg.Money{ISO4217: l.ISO4217, Time: res.AsOf},
+				ExpectedCreditLoss: &pkg.Money{ISO4217: l.ISO4217, Time: res.AsOf},
+			}
+		}
+		stages[key].NumOfLoans++
+		stages[key].ExposureAtDefault.Amount += l.ExposureAtDefault
+		stages[key].ExpectedCreditLoss.Amount += l.ExpectedCreditLoss
+	}
+	for _, key := range slices.Sorted(maps.Keys(stages)) {
+		res.Portfolio = append(res.Portfolio, *stages[key])
+	}
+
+	lenders := map[string]*SnapshotLender{}
+	for _, l := range snapshot.Lenders {
+		key := fmt.Sprint(pkg.BtoA(l.UserID), l.ISO4217)
+		if _, ok := lenders[key]; !ok {
+			lenders[key] = &SnapshotLender{
+				LenderID:           l.UserID,
+				ExposureAtDefault:  &pkg.Money{ISO4217: l.ISO4217, Time: res.AsOf},
+				ExpectedCreditLoss: &pkg.Money{ISO4217: l.ISO4217, Time: res.AsOf},
+			}
+		}
+		lenders[key].ExposureAtDefault.Amount += l.ExposureAtDefault
+		lenders[key].ExpectedCreditLoss.Amount += l.ExpectedCreditLoss
+	}
+	for _, key := range slices.Sorted(maps.Keys(lenders)) {
+		res.Lenders = append(res.Lenders, *lenders[key])
+	}
+	return res
+}
@@ -0,0 +1,56 @@
+package reconciliation
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/gunawanwijaya/loan-svc/pkg"
+)
+
+// WriteCSV will write the report as a single sheet for the finance team, a row per matched pair, unmatched
+// transaction & unmatched payment, distinguished by the status column.
+func (x ReportResponse) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{
+		"status", "match_type", "bank_reference", "reference", "counterparty",
+		"loan_id", "payment_id", "payment_type", "iso4217",
+		"amount", "expected_amount", "amount_diff", "value_date", "due_date", "days_diff",
+	})
+	amount := func(m *pkg.Money) string {
+		if m == nil {
+			return ""
+		}
+		return fmt.Sprint(m.Amount)
+	}
+	date := func(m *pkg.Money) string {
+		if m == nil {
+			return ""
+		}
+		return m.Time.UTC().Format(time.DateOnly)
+	}
+	for _, m := range x.Matched {
+		_ = cw.Write([]string{
+			"matched", m.MatchType, m.BankReference, m.Reference, m.Counterparty,
+			pkg.BtoA(m.LoanID), pkg.BtoA(m.PaymentID), m.PaymentType, m.Amount.ISO4217,
+			amount(m.Amount), amount(m.Expected), fmt.Sprint(m.AmountDiff), date(m.Amount), date(m.Expected), fmt.Sprint(m.DaysDiff),
+		})
+	}
+	for _, t := range x.UnmatchedTransactions {
+		_ = cw.Write([]string{
+			"unmatched_transaction", "", t.BankReference, t.Reference, t.Counterparty,
+			"", "", "", t.Amount.ISO4217,
+			amount(t.Amount), "", "", date(t.Amount), "", "",
+		})
+	}
+	for _, p := range x.UnmatchedPayments {
+		_ = cw.Write([]string{
+			"unmatched_payment", "", "", "", "",
+			pkg.BtoA(p.LoanID), pkg.BtoA(p.PaymentID), p.PaymentType, p.Expected.ISO4217,
+			"", amount(p.Expected), "", "", date(p.Expected), fmt.Sprint(p.DaysPastDue),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,298 @@
+package reconciliation
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gunawanwijaya/loan-svc/internal/repository/datastore"
+	"github.com/gunawanwijaya/loan-svc/pkg"
+)
+
+type ReconcileRequest struct {
+	Transactions []Transaction `json:"transactions,omitempty"`
+	Source       string        `json:"-"` // recorded on the transactions, default to api
+	ReportRequest
+}
+
+// Transaction is a settled cash movement, positive amount when money is received & negative when money is paid,
+// the time of the amount is the value date.
+type Transaction struct {
+	BankReference string     `json:"bank_reference,omitempty"`
+	Reference     string     `json:"reference,omitempty"`
+	Counterparty  string     `json:"counterparty,omitempty"`
+	Amount        *pkg.Money `json:"amount,omitempty"`
+}
+
+func (x Transaction) Validate(ctx context.Context) (_ Transaction, err error) {
+	if x.BankReference == "" {
+		return x, fmt.Errorf("invalid bank_reference")
+	}
+	if x.Amount == nil || x.Amount.Time.IsZero() {
+		return x, fmt.Errorf("invalid amount of [%s]", x.BankReference)
+	}
+	if x.Amount, err = x.Amount.Validate(ctx); err != nil {
+		return x, err
+	}
+	if x.Amount.Amount == 0 {
+		return x, fmt.Errorf("zero amount of [%s]", x.BankReference)
+	}
+	return x, nil
+}
+
+type ReportRequest struct {
+	From *time.Time `json:"from,omitempty"`  // default to the beginning, only limit the matched
+	AsOf *time.Time `json:"as_of,omitempty"` // default to now
+}
+
+type ReportResponse struct {
+	From     time.Time `json:"from"`
+	AsOf     time.Time `json:"as_of"`
+	Imported int64     `json:"imported"`
+
+	Matched               []Matched              `json:"matched,omitempty"`
+	UnmatchedTransactions []UnmatchedTransaction `json:"unmatched_transactions,omitempty"`
+	UnmatchedPayments     []UnmatchedPayment     `json:"unmatched_payments,omitempty"`
+}
+
+type Matched struct {
+	MatchType     string     `json:"match_type,omitempty"`
+	BankReference string     `json:"bank_reference,omitempty"`
+	Reference     string     `json:"reference,omitempty"`
+	Counterparty  string     `json:"counterparty,omitempty"`
+	LoanID        []byte     `json:"loan_id,omitempty"`
+	PaymentID     []byte     `json:"payment_id,omitempty"`
+	PaymentType   string     `json:"payment_type,omitempty"`
+	Amount        *pkg.Money `json:"amount,omitempty"`
+	Expected      *pkg.Money `json:"expected,omitempty"`
+	AmountDiff    float64    `json:"amount_diff"`
+	DaysDiff      int        `json:"days_diff"`
+}
+
+type UnmatchedTransaction struct {
+	TransactionID []byte     `json:"transaction_id,omitempty"`
+	BankReference string     `json:"bank_reference,omitempty"`
+	Reference     string     `json:"reference,omitempty"`
+	Counterparty  string     `json:"counterparty,omitempty"`
+	Amount        *pkg.Money `json:"amount,omitempty"`
+}
+
+type UnmatchedPayment struct {
+	LoanID      []byte     `json:"loan_id,omitempty"`
+	BorrowerID  []byte     `json:"borrower_id,omitempty"`
+	PaymentID   []byte     `json:"payment_id,omitempty"`
+	PaymentType string     `json:"payment_type,omitempty"`
+	Expected    *pkg.Money `json:"expected,omitempty"`
+	DaysPastDue int        `json:"days_past_due"`
+}
+
+// Reconcile will import the settled transactions, a transaction with known bank reference is ignored, then match
+// every unmatched transaction to the expected payments of disbursed loans
+//   - same currency & direction, with amount within tolerance
+//   - the oldest payment referenced by the transaction, matched as exact when the date is within tolerance
+//   - otherwise the only payment with date within tolerance
+//
+// matched payments are settled on the value date, the report is returned afterwards.
+func (x *reconciliation) Reconcile(ctx context.Context, req ReconcileRequest) (res ReportResponse, err error) {
+	log := pkg.Context.SlogLogger(ctx)
+	source := pkg.OrElse(req.Source != "", req.Source, sourceAPI)
+	transactions := make([]datastore.SettledTransaction, len(req.Transactions))
+	for i, t := range req.Transactions {
+		if transactions[i], err = settled(ctx, t, source); err != nil {
+			return
+		}
+	}
+
+	var imported int64
+	if len(transactions) > 0 {
+		var mut datastore.MutationResponse
+		mut, err = x.Dependency.Datastore.Mutation(ctx, datastore.MutationRequest{
+			Reconciliations: &datastore.MutationRequestReconciliations{Transactions: transactions},
+		})
+		if err != nil {
+			return
+		}
+		imported = mut.Reconciliations.Imported
+	}
+
+	var qry datastore.QueryResponse
+	qry, err = x.Dependency.Datastore.Query(ctx, datastore.QueryRequest{
+		Reconciliations: &datastore.QueryRequestReconciliations{},
+	})
+	if err != nil {
+		return
+	}
+	if matches := x.match(qry.Reconciliations.Transactions, qry.Reconciliations.Payments); len(matches) > 0 {
+		if _, err = x.Dependency.Datastore.Mutation(ctx, datastore.MutationRequest{
+			Reconciliations: &datastore.MutationRequestReconciliations{Matches: matches},
+		}); err != nil {
+			return
+		}
+	}
+
+	res, err = x.Report(ctx, req.ReportRequest)
+	res.Imported = imported
+
+	log.DebugContext(ctx, "feature/reconciliation.Reconcile",
+		slog.Any("req", req),
+		slog.Any("res", res),
+		slog.Any("err", err),
+	)
+	return
+}
+
+// Report will list the matches of transactions settled within the range, along with unmatched transactions &
+// payments due until `as_of`.
+func (x *reconciliation) Report(ctx context.Context, req ReportRequest) (res ReportResponse, err error) {
+	log := pkg.Context.SlogLogger(ctx)
+	res.AsOf = time.Now()
+	if req.AsOf != nil {
+		res.AsOf = *req.AsOf
+	}
+	res.From = time.Unix(0, 0).UTC()
+	if req.From != nil {
+		res.From = *req.From
+	}
+
+	var qry datastore.QueryResponse
+	qry, err = x.Dependency.Datastore.Query(ctx, datastore.QueryRequest{
+		Reconciliations: &datastore.QueryRequestReconciliations{
+			ByFrom:  res.From.Unix(),
+			ByUntil: res.AsOf.Unix(),
+		},
+	})
+	if err != nil {
+		return
+	}
+
+	for _, m := range qry.Reconciliations.Matches {
+		res.Matched = append(res.Matched, Matched{
+			MatchType:     m.MatchType.String(),
+			BankReference: m.Transaction.BankReference,
+			Reference:     m.Transaction.Reference,
+			Counterparty:  m.Transaction.Counterparty,
+			LoanID:        m.Payment.LoanID,
+			PaymentID:     m.PaymentID,
+			PaymentType:   m.Payment.PaymentType.String(),
+			Amount:        money(m.Transaction.ISO4217, m.Transaction.Amount, m.Transaction.ValueTime),
+			Expected:      money(m.Payment.ISO4217, m.Payment.Amount, m.Payment.Time),
+			AmountDiff:    m.AmountDiff,
+			DaysDiff:      m.DaysDiff,
+		})
+	}
+	for _, t := range qry.Reconciliations.Transactions {
+		if t.ValueTime >= res.AsOf.Unix() {
+			continue
+		}
+		res.UnmatchedTransactions = append(res.UnmatchedTransactions, UnmatchedTransaction{
+			TransactionID: t.TransactionID,
+			BankReference: t.BankReference,
+			Reference:     t.Reference,
+			Counterparty:  t.Counterparty,
+			Amount:        money(t.ISO4217, t.Amount, t.ValueTime),
+		})
+	}
+	for _, p := range qry.Reconciliations.Payments {
+		if p.Time >= res.AsOf.Unix() {
+			continue
+		}
+		res.UnmatchedPayments = append(res.UnmatchedPayments, UnmatchedPayment{
+			LoanID:      p.LoanID,
+			BorrowerID:  p.UserID,
+			PaymentID:   p.PaymentID,
+			PaymentType: p.PaymentType.String(),
+			Expected:    money(p.ISO4217, p.Amount, p.Time),
+			DaysPastDue: days(time.Unix(p.Time, 0), res.AsOf),
+		})
+	}
+
+	log.DebugContext(ctx, "feature/reconciliation.Report",
+		slog.Any("req", req),
+		slog.Any("res", res),
+		slog.Any("err", err),
+	)
+	return
+}
+
+func (x *reconciliation) match(transactions []datastore.SettledTransaction, payments []datastore.ExpectedPayment) (matches []datastore.ReconciliationMatch) {
+	used := make([]bool, len(payments))
+	for _, t := range transactions {
+		valueTime := time.Unix(t.ValueTime, 0)
+		referenced, dated := -1, []int{}
+		for i, p := range payments {
+			diff := t.Amount - p.Amount
+			if used[i] || p.ISO4217 != t.ISO4217 || t.Amount*p.Amount <= 0 || math.Abs(diff) > x.Configuration.AmountTolerance+1e-9 {
+				continue
+			}
+			if referenced < 0 && isReferenced(t, p) {
+				referenced = i // payments are ordered by due date, the oldest is taken first
+			}
+			if d := days(time.Unix(p.Time, 0), valueTime); d >= -x.Configuration.DateTolerance && d <= x.Configuration.DateTolerance {
+				dated = append(dated, i)
+			}
+		}
+
+		i, matchType := referenced, datastore.MatchReference
+		switch {
+		case referenced >= 0:
+			if d := days(time.Unix(payments[i].Time, 0), valueTime); d >= -x.Configuration.DateTolerance && d <= x.Configuration.DateTolerance {
+				matchType = datastore.MatchExact
+			}
+		case len(dated) == 1:
+			i, matchType = dated[0], datastore.MatchAmountDate
+		default:
+			continue // unmatched or ambiguous
+		}
+		used[i] = true
+		matches = append(matches, datastore.ReconciliationMatch{
+			TransactionID: t.TransactionID,
+			PaymentID:     payments[i].PaymentID,
+			MatchType:     matchType,
+			AmountDiff:    t.Amount - payments[i].Amount,
+			DaysDiff:      days(time.Unix(payments[i].Time, 0), valueTime),
+			Transaction:   t,
+			Payment:       payments[i],
+		})
+	}
+	return matches
+}
+
+// minPaymentReference is the shortest payment reference looked up within the free text, shorter numbers such as
+// amounts or installment numbers pass the check digits too often.
+const minPaymentReference = 10
+
+// isReferenced will check whether the transaction is a candidate repayment of the loan, or its free text reference
+// mention the payment, its loan or the loan payment reference.
+func isReferenced(t datastore.SettledTransaction, p datastore.ExpectedPayment) bool {
+	if len(t.LoanID) > 0 && bytes.Equal(t.LoanID, p.LoanID) {
+		return true
+	}
+	if t.Reference == "" {
+		return false
+	}
+	if p.PaymentReference != "" && slices.Contains(strings.FieldsFunc(t.Reference, func(r rune) bool {
+		return r < '0' || r > '9'
+	}), p.PaymentReference) {
+		return true
+	}
+	for _, id := range [][]byte{p.PaymentID, p.LoanID} {
+		if len(id) > 0 && strings.Contains(t.Reference, pkg.BtoA(id)) {
+			return true
+		}
+	}
+	return false
+}
+
+func money(iso4217 string, amount float64, t int64) *pkg.Money {
+	return &pkg.Money{ISO4217: iso4217, Amount: amount, Time: time.Unix(t, 0)}
+}
+
+// days will count the calendar days in UTC from `from` until `to`, negative when `to` is before `from`.
+func days(from, to time.Time) int {
+	return int(to.UTC().Truncate(24*time.Hour).Sub(from.UTC().Truncate(24*time.Hour)).Hours() / 24)
+}
@@ -0,0 +1,57 @@
+//go:generate mockgen -destination reconciliation_mock.go -package reconciliation . Reconciliation
+package reconciliation
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gunawanwijaya/loan-svc/internal/repository/datastore"
+	"github.com/gunawanwijaya/loan-svc/pkg"
+)
+
+type Configuration struct {
+	AmountTolerance float64 `json:"amount_tolerance,omitempty"` // absolute difference of amount still considered as match
+	DateTolerance   int     `json:"date_tolerance,omitempty"`   // days between value date & due date still considered as match
+}
+type Dependency struct {
+	datastore.Datastore
+}
+type Reconciliation interface {
+	Import(ctx context.Context, req ImportRequest) (res ImportResponse, err error)
+	Reconcile(ctx context.Context, req ReconcileRequest) (res ReportResponse, err error)
+	Report(ctx context.Context, req ReportRequest) (res ReportResponse, err error)
+}
+
+func New(ctx context.Context, cfg Configuration, dep Dependency) (_ Reconciliation, err error) {
+	cfg, err = pkg.AsValidator(cfg).Validate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	dep, err = pkg.AsValidator(dep).Validate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &reconciliation{cfg, dep}, nil
+}
+
+type reconciliation struct {
+	Configuration
+	Dependency
+}
+
+func (cfg Configuration) Validate(ctx context.Context) (_ Configuration, err error) {
+	if cfg.AmountTolerance < 0 {
+		return cfg, fmt.Errorf("feature/reconciliation: negative amount tolerance")
+	}
+	if cfg.DateTolerance < 0 {
+		return cfg, fmt.Errorf("feature/reconciliation: negative date tolerance")
+	}
+	return cfg, nil
+}
+
+func (dep Dependency) Validate(ctx context.Context) (_ Dependency, err error) {
+	if dep.Datastore == nil {
+		return dep, fmt.Errorf("feature/reconciliation: uninitialized repository/datastore")
+	}
+	return dep, nil
+}
@@ -0,0 +1,274 @@
+package reconciliation_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/gunawanwijaya/loan-svc/internal/feature/reconciliation"
+	"github.com/gunawanwijaya/loan-svc/internal/repository/datastore"
+	"github.com/gunawanwijaya/loan-svc/pkg"
+	"github.com/rs/xid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestReconciliation(t *testing.T) {
+	ctx := pkg.Context.PutSlogLogger(context.Background(), slog.Default())
+	ctrl := gomock.NewController(t)
+	mockDatastore := datastore.NewMockDatastore(ctrl)
+
+	_, err := reconciliation.New(ctx, reconciliation.Configuration{DateTolerance: -1}, reconciliation.Dependency{Datastore: mockDatastore})
+	require.Error(t, err)
+
+	featReconciliation, err := reconciliation.New(ctx, reconciliation.Configuration{
+		AmountTolerance: 1,
+		DateTolerance:   3,
+	}, reconciliation.Dependency{
+		Datastore: mockDatastore,
+	})
+	require.NoError(t, err)
+
+	date := func(m time.Month, d int) time.Time { return time.Date(2025, m, d, 0, 0, 0, 0, time.UTC) }
+	expected := func(loanID []byte, paymentType datastore.PaymentType, amount float64, due time.Time) datastore.ExpectedPayment {
+		return datastore.ExpectedPayment{LoanID: loanID, UserID: []byte("900"), LoanPartyPayment: datastore.LoanPartyPayment{
+			PaymentID:   xid.New().Bytes(),
+			PaymentType: paymentType,
+			ISO4217:     "IDR",
+			Amount:      amount,
+			Time:        due.Unix(),
+		}}
+	}
+	loanID1, loanID2 := xid.New().Bytes(), xid.New().Bytes()
+	principal := expected(loanID1, datastore.PaymentPrincipalDisbursement, -300_000, date(12, 1).AddDate(-1, 0, 0))
+	installment1 := expected(loanID1, datastore.PaymentInstallment, 100_000, date(1, 1))
+	installment2 := expected(loanID1, datastore.PaymentInstallment, 100_000, date(2, 1))
+	installment3 := expected(loanID1, datastore.PaymentInstallment, 100_000, date(3, 1))
+	other := expected(loanID2, datastore.PaymentInstallment, 100_000, date(1, 2))
+	paymentReference := "8808123456789072"
+	for _, p := range []*datastore.ExpectedPayment{&principal, &installment1, &installment2, &installment3} {
+		p.PaymentReference = paymentReference
+	}
+	payments := []datastore.ExpectedPayment{principal, installment1, other, installment2, installment3}
+
+	settled := func(bankReference, reference string, amount float64, valueDate time.Time) reconciliation.Transaction {
+		return reconciliation.Transaction{
+			BankReference: bankReference,
+			Reference:     reference,
+			Amount:        &pkg.Money{ISO4217: "IDR", Amount: amount, Time: valueDate},
+		}
+	}
+	transactions := []reconciliation.Transaction{
+		settled("B1", "payout "+pkg.BtoA(principal.PaymentID), -300_000, date(12, 1).AddDate(-1, 0, 0)), // exact, outgoing
+		settled("B2", "loan "+pkg.BtoA(loanID1), 100_000, date(1, 2)),                                   // exact, oldest installment
+		settled("B3", "VA "+paymentReference+" Jan", 99_999.5, date(1, 20)),                             // reference, paid 12 days early
+		settled("B4", "", 100_000, date(1, 3)),                                                          // amount & date, the only candidate left
+		settled("B5", "", 55_000, date(1, 3)),                                                           // unmatched
+	}
+
+	asOf := date(2, 15)
+	{
+		mockDatastore.EXPECT().
+			Mutation(ctx, gomock.Any()).
+			DoAndReturn(func(ctx context.Context, req datastore.MutationRequest) (datastore.MutationResponse, error) {
+				require.Len(t, req.Reconciliations.Transactions, len(transactions))
+				require.Equal(t, loanID1, req.Reconciliations.Transactions[1].LoanID)
+				require.Equal(t, paymentReference, req.Reconciliations.Transactions[2].PaymentReference)
+				return datastore.MutationResponse{Reconciliations: &datastore.MutationResponseReconciliations{Imported: 5}}, nil
+			})
+		mockDatastore.EXPECT().
+			Query(ctx, datastore.QueryRequest{Reconciliations: &datastore.QueryRequestReconciliations{}}).
+			DoAndReturn(func(ctx context.Context, req datastore.QueryRequest) (datastore.QueryResponse, error) {
+				res := &datastore.QueryResponseReconciliations{Payments: payments}
+				for _, t := range transactions {
+					res.Transactions = append(res.Transactions, datastore.SettledTransaction{
+						TransactionID: []byte(t.BankReference),
+						BankReference: t.BankReference,
+						Reference:     t.Reference,
+						ISO4217:       t.Amount.ISO4217,
+						Amount:        t.Amount.Amount,
+						ValueTime:     t.Amount.Time.Unix(),
+					})
+				}
+				return datastore.QueryResponse{Reconciliations: res}, nil
+			})
+		mockDatastore.EXPECT().
+			Mutation(ctx, gomock.Any()).
+			DoAndReturn(func(ctx context.Context, req datastore.MutationRequest) (datastore.MutationResponse, error) {
+				matches := req.Reconciliations.Matches
+				require.Len(t, matches, 4)
+				for i, m := range []struct {
+					transactionID string
+					paymentID     []byte
+					datastore.MatchType
+					daysDiff int
+				}{
+					{"B1", principal.PaymentID, datastore.MatchExact, 0},
+					{"B2", installment1.PaymentID, datastore.MatchExact, 1},
+					{"B3", installment2.PaymentID, datastore.MatchReference, -12},
+					{"B4", other.PaymentID, datastore.MatchAmountDate, 1},
+				} {
+					require.Equal(t, m.transactionID, string(matches[i].TransactionID))
+					require.Equal(t, m.paymentID, matches[i].PaymentID)
+					require.Equal(t, m.MatchType, matches[i].MatchType)
+					require.Equal(t, m.daysDiff, matches[i].DaysDiff)
+				}
+				require.InDelta(t, -0.5, matches[2].AmountDiff, 1e-9)
+				return datastore.MutationResponse{Reconciliations: &datastore.MutationResponseReconciliations{Matches: matches}}, nil
+			})
+		mockDatastore.EXPECT().
+			Query(ctx, datastore.QueryRequest{Reconciliations: &datastore.QueryRequestReconciliations{ByFrom: 0, ByUntil: asOf.Unix()}}).
+			Return(datastore.QueryResponse{Reconciliations: &datastore.QueryResponseReconciliations{
+				Transactions: []datastore.SettledTransaction{{BankReference: "B5", ISO4217: "IDR", Amount: 55_000, ValueTime: date(1, 3).Unix()}},
+				Payments:     []datastore.ExpectedPayment{installment3},
+				Matches: []datastore.ReconciliationMatch{{
+					PaymentID:   installment1.PaymentID,
+					MatchType:   datastore.MatchExact,
+					DaysDiff:    1,
+					Transaction: datastore.SettledTransaction{BankReference: "B2", ISO4217: "IDR", Amount: 100_000, ValueTime: date(1, 2).Unix()},
+					Payment:     installment1,
+				}},
+			}}, nil)
+	}
+	res, err := featReconciliation.Reconcile(ctx, reconciliation.ReconcileRequest{
+		Transactions:  transactions,
+		ReportRequest: reconciliation.ReportRequest{From: pkg.Ptr(time.Unix(0, 0)), AsOf: &asOf},
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(5), res.Imported)
+	require.Len(t, res.Matched, 1)
+	require.Equal(t, "exact", res.Matched[0].MatchType)
+	require.Len(t, res.UnmatchedTransactions, 1)
+	require.Len(t, res.UnmatchedPayments, 0) // installment3 is not due yet
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, res.WriteCSV(buf))
+	records, err := csv.NewReader(buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	require.Equal(t, "status", records[0][0])
+	require.Equal(t, "matched", records[1][0])
+	require.Equal(t, "unmatched_transaction", records[2][0])
+
+	_, err = featReconciliation.Reconcile(ctx, reconciliation.ReconcileRequest{
+		Transactions: []reconciliation.Transaction{settled("", "", 1, asOf)},
+	})
+	require.Error(t, err)
+}
+
+func TestImport(t *testing.T) {
+	ctx := pkg.Context.PutSlogLogger(context.Background(), slog.Default())
+	ctrl := gomock.NewController(t)
+	mockDatastore := datastore.NewMockDatastore(ctrl)
+
+	featReconciliation, err := reconciliation.New(ctx, reconciliation.Configuration{}, reconciliation.Dependency{
+		Datastore: mockDatastore,
+	})
+	require.NoError(t, err)
+
+	loanID := xid.New().Bytes()
+	date := func(m time.Month, d int) int64 { return time.Date(2025, m, d, 0, 0, 0, 0, time.UTC).Unix() }
+	type expected struct {
+		bankReference, reference, counterparty string
+		amount                                 float64
+		valueTime                              int64
+		loanID                                 []byte
+	}
+	for _, tc := range []struct {
+		format    string
+		statement string
+		expected  []expected
+	}{{
+		format: reconciliation.FormatCSV,
+		statement: "bank_reference;value_date;currency;credit;debit;reference;counterparty\n" +
+			"C1;2025-01-02;IDR;100000;;installment loan " + pkg.BtoA(loanID) + ";Budi\n" +
+			"C2;2025-01-03;idr;;50000;payout;Lender\n",
+		expected: []expected{
+			{"C1", "installment loan " + pkg.BtoA(loanID), "Budi", 100_000, date(1, 2), loanID},
+			{"C2", "payout", "Lender", -50_000, date(1, 3), nil},
+		},
+	}, {
+		format: reconciliation.FormatMT940,
+		statement: "{1:F01BANKIDJAAXXX0000000000}{2:O9400000250103BANKIDJAAXXX00000000002501030000N}{4:\n" +
+			":20:STMT20250103\n" +
+			":25:BANKIDJA/1234567890\n" +
+			":28C:1/1\n" +
+			":60F:C250101IDR1000000,00\n" +
+			":61:2501020102C100000,00NTRFNONREF//M1\n" +
+			":86:166?00TRANSFER?20installment loan ?21" + pkg.BtoA(loanID) + "?32Budi?33 Santoso\n" +
+			":61:250103D50000,00NTRFPAYOUT1\n" +
+			":86:disbursement\n" +
+			"payout\n" +
+			":61:250103C100000,00NTRFINST7\n" +
+			":86:installment\n" +
+			":61:250103C100000,00NTRFINST7\n" +
+			":86:installment\n" +
+			":62F:C250103IDR1250000,00\n" +
+			"-}",
+		expected: []expected{
+			{"M1", "installment loan " + pkg.BtoA(loanID), "Budi Santoso", 100_000, date(1, 2), loanID},
+			{"STMT20250103/1/1/2", "PAYOUT1 disbursement payout", "", -50_000, date(1, 3), nil},
+			{"STMT20250103/1/1/3", "INST7 installment", "", 100_000, date(1, 3), nil}, // customer reference is reused
+			{"STMT20250103/1/1/4", "INST7 installment", "", 100_000, date(1, 3), nil},
+		},
+	}, {
+		format: reconciliation.FormatCAMT053,
+		statement: `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.053.001.08"><BkToCstmrStmt><Stmt><Id>S1</Id>
+	<Ntry><Amt Ccy="IDR">100000.00</Amt><CdtDbtInd>CRDT</CdtDbtInd><Sts><Cd>BOOK</Cd></Sts>
+		<ValDt><Dt>2025-01-02</Dt></ValDt><AcctSvcrRef>X1</AcctSvcrRef>
+		<NtryDtls><TxDtls><Refs><EndToEndId>NOTPROVIDED</EndToEndId></Refs>
+			<RltdPties><Dbtr><Nm>Budi</Nm></Dbtr></RltdPties>
+			<RmtInf><Ustrd>installment loan ` + pkg.BtoA(loanID) + `</Ustrd></RmtInf></TxDtls></NtryDtls></Ntry>
+	<Ntry><Amt Ccy="IDR">20000.00</Amt><CdtDbtInd>CRDT</CdtDbtInd><Sts><Cd>PDNG</Cd></Sts>
+		<ValDt><Dt>2025-01-03</Dt></ValDt><AcctSvcrRef>X2</AcctSvcrRef></Ntry>
+	<Ntry><Amt Ccy="IDR">50000.00</Amt><CdtDbtInd>DBIT</CdtDbtInd><Sts><Cd>BOOK</Cd></Sts>
+		<BookgDt><DtTm>2025-01-03T00:00:00Z</DtTm></BookgDt><AcctSvcrRef>X3</AcctSvcrRef>
+		<NtryDtls>
+			<TxDtls><Amt Ccy="IDR">30000.00</Amt><CdtDbtInd>DBIT</CdtDbtInd><RltdPties><Cdtr><Nm>Lender A</Nm></Cdtr></RltdPties></TxDtls>
+			<TxDtls><Amt Ccy="IDR">20000.00</Amt><CdtDbtInd>DBIT</CdtDbtInd><RltdPties><Cdtr><Nm>Lender B</Nm></Cdtr></RltdPties></TxDtls>
+		</NtryDtls></Ntry>
+</Stmt></BkToCstmrStmt></Document>`,
+		expected: []expected{
+			{"X1", "installment loan " + pkg.BtoA(loanID), "Budi", 100_000, date(1, 2), loanID},
+			{"X3/1", "", "Lender A", -30_000, date(1, 3), nil},
+			{"X3/2", "", "Lender B", -20_000, date(1, 3), nil},
+		},
+	}} {
+		mockDatastore.EXPECT().
+			Mutation(ctx, gomock.Any()).
+			DoAndReturn(func(ctx context.Context, req datastore.MutationRequest) (datastore.MutationResponse, error) {
+				list := req.Reconciliations.Transactions
+				require.Len(t, list, len(tc.expected), tc.format)
+				for i, e := range tc.expected {
+					require.Equal(t, e.bankReference, list[i].BankReference, tc.format)
+					require.Equal(t, e.reference, list[i].Reference, tc.format)
+					require.Equal(t, e.counterparty, list[i].Counterparty, tc.format)
+					require.Equal(t, "IDR", list[i].ISO4217, tc.format)
+					require.Equal(t, e.amount, list[i].Amount, tc.format)
+					require.Equal(t, e.valueTime, list[i].ValueTime, tc.format)
+					require.Equal(t, e.loanID, list[i].LoanID, tc.format)
+					require.Equal(t, tc.format, list[i].Source, tc.format)
+				}
+				return datastore.MutationResponse{Reconciliations: &datastore.MutationResponseReconciliations{
+					Imported:     1,
+					Transactions: list[:1], // the rest is known
+				}}, nil
+			})
+		res, err := featReconciliation.Import(ctx, reconciliation.ImportRequest{Statement: []byte(tc.statement)})
+		require.NoError(t, err, tc.format)
+		require.Equal(t, tc.format, res.Format)
+		require.Equal(t, len(tc.expected), res.Parsed)
+		require.Equal(t, int64(1), res.Imported)
+		require.Len(t, res.Candidates, 1)
+		require.Equal(t, loanID, res.Candidates[0].LoanID)
+	}
+
+	_, err = featReconciliation.Import(ctx, reconciliation.ImportRequest{Format: "bai2"})
+	require.Error(t, err)
+	_, err = featReconciliation.Import(ctx, reconciliation.ImportRequest{Statement: []byte("bank_reference,value_date\nC1,2025-01-02\n")})
+	require.Error(t, err)
+}
@@ -0,0 +1,152 @@
+package reconciliation
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/gunawanwijaya/loan-svc/internal/repository/datastore"
+	"github.com/gunawanwijaya/loan-svc/pkg"
+	"github.com/rs/xid"
+)
+
+const (
+	FormatCSV     = "csv"     // header row of bank_reference, value_date, currency, amount (or credit & debit), reference & counterparty
+	FormatMT940   = "mt940"   // SWIFT MT940 customer statement
+	FormatCAMT053 = "camt053" // ISO 20022 camt.053 bank to customer statement, only booked entries are imported
+
+	sourceAPI = "api"
+)
+
+type ImportRequest struct {
+	Format    string `json:"format,omitempty"` // detected from the statement when empty
+	Statement []byte `json:"statement,omitempty"`
+}
+
+type ImportResponse struct {
+	Format     string      `json:"format"`
+	Parsed     int         `json:"parsed"`
+	Imported   int64       `json:"imported"`
+	Candidates []Candidate `json:"candidates,omitempty"`
+}
+
+// Candidate is an imported incoming credit referencing a known loan, a candidate repayment of said loan.
+type Candidate struct {
+	LoanID        []byte     `json:"loan_id,omitempty"`
+	TransactionID []byte     `json:"transaction_id,omitempty"`
+	BankReference string     `json:"bank_reference,omitempty"`
+	Reference     string     `json:"reference,omitempty"`
+	Counterparty  string     `json:"counterparty,omitempty"`
+	Amount        *pkg.Money `json:"amount,omitempty"`
+}
+
+// Import will parse a bank statement into the settled transactions, a transaction with known bank reference is
+// ignored. Matching is left to Reconcile.
+func (x *reconciliation) Import(ctx context.Context, req ImportRequest) (res ImportResponse, err error) {
+	log := pkg.Context.SlogLogger(ctx)
+	res.Format = strings.ToLower(req.Format)
+	if res.Format == "" {
+		res.Format = detect(req.Statement)
+	}
+
+	var parsed []Transaction
+	switch res.Format {
+	case FormatCSV:
+		parsed, err = parseCSV(req.Statement)
+	case FormatMT940:
+		parsed, err = parseMT940(req.Statement)
+	case FormatCAMT053:
+		parsed, err = parseCAMT053(req.Statement)
+	default:
+		err = fmt.Errorf("unknown statement format [%s]", req.Format)
+	}
+	if err != nil {
+		return
+	}
+
+	res.Parsed = len(parsed)
+	transactions := make([]datastore.SettledTransaction, len(parsed))
+	for i, t := range parsed {
+		if transactions[i], err = settled(ctx, t, res.Format); err != nil {
+			return
+		}
+	}
+	if len(transactions) > 0 {
+		var mut datastore.MutationResponse
+		mut, err = x.Dependency.Datastore.Mutation(ctx, datastore.MutationRequest{
+			Reconciliations: &datastore.MutationRequestReconciliations{Transactions: transactions},
+		})
+		if err != nil {
+			return
+		}
+		res.Imported = mut.Reconciliations.Imported
+		for _, t := range mut.Reconciliations.Transactions {
+			if len(t.LoanID) > 0 {
+				res.Candidates = append(res.Candidates, Candidate{
+					LoanID:        t.LoanID,
+					TransactionID: t.TransactionID,
+					BankReference: t.BankReference,
+					Reference:     t.Reference,
+					Counterparty:  t.Counterparty,
+					Amount:        money(t.ISO4217, t.Amount, t.ValueTime),
+				})
+			}
+		}
+	}
+
+	log.DebugContext(ctx, "feature/reconciliation.Import",
+		slog.String("format", res.Format),
+		slog.Any("res", res),
+		slog.Any("err", err),
+	)
+	return
+}
+
+// settled will validate & normalise the transaction, an incoming credit carry the first loan it reference either by
+// its ID or its payment reference.
+func settled(ctx context.Context, t Transaction, source string) (_ datastore.SettledTransaction, err error) {
+	if t, err = t.Validate(ctx); err != nil {
+		return
+	}
+	st := datastore.SettledTransaction{
+		TransactionID: xid.New().Bytes(),
+		BankReference: t.BankReference,
+		Reference:     t.Reference,
+		ISO4217:       t.Amount.ISO4217,
+		Amount:        t.Amount.Amount,
+		ValueTime:     t.Amount.Time.Unix(),
+		Counterparty:  t.Counterparty,
+		Source:        source,
+	}
+	if st.Amount > 0 {
+		st.LoanID = referencedID(t.Reference)
+		st.PaymentReference = pkg.FindPaymentReference(t.Reference, minPaymentReference)
+	}
+	return st, nil
+}
+
+// referencedID will find the first ID encoded in base64 within the free text reference.
+func referencedID(reference string) []byte {
+	for _, token := range strings.FieldsFunc(reference, func(r rune) bool {
+		return !(r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '+' || r == '/' || r == '=')
+	}) {
+		if id := pkg.AtoB(token); len(id) == len(xid.NilID()) {
+			return id
+		}
+	}
+	return nil
+}
+
+// detect will guess the format of a statement from its content, default to CSV.
+func detect(statement []byte) string {
+	s := bytes.TrimSpace(statement)
+	switch {
+	case bytes.HasPrefix(s, []byte("<")):
+		return FormatCAMT053
+	case bytes.Contains(s, []byte(":20:")) && bytes.Contains(s, []byte(":61:")):
+		return FormatMT940
+	}
+	return FormatCSV
+}
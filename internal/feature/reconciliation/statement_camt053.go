@@ -0,0 +1,165 @@
+package reconciliation
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gunawanwijaya/loan-svc/pkg"
+)
+
+// camt053 is the subset of ISO 20022 camt.053 needed for reconciliation, the element is matched regardless of
+// the namespace version.
+type camt053 struct {
+	Statements []struct {
+		ID      string         `xml:"Id"`
+		Entries []camt053Entry `xml:"Ntry"`
+	} `xml:"BkToCstmrStmt>Stmt"`
+}
+
+type camt053Entry struct {
+	NtryRef     string        `xml:"NtryRef"`
+	AcctSvcrRef string        `xml:"AcctSvcrRef"`
+	Amt         camt053Amount `xml:"Amt"`
+	CdtDbtInd   string        `xml:"CdtDbtInd"`
+	Sts         struct {
+		Text string `xml:",chardata"` // camt.053.001.02 until .07
+		Cd   string `xml:"Cd"`        // camt.053.001.08 onward
+	} `xml:"Sts"`
+	ValDt   camt053Date     `xml:"ValDt"`
+	BookgDt camt053Date     `xml:"BookgDt"`
+	TxDtls  []camt053Detail `xml:"NtryDtls>TxDtls"`
+}
+
+type camt053Detail struct {
+	Refs struct {
+		AcctSvcrRef string `xml:"AcctSvcrRef"`
+		EndToEndID  string `xml:"EndToEndId"`
+	} `xml:"Refs"`
+	Amt       *camt053Amount `xml:"Amt"`
+	CdtDbtInd string         `xml:"CdtDbtInd"`
+	Dbtr      string         `xml:"RltdPties>Dbtr>Nm"`
+	Cdtr      string         `xml:"RltdPties>Cdtr>Nm"`
+	Ustrd     []string       `xml:"RmtInf>Ustrd"`
+	CdtrRef   []string       `xml:"RmtInf>Strd>CdtrRefInf>Ref"`
+}
+
+type camt053Amount struct {
+	Ccy   string `xml:"Ccy,attr"`
+	Value string `xml:",chardata"`
+}
+
+type camt053Date struct {
+	Dt   string `xml:"Dt"`
+	DtTm string `xml:"DtTm"`
+}
+
+// parseCAMT053 will parse the booked entries, an entry batching several transaction details with its own amount is
+// split into one transaction per detail.
+func parseCAMT053(statement []byte) (list []Transaction, err error) {
+	var doc camt053
+	if err = xml.Unmarshal(statement, &doc); err != nil {
+		return nil, fmt.Errorf("invalid camt053 statement: %w", err)
+	}
+	for _, stmt := range doc.Statements {
+		for n, e := range stmt.Entries {
+			if status := strings.TrimSpace(e.Sts.Text + e.Sts.Cd); status != "BOOK" {
+				continue // pending & information entries are not settled
+			}
+			date := e.ValDt
+			if date.Dt == "" && date.DtTm == "" {
+				date = e.BookgDt
+			}
+			valueTime, err := date.time()
+			if err != nil {
+				return nil, fmt.Errorf("invalid camt053 value date of entry %d: %w", n+1, err)
+			}
+
+			details := e.TxDtls
+			split := len(details) > 1
+			for _, d := range details {
+				split = split && d.Amt != nil
+			}
+			if !split {
+				if len(details) > 1 {
+					details = details[:1] // only the remittance of the first detail is kept
+				}
+				if len(details) == 0 {
+					details = []camt053Detail{{}}
+				}
+				details[0].Amt, details[0].CdtDbtInd = &e.Amt, e.CdtDbtInd
+			}
+
+			for i, d := range details {
+				if d.CdtDbtInd == "" {
+					d.CdtDbtInd = e.CdtDbtInd
+				}
+				amount, err := d.Amt.amount(d.CdtDbtInd)
+				if err != nil {
+					return nil, fmt.Errorf("invalid camt053 amount of entry %d: %w", n+1, err)
+				}
+				t := Transaction{
+					BankReference: firstOf(d.Refs.AcctSvcrRef, e.AcctSvcrRef, e.NtryRef),
+					Reference:     d.reference(),
+					Counterparty:  d.Dbtr,
+					Amount:        &pkg.Money{ISO4217: d.Amt.Ccy, Amount: amount, Time: valueTime},
+				}
+				if amount < 0 {
+					t.Counterparty = d.Cdtr
+				}
+				if t.BankReference == "" {
+					t.BankReference = fmt.Sprintf("%s/%d", stmt.ID, n+1)
+				}
+				if split && d.Refs.AcctSvcrRef == "" {
+					t.BankReference = fmt.Sprintf("%s/%d", t.BankReference, i+1)
+				}
+				list = append(list, t)
+			}
+		}
+	}
+	return list, nil
+}
+
+// reference will join the structured & unstructured remittance, along with the end to end ID when provided.
+func (x camt053Detail) reference() string {
+	list := append(append([]string{}, x.CdtrRef...), x.Ustrd...)
+	if x.Refs.EndToEndID != "NOTPROVIDED" {
+		list = append(list, x.Refs.EndToEndID)
+	}
+	return strings.TrimSpace(strings.Join(list, " "))
+}
+
+func (x *camt053Amount) amount(cdtDbtInd string) (float64, error) {
+	amount, err := strconv.ParseFloat(strings.TrimSpace(x.Value), 64)
+	if err != nil {
+		return 0, err
+	}
+	switch cdtDbtInd {
+	case "CRDT":
+		return amount, nil
+	case "DBIT":
+		return -amount, nil
+	}
+	return 0, fmt.Errorf("unknown credit debit indicator [%s]", cdtDbtInd)
+}
+
+func (x camt053Date) time() (time.Time, error) {
+	if x.DtTm != "" {
+		if t, err := time.Parse(time.RFC3339, x.DtTm); err == nil {
+			return t, nil
+		}
+		return time.Parse("2006-01-02T15:04:05", x.DtTm) // local time of the bank without offset
+	}
+	return time.Parse(time.DateOnly, x.Dt)
+}
+
+func firstOf(list ...string) string {
+	for _, s := range list {
+		if s = strings.TrimSpace(s); s != "" {
+			return s
+		}
+	}
+	return ""
+}
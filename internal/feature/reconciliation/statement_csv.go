@@ -0,0 +1,104 @@
+package reconciliation
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gunawanwijaya/loan-svc/pkg"
+)
+
+// parseCSV will parse a statement with a header row, the column are matched by name regardless of the order
+//   - bank_reference, value_date (2006-01-02 or RFC3339), currency (or iso4217), reference & counterparty
+//   - either a signed amount, or separated credit & debit columns
+//
+// the delimiter is either comma or semicolon, whichever found more in the header.
+func parseCSV(statement []byte) (list []Transaction, err error) {
+	r := csv.NewReader(bytes.NewReader(statement))
+	if header, _, _ := bytes.Cut(statement, []byte("\n")); bytes.Count(header, []byte(";")) > bytes.Count(header, []byte(",")) {
+		r.Comma = ';'
+	}
+	r.TrimLeadingSpace = true
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid csv statement: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("invalid csv statement: missing header")
+	}
+
+	col := map[string]int{}
+	for i, name := range records[0] {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if name == "iso4217" {
+			name = "currency"
+		}
+		col[name] = i
+	}
+	for _, name := range []string{"bank_reference", "value_date", "currency"} {
+		if _, ok := col[name]; !ok {
+			return nil, fmt.Errorf("invalid csv statement: missing column [%s]", name)
+		}
+	}
+	_, hasAmount := col["amount"]
+	_, hasCredit := col["credit"]
+	_, hasDebit := col["debit"]
+	if !hasAmount && !(hasCredit && hasDebit) {
+		return nil, fmt.Errorf("invalid csv statement: missing column [amount] or [credit] & [debit]")
+	}
+
+	for n, record := range records[1:] {
+		get := func(name string) string {
+			if i, ok := col[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		row := n + 2
+		valueTime, err := parseDate(get("value_date"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid value_date on row %d: %w", row, err)
+		}
+		var amount float64
+		if hasAmount && get("amount") != "" {
+			if amount, err = parseAmount(get("amount")); err != nil {
+				return nil, fmt.Errorf("invalid amount on row %d: %w", row, err)
+			}
+		} else {
+			credit, err := parseAmount(get("credit"))
+			if err != nil {
+				return nil, fmt.Errorf("invalid credit on row %d: %w", row, err)
+			}
+			debit, err := parseAmount(get("debit"))
+			if err != nil {
+				return nil, fmt.Errorf("invalid debit on row %d: %w", row, err)
+			}
+			amount = credit - debit
+		}
+		list = append(list, Transaction{
+			BankReference: get("bank_reference"),
+			Reference:     get("reference"),
+			Counterparty:  get("counterparty"),
+			Amount:        &pkg.Money{ISO4217: strings.ToUpper(get("currency")), Amount: amount, Time: valueTime},
+		})
+	}
+	return list, nil
+}
+
+func parseDate(s string) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+// parseAmount will parse a decimal with dot as separator, an empty string is zero.
+func parseAmount(s string) (float64, error) {
+	if s = strings.ReplaceAll(s, " ", ""); s == "" {
+		return 0, nil
+	}
+	return strconv.ParseFloat(s, 64)
+}
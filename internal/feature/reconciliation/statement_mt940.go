@@ -0,0 +1,127 @@
+package reconciliation
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gunawanwijaya/loan-svc/pkg"
+)
+
+var (
+	mt940Tag = regexp.MustCompile(`^:(\d{2}[A-Z]?):(.*)$`)
+
+	// value date, entry date, debit/credit mark, funds code, amount, transaction type, customer & bank reference
+	mt940Line = regexp.MustCompile(`^(\d{6})(\d{4})?(RC|RD|C|D)([A-Z])?(\d+,\d*)([NSF][A-Z0-9]{3})([^/]*)(?://(.*))?$`)
+
+	// structured information to account owner, e.g. `?20` to `?29` for remittance & `?32` to `?33` for the name
+	mt940Sub = regexp.MustCompile(`\?(\d{2})([^?]*)`)
+)
+
+// parseMT940 will parse the statement lines (:61:) along with its information to account owner (:86:), the currency
+// is taken from the opening balance (:60F: or :60M:).
+func parseMT940(statement []byte) (list []Transaction, err error) {
+	var (
+		stmtRef, seq, iso4217 string
+		tag, value            string
+		cur                   *Transaction
+	)
+	flush := func() error {
+		defer func() { tag, value = "", "" }()
+		switch tag {
+		case "20":
+			stmtRef = value
+		case "28C":
+			seq = value
+		case "60F", "60M":
+			if len(value) < 10 {
+				return fmt.Errorf("invalid mt940 opening balance [%s]", value)
+			}
+			iso4217 = value[7:10]
+		case "61":
+			t, err := mt940Transaction(value, iso4217)
+			if err != nil {
+				return err
+			}
+			if t.BankReference == "" {
+				t.BankReference = fmt.Sprintf("%s/%s/%d", stmtRef, seq, len(list)+1)
+			}
+			list = append(list, t)
+			cur = &list[len(list)-1]
+		case "86":
+			if cur != nil {
+				cur.Reference, cur.Counterparty = mt940Information(value, cur.Reference)
+			}
+			cur = nil
+		case "62F", "62M", "64", "65":
+			cur = nil
+		}
+		return nil
+	}
+
+	for _, line := range strings.Split(strings.ReplaceAll(string(statement), "\r\n", "\n"), "\n") {
+		if m := mt940Tag.FindStringSubmatch(line); m != nil {
+			if err = flush(); err != nil {
+				return nil, err
+			}
+			tag, value = m[1], m[2]
+		} else if tag != "" && line != "-" && !strings.HasPrefix(line, "-}") {
+			value += "\n" + line
+		}
+	}
+	if err = flush(); err != nil {
+		return nil, err
+	}
+	if iso4217 == "" && len(list) > 0 {
+		return nil, fmt.Errorf("invalid mt940 statement: missing opening balance")
+	}
+	return list, nil
+}
+
+func mt940Transaction(value, iso4217 string) (t Transaction, err error) {
+	first, supplementary, _ := strings.Cut(value, "\n")
+	m := mt940Line.FindStringSubmatch(strings.TrimSpace(first))
+	if m == nil {
+		return t, fmt.Errorf("invalid mt940 statement line [%s]", first)
+	}
+	valueTime, err := time.Parse("060102", m[1])
+	if err != nil {
+		return t, fmt.Errorf("invalid mt940 value date [%s]: %w", m[1], err)
+	}
+	amount, err := parseAmount(strings.Replace(m[5], ",", ".", 1))
+	if err != nil {
+		return t, fmt.Errorf("invalid mt940 amount [%s]: %w", m[5], err)
+	}
+	if m[3] == "D" || m[3] == "RC" { // debit, or reversal of credit
+		amount = -amount
+	}
+	t = Transaction{
+		BankReference: strings.TrimSpace(m[8]),
+		Reference:     strings.TrimSpace(supplementary),
+		Amount:        &pkg.Money{ISO4217: iso4217, Amount: amount, Time: valueTime},
+	}
+	if customer := strings.TrimSpace(m[7]); customer != "" && customer != "NONREF" {
+		t.Reference = strings.TrimSpace(customer + " " + t.Reference)
+	}
+	return t, nil
+}
+
+// mt940Information will return the remittance & name of the structured information, otherwise the whole
+// information is appended to the reference.
+func mt940Information(value, reference string) (_, counterparty string) {
+	subs := mt940Sub.FindAllStringSubmatch(strings.ReplaceAll(value, "\n", ""), -1) // subfield may wrap the line
+	if len(subs) == 0 {
+		return strings.TrimSpace(reference + " " + strings.ReplaceAll(value, "\n", " ")), ""
+	}
+	var remittance []string
+	for _, sub := range subs {
+		switch code := sub[1]; {
+		case code >= "20" && code <= "29", code >= "60" && code <= "63":
+			remittance = append(remittance, sub[2])
+		case code == "32" || code == "33":
+			counterparty += sub[2]
+		}
+	}
+	return strings.TrimSpace(reference + " " + strings.Join(remittance, "")), strings.TrimSpace(counterparty)
+}
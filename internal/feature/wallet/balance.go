@@ -0,0 +1,165 @@
+package wallet
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/gunawanwijaya/loan-svc/internal/repository/datastore"
+	"github.com/gunawanwijaya/loan-svc/pkg"
+	"github.com/rs/xid"
+)
+
+type BalanceRequest struct {
+	UserID []byte `json:"user_id,omitempty"`
+}
+
+// BalanceResponse is the wallet of a single currency, multiple currencies are returned in List.
+type BalanceResponse struct {
+	List []BalanceResponse `json:"list,omitempty"`
+
+	UserID    []byte     `json:"user_id,omitempty"`
+	Available *pkg.Money `json:"available,omitempty"` // free to be invested or withdrawn
+	Reserved  *pkg.Money `json:"reserved,omitempty"`  // invested on loans not yet disbursed
+}
+
+type DepositRequest struct {
+	UserID []byte     `json:"user_id,omitempty"`
+	Amount *pkg.Money `json:"amount,omitempty"`
+}
+
+func (x *DepositRequest) Validate(ctx context.Context) (_ *DepositRequest, err error) {
+	if len(x.UserID) < 1 {
+		return nil, fmt.Errorf("invalid user_id")
+	}
+	if x.Amount == nil {
+		return nil, fmt.Errorf("invalid amount")
+	}
+	if x.Amount, err = x.Amount.Validate(ctx); err != nil {
+		return nil, err
+	}
+	if x.Amount.Amount <= 0 {
+		return nil, fmt.Errorf("amount should be more than 0")
+	}
+	return x, nil
+}
+
+type WithdrawRequest struct {
+	UserID []byte     `json:"user_id,omitempty"`
+	Amount *pkg.Money `json:"amount,omitempty"`
+}
+
+func (x *WithdrawRequest) Validate(ctx context.Context) (_ *WithdrawRequest, err error) {
+	d := DepositRequest(*x)
+	if _, err = d.Validate(ctx); err != nil {
+		return nil, err
+	}
+	*x = WithdrawRequest(d)
+	return x, nil
+}
+
+// Balance will list the available & reserved balance of each currency held by the user.
+func (x *wallet) Balance(ctx context.Context, req BalanceRequest) (res BalanceResponse, err error) {
+	log := pkg.Context.SlogLogger(ctx)
+	if len(req.UserID) < 1 {
+		err = fmt.Errorf("invalid user_id")
+		return
+	}
+
+	var qry datastore.QueryResponse
+	qry, err = x.Dependency.Datastore.Query(ctx, datastore.QueryRequest{
+		Wallets: &datastore.QueryRequestWallets{ByUserID: req.UserID},
+	})
+	if err != nil {
+		return
+	}
+	for _, qry := range qry.List {
+		res.List = append(res.List, fromWallet(qry.Wallets.Wallet))
+	}
+	if len(res.List) == 1 {
+		res = res.List[0]
+	} else {
+		res.UserID = req.UserID
+	}
+
+	log.DebugContext(ctx, "feature/wallet.Balance",
+		slog.Any("req", req),
+		slog.Any("res", res),
+		slog.Any("err", err),
+	)
+	return
+}
+
+// Deposit will credit the available balance, the money is received on the platform bank account.
+//
+//	Dr cash_at_bank
+//	    Cr lender_wallet
+func (x *wallet) Deposit(ctx context.Context, req DepositRequest) (res BalanceResponse, err error) {
+	var d *DepositRequest
+	if d, err = pkg.AsValidator(&req).Validate(ctx); err != nil {
+		return
+	}
+	return x.transfer(ctx, "Deposit", d.UserID, d.Amount, 1)
+}
+
+// Withdraw will debit the available balance, rejected when the available balance is insufficient.
+//
+//	Dr lender_wallet
+//	    Cr cash_at_bank
+func (x *wallet) Withdraw(ctx context.Context, req WithdrawRequest) (res BalanceResponse, err error) {
+	var w *WithdrawRequest
+	if w, err = pkg.AsValidator(&req).Validate(ctx); err != nil {
+		return
+	}
+	return x.transfer(ctx, "Withdrawal", w.UserID, w.Amount, -1)
+}
+
+func (x *wallet) transfer(ctx context.Context, description string, userID []byte, amount *pkg.Money, sign float64) (res BalanceResponse, err error) {
+	log := pkg.Context.SlogLogger(ctx)
+	bank := datastore.Posting{LedgerAccount: datastore.AccountCashAtBank, ISO4217: amount.ISO4217}
+	wallet := datastore.Posting{LedgerAccount: datastore.AccountLenderWallet, UserID: userID, ISO4217: amount.ISO4217}
+	if sign > 0 {
+		bank.Debit, wallet.Credit = amount.Amount, amount.Amount
+	} else {
+		wallet.Debit, bank.Credit = amount.Amount, amount.Amount
+	}
+	if amount.Details != "" {
+		description += ": " + amount.Details
+	}
+
+	var mut datastore.MutationResponse
+	mut, err = x.Dependency.Datastore.Mutation(ctx, datastore.MutationRequest{
+		Wallets: &datastore.MutationRequestWallets{
+			List: []datastore.WalletEntry{{
+				UserID:    userID,
+				ISO4217:   amount.ISO4217,
+				Available: sign * amount.Amount,
+			}},
+			Journals: []datastore.Journal{{
+				JournalID:   xid.New().Bytes(),
+				Description: description,
+				Postings:    []datastore.Posting{bank, wallet},
+			}},
+		},
+	})
+	if err == nil && len(mut.List) > 0 {
+		res = fromWallet(mut.List[0].Wallets.Wallet)
+	}
+
+	log.DebugContext(ctx, "feature/wallet.transfer "+description,
+		slog.Any("amount", amount),
+		slog.Any("res", res),
+		slog.Any("err", err),
+	)
+	return
+}
+
+func fromWallet(w datastore.Wallet) BalanceResponse {
+	t := time.Unix(w.UpdatedAt, 0)
+	return BalanceResponse{
+		UserID:    w.UserID,
+		Available: &pkg.Money{ISO4217: w.ISO4217, Amount: w.Available, Time: t},
+		Reserved:  &pkg.Money{ISO4217: w.ISO4217, Amount: w.Reserved, Time: t},
+	}
+}
@@ -0,0 +1,50 @@
+//go:generate mockgen -destination wallet_mock.go -package wallet . Wallet
+package wallet
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gunawanwijaya/loan-svc/internal/repository/datastore"
+	"github.com/gunawanwijaya/loan-svc/pkg"
+)
+
+type Configuration struct {
+	//
+}
+type Dependency struct {
+	datastore.Datastore
+}
+type Wallet interface {
+	Balance(ctx context.Context, req BalanceRequest) (res BalanceResponse, err error)
+	Deposit(ctx context.Context, req DepositRequest) (res BalanceResponse, err error)
+	Withdraw(ctx context.Context, req WithdrawRequest) (res BalanceResponse, err error)
+}
+
+func New(ctx context.Context, cfg Configuration, dep Dependency) (_ Wallet, err error) {
+	cfg, err = pkg.AsValidator(cfg).Validate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	dep, err = pkg.AsValidator(dep).Validate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &wallet{cfg, dep}, nil
+}
+
+type wallet struct {
+	Configuration
+	Dependency
+}
+
+func (cfg Configuration) Validate(ctx context.Context) (_ Configuration, err error) {
+	return cfg, nil
+}
+
+func (dep Dependency) Validate(ctx context.Context) (_ Dependency, err error) {
+	if dep.Datastore == nil {
+		return dep, fmt.Errorf("feature/wallet: uninitialized repository/datastore")
+	}
+	return dep, nil
+}
@@ -0,0 +1,91 @@
+package wallet_test
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/gunawanwijaya/loan-svc/internal/feature/wallet"
+	"github.com/gunawanwijaya/loan-svc/internal/repository/datastore"
+	"github.com/gunawanwijaya/loan-svc/pkg"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestWallet(t *testing.T) {
+	ctx := pkg.Context.PutSlogLogger(context.Background(), slog.Default())
+	ctrl := gomock.NewController(t)
+	mockDatastore := datastore.NewMockDatastore(ctrl)
+
+	_, err := wallet.New(ctx, wallet.Configuration{}, wallet.Dependency{})
+	require.Error(t, err)
+
+	featWallet, err := wallet.New(ctx, wallet.Configuration{}, wallet.Dependency{
+		Datastore: mockDatastore,
+	})
+	require.NoError(t, err)
+
+	lenderID := []byte("1111")
+	{
+		mockDatastore.EXPECT().
+			Mutation(ctx, gomock.Any()).
+			DoAndReturn(func(ctx context.Context, req datastore.MutationRequest) (datastore.MutationResponse, error) {
+				require.Equal(t, []datastore.WalletEntry{{UserID: lenderID, ISO4217: "IDR", Available: 1_000_000.00}}, req.Wallets.List)
+				require.Len(t, req.Wallets.Journals, 1)
+				journal, err := req.Wallets.Journals[0].Validate(ctx)
+				require.NoError(t, err)
+				require.Equal(t, datastore.AccountCashAtBank, journal.Postings[0].LedgerAccount)
+				require.Equal(t, 1_000_000.00, journal.Postings[0].Debit)
+				require.Equal(t, datastore.AccountLenderWallet, journal.Postings[1].LedgerAccount)
+				require.Equal(t, 1_000_000.00, journal.Postings[1].Credit)
+				return datastore.MutationResponse{List: []datastore.MutationResponse{{Wallets: &datastore.MutationResponseWallets{Wallet: datastore.Wallet{
+					UserID:    lenderID,
+					ISO4217:   "IDR",
+					Available: 1_000_000.00,
+				}}}}}, nil
+			})
+	}
+	res, err := featWallet.Deposit(ctx, wallet.DepositRequest{
+		UserID: lenderID,
+		Amount: &pkg.Money{ISO4217: "IDR", Amount: 1_000_000.00},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1_000_000.00, res.Available.Amount)
+	require.Equal(t, 0.0, res.Reserved.Amount)
+
+	_, err = featWallet.Deposit(ctx, wallet.DepositRequest{
+		UserID: lenderID,
+		Amount: &pkg.Money{ISO4217: "IDR", Amount: -1_000_000.00},
+	})
+	require.Error(t, err)
+
+	{
+		mockDatastore.EXPECT().
+			Mutation(ctx, gomock.Any()).
+			DoAndReturn(func(ctx context.Context, req datastore.MutationRequest) (datastore.MutationResponse, error) {
+				require.Equal(t, []datastore.WalletEntry{{UserID: lenderID, ISO4217: "IDR", Available: -2_000_000.00}}, req.Wallets.List)
+				return datastore.MutationResponse{}, &datastore.InsufficientBalanceError{ISO4217: "IDR", Balance: 1_000_000.00, Required: 2_000_000.00}
+			})
+	}
+	_, err = featWallet.Withdraw(ctx, wallet.WithdrawRequest{
+		UserID: lenderID,
+		Amount: &pkg.Money{ISO4217: "IDR", Amount: 2_000_000.00},
+	})
+	var insufficient *datastore.InsufficientBalanceError
+	require.ErrorAs(t, err, &insufficient)
+
+	{
+		mockDatastore.EXPECT().
+			Query(ctx, datastore.QueryRequest{Wallets: &datastore.QueryRequestWallets{ByUserID: lenderID}}).
+			Return(datastore.QueryResponse{List: []datastore.QueryResponse{
+				{Wallets: &datastore.QueryResponseWallets{Wallet: datastore.Wallet{UserID: lenderID, ISO4217: "IDR", Available: 1_000_000.00}}},
+				{Wallets: &datastore.QueryResponseWallets{Wallet: datastore.Wallet{UserID: lenderID, ISO4217: "USD", Available: 10.00, Reserved: 90.00}}},
+			}}, nil)
+	}
+	res, err = featWallet.Balance(ctx, wallet.BalanceRequest{UserID: lenderID})
+	require.NoError(t, err)
+	require.Len(t, res.List, 2)
+	require.Equal(t, lenderID, res.UserID)
+	require.Equal(t, "USD", res.List[1].Reserved.ISO4217)
+	require.Equal(t, 90.00, res.List[1].Reserved.Amount)
+}
@@ -0,0 +1,297 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gunawanwijaya/loan-svc/internal/repository/datastore"
+	"github.com/gunawanwijaya/loan-svc/pkg"
+)
+
+// Delivery is an event posted to a subscription, following the status lifecycle
+//
+//	pending -> delivered
+//	   |
+//	   +-----> dead -> pending (replay)
+type Delivery struct {
+	DeliveryID     []byte          `json:"delivery_id,omitempty"`
+	SubscriptionID []byte          `json:"subscription_id,omitempty"`
+	EventID        []byte          `json:"event_id,omitempty"`
+	EventType      string          `json:"event_type,omitempty"`
+	Status         string          `json:"status,omitempty"`
+	Attempts       int             `json:"attempts"`                  // since created or replayed
+	NextAttemptAt  *time.Time      `json:"next_attempt_at,omitempty"` // nil once delivered or dead
+	Payload        json.RawMessage `json:"payload,omitempty"`         // body of the HTTP POST
+	Log            []Attempt       `json:"log,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+}
+
+type Attempt struct {
+	DeliveryID    []byte     `json:"delivery_id,omitempty"`
+	Attempt       int        `json:"attempt"`
+	Status        string     `json:"status,omitempty"` // of the delivery after the attempt
+	StatusCode    int        `json:"status_code"`      // zero when no response
+	Error         string     `json:"error,omitempty"`
+	DurationMS    int64      `json:"duration_ms"`
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"` // nil once delivered or dead
+	AttemptedAt   time.Time  `json:"attempted_at"`
+}
+
+type DeliverRequest struct {
+	BatchSize int `json:"batch_size,omitempty"` // default to the configured batch size
+}
+
+type DeliverResponse struct {
+	Attempts []Attempt `json:"attempts,omitempty"`
+	More     bool      `json:"more"` // the batch is full, more deliveries may be due
+}
+
+// Deliver will post the due deliveries & record each attempt. A response other than 2xx is retried with exponential
+// backoff, until the maximum attempts where the delivery is dead & waiting to be replayed.
+func (x *webhook) Deliver(ctx context.Context, req DeliverRequest) (res DeliverResponse, err error) {
+	log := pkg.Context.SlogLogger(ctx)
+	batchSize := pkg.OrElse(req.BatchSize > 0, req.BatchSize, x.Configuration.BatchSize)
+
+	var qry datastore.QueryResponse
+	qry, err = x.Dependency.Datastore.Query(ctx, datastore.QueryRequest{
+		Deliveries: &datastore.QueryRequestDeliveries{ByDueAt: time.Now().Unix(), ByLimit: batchSize},
+	})
+	if err != nil {
+		return
+	}
+	res.More = len(qry.List) >= batchSize
+
+	for _, q := range qry.List {
+		a := x.attempt(ctx, q.Deliveries.WebhookDelivery)
+		// a failure here leaves the delivery due, it is posted again by the next attempt
+		mut, mutErr := x.Dependency.Datastore.Mutation(ctx, datastore.MutationRequest{
+			Webhooks: &datastore.MutationRequestWebhooks{Attempts: []datastore.WebhookAttempt{a}},
+		})
+		if mutErr != nil {
+			err = errors.Join(err, mutErr)
+			continue
+		}
+		for _, a := range mut.Webhooks.Attempts {
+			res.Attempts = append(res.Attempts, attempt(a))
+		}
+	}
+
+	log.DebugContext(ctx, "feature/webhook.Deliver",
+		slog.Int("attempts", len(res.Attempts)),
+		slog.Bool("more", res.More),
+		slog.Any("err", err),
+	)
+	return
+}
+
+// attempt will post the delivery once & describe the outcome.
+func (x *webhook) attempt(ctx context.Context, d datastore.WebhookDelivery) (a datastore.WebhookAttempt) {
+	a = datastore.WebhookAttempt{DeliveryID: d.DeliveryID, Attempt: d.Attempts + 1}
+	start := time.Now()
+	a.StatusCode, a.Error = x.post(ctx, d, start)
+	end := time.Now()
+	a.DurationMS = end.Sub(start).Milliseconds()
+
+	switch {
+	case a.Error == "":
+		a.WebhookStatus = datastore.WebhookDelivered
+	case a.Attempt >= x.Configuration.MaxAttempts:
+		a.WebhookStatus = datastore.WebhookDead
+	default:
+		a.WebhookStatus = datastore.WebhookPending
+		a.NextAttemptAt = end.Add(x.backoff(a.Attempt)).Unix()
+	}
+	return a
+}
+
+// maxErrorBody is the maximum length of the response body kept as error in the delivery log.
+const maxErrorBody = 256
+
+func (x *webhook) post(ctx context.Context, d datastore.WebhookDelivery, now time.Time) (statusCode int, errMsg string) {
+	ctx, cancel := context.WithTimeout(ctx, x.Configuration.Timeout)
+	defer cancel()
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, d.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return 0, err.Error()
+	}
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set(SignatureHeader, Sign(d.Secret, now, d.Payload))
+	r.Header.Set(EventHeader, d.EventType)
+	r.Header.Set(DeliveryHeader, pkg.BtoA(d.DeliveryID))
+	resp, err := x.Dependency.Client.Do(r)
+	if err != nil {
+		return 0, err.Error()
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Sprintf("%s: %s", resp.Status, bytes.TrimSpace(body))
+	}
+	return resp.StatusCode, ""
+}
+
+// backoff will double the delay on each retry, bounded by the maximum backoff.
+func (x *webhook) backoff(attempt int) time.Duration {
+	delay := x.Configuration.Backoff
+	for i := 1; i < attempt && delay < x.Configuration.MaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, x.Configuration.MaxBackoff)
+}
+
+// Run will deliver repeatedly until the context is done, pausing for the configured interval once nothing is due
+// or the delivery fails.
+func (x *webhook) Run(ctx context.Context) {
+	log := pkg.Context.SlogLogger(ctx)
+	for {
+		res, err := x.Deliver(ctx, DeliverRequest{})
+		if err != nil {
+			log.ErrorContext(ctx, "feature/webhook.Run", slog.Any("err", err))
+		}
+		if err == nil && res.More {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(x.Configuration.Interval):
+		}
+	}
+}
+
+type DeliveriesRequest struct {
+	SubscriptionID []byte `json:"subscription_id,omitempty"`
+	DeliveryID     []byte `json:"delivery_id,omitempty"`
+	Status         string `json:"status,omitempty"` // empty for all status, dead for the dead-letter list
+}
+
+type DeliveriesResponse struct {
+	List []Delivery `json:"list,omitempty"`
+}
+
+// Deliveries will return the deliveries along with their delivery log, ordered by the next attempt.
+func (x *webhook) Deliveries(ctx context.Context, req DeliveriesRequest) (res DeliveriesResponse, err error) {
+	var status datastore.WebhookStatus
+	if req.Status != "" {
+		if status, err = parseStatus(req.Status); err != nil {
+			return
+		}
+	}
+	var qry datastore.QueryResponse
+	qry, err = x.Dependency.Datastore.Query(ctx, datastore.QueryRequest{
+		Deliveries: &datastore.QueryRequestDeliveries{
+			BySubscriptionID: req.SubscriptionID,
+			ByDeliveryID:     req.DeliveryID,
+			ByWebhookStatus:  status,
+			WithAttempts:     true,
+		},
+	})
+	if err != nil {
+		return
+	}
+	for _, q := range qry.List {
+		res.List = append(res.List, delivery(*q.Deliveries))
+	}
+	return
+}
+
+type ReplayRequest struct {
+	List []ReplayRequest `json:"list,omitempty"`
+
+	DeliveryID []byte `json:"delivery_id,omitempty"`
+}
+
+// Replay will move the dead deliveries back to pending & due immediately with their attempts restarted, either all
+// or none of them are moved.
+func (x *webhook) Replay(ctx context.Context, req ReplayRequest) (res DeliveriesResponse, err error) {
+	log := pkg.Context.SlogLogger(ctx)
+	list := req.List
+	if len(list) == 0 {
+		list = []ReplayRequest{req}
+	}
+	mut := &datastore.MutationRequestWebhooks{}
+	for _, r := range list {
+		if len(r.DeliveryID) < 1 {
+			err = fmt.Errorf("invalid delivery_id")
+			return
+		}
+		mut.Replays = append(mut.Replays, datastore.WebhookDelivery{DeliveryID: r.DeliveryID})
+	}
+	if _, err = x.Dependency.Datastore.Mutation(ctx, datastore.MutationRequest{Webhooks: mut}); err != nil {
+		return
+	}
+	for _, r := range list {
+		var d DeliveriesResponse
+		if d, err = x.Deliveries(ctx, DeliveriesRequest{DeliveryID: r.DeliveryID}); err != nil {
+			return
+		}
+		res.List = append(res.List, d.List...)
+	}
+
+	log.DebugContext(ctx, "feature/webhook.Replay",
+		slog.Int("len", len(list)),
+		slog.Any("err", err),
+	)
+	return
+}
+
+func parseStatus(s string) (datastore.WebhookStatus, error) {
+	for _, status := range []datastore.WebhookStatus{
+		datastore.WebhookPending,
+		datastore.WebhookDelivered,
+		datastore.WebhookDead,
+	} {
+		if status.String() == s {
+			return status, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown webhook status [%s]", s)
+}
+
+func delivery(d datastore.QueryResponseDeliveries) Delivery {
+	res := Delivery{
+		DeliveryID:     d.DeliveryID,
+		SubscriptionID: d.SubscriptionID,
+		EventID:        d.EventID,
+		EventType:      d.EventType,
+		Status:         d.WebhookStatus.String(),
+		Attempts:       d.WebhookDelivery.Attempts,
+		NextAttemptAt:  nextAttemptAt(d.NextAttemptAt),
+		Payload:        d.Payload,
+		CreatedAt:      time.Unix(d.CreatedAt, 0),
+		UpdatedAt:      time.Unix(d.UpdatedAt, 0),
+	}
+	for _, a := range d.Log {
+		res.Log = append(res.Log, attempt(a))
+	}
+	return res
+}
+
+func attempt(a datastore.WebhookAttempt) Attempt {
+	return Attempt{
+		DeliveryID:    a.DeliveryID,
+		Attempt:       a.Attempt,
+		Status:        a.WebhookStatus.String(),
+		StatusCode:    a.StatusCode,
+		Error:         a.Error,
+		DurationMS:    a.DurationMS,
+		NextAttemptAt: nextAttemptAt(a.NextAttemptAt),
+		AttemptedAt:   time.Unix(a.CreatedAt, 0),
+	}
+}
+
+func nextAttemptAt(unix int64) *time.Time {
+	if unix == 0 {
+		return nil
+	}
+	t := time.Unix(unix, 0)
+	return &t
+}
@@ -0,0 +1,67 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// SignatureHeader is the HTTP header carrying the signature of the body as `t=<unix>,v1=<hex>`, where v1 is the
+	// HMAC-SHA256 of `<unix>.<body>` keyed by the secret of the subscription.
+	SignatureHeader = "X-Webhook-Signature"
+	EventHeader     = "X-Webhook-Event"    // event type, e.g. loan.approved
+	DeliveryHeader  = "X-Webhook-Delivery" // base64 of the delivery ID, the same on each retry
+)
+
+var ErrInvalidSignature = errors.New("feature/webhook: invalid signature")
+
+// Sign will sign the body as posted at the given time, the time is signed along to let the receiver reject a
+// replayed request.
+func Sign(secret []byte, t time.Time, body []byte) string {
+	ts := strconv.FormatInt(t.Unix(), 10)
+	return "t=" + ts + ",v1=" + hex.EncodeToString(mac(secret, ts, body))
+}
+
+// Verify will accept the body when the signature is valid & signed within the tolerance of now, a zero tolerance
+// accept any time. It is meant for the receiver, e.g. in tests & partner examples.
+func Verify(secret []byte, signature string, body []byte, now time.Time, tolerance time.Duration) error {
+	var ts string
+	var sums [][]byte
+	for _, s := range strings.Split(signature, ",") {
+		k, v, _ := strings.Cut(strings.TrimSpace(s), "=")
+		switch k {
+		case "t":
+			ts = v
+		case "v1":
+			if sum, err := hex.DecodeString(v); err == nil {
+				sums = append(sums, sum)
+			}
+		}
+	}
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if tolerance > 0 && now.Sub(time.Unix(unix, 0)).Abs() > tolerance {
+		return ErrInvalidSignature
+	}
+	expected := mac(secret, ts, body)
+	for _, sum := range sums {
+		if hmac.Equal(sum, expected) {
+			return nil
+		}
+	}
+	return ErrInvalidSignature
+}
+
+func mac(secret []byte, ts string, body []byte) []byte {
+	m := hmac.New(sha256.New, secret)
+	m.Write([]byte(ts + "."))
+	m.Write(body)
+	return m.Sum(nil)
+}
@@ -0,0 +1,206 @@
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gunawanwijaya/loan-svc/internal/feature/outbox"
+	"github.com/gunawanwijaya/loan-svc/internal/repository/datastore"
+	"github.com/gunawanwijaya/loan-svc/pkg"
+	"github.com/rs/xid"
+)
+
+// minSecret is the minimum length of a secret given by the subscriber, a generated secret is 32 bytes.
+const minSecret = 16
+
+type Subscription struct {
+	SubscriptionID []byte    `json:"subscription_id,omitempty"`
+	URL            string    `json:"url,omitempty"`
+	EventTypes     []string  `json:"event_types,omitempty"` // empty for every event
+	Secret         []byte    `json:"secret,omitempty"`      // only returned upon subscribe
+	Active         bool      `json:"active"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+type SubscribeRequest struct {
+	URL        string   `json:"url,omitempty"`         // http or https
+	EventTypes []string `json:"event_types,omitempty"` // e.g. loan.approved, empty for every event
+	Secret     []byte   `json:"secret,omitempty"`      // generated when empty
+}
+
+func (x SubscribeRequest) Validate(ctx context.Context) (_ SubscribeRequest, err error) {
+	u, err := url.Parse(x.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return x, fmt.Errorf("invalid url")
+	}
+	for _, t := range x.EventTypes {
+		if t == "" || strings.ContainsAny(t, ", ") {
+			return x, fmt.Errorf("invalid event type [%s]", t)
+		}
+	}
+	if len(x.Secret) > 0 && len(x.Secret) < minSecret {
+		return x, fmt.Errorf("secret is shorter than %d bytes", minSecret)
+	}
+	return x, nil
+}
+
+// Subscribe will register the URL to receive the events of the given types, the secret is returned only once.
+func (x *webhook) Subscribe(ctx context.Context, req SubscribeRequest) (res Subscription, err error) {
+	log := pkg.Context.SlogLogger(ctx)
+	if req, err = pkg.AsValidator(req).Validate(ctx); err != nil {
+		return
+	}
+	secret := req.Secret
+	if len(secret) < 1 {
+		secret = make([]byte, 32)
+		if _, err = rand.Read(secret); err != nil {
+			return
+		}
+	}
+	var mut datastore.MutationResponse
+	mut, err = x.Dependency.Datastore.Mutation(ctx, datastore.MutationRequest{
+		Webhooks: &datastore.MutationRequestWebhooks{
+			Subscriptions: []datastore.WebhookSubscription{{
+				SubscriptionID: xid.New().Bytes(),
+				URL:            req.URL,
+				EventTypes:     strings.Join(req.EventTypes, ","),
+				Secret:         secret,
+				Active:         true,
+			}},
+		},
+	})
+	if err != nil {
+		return
+	}
+	res = subscription(mut.Webhooks.Subscriptions[0])
+	res.Secret = secret
+
+	log.DebugContext(ctx, "feature/webhook.Subscribe",
+		slog.String("url", req.URL),
+		slog.Any("event_types", req.EventTypes),
+		slog.Any("err", err),
+	)
+	return
+}
+
+type ListRequest struct {
+	SubscriptionID []byte `json:"subscription_id,omitempty"`
+	Active         bool   `json:"active,omitempty"` // false for both active & unsubscribed
+}
+
+type ListResponse struct {
+	List []Subscription `json:"list,omitempty"`
+}
+
+// List will return the subscriptions ordered by creation, without their secret.
+func (x *webhook) List(ctx context.Context, req ListRequest) (res ListResponse, err error) {
+	var qry datastore.QueryResponse
+	qry, err = x.Dependency.Datastore.Query(ctx, datastore.QueryRequest{
+		Webhooks: &datastore.QueryRequestWebhooks{BySubscriptionID: req.SubscriptionID, ByActive: req.Active},
+	})
+	if err != nil {
+		return
+	}
+	for _, q := range qry.List {
+		res.List = append(res.List, subscription(q.Webhooks.WebhookSubscription))
+	}
+	return
+}
+
+type UnsubscribeRequest struct {
+	SubscriptionID []byte `json:"subscription_id,omitempty"`
+}
+
+// Unsubscribe will stop creating & attempting the deliveries of the subscription, the delivery log is kept.
+func (x *webhook) Unsubscribe(ctx context.Context, req UnsubscribeRequest) (res Subscription, err error) {
+	log := pkg.Context.SlogLogger(ctx)
+	if len(req.SubscriptionID) < 1 {
+		err = fmt.Errorf("invalid subscription_id")
+		return
+	}
+	var qry datastore.QueryResponse
+	qry, err = x.Dependency.Datastore.Query(ctx, datastore.QueryRequest{
+		Webhooks: &datastore.QueryRequestWebhooks{BySubscriptionID: req.SubscriptionID},
+	})
+	if err != nil {
+		return
+	}
+	if len(qry.List) != 1 {
+		err = fmt.Errorf("subscription [%s] not found", pkg.BtoA(req.SubscriptionID))
+		return
+	}
+	s := qry.List[0].Webhooks.WebhookSubscription
+	s.Active = false
+	var mut datastore.MutationResponse
+	mut, err = x.Dependency.Datastore.Mutation(ctx, datastore.MutationRequest{
+		Webhooks: &datastore.MutationRequestWebhooks{Subscriptions: []datastore.WebhookSubscription{s}},
+	})
+	if err != nil {
+		return
+	}
+	res = subscription(mut.Webhooks.Subscriptions[0])
+
+	log.DebugContext(ctx, "feature/webhook.Unsubscribe",
+		slog.String("subscription_id", pkg.BtoA(req.SubscriptionID)),
+		slog.Any("err", err),
+	)
+	return
+}
+
+// Enqueue will create a delivery of the event for each active subscription of its type, it is meant to subscribe
+// to the outbox. An event published again by the outbox is not delivered twice to the same subscription.
+func (x *webhook) Enqueue(ctx context.Context, e outbox.Event) (err error) {
+	var qry datastore.QueryResponse
+	qry, err = x.Dependency.Datastore.Query(ctx, datastore.QueryRequest{
+		Webhooks: &datastore.QueryRequestWebhooks{ByActive: true},
+	})
+	if err != nil {
+		return
+	}
+	body, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	mut := &datastore.MutationRequestWebhooks{}
+	for _, q := range qry.List {
+		s := subscription(q.Webhooks.WebhookSubscription)
+		if len(s.EventTypes) > 0 && !slices.Contains(s.EventTypes, e.EventType) {
+			continue
+		}
+		mut.Deliveries = append(mut.Deliveries, datastore.WebhookDelivery{
+			DeliveryID:     xid.New().Bytes(),
+			SubscriptionID: s.SubscriptionID,
+			EventID:        e.EventID,
+			EventType:      e.EventType,
+			Payload:        body,
+		})
+	}
+	if len(mut.Deliveries) < 1 {
+		return
+	}
+	_, err = x.Dependency.Datastore.Mutation(ctx, datastore.MutationRequest{Webhooks: mut})
+	return
+}
+
+func subscription(s datastore.WebhookSubscription) Subscription {
+	var eventTypes []string
+	if s.EventTypes != "" {
+		eventTypes = strings.Split(s.EventTypes, ",")
+	}
+	return Subscription{
+		SubscriptionID: s.SubscriptionID,
+		URL:            s.URL,
+		EventTypes:     eventTypes,
+		Active:         s.Active,
+		CreatedAt:      time.Unix(s.CreatedAt, 0),
+		UpdatedAt:      time.Unix(s.UpdatedAt, 0),
+	}
+}
@@ -0,0 +1,80 @@
+//go:generate mockgen -destination webhook_mock.go -package webhook . Webhook
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gunawanwijaya/loan-svc/internal/feature/outbox"
+	"github.com/gunawanwijaya/loan-svc/internal/repository/datastore"
+	"github.com/gunawanwijaya/loan-svc/pkg"
+)
+
+type Configuration struct {
+	MaxAttempts int           `json:"max_attempts,omitempty"` // attempts before a delivery is dead, default to 8
+	Backoff     time.Duration `json:"backoff,omitempty"`      // delay of the first retry, doubled on each retry, default to 30s
+	MaxBackoff  time.Duration `json:"max_backoff,omitempty"`  // upper bound of the retry delay, default to 6h
+	Timeout     time.Duration `json:"timeout,omitempty"`      // of each HTTP POST, default to 10s
+	BatchSize   int           `json:"batch_size,omitempty"`   // maximum deliveries attempted at once, default to 100
+	Interval    time.Duration `json:"interval,omitempty"`     // pause of the deliverer once nothing is due, default to 5s
+}
+type Dependency struct {
+	datastore.Datastore
+
+	Client *http.Client // default to http.DefaultClient
+}
+type Webhook interface {
+	Deliver(ctx context.Context, req DeliverRequest) (res DeliverResponse, err error)
+	Deliveries(ctx context.Context, req DeliveriesRequest) (res DeliveriesResponse, err error)
+	Enqueue(ctx context.Context, e outbox.Event) (err error)
+	List(ctx context.Context, req ListRequest) (res ListResponse, err error)
+	Replay(ctx context.Context, req ReplayRequest) (res DeliveriesResponse, err error)
+	Run(ctx context.Context)
+	Subscribe(ctx context.Context, req SubscribeRequest) (res Subscription, err error)
+	Unsubscribe(ctx context.Context, req UnsubscribeRequest) (res Subscription, err error)
+}
+
+func New(ctx context.Context, cfg Configuration, dep Dependency) (_ Webhook, err error) {
+	cfg, err = pkg.AsValidator(cfg).Validate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	dep, err = pkg.AsValidator(dep).Validate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &webhook{cfg, dep}, nil
+}
+
+type webhook struct {
+	Configuration
+	Dependency
+}
+
+func (cfg Configuration) Validate(ctx context.Context) (_ Configuration, err error) {
+	if cfg.MaxAttempts < 0 || cfg.Backoff < 0 || cfg.MaxBackoff < 0 || cfg.Timeout < 0 || cfg.BatchSize < 0 || cfg.Interval < 0 {
+		return cfg, fmt.Errorf("feature/webhook: negative configuration")
+	}
+	cfg.MaxAttempts = pkg.OrElse(cfg.MaxAttempts > 0, cfg.MaxAttempts, 8)
+	cfg.Backoff = pkg.OrElse(cfg.Backoff > 0, cfg.Backoff, 30*time.Second)
+	cfg.MaxBackoff = pkg.OrElse(cfg.MaxBackoff > 0, cfg.MaxBackoff, 6*time.Hour)
+	cfg.Timeout = pkg.OrElse(cfg.Timeout > 0, cfg.Timeout, 10*time.Second)
+	cfg.BatchSize = pkg.OrElse(cfg.BatchSize > 0, cfg.BatchSize, 100)
+	cfg.Interval = pkg.OrElse(cfg.Interval > 0, cfg.Interval, 5*time.Second)
+	if cfg.MaxBackoff < cfg.Backoff {
+		return cfg, fmt.Errorf("feature/webhook: max backoff is shorter than backoff")
+	}
+	return cfg, nil
+}
+
+func (dep Dependency) Validate(ctx context.Context) (_ Dependency, err error) {
+	if dep.Datastore == nil {
+		return dep, fmt.Errorf("feature/webhook: uninitialized repository/datastore")
+	}
+	if dep.Client == nil {
+		dep.Client = http.DefaultClient
+	}
+	return dep, nil
+}
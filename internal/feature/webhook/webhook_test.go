@@ -0,0 +1,210 @@
+package webhook_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gunawanwijaya/loan-svc/internal/feature/outbox"
+	"github.com/gunawanwijaya/loan-svc/internal/feature/webhook"
+	"github.com/gunawanwijaya/loan-svc/internal/repository/datastore"
+	"github.com/gunawanwijaya/loan-svc/pkg"
+	"github.com/rs/xid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestWebhook(t *testing.T) {
+	ctx := pkg.Context.PutSlogLogger(context.Background(), slog.Default())
+	ctrl := gomock.NewController(t)
+	mockDatastore := datastore.NewMockDatastore(ctrl)
+
+	_, err := webhook.New(ctx, webhook.Configuration{}, webhook.Dependency{})
+	require.Error(t, err) // no datastore
+	_, err = webhook.New(ctx, webhook.Configuration{Backoff: time.Hour, MaxBackoff: time.Minute}, webhook.Dependency{Datastore: mockDatastore})
+	require.Error(t, err)
+
+	featWebhook, err := webhook.New(ctx, webhook.Configuration{
+		MaxAttempts: 3,
+		Backoff:     time.Minute,
+		MaxBackoff:  90 * time.Second,
+		Timeout:     time.Second,
+	}, webhook.Dependency{Datastore: mockDatastore})
+	require.NoError(t, err)
+
+	secret := []byte("field-officer-app-secret")
+	failures := 0
+	received := []outbox.Event{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.NoError(t, webhook.Verify(secret, r.Header.Get(webhook.SignatureHeader), body, time.Now(), time.Minute))
+		require.ErrorIs(t, webhook.Verify(secret, r.Header.Get(webhook.SignatureHeader), append(body, ' '), time.Now(), 0), webhook.ErrInvalidSignature)
+		require.ErrorIs(t, webhook.Verify(secret, r.Header.Get(webhook.SignatureHeader), body, time.Now().Add(time.Hour), time.Minute), webhook.ErrInvalidSignature)
+		require.Equal(t, "loan.approved", r.Header.Get(webhook.EventHeader))
+		require.NotEmpty(t, pkg.AtoB(r.Header.Get(webhook.DeliveryHeader)))
+		if failures > 0 {
+			failures--
+			http.Error(w, "maintenance", http.StatusServiceUnavailable)
+			return
+		}
+		var e outbox.Event
+		require.NoError(t, json.Unmarshal(body, &e))
+		received = append(received, e)
+	}))
+	defer srv.Close()
+
+	{ // subscribe
+		_, err = featWebhook.Subscribe(ctx, webhook.SubscribeRequest{URL: "ftp://example.com"})
+		require.Error(t, err)
+		_, err = featWebhook.Subscribe(ctx, webhook.SubscribeRequest{URL: srv.URL, Secret: []byte("short")})
+		require.Error(t, err)
+
+		mockDatastore.EXPECT().
+			Mutation(ctx, gomock.Any()).
+			DoAndReturn(func(ctx context.Context, req datastore.MutationRequest) (datastore.MutationResponse, error) {
+				s := req.Webhooks.Subscriptions[0]
+				require.Equal(t, srv.URL, s.URL)
+				require.Equal(t, "loan.approved,loan.disbursed", s.EventTypes)
+				require.Len(t, s.Secret, 32)
+				require.True(t, s.Active)
+				return datastore.MutationResponse{Webhooks: &datastore.MutationResponseWebhooks{Subscriptions: req.Webhooks.Subscriptions}}, nil
+			})
+		res, err := featWebhook.Subscribe(ctx, webhook.SubscribeRequest{URL: srv.URL, EventTypes: []string{"loan.approved", "loan.disbursed"}})
+		require.NoError(t, err)
+		require.Len(t, res.Secret, 32)
+		require.Equal(t, []string{"loan.approved", "loan.disbursed"}, res.EventTypes)
+	}
+
+	subscriptions := []datastore.WebhookSubscription{
+		{SubscriptionID: xid.New().Bytes(), URL: srv.URL, EventTypes: "loan.disbursed", Secret: secret, Active: true},
+		{SubscriptionID: xid.New().Bytes(), URL: srv.URL, EventTypes: "", Secret: secret, Active: true},
+	}
+	event := outbox.Event{
+		Sequence:    2,
+		EventID:     xid.New().Bytes(),
+		EventType:   "loan.approved",
+		AggregateID: xid.New().Bytes(),
+		Payload:     json.RawMessage(`{"loan_state":"approved"}`),
+		OccurredAt:  time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	var queued datastore.WebhookDelivery
+	{ // only the subscription of every event receive loan.approved
+		mockDatastore.EXPECT().
+			Query(ctx, datastore.QueryRequest{Webhooks: &datastore.QueryRequestWebhooks{ByActive: true}}).
+			Return(datastore.QueryResponse{List: []datastore.QueryResponse{
+				{Webhooks: &datastore.QueryResponseWebhooks{WebhookSubscription: subscriptions[0]}},
+				{Webhooks: &datastore.QueryResponseWebhooks{WebhookSubscription: subscriptions[1]}},
+			}}, nil)
+		mockDatastore.EXPECT().
+			Mutation(ctx, gomock.Any()).
+			DoAndReturn(func(ctx context.Context, req datastore.MutationRequest) (datastore.MutationResponse, error) {
+				require.Len(t, req.Webhooks.Deliveries, 1)
+				queued = req.Webhooks.Deliveries[0]
+				require.Equal(t, subscriptions[1].SubscriptionID, queued.SubscriptionID)
+				require.Equal(t, event.EventID, queued.EventID)
+				return datastore.MutationResponse{Webhooks: &datastore.MutationResponseWebhooks{Deliveries: req.Webhooks.Deliveries}}, nil
+			})
+	}
+	require.NoError(t, featWebhook.Enqueue(ctx, event))
+	queued.URL, queued.Secret, queued.WebhookStatus = srv.URL, secret, datastore.WebhookPending
+
+	deliver := func(d datastore.WebhookDelivery, check func(a datastore.WebhookAttempt)) webhook.DeliverResponse {
+		mockDatastore.EXPECT().
+			Query(ctx, gomock.Any()).
+			DoAndReturn(func(ctx context.Context, req datastore.QueryRequest) (datastore.QueryResponse, error) {
+				require.NotZero(t, req.Deliveries.ByDueAt)
+				require.Equal(t, 100, req.Deliveries.ByLimit)
+				return datastore.QueryResponse{List: []datastore.QueryResponse{
+					{Deliveries: &datastore.QueryResponseDeliveries{WebhookDelivery: d}},
+				}}, nil
+			})
+		mockDatastore.EXPECT().
+			Mutation(ctx, gomock.Any()).
+			DoAndReturn(func(ctx context.Context, req datastore.MutationRequest) (datastore.MutationResponse, error) {
+				a := req.Webhooks.Attempts[0]
+				require.Equal(t, d.DeliveryID, a.DeliveryID)
+				require.Equal(t, d.Attempts+1, a.Attempt)
+				check(a)
+				return datastore.MutationResponse{Webhooks: &datastore.MutationResponseWebhooks{Attempts: req.Webhooks.Attempts}}, nil
+			})
+		res, err := featWebhook.Deliver(ctx, webhook.DeliverRequest{})
+		require.NoError(t, err)
+		require.False(t, res.More)
+		require.Len(t, res.Attempts, 1)
+		return res
+	}
+
+	failures = 2
+	res := deliver(queued, func(a datastore.WebhookAttempt) {
+		require.Equal(t, datastore.WebhookPending, a.WebhookStatus)
+		require.Equal(t, http.StatusServiceUnavailable, a.StatusCode)
+		require.Contains(t, a.Error, "maintenance")
+		require.InDelta(t, time.Now().Add(time.Minute).Unix(), a.NextAttemptAt, 2)
+	})
+	require.Equal(t, "pending", res.Attempts[0].Status)
+	queued.Attempts = 1
+	deliver(queued, func(a datastore.WebhookAttempt) { // doubled, bounded by the max backoff
+		require.Equal(t, datastore.WebhookPending, a.WebhookStatus)
+		require.InDelta(t, time.Now().Add(90*time.Second).Unix(), a.NextAttemptAt, 2)
+	})
+	queued.Attempts = 2
+	deliver(queued, func(a datastore.WebhookAttempt) {
+		require.Equal(t, datastore.WebhookDelivered, a.WebhookStatus)
+		require.Equal(t, http.StatusOK, a.StatusCode)
+		require.Empty(t, a.Error)
+	})
+	require.Len(t, received, 1)
+	require.Equal(t, event.EventID, received[0].EventID)
+	require.JSONEq(t, `{"loan_state":"approved"}`, string(received[0].Payload))
+
+	failures = 1
+	deliver(queued, func(a datastore.WebhookAttempt) { // the last attempt
+		require.Equal(t, datastore.WebhookDead, a.WebhookStatus)
+		require.Zero(t, a.NextAttemptAt)
+	})
+
+	unreachable := queued
+	unreachable.URL = "http://127.0.0.1:1"
+	deliver(unreachable, func(a datastore.WebhookAttempt) {
+		require.Zero(t, a.StatusCode)
+		require.NotEmpty(t, a.Error)
+	})
+
+	{ // replay the dead-letter
+		dead := queued
+		dead.WebhookStatus, dead.Attempts = datastore.WebhookDead, 3
+		mockDatastore.EXPECT().
+			Query(ctx, datastore.QueryRequest{Deliveries: &datastore.QueryRequestDeliveries{ByWebhookStatus: datastore.WebhookDead, WithAttempts: true}}).
+			Return(datastore.QueryResponse{List: []datastore.QueryResponse{{Deliveries: &datastore.QueryResponseDeliveries{
+				WebhookDelivery: dead,
+				Log:             []datastore.WebhookAttempt{{DeliveryID: dead.DeliveryID, Attempt: 3, WebhookStatus: datastore.WebhookDead}},
+			}}}}, nil)
+		mockDatastore.EXPECT().
+			Mutation(ctx, datastore.MutationRequest{Webhooks: &datastore.MutationRequestWebhooks{
+				Replays: []datastore.WebhookDelivery{{DeliveryID: dead.DeliveryID}},
+			}}).
+			Return(datastore.MutationResponse{Webhooks: &datastore.MutationResponseWebhooks{}}, nil)
+		replayed := queued
+		replayed.Attempts = 0
+		mockDatastore.EXPECT().
+			Query(ctx, datastore.QueryRequest{Deliveries: &datastore.QueryRequestDeliveries{ByDeliveryID: dead.DeliveryID, WithAttempts: true}}).
+			Return(datastore.QueryResponse{List: []datastore.QueryResponse{{Deliveries: &datastore.QueryResponseDeliveries{WebhookDelivery: replayed}}}}, nil)
+	}
+	_, err = featWebhook.Deliveries(ctx, webhook.DeliveriesRequest{Status: "lost"})
+	require.Error(t, err)
+	dead, err := featWebhook.Deliveries(ctx, webhook.DeliveriesRequest{Status: "dead"})
+	require.NoError(t, err)
+	require.Len(t, dead.List, 1)
+	require.Equal(t, "dead", dead.List[0].Log[0].Status)
+	replayed, err := featWebhook.Replay(ctx, webhook.ReplayRequest{DeliveryID: dead.List[0].DeliveryID})
+	require.NoError(t, err)
+	require.Len(t, replayed.List, 1)
+	require.Equal(t, "pending", replayed.List[0].Status)
+	require.Zero(t, replayed.List[0].Attempts)
+}
@@ -0,0 +1,74 @@
+package datastore
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+
+	"github.com/gunawanwijaya/loan-svc/internal/repository/datastore/queries"
+)
+
+// chains named by ChainCheckpoint & BrokenLink, after the table being chained
+const (
+	ChainPayments    = "loan_party_payments"
+	ChainLoanEvents  = "loan_events"
+	ChainCheckpoints = "chain_checkpoints"
+)
+
+// paymentHash will hash the payment along with its position & both previous hashes, SettledAt is excluded as it is
+// set by reconciliation.
+func paymentHash(loanID, loanPartyID []byte, p LoanPartyPayment) []byte {
+	h := sha256.Sum256(canonical(ChainPayments+".hash",
+		p.PrevHash, p.PrevLoanHash, p.ChainSequence,
+		p.PaymentID, loanPartyID, loanID, int64(p.PaymentType), p.ISO4217, p.Amount, p.Time, p.Details, p.CreatedAt,
+	))
+	return h[:]
+}
+
+// eventHash will hash the event along with both previous hashes, the sequence is excluded as it is given by the
+// table once inserted.
+func eventHash(e LoanEvent) []byte {
+	h := sha256.Sum256(canonical(ChainLoanEvents+".hash",
+		e.PrevHash, e.PrevLoanHash,
+		e.EventID, e.LoanID, int64(e.Version), int64(e.LoanEventType), e.Payload, e.CreatedAt,
+	))
+	return h[:]
+}
+
+// checkpointContent will return what is signed by the checkpoint.
+func checkpointContent(c ChainCheckpoint) []byte {
+	return canonical(ChainCheckpoints+".created_sign", c.CheckpointID, c.Chain, c.HeadSequence, c.HeadHash, c.CreatedAt)
+}
+
+// paymentChain is the head of the chain of the payments & of a loan, advanced as each payment is inserted.
+type paymentChain struct {
+	Sequence int64
+	Hash     []byte
+	LoanHash []byte
+}
+
+// paymentHead will read the head of the chain of the payments as seen by the transaction.
+func paymentHead(ctx context.Context, tx *sql.Tx, loanID []byte) (h paymentChain, err error) {
+	err = tx.QueryRowContext(ctx, queries.LoanSvc.SQLite3.QueryPaymentHead(), loanID).Scan(&h.Sequence, &h.Hash, &h.LoanHash)
+	return
+}
+
+// link will chain the payment after the head, the head is only advanced once the payment is inserted.
+func (h paymentChain) link(loanID, loanPartyID []byte, p *LoanPartyPayment) {
+	p.ChainSequence, p.PrevHash, p.PrevLoanHash = h.Sequence+1, h.Hash, h.LoanHash
+	p.Hash = paymentHash(loanID, loanPartyID, *p)
+}
+
+// advance will move the head to the inserted payment, an ignored payment is left unchained as the row found is kept.
+func (h *paymentChain) advance(exec sql.Result, p *LoanPartyPayment) error {
+	ra, err := exec.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if ra < 1 {
+		p.ChainSequence, p.ChainLink = 0, ChainLink{}
+		return nil
+	}
+	h.Sequence, h.Hash, h.LoanHash = p.ChainSequence, p.Hash, p.Hash
+	return nil
+}
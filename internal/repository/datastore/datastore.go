@@ -7,22 +7,23 @@ import (
 	"database/sql"
 	"fmt"
 	"log/slog"
+	"slices"
 	"time"
 
 	"github.com/gunawanwijaya/loan-svc/internal/repository/datastore/queries"
+	"github.com/gunawanwijaya/loan-svc/internal/repository/keyring"
 	"github.com/gunawanwijaya/loan-svc/pkg"
 	"github.com/mattn/go-sqlite3"
 )
 
 type Configuration struct {
-	//
+	TrustedKeys []string `json:"trusted_keys,omitempty"` // base64 ed25519 public keys of signatures made before the keyring
 }
 type Dependency struct {
 	DB struct {
 		SQLite3 *sql.DB
 	}
-	ed25519.PublicKey
-	ed25519.PrivateKey
+	keyring.Keyring
 }
 type Datastore interface {
 	Query(ctx context.Context, req QueryRequest) (res QueryResponse, err error)
@@ -47,6 +48,11 @@ type datastore struct {
 }
 
 func (cfg Configuration) Validate(ctx context.Context) (_ Configuration, err error) {
+	for _, k := range cfg.TrustedKeys {
+		if len(pkg.AtoB(k)) != ed25519.PublicKeySize {
+			return cfg, fmt.Errorf("repository/datastore: invalid trusted key %q", k)
+		}
+	}
 	return cfg, nil
 }
 
@@ -93,30 +99,104 @@ func (dep Dependency) Validate(ctx context.Context) (_ Dependency, err error) {
 		)
 		log.DebugContext(ctx, "stats", slog.Any("stats", db.Stats()))
 
-		now := time.Now().Unix()
-		msg := []byte(fmt.Sprint(now))
-		sig := ed25519.Sign(dep.PrivateKey, msg)
-		ok := ed25519.Verify(dep.PublicKey, msg, sig)
-		field := append(dep.PublicKey, sig...)
-		ok2 := ed25519.Verify(field[:ed25519.PublicKeySize], msg, field[ed25519.PublicKeySize:])
-
-		_, _ = ok, ok2
-		q := queries.LoanSvc.SQLite3.Migration000()
-		var res sql.Result
-		if res, err = conn.ExecContext(ctx, q); err != nil {
-			return dep, err
+		// rows are verified by the key ID, a signing key missing from the keyring would sign rows never verified
+		if dep.Keyring == nil {
+			return dep, fmt.Errorf("repository/datastore: uninitialized repository/keyring")
 		}
-		var li, ra int64
-		if li, err = res.LastInsertId(); err != nil {
-			return dep, err
+		msg := []byte(fmt.Sprint(time.Now().Unix()))
+		if sig := dep.Keyring.Sign(msg); len(sig) != keyring.KeyIDSize+ed25519.SignatureSize {
+			return dep, fmt.Errorf("repository/datastore: malformed signature of repository/keyring")
+		} else if pub, ok := dep.Keyring.PublicKey(sig[:keyring.KeyIDSize]); !ok || !ed25519.Verify(pub, msg, sig[keyring.KeyIDSize:]) {
+			return dep, fmt.Errorf("repository/datastore: signing key does not match the public key of repository/keyring")
 		}
-		if ra, err = res.RowsAffected(); err != nil {
+		var version int
+		if err = conn.QueryRowContext(ctx, "PRAGMA user_version;").Scan(&version); err != nil {
 			return dep, err
 		}
-		log.DebugContext(ctx, "migration",
-			slog.Int64("LastInsertId", li),
-			slog.Int64("RowsAffected", ra),
-		)
+		for i, q := range queries.LoanSvc.SQLite3.Migrations() {
+			if i < version {
+				continue // already migrated
+			}
+			if tx, err = conn.BeginTx(ctx, &sql.TxOptions{}); err != nil {
+				return dep, err
+			}
+			var res sql.Result
+			if res, err = tx.ExecContext(ctx, q); err != nil {
+				_ = tx.Rollback()
+				return dep, err
+			}
+			if _, err = tx.ExecContext(ctx, fmt.Sprintf("PRAGMA user_version = %d;", i+1)); err != nil {
+				_ = tx.Rollback()
+				return dep, err
+			}
+			if err = tx.Commit(); err != nil {
+				return dep, err
+			}
+			var li, ra int64
+			if li, err = res.LastInsertId(); err != nil {
+				return dep, err
+			}
+			if ra, err = res.RowsAffected(); err != nil {
+				return dep, err
+			}
+			log.DebugContext(ctx, "migration",
+				slog.Int("version", i+1),
+				slog.Int64("LastInsertId", li),
+				slog.Int64("RowsAffected", ra),
+			)
+		}
 	} //////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 	return dep, nil
 }
+
+// sign will return the key ID followed by the signature of `now`.
+func (x *datastore) sign(now int64) []byte {
+	return x.signMessage([]byte(fmt.Sprint(now)))
+}
+
+// signMessage will return the key ID of the signing key followed by the signature of `msg`.
+func (x *datastore) signMessage(msg []byte) []byte {
+	return x.Dependency.Keyring.Sign(msg)
+}
+
+// verify will check the signature of `at` as returned by sign.
+func (x *datastore) verify(at int64, sig []byte) Verification {
+	return x.verifyMessage([]byte(fmt.Sprint(at)), sig)
+}
+
+// verifyMessage will check the signature of `msg` as returned by signMessage, signatures made before the keyring
+// carry the public key instead of the key ID & should be signed by a key of the keyring or trusted.
+func (x *datastore) verifyMessage(msg, sig []byte) Verification {
+	if len(sig) < 1 {
+		return SignatureUnsigned
+	}
+	keyID, pub := x.signer(sig)
+	if pub == nil && keyID != nil {
+		return SignatureUnknownKey
+	} else if pub == nil {
+		return SignatureInvalid
+	}
+	if !ed25519.Verify(pub, msg, sig[len(sig)-ed25519.SignatureSize:]) {
+		return SignatureInvalid
+	}
+	if keyID != nil || slices.Contains(x.Configuration.TrustedKeys, pkg.BtoA(pub)) {
+		return SignatureValid
+	}
+	return SignatureUnknownKey
+}
+
+// signer will return the key ID & the public key of the signature, nil when unknown. The key ID of a signature made
+// before the keyring is only known once its public key is in the keyring, the public key of a key ID likewise.
+func (x *datastore) signer(sig []byte) (keyID []byte, pub ed25519.PublicKey) {
+	switch len(sig) {
+	case keyring.KeyIDSize + ed25519.SignatureSize:
+		keyID = sig[:keyring.KeyIDSize]
+		pub, _ = x.Dependency.Keyring.PublicKey(keyID)
+	case ed25519.PublicKeySize + ed25519.SignatureSize:
+		pub = sig[:ed25519.PublicKeySize]
+		if k, ok := x.Dependency.Keyring.PublicKey(keyring.KeyID(pub)); ok && k.Equal(pub) {
+			keyID = keyring.KeyID(pub)
+		}
+	}
+	return
+}
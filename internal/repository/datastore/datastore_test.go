@@ -0,0 +1,732 @@
+package datastore_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gunawanwijaya/loan-svc/internal/repository/datastore"
+	"github.com/gunawanwijaya/loan-svc/internal/repository/keyring"
+	"github.com/gunawanwijaya/loan-svc/pkg"
+	"github.com/rs/xid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignatures(t *testing.T) {
+	ctx := pkg.Context.PutSlogLogger(context.Background(), slog.Default())
+	db, err := sql.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), "loan-svc.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	seed := make([]byte, ed25519.SeedSize)
+	_, err = rand.Read(seed)
+	require.NoError(t, err)
+	key := ed25519.NewKeyFromSeed(seed)
+	pub := key.Public().(ed25519.PublicKey)
+	repoKeyring, err := keyring.New(ctx, keyring.Configuration{}, keyring.Dependency{Seeds: [][]byte{seed}})
+	require.NoError(t, err)
+	repoDatastore, err := datastore.New(ctx, datastore.Configuration{}, datastore.Dependency{
+		DB:      struct{ SQLite3 *sql.DB }{SQLite3: db},
+		Keyring: repoKeyring,
+	})
+	require.NoError(t, err)
+
+	propose := func(borrowerID []byte, amount float64) datastore.Loan {
+		res, err := repoDatastore.Mutation(ctx, datastore.MutationRequest{Loans: &datastore.MutationRequestLoans{Loan: datastore.Loan{
+			LoanID:           xid.New().Bytes(),
+			LoanState:        datastore.StateProposed,
+			APR:              pkg.Ptr(.1234),
+			EffectiveRate:    pkg.Ptr(.1),
+			PaymentReference: pkg.Ptr(xid.New().String()),
+			Parties: []datastore.LoanParty{{
+				LoanPartyID:     xid.New().Bytes(),
+				UserID:          borrowerID,
+				LoanPartyRoleAs: datastore.RoleAsBorrower,
+				Payments: []datastore.LoanPartyPayment{{
+					PaymentID:   xid.New().Bytes(),
+					PaymentType: datastore.PaymentPrincipalDisbursement,
+					ISO4217:     "IDR",
+					Amount:      amount,
+					Time:        time.Now().Unix(),
+					Details:     "factory expansion",
+				}},
+			}},
+		}}})
+		require.NoError(t, err)
+		return res.Loans.Loan
+	}
+	query := func(loanID []byte) datastore.Loan {
+		res, err := repoDatastore.Query(ctx, datastore.QueryRequest{Loans: &datastore.QueryRequestLoans{ByLoanID: loanID}})
+		require.NoError(t, err)
+		require.Len(t, res.List, 1)
+		return res.List[0].Loans.Loan
+	}
+	scan := func() map[string]datastore.Verification {
+		res, err := repoDatastore.Query(ctx, datastore.QueryRequest{Signatures: &datastore.QueryRequestSignatures{}})
+		require.NoError(t, err)
+		found := map[string]datastore.Verification{}
+		for _, r := range res.List {
+			row := r.Signatures.SignedRow
+			found[fmt.Sprintf("%s/%d/%s", row.Table, row.RowID, row.Column)] = row.Verification
+		}
+		return found
+	}
+
+	t.Run("content is signed", func(t *testing.T) {
+		l1 := propose([]byte("900"), -1_000_000)
+		l2 := propose([]byte("901"), -1_000_000)
+		require.Equal(t, datastore.SignCanonical, l1.CreatedSignVersion)
+		require.Equal(t, datastore.SignCanonical, l1.Parties[0].CreatedSignVersion)
+		require.Equal(t, datastore.SignCanonical, l1.Parties[0].Payments[0].CreatedSignVersion)
+		require.Equal(t, keyring.KeyID(pub), l1.CreatedSign[:keyring.KeyIDSize])
+		// written within the same second, yet signed over different content
+		require.NotEqual(t, l1.CreatedSign, l2.CreatedSign)
+		require.NotEqual(t, l1.Parties[0].CreatedSign, l2.Parties[0].CreatedSign)
+		require.NotEqual(t, l1.Parties[0].Payments[0].CreatedSign, l2.Parties[0].Payments[0].CreatedSign)
+
+		l := query(l1.LoanID)
+		require.True(t, l.Verified)
+		require.True(t, l.Parties[0].Verified)
+		require.True(t, l.Parties[0].Payments[0].Verified)
+		require.Equal(t, datastore.SignCanonical, l.Parties[0].Payments[0].CreatedSignVersion)
+		require.Empty(t, scan())
+	})
+
+	t.Run("tampered content", func(t *testing.T) {
+		l := propose([]byte("902"), -2_000_000)
+		_, err := repoDatastore.Mutation(ctx, datastore.MutationRequest{Loans: &datastore.MutationRequestLoans{Loan: datastore.Loan{
+			LoanID:      l.LoanID,
+			LoanState:   datastore.StateApproved,
+			ApprovedBy:  []byte("777"),
+			ApprovedDoc: pkg.Ptr("https://example.com/approval.pdf"),
+		}}})
+		require.NoError(t, err)
+		require.True(t, query(l.LoanID).Verified)
+
+		// the signed timestamp is untouched, only the content
+		_, err = db.ExecContext(ctx, `UPDATE loan_party_payments SET amount = -20000000 WHERE payment_id = ?`,
+			l.Parties[0].Payments[0].PaymentID)
+		require.NoError(t, err)
+		q := query(l.LoanID)
+		require.True(t, q.Verified)
+		require.True(t, q.Parties[0].Verified)
+		require.False(t, q.Parties[0].Payments[0].Verified)
+
+		_, err = db.ExecContext(ctx, `UPDATE loan_parties SET user_id = ? WHERE loan_party_id = ?`,
+			[]byte("666"), l.Parties[0].LoanPartyID)
+		require.NoError(t, err)
+		require.False(t, query(l.LoanID).Parties[0].Verified)
+
+		_, err = db.ExecContext(ctx, `UPDATE loans SET approved_doc = ? WHERE loan_id = ?`,
+			"https://example.com/forged.pdf", l.LoanID)
+		require.NoError(t, err)
+		require.False(t, query(l.LoanID).Verified)
+
+		var loanRowID, partyRowID, paymentRowID int64
+		require.NoError(t, db.QueryRowContext(ctx, `SELECT rowid FROM loans WHERE loan_id = ?`, l.LoanID).Scan(&loanRowID))
+		require.NoError(t, db.QueryRowContext(ctx, `SELECT rowid FROM loan_parties WHERE loan_party_id = ?`, l.Parties[0].LoanPartyID).Scan(&partyRowID))
+		require.NoError(t, db.QueryRowContext(ctx, `SELECT rowid FROM loan_party_payments WHERE payment_id = ?`, l.Parties[0].Payments[0].PaymentID).Scan(&paymentRowID))
+		require.Equal(t, map[string]datastore.Verification{
+			fmt.Sprintf("loans/%d/approved_sign", loanRowID):                 datastore.SignatureInvalid,
+			fmt.Sprintf("loan_parties/%d/created_sign", partyRowID):          datastore.SignatureInvalid,
+			fmt.Sprintf("loan_party_payments/%d/created_sign", paymentRowID): datastore.SignatureInvalid,
+		}, scan())
+
+		// restored as signed
+		_, err = db.ExecContext(ctx, `UPDATE loan_party_payments SET amount = ? WHERE payment_id = ?`,
+			l.Parties[0].Payments[0].Amount, l.Parties[0].Payments[0].PaymentID)
+		require.NoError(t, err)
+		_, err = db.ExecContext(ctx, `UPDATE loan_parties SET user_id = ? WHERE loan_party_id = ?`,
+			l.Parties[0].UserID, l.Parties[0].LoanPartyID)
+		require.NoError(t, err)
+		_, err = db.ExecContext(ctx, `UPDATE loans SET approved_doc = ? WHERE loan_id = ?`,
+			"https://example.com/approval.pdf", l.LoanID)
+		require.NoError(t, err)
+		require.Empty(t, scan())
+	})
+
+	t.Run("timestamp signed before the version & the keyring are recorded", func(t *testing.T) {
+		now := time.Now().Unix()
+		sig := append(append([]byte{}, pub...), ed25519.Sign(key, []byte(fmt.Sprint(now)))...)
+		loanID, loanPartyID, paymentID := xid.New().Bytes(), xid.New().Bytes(), xid.New().Bytes()
+		_, err := db.ExecContext(ctx, `INSERT INTO loans (loan_id, loan_state, created_at, created_sign) VALUES (?,?,?,?)`,
+			loanID, int(datastore.StateProposed), now, sig)
+		require.NoError(t, err)
+		_, err = db.ExecContext(ctx, `INSERT INTO loan_parties (loan_party_id, loan_id, user_id, role_as, created_at, created_sign) VALUES (?,?,?,?,?,?)`,
+			loanPartyID, loanID, []byte("903"), int(datastore.RoleAsBorrower), now, sig)
+		require.NoError(t, err)
+		_, err = db.ExecContext(ctx, `INSERT INTO loan_party_payments (loan_party_id, payment_id, iso4217, amount, due_time, details, created_at, created_sign) VALUES (?,?,?,?,?,?,?,?)`,
+			loanPartyID, paymentID, "IDR", -3_000_000, now, "", now, sig)
+		require.NoError(t, err)
+
+		l := query(loanID)
+		require.Equal(t, datastore.SignTimestamp, l.CreatedSignVersion)
+		require.Equal(t, datastore.SignTimestamp, l.Parties[0].CreatedSignVersion)
+		require.Equal(t, datastore.SignTimestamp, l.Parties[0].Payments[0].CreatedSignVersion)
+		require.True(t, l.Verified)
+		require.True(t, l.Parties[0].Verified)
+		require.True(t, l.Parties[0].Payments[0].Verified)
+		require.Empty(t, scan())
+
+		// content is not covered, only the timestamp
+		_, err = db.ExecContext(ctx, `UPDATE loan_party_payments SET amount = -30000000 WHERE payment_id = ?`, paymentID)
+		require.NoError(t, err)
+		require.True(t, query(loanID).Parties[0].Payments[0].Verified)
+		_, err = db.ExecContext(ctx, `UPDATE loan_party_payments SET created_at = created_at - 1 WHERE payment_id = ?`, paymentID)
+		require.NoError(t, err)
+		require.False(t, query(loanID).Parties[0].Payments[0].Verified)
+
+		// a canonical version claimed over a timestamp signature
+		_, err = db.ExecContext(ctx, `UPDATE loans SET created_sign_version = ? WHERE loan_id = ?`, int(datastore.SignCanonical), loanID)
+		require.NoError(t, err)
+		require.False(t, query(loanID).Verified)
+	})
+
+	t.Run("timestamp version claimed by the keyring", func(t *testing.T) {
+		l := propose([]byte("905"), -5_000_000)
+		var rowID int64
+		require.NoError(t, db.QueryRowContext(ctx, `SELECT rowid FROM loans WHERE loan_id = ?`, l.LoanID).Scan(&rowID))
+		row := fmt.Sprintf("loans/%d/created_sign", rowID)
+
+		// a timestamp signed by the keyring as of any other table, the content is no longer covered when downgraded
+		sig := repoKeyring.Sign([]byte(fmt.Sprint(l.CreatedAt)))
+		for _, v := range []datastore.SignVersion{0, datastore.SignTimestamp} {
+			_, err := db.ExecContext(ctx, `UPDATE loans SET created_sign = ?, created_sign_version = ?, apr = ? WHERE loan_id = ?`,
+				sig, int(v), .99, l.LoanID)
+			require.NoError(t, err)
+			require.False(t, query(l.LoanID).Verified)
+			require.Equal(t, datastore.SignatureInvalid, scan()[row])
+		}
+
+		_, err := db.ExecContext(ctx, `UPDATE loans SET created_sign = ?, created_sign_version = ?, apr = ? WHERE loan_id = ?`,
+			l.CreatedSign, int(l.CreatedSignVersion), *l.APR, l.LoanID)
+		require.NoError(t, err)
+		require.True(t, query(l.LoanID).Verified)
+		require.NotContains(t, scan(), row)
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		l := propose([]byte("904"), -4_000_000)
+		var rowID int64
+		require.NoError(t, db.QueryRowContext(ctx, `SELECT rowid FROM loans WHERE loan_id = ?`, l.LoanID).Scan(&rowID))
+		row := fmt.Sprintf("loans/%d/created_sign", rowID)
+
+		// a key ID not found in the keyring
+		forged := append([]byte("unknown!"), l.CreatedSign[keyring.KeyIDSize:]...)
+		_, err := db.ExecContext(ctx, `UPDATE loans SET created_sign = ? WHERE loan_id = ?`, forged, l.LoanID)
+		require.NoError(t, err)
+		require.False(t, query(l.LoanID).Verified)
+		require.Equal(t, datastore.SignatureUnknownKey, scan()[row])
+
+		// a public key carried by the signature, neither in the keyring nor trusted
+		other, otherKey, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+		sig := append(append([]byte{}, other...), ed25519.Sign(otherKey, []byte(fmt.Sprint(l.CreatedAt)))...)
+		_, err = db.ExecContext(ctx, `UPDATE loans SET created_sign = ?, created_sign_version = ? WHERE loan_id = ?`,
+			sig, int(datastore.SignTimestamp), l.LoanID)
+		require.NoError(t, err)
+		require.False(t, query(l.LoanID).Verified)
+		require.Equal(t, datastore.SignatureUnknownKey, scan()[row])
+
+		_, err = db.ExecContext(ctx, `UPDATE loans SET created_sign = ?, created_sign_version = ? WHERE loan_id = ?`,
+			l.CreatedSign, int(l.CreatedSignVersion), l.LoanID)
+		require.NoError(t, err)
+		require.True(t, query(l.LoanID).Verified)
+	})
+}
+
+func TestChains(t *testing.T) {
+	ctx := pkg.Context.PutSlogLogger(context.Background(), slog.Default())
+	repoKeyring, err := keyring.New(ctx, keyring.Configuration{}, keyring.Dependency{})
+	require.NoError(t, err)
+	open := func(t *testing.T) (*sql.DB, datastore.Datastore) {
+		db, err := sql.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), "loan-svc.db"))
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = db.Close() })
+		repoDatastore, err := datastore.New(ctx, datastore.Configuration{}, datastore.Dependency{
+			DB:      struct{ SQLite3 *sql.DB }{SQLite3: db},
+			Keyring: repoKeyring,
+		})
+		require.NoError(t, err)
+		return db, repoDatastore
+	}
+	propose := func(t *testing.T, repoDatastore datastore.Datastore) datastore.Loan {
+		payment := func(amount float64) datastore.LoanPartyPayment {
+			return datastore.LoanPartyPayment{
+				PaymentID:   xid.New().Bytes(),
+				PaymentType: datastore.PaymentPrincipalDisbursement,
+				ISO4217:     "IDR",
+				Amount:      amount,
+				Time:        time.Now().Unix(),
+			}
+		}
+		res, err := repoDatastore.Mutation(ctx, datastore.MutationRequest{Loans: &datastore.MutationRequestLoans{Loan: datastore.Loan{
+			LoanID:    xid.New().Bytes(),
+			LoanState: datastore.StateProposed,
+			Parties: []datastore.LoanParty{{
+				LoanPartyID:     xid.New().Bytes(),
+				UserID:          []byte("900"),
+				LoanPartyRoleAs: datastore.RoleAsBorrower,
+				Payments:        []datastore.LoanPartyPayment{payment(-1_000_000), payment(500_000)},
+			}},
+		}}})
+		require.NoError(t, err)
+		return res.Loans.Loan
+	}
+	verify := func(t *testing.T, repoDatastore datastore.Datastore) map[string]string {
+		res, err := repoDatastore.Query(ctx, datastore.QueryRequest{Chains: &datastore.QueryRequestChains{}})
+		require.NoError(t, err)
+		found := map[string]string{}
+		for _, r := range res.List {
+			found[r.Chains.Chain] = fmt.Sprintf("%d/%s", r.Chains.Sequence, r.Chains.Reason)
+		}
+		return found
+	}
+	checkpoint := func(t *testing.T, repoDatastore datastore.Datastore) []datastore.ChainCheckpoint {
+		res, err := repoDatastore.Mutation(ctx, datastore.MutationRequest{Checkpoints: &datastore.MutationRequestCheckpoints{}})
+		require.NoError(t, err)
+		return res.Checkpoints.List
+	}
+
+	t.Run("linked", func(t *testing.T) {
+		_, repoDatastore := open(t)
+		l1, l2 := propose(t, repoDatastore), propose(t, repoDatastore)
+		p1, p2 := l1.Parties[0].Payments, l2.Parties[0].Payments
+		require.Equal(t, []int64{1, 2, 3, 4}, []int64{p1[0].ChainSequence, p1[1].ChainSequence, p2[0].ChainSequence, p2[1].ChainSequence})
+		require.Nil(t, p1[0].PrevHash)
+		require.Equal(t, p1[1].Hash, p2[0].PrevHash)
+		require.Nil(t, p2[0].PrevLoanHash) // first payment of the loan
+		require.Equal(t, p2[0].Hash, p2[1].PrevLoanHash)
+
+		res, err := repoDatastore.Query(ctx, datastore.QueryRequest{LoanEvents: &datastore.QueryRequestLoanEvents{}})
+		require.NoError(t, err)
+		require.Len(t, res.List, 2)
+		e1, e2 := res.List[0].LoanEvents.LoanEvent, res.List[1].LoanEvents.LoanEvent
+		require.Equal(t, e1.Hash, e2.PrevHash)
+		require.Nil(t, e2.PrevLoanHash)
+		require.Empty(t, verify(t, repoDatastore))
+
+		// each chain is signed once, until it moves
+		require.Len(t, checkpoint(t, repoDatastore), 2)
+		require.Empty(t, checkpoint(t, repoDatastore))
+		propose(t, repoDatastore)
+		cs := checkpoint(t, repoDatastore)
+		require.Len(t, cs, 2)
+		require.Equal(t, datastore.ChainPayments, cs[0].Chain)
+		require.Equal(t, int64(6), cs[0].HeadSequence)
+		require.Empty(t, verify(t, repoDatastore))
+	})
+
+	t.Run("tampered", func(t *testing.T) {
+		db, repoDatastore := open(t)
+		l := propose(t, repoDatastore)
+		propose(t, repoDatastore)
+		_, err := db.ExecContext(ctx, `UPDATE loan_party_payments SET amount = -10000000 WHERE payment_id = ?`,
+			l.Parties[0].Payments[1].PaymentID)
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{datastore.ChainPayments: "2/hash"}, verify(t, repoDatastore))
+	})
+
+	t.Run("removed", func(t *testing.T) {
+		db, repoDatastore := open(t)
+		l := propose(t, repoDatastore)
+		propose(t, repoDatastore)
+		_, err := db.ExecContext(ctx, `DELETE FROM loan_party_payments WHERE payment_id = ?`, l.Parties[0].Payments[1].PaymentID)
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{datastore.ChainPayments: "3/sequence"}, verify(t, repoDatastore))
+	})
+
+	t.Run("reordered", func(t *testing.T) {
+		db, repoDatastore := open(t)
+		propose(t, repoDatastore)
+		propose(t, repoDatastore)
+		_, err := db.ExecContext(ctx, `UPDATE loan_party_payments SET chain_sequence = -chain_sequence WHERE chain_sequence IN (2, 3)`)
+		require.NoError(t, err)
+		_, err = db.ExecContext(ctx, `UPDATE loan_party_payments SET chain_sequence = 5 + chain_sequence WHERE chain_sequence IN (-2, -3)`)
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{datastore.ChainPayments: "2/prev_hash"}, verify(t, repoDatastore))
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		db, repoDatastore := open(t)
+		propose(t, repoDatastore)
+		l := propose(t, repoDatastore)
+		require.Len(t, checkpoint(t, repoDatastore), 2)
+
+		// the rest of the chain is intact, only the checkpoint finds the tail being removed
+		_, err := db.ExecContext(ctx, `DELETE FROM loan_party_payments WHERE payment_id = ?`, l.Parties[0].Payments[1].PaymentID)
+		require.NoError(t, err)
+		_, err = db.ExecContext(ctx, `DROP TRIGGER loan_events_no_delete`)
+		require.NoError(t, err)
+		_, err = db.ExecContext(ctx, `DELETE FROM loan_events WHERE loan_id = ?`, l.LoanID)
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{
+			datastore.ChainPayments:   "4/checkpoint_missing",
+			datastore.ChainLoanEvents: "2/checkpoint_missing",
+		}, verify(t, repoDatastore))
+
+		// a checkpoint is not forged without the key
+		_, err = db.ExecContext(ctx, `DROP TRIGGER chain_checkpoints_no_update`)
+		require.NoError(t, err)
+		_, err = db.ExecContext(ctx, `UPDATE chain_checkpoints SET head_sequence = 3 WHERE chain = ?`, datastore.ChainPayments)
+		require.NoError(t, err)
+		require.Equal(t, "3/created_sign invalid", verify(t, repoDatastore)[datastore.ChainCheckpoints])
+	})
+}
+
+func TestPaymentReference(t *testing.T) {
+	ctx := pkg.Context.PutSlogLogger(context.Background(), slog.Default())
+	repoKeyring, err := keyring.New(ctx, keyring.Configuration{}, keyring.Dependency{})
+	require.NoError(t, err)
+	db, err := sql.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), "loan-svc.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	repoDatastore, err := datastore.New(ctx, datastore.Configuration{}, datastore.Dependency{
+		DB:      struct{ SQLite3 *sql.DB }{SQLite3: db},
+		Keyring: repoKeyring,
+	})
+	require.NoError(t, err)
+
+	propose := func(reference string) (datastore.Loan, error) {
+		loan := datastore.Loan{
+			LoanID:           xid.New().Bytes(),
+			LoanState:        datastore.StateProposed,
+			PaymentReference: &reference,
+			Parties: []datastore.LoanParty{{
+				LoanPartyID:     xid.New().Bytes(),
+				UserID:          []byte("900"),
+				LoanPartyRoleAs: datastore.RoleAsBorrower,
+				Payments: []datastore.LoanPartyPayment{{
+					PaymentID:   xid.New().Bytes(),
+					PaymentType: datastore.PaymentPrincipalDisbursement,
+					ISO4217:     "IDR",
+					Amount:      -1_000_000.00,
+					Time:        time.Now().Unix(),
+				}, {
+					PaymentID:   xid.New().Bytes(),
+					PaymentType: datastore.PaymentInstallment,
+					ISO4217:     "IDR",
+					Amount:      1_150_000.00,
+					Time:        time.Now().AddDate(0, 1, 0).Unix(),
+				}},
+			}},
+		}
+		_, err := repoDatastore.Mutation(ctx, datastore.MutationRequest{Loans: &datastore.MutationRequestLoans{Loan: loan}})
+		return loan, err
+	}
+	_, err = propose("880800000000000197")
+	require.NoError(t, err)
+
+	// a taken reference reject the whole loan, no party nor payment is left behind
+	loan, err := propose("880800000000000197")
+	var conflict *datastore.PaymentReferenceConflictError
+	require.ErrorAs(t, err, &conflict)
+	require.Equal(t, "880800000000000197", conflict.PaymentReference)
+	res, err := repoDatastore.Query(ctx, datastore.QueryRequest{Loans: &datastore.QueryRequestLoans{ByLoanID: loan.LoanID}})
+	require.NoError(t, err)
+	require.Empty(t, res.List)
+	var parties int
+	require.NoError(t, db.QueryRowContext(ctx, "SELECT COUNT(*) FROM loan_parties WHERE loan_id = ?", loan.LoanID).Scan(&parties))
+	require.Zero(t, parties)
+}
+
+func TestLoansByLender(t *testing.T) {
+	ctx := pkg.Context.PutSlogLogger(context.Background(), slog.Default())
+	repoKeyring, err := keyring.New(ctx, keyring.Configuration{}, keyring.Dependency{})
+	require.NoError(t, err)
+	db, err := sql.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), "loan-svc.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	repoDatastore, err := datastore.New(ctx, datastore.Configuration{}, datastore.Dependency{
+		DB:      struct{ SQLite3 *sql.DB }{SQLite3: db},
+		Keyring: repoKeyring,
+	})
+	require.NoError(t, err)
+
+	res, err := repoDatastore.Mutation(ctx, datastore.MutationRequest{Loans: &datastore.MutationRequestLoans{Loan: datastore.Loan{
+		LoanID:    xid.New().Bytes(),
+		LoanState: datastore.StateProposed,
+		Parties: []datastore.LoanParty{{
+			LoanPartyID:     xid.New().Bytes(),
+			UserID:          []byte("900"),
+			LoanPartyRoleAs: datastore.RoleAsBorrower,
+			Payments: []datastore.LoanPartyPayment{{
+				PaymentID:   xid.New().Bytes(),
+				PaymentType: datastore.PaymentPrincipalDisbursement,
+				ISO4217:     "IDR",
+				Amount:      -1_000_000.00,
+				Time:        time.Now().Unix(),
+			}},
+		}},
+	}}})
+	require.NoError(t, err)
+	loanID, lenderPartyID := res.Loans.Loan.LoanID, xid.New().Bytes()
+	now := time.Now().Unix()
+	_, err = db.ExecContext(ctx, `INSERT INTO loan_parties (loan_party_id, loan_id, user_id, role_as, created_at, created_sign) VALUES (?,?,?,?,?,?)`,
+		lenderPartyID, loanID, []byte("901"), int(datastore.RoleAsLender), now, []byte{})
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, `INSERT INTO loan_party_payments (loan_party_id, payment_id, iso4217, amount, due_time, details, created_at, created_sign) VALUES (?,?,?,?,?,?,?,?)`,
+		lenderPartyID, xid.New().Bytes(), "IDR", 1_000_000, now, "", now, []byte{})
+	require.NoError(t, err)
+
+	// the loans of the lender are listed along with every party, the borrower included
+	qry, err := repoDatastore.Query(ctx, datastore.QueryRequest{Loans: &datastore.QueryRequestLoans{ByLenderID: []byte("901")}})
+	require.NoError(t, err)
+	require.Len(t, qry.List, 1)
+	require.Len(t, qry.List[0].Loans.Loan.Parties, 2)
+	qry, err = repoDatastore.Query(ctx, datastore.QueryRequest{Loans: &datastore.QueryRequestLoans{ByLenderID: []byte("900")}})
+	require.NoError(t, err)
+	require.Empty(t, qry.List)
+}
+
+func TestOutbox(t *testing.T) {
+	ctx := pkg.Context.PutSlogLogger(context.Background(), slog.Default())
+	repoKeyring, err := keyring.New(ctx, keyring.Configuration{}, keyring.Dependency{})
+	require.NoError(t, err)
+	db, err := sql.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), "loan-svc.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	repoDatastore, err := datastore.New(ctx, datastore.Configuration{}, datastore.Dependency{
+		DB:      struct{ SQLite3 *sql.DB }{SQLite3: db},
+		Keyring: repoKeyring,
+	})
+	require.NoError(t, err)
+
+	loanID, principalID, installmentID := xid.New().Bytes(), xid.New().Bytes(), xid.New().Bytes()
+	dueTime := time.Now().AddDate(0, 1, 0).Unix()
+	payout := datastore.PayoutInstruction{
+		InstructionID: xid.New().Bytes(),
+		LoanID:        loanID,
+		PaymentID:     principalID,
+		ISO4217:       "IDR",
+		Amount:        1_000_000.00,
+		BankAccount:   datastore.BankAccount{AccountName: "Borrower", AccountNumber: "9876543210", BankCode: "CENAIDJA"},
+		Reference:     "Disbursement",
+	}
+	_, err = repoDatastore.Mutation(ctx, datastore.MutationRequest{Loans: &datastore.MutationRequestLoans{
+		Loan: datastore.Loan{
+			LoanID:    loanID,
+			LoanState: datastore.StateProposed,
+			Parties: []datastore.LoanParty{{
+				LoanPartyID:     xid.New().Bytes(),
+				UserID:          []byte("900"),
+				LoanPartyRoleAs: datastore.RoleAsBorrower,
+				Payments: []datastore.LoanPartyPayment{{
+					PaymentID:   principalID,
+					PaymentType: datastore.PaymentPrincipalDisbursement,
+					ISO4217:     "IDR",
+					Amount:      -1_000_000.00,
+					Time:        time.Now().Unix(),
+				}, {
+					PaymentID:   installmentID,
+					PaymentType: datastore.PaymentInstallment,
+					ISO4217:     "IDR",
+					Amount:      1_150_000.00,
+					Time:        dueTime,
+				}},
+			}},
+		},
+		Payouts: []datastore.PayoutInstruction{payout},
+	}})
+	require.NoError(t, err)
+	_, err = repoDatastore.Mutation(ctx, datastore.MutationRequest{Payouts: &datastore.MutationRequestPayouts{
+		BatchID: xid.New().Bytes(),
+		List:    []datastore.PayoutInstruction{{InstructionID: payout.InstructionID, PayoutStatus: datastore.PayoutExported}},
+	}})
+	require.NoError(t, err)
+
+	// the repayment settle the installment while the debit of the principal confirm the exported payout
+	repayment := datastore.SettledTransaction{
+		TransactionID: xid.New().Bytes(),
+		BankReference: "BANK-0001",
+		ISO4217:       "IDR",
+		Amount:        1_150_000.00,
+		ValueTime:     dueTime,
+		Source:        "csv",
+		LoanID:        loanID,
+	}
+	disbursement := datastore.SettledTransaction{
+		TransactionID: xid.New().Bytes(),
+		BankReference: "BANK-0002",
+		ISO4217:       "IDR",
+		Amount:        -1_000_000.00,
+		ValueTime:     time.Now().Unix(),
+		Source:        "csv",
+		LoanID:        loanID,
+	}
+	_, err = repoDatastore.Mutation(ctx, datastore.MutationRequest{Reconciliations: &datastore.MutationRequestReconciliations{
+		Transactions: []datastore.SettledTransaction{repayment, disbursement},
+		Matches: []datastore.ReconciliationMatch{
+			{TransactionID: repayment.TransactionID, PaymentID: installmentID, MatchType: datastore.MatchExact, Transaction: repayment},
+			{TransactionID: disbursement.TransactionID, PaymentID: principalID, MatchType: datastore.MatchExact, Transaction: disbursement},
+		},
+	}})
+	require.NoError(t, err)
+
+	// settled at the value date, recorded at the time of the match
+	qry, err := repoDatastore.Query(ctx, datastore.QueryRequest{Loans: &datastore.QueryRequestLoans{ByLoanID: loanID}})
+	require.NoError(t, err)
+	settled := qry.List[0].Loans.Loan.Parties[0].Payments[1]
+	require.Equal(t, pkg.Ptr(dueTime), settled.SettledAt)
+	require.NotNil(t, settled.SettlementRecordedAt)
+	require.Less(t, *settled.SettlementRecordedAt, dueTime)
+
+	_, err = repoDatastore.Mutation(ctx, datastore.MutationRequest{Wallets: &datastore.MutationRequestWallets{
+		List: []datastore.WalletEntry{{UserID: []byte("901"), ISO4217: "IDR", Available: 500_000.00}},
+	}})
+	require.NoError(t, err)
+
+	res, err := repoDatastore.Query(ctx, datastore.QueryRequest{Outbox: &datastore.QueryRequestOutbox{ByLimit: 100}})
+	require.NoError(t, err)
+	var types []string
+	events := map[string]datastore.OutboxEvent{}
+	for _, r := range res.List {
+		types = append(types, r.Outbox.EventType)
+		events[r.Outbox.EventType] = r.Outbox.OutboxEvent
+	}
+	require.Equal(t, []string{
+		"loan.proposed",
+		"payout.exported",
+		"loan.payment_settled",
+		"loan.payment_settled",
+		"payout.confirmed",
+		"wallet.deposited",
+	}, types)
+	require.Equal(t, loanID, events["loan.payment_settled"].AggregateID)
+	require.Equal(t, loanID, events["payout.confirmed"].AggregateID)
+	require.Equal(t, []byte("901"), events["wallet.deposited"].AggregateID)
+	require.JSONEq(t, `{"user_id":"OTAx","iso4217":"IDR","amount":500000,"available":500000}`,
+		string(events["wallet.deposited"].Payload))
+}
+
+func TestContracts(t *testing.T) {
+	ctx := pkg.Context.PutSlogLogger(context.Background(), slog.Default())
+	repoKeyring, err := keyring.New(ctx, keyring.Configuration{}, keyring.Dependency{})
+	require.NoError(t, err)
+	db, err := sql.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), "loan-svc.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	repoDatastore, err := datastore.New(ctx, datastore.Configuration{}, datastore.Dependency{
+		DB:      struct{ SQLite3 *sql.DB }{SQLite3: db},
+		Keyring: repoKeyring,
+	})
+	require.NoError(t, err)
+
+	borrowerID, loanID := []byte("900"), xid.New().Bytes()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	register := func(userID []byte, pub ed25519.PublicKey) datastore.UserKey {
+		res, err := repoDatastore.Mutation(ctx, datastore.MutationRequest{UserKeys: &datastore.MutationRequestUserKeys{UserKey: datastore.UserKey{
+			KeyID:     keyring.KeyID(pub),
+			UserID:    userID,
+			PublicKey: pub,
+		}}})
+		require.NoError(t, err)
+		return res.UserKeys.UserKey
+	}
+	key := register(borrowerID, pub)
+	other, otherPriv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	otherKey := register([]byte("901"), other)
+
+	doc := []byte("LOAN AGREEMENT (BORROWER)")
+	hash := sha256.Sum256(doc)
+	res, err := repoDatastore.Mutation(ctx, datastore.MutationRequest{Contracts: &datastore.MutationRequestContracts{Contract: &datastore.Contract{
+		ContractID:      xid.New().Bytes(),
+		LoanID:          loanID,
+		UserID:          borrowerID,
+		LoanPartyRoleAs: datastore.RoleAsBorrower,
+		Document:        doc,
+		Hash:            hash[:],
+	}}})
+	require.NoError(t, err)
+	c := res.Contracts.Contract
+	sign := func(keyID []byte, sig []byte) error {
+		_, err := repoDatastore.Mutation(ctx, datastore.MutationRequest{Contracts: &datastore.MutationRequestContracts{Signature: &datastore.ContractSignature{
+			ContractID: c.ContractID,
+			KeyID:      keyID,
+			Signature:  sig,
+		}}})
+		return err
+	}
+	require.NoError(t, sign(otherKey.KeyID, ed25519.Sign(otherPriv, hash[:]))) // not the key of the party
+	require.NoError(t, sign(key.KeyID, ed25519.Sign(priv, hash[:])))
+	require.Error(t, sign(key.KeyID, ed25519.Sign(priv, hash[:]))) // signed once by each key
+
+	verified := func() []bool {
+		res, err := repoDatastore.Query(ctx, datastore.QueryRequest{Contracts: &datastore.QueryRequestContracts{ByLoanID: loanID}})
+		require.NoError(t, err)
+		require.Len(t, res.List, 1)
+		found := []bool{}
+		for _, s := range res.List[0].Contracts.Signatures {
+			found = append(found, s.Verified)
+		}
+		return found
+	}
+	require.Equal(t, []bool{false, true}, verified())
+
+	// a revoked key keeps the signatures accepted beforehand
+	time.Sleep(time.Second)
+	_, err = repoDatastore.Mutation(ctx, datastore.MutationRequest{UserKeys: &datastore.MutationRequestUserKeys{UserKey: key, Revoke: true}})
+	require.NoError(t, err)
+	_, err = repoDatastore.Mutation(ctx, datastore.MutationRequest{UserKeys: &datastore.MutationRequestUserKeys{UserKey: key, Revoke: true}})
+	require.Error(t, err)
+	require.Equal(t, []bool{false, true}, verified())
+
+	// the document is immutable
+	_, err = db.ExecContext(ctx, "UPDATE contracts SET document = ? WHERE contract_id = ?", []byte("tampered"), c.ContractID)
+	require.Error(t, err)
+}
+
+func TestDocuments(t *testing.T) {
+	ctx := pkg.Context.PutSlogLogger(context.Background(), slog.Default())
+	repoKeyring, err := keyring.New(ctx, keyring.Configuration{}, keyring.Dependency{})
+	require.NoError(t, err)
+	db, err := sql.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), "loan-svc.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	repoDatastore, err := datastore.New(ctx, datastore.Configuration{}, datastore.Dependency{
+		DB:      struct{ SQLite3 *sql.DB }{SQLite3: db},
+		Keyring: repoKeyring,
+	})
+	require.NoError(t, err)
+
+	loanID, content := xid.New().Bytes(), []byte("%PDF-1.4")
+	hash := sha256.Sum256(content)
+	doc := datastore.Document{
+		Hash:            hash[:],
+		LoanID:          loanID,
+		UserID:          []byte("900"),
+		Kind:            "borrower_agreement",
+		TemplateVersion: 1,
+		MediaType:       "application/pdf",
+		Content:         content,
+	}
+	store := func(docs ...datastore.Document) error {
+		_, err := repoDatastore.Mutation(ctx, datastore.MutationRequest{Documents: &datastore.MutationRequestDocuments{List: docs}})
+		return err
+	}
+	require.NoError(t, store(doc))
+	require.NoError(t, store(doc)) // stored once by its hash
+
+	tampered := doc
+	tampered.Content = []byte("%PDF-1.5")
+	require.ErrorContains(t, store(tampered), "hash mismatch")
+
+	res, err := repoDatastore.Query(ctx, datastore.QueryRequest{Documents: &datastore.QueryRequestDocuments{ByLoanID: loanID}})
+	require.NoError(t, err)
+	require.Len(t, res.List, 1)
+	require.Equal(t, content, res.List[0].Documents.Content)
+
+	res, err = repoDatastore.Query(ctx, datastore.QueryRequest{Documents: &datastore.QueryRequestDocuments{ByHash: hash[:]}})
+	require.NoError(t, err)
+	require.Len(t, res.List, 1)
+	require.Equal(t, 1, res.List[0].Documents.TemplateVersion)
+}
@@ -1,33 +1,106 @@
 package datastore
 
 import (
+	"bytes"
 	"context"
-	"crypto/ed25519"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"math"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/gunawanwijaya/loan-svc/internal/repository/datastore/queries"
 	"github.com/gunawanwijaya/loan-svc/pkg"
+	"github.com/mattn/go-sqlite3"
+	"github.com/rs/xid"
 )
 
 type MutationRequest struct {
 	List []MutationRequest
 
-	Loans *MutationRequestLoans
+	Loans           *MutationRequestLoans
+	Accruals        *MutationRequestAccruals
+	Provisions      *MutationRequestProvisions
+	Wallets         *MutationRequestWallets
+	Reconciliations *MutationRequestReconciliations
+	Payouts         *MutationRequestPayouts
+	Callbacks       *MutationRequestCallbacks
+	Outbox          *MutationRequestOutbox
+	Webhooks        *MutationRequestWebhooks
+	LoanEvents      *MutationRequestLoanEvents
+	Checkpoints     *MutationRequestCheckpoints
+	UserKeys        *MutationRequestUserKeys
+	Contracts       *MutationRequestContracts
+	Documents       *MutationRequestDocuments
 }
 
 type MutationResponse struct {
 	List []MutationResponse
 
-	Loans *MutationResponseLoans
+	Loans           *MutationResponseLoans
+	Accruals        *MutationResponseAccruals
+	Provisions      *MutationResponseProvisions
+	Wallets         *MutationResponseWallets
+	Reconciliations *MutationResponseReconciliations
+	Payouts         *MutationResponsePayouts
+	Callbacks       *MutationResponseCallbacks
+	Outbox          *MutationResponseOutbox
+	Webhooks        *MutationResponseWebhooks
+	LoanEvents      *MutationResponseLoanEvents
+	Checkpoints     *MutationResponseCheckpoints
+	UserKeys        *MutationResponseUserKeys
+	Contracts       *MutationResponseContracts
+	Documents       *MutationResponseDocuments
 }
 
 func (x *datastore) Mutation(ctx context.Context, req MutationRequest) (res MutationResponse, err error) {
 	if req.Loans != nil {
 		return x.mutationLoans(ctx, req)
 	}
+	if req.Accruals != nil {
+		return x.mutationAccruals(ctx, req)
+	}
+	if req.Provisions != nil {
+		return x.mutationProvisions(ctx, req)
+	}
+	if req.Wallets != nil {
+		return x.mutationWallets(ctx, req)
+	}
+	if req.Reconciliations != nil {
+		return x.mutationReconciliations(ctx, req)
+	}
+	if req.Payouts != nil {
+		return x.mutationPayouts(ctx, req)
+	}
+	if req.Callbacks != nil {
+		return x.mutationCallbacks(ctx, req)
+	}
+	if req.Outbox != nil {
+		return x.mutationOutbox(ctx, req)
+	}
+	if req.Webhooks != nil {
+		return x.mutationWebhooks(ctx, req)
+	}
+	if req.LoanEvents != nil {
+		return x.mutationLoanEvents(ctx, req)
+	}
+	if req.Checkpoints != nil {
+		return x.mutationCheckpoints(ctx, req)
+	}
+	if req.UserKeys != nil {
+		return x.mutationUserKeys(ctx, req)
+	}
+	if req.Contracts != nil {
+		return x.mutationContracts(ctx, req)
+	}
+	if req.Documents != nil {
+		return x.mutationDocuments(ctx, req)
+	}
 	return
 }
 
@@ -36,9 +109,37 @@ func (x *datastore) mutationLoans(ctx context.Context, req MutationRequest) (res
 	var exec sql.Result
 	db := x.Dependency.DB.SQLite3
 	conn, err := db.Conn(ctx)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
 	tx, err := conn.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return
+	}
 
+	now := time.Now().Unix()
+	sig := x.sign(now)
+	var loans auditedLoans
 	defer func() {
+		if e, ok := loanEvent(req.Loans.Loan); ok && err == nil {
+			err = x.appendLoanEvent(ctx, tx, now, sig, e)
+			if err == nil {
+				err = loans.audit(ctx, x, tx, "loans."+req.Loans.Loan.LoanState.String())
+			}
+		}
+		if err == nil {
+			err = x.applyWallets(ctx, tx, now, sig, req.Loans.Wallets)
+		}
+		if err == nil {
+			err = x.postJournals(ctx, tx, req.Loans.Journals)
+		}
+		if err == nil {
+			err = x.createPayouts(ctx, tx, now, sig, req.Loans.Payouts)
+		}
+		if err == nil {
+			err = x.writeOutbox(ctx, tx, now, sig, loanEvents(req.Loans.Loan)...)
+		}
 		if err != nil {
 			log.ErrorContext(ctx, "repository/datastore.mutationLoans "+req.Loans.Loan.LoanState.String(),
 				slog.Any("err", err),
@@ -60,73 +161,1548 @@ func (x *datastore) mutationLoans(ctx context.Context, req MutationRequest) (res
 		}
 	}()
 
-	now := time.Now().Unix()
-	msg := []byte(fmt.Sprint(now))
-	sig := append(x.Dependency.PublicKey, ed25519.Sign(x.Dependency.PrivateKey, msg)...)
+	if err = loans.touch(ctx, tx, req.Loans.Loan.LoanID); err != nil {
+		return
+	}
+	for i := range req.Loans.Journals {
+		req.Loans.Journals[i].CreatedAt = now
+		req.Loans.Journals[i].CreatedSign = sig
+		if req.Loans.Journals[i], err = req.Loans.Journals[i].Validate(ctx); err != nil {
+			return
+		}
+	}
+	if required, ok := map[LoanState]LoanState{
+		StateApproved:  StateProposed,
+		StateInvested:  StateApproved,
+		StateDisbursed: StateInvested,
+	}[req.Loans.Loan.LoanState]; ok {
+		// the UPDATE below match zero rows on other state, check it beforehand so that no journal is posted
+		var state LoanState
+		if err = tx.QueryRowContext(ctx, queries.LoanSvc.SQLite3.QueryLoanState(), req.Loans.Loan.LoanID).Scan(&state); err != nil {
+			return
+		}
+		if state != required {
+			err = fmt.Errorf("repository/datastore: loan is %s, required %s", state, required)
+			return
+		}
+		// a loan projected before the events were recorded is imported as is, before the event of the transition
+		if _, err = x.importLoan(ctx, tx, now, sig, req.Loans.Loan.LoanID); err != nil {
+			return
+		}
+	}
 
 	switch req.Loans.Loan.LoanState {
 	default:
 		return
 	case StateProposed:
 		req.Loans.Loan.CreatedAt = now
-		req.Loans.Loan.CreatedSign = sig
+		req.Loans.Loan.CreatedSignVersion = SignCanonical
+		req.Loans.Loan.CreatedSign = x.signContent(loanCreated(req.Loans.Loan))
+		x.signParties(now, &req.Loans.Loan)
+		var chain paymentChain
+		if chain, err = paymentHead(ctx, tx, req.Loans.Loan.LoanID); err != nil {
+			return
+		}
+		_, err = tx.ExecContext(ctx, queries.LoanSvc.SQLite3.MutationLoanProposed(),
+			req.Loans.Loan.LoanID, req.Loans.Loan.LoanState, req.Loans.Loan.APR, req.Loans.Loan.EffectiveRate, req.Loans.Loan.PaymentReference, req.Loans.Loan.CreatedAt, req.Loans.Loan.CreatedSign, int(req.Loans.Loan.CreatedSignVersion),
+		)
+		if sqliteErr := (sqlite3.Error{}); errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique &&
+			strings.Contains(sqliteErr.Error(), "loans.payment_reference") {
+			err = &PaymentReferenceConflictError{PaymentReference: *req.Loans.Loan.PaymentReference}
+		}
+		if err != nil {
+			return res, err
+		}
 		for i := range req.Loans.Parties {
-			req.Loans.Loan.Parties[i].CreatedAt = now
-			req.Loans.Loan.Parties[i].CreatedSign = sig
 			for j := range req.Loans.Loan.Parties[i].Payments {
-				req.Loans.Loan.Parties[i].Payments[j].CreatedAt = now
-				req.Loans.Loan.Parties[i].Payments[j].CreatedSign = sig
-				exec, err = tx.ExecContext(ctx, queries.LoanSvc.SQLite3.MutationLoanProposed(),
-					req.Loans.Loan.LoanID, req.Loans.Loan.LoanState, req.Loans.Loan.CreatedAt, req.Loans.Loan.CreatedSign,
-					req.Loans.Loan.Parties[i].LoanPartyID, req.Loans.Loan.LoanID, req.Loans.Loan.Parties[i].UserID, int(req.Loans.Loan.Parties[i].LoanPartyRoleAs), req.Loans.Loan.Parties[i].CreatedAt, req.Loans.Loan.Parties[i].CreatedSign,
-					req.Loans.Loan.Parties[i].LoanPartyID, req.Loans.Loan.Parties[i].Payments[j].ISO4217, req.Loans.Loan.Parties[i].Payments[j].Amount, req.Loans.Loan.Parties[i].Payments[j].Time, req.Loans.Loan.Parties[i].Payments[j].Details, req.Loans.Loan.Parties[i].Payments[j].CreatedAt, req.Loans.Loan.Parties[i].Payments[j].CreatedSign,
+				chain.link(req.Loans.Loan.LoanID, req.Loans.Loan.Parties[i].LoanPartyID, &req.Loans.Loan.Parties[i].Payments[j])
+				exec, err = tx.ExecContext(ctx, queries.LoanSvc.SQLite3.MutationLoanProposedPayment(),
+					req.Loans.Loan.Parties[i].LoanPartyID, req.Loans.Loan.LoanID, req.Loans.Loan.Parties[i].UserID, int(req.Loans.Loan.Parties[i].LoanPartyRoleAs), req.Loans.Loan.Parties[i].CreatedAt, req.Loans.Loan.Parties[i].CreatedSign, int(req.Loans.Loan.Parties[i].CreatedSignVersion),
+					req.Loans.Loan.Parties[i].LoanPartyID, req.Loans.Loan.Parties[i].Payments[j].PaymentID, int(req.Loans.Loan.Parties[i].Payments[j].PaymentType), req.Loans.Loan.Parties[i].Payments[j].ISO4217, req.Loans.Loan.Parties[i].Payments[j].Amount, req.Loans.Loan.Parties[i].Payments[j].Time, req.Loans.Loan.Parties[i].Payments[j].Details, req.Loans.Loan.Parties[i].Payments[j].CreatedAt, req.Loans.Loan.Parties[i].Payments[j].CreatedSign, int(req.Loans.Loan.Parties[i].Payments[j].CreatedSignVersion),
+					req.Loans.Loan.Parties[i].Payments[j].ChainSequence, req.Loans.Loan.Parties[i].Payments[j].PrevHash, req.Loans.Loan.Parties[i].Payments[j].PrevLoanHash, req.Loans.Loan.Parties[i].Payments[j].Hash,
 				)
 				if err != nil {
 					return res, err
 				}
+				if err = chain.advance(exec, &req.Loans.Loan.Parties[i].Payments[j]); err != nil {
+					return res, err
+				}
 			}
 		}
 	case StateApproved:
 		req.Loans.Loan.ApprovedAt = &now
-		req.Loans.Loan.ApprovedSign = sig
+		req.Loans.Loan.ApprovedSignVersion = SignCanonical
+		req.Loans.Loan.ApprovedSign = x.signContent(loanApproved(req.Loans.Loan))
 		exec, err = tx.ExecContext(ctx, queries.LoanSvc.SQLite3.MutationLoanApproved(),
-			req.Loans.Loan.LoanState, req.Loans.Loan.ApprovedBy, req.Loans.Loan.ApprovedDoc, req.Loans.Loan.ApprovedAt, req.Loans.Loan.ApprovedSign,
+			req.Loans.Loan.LoanState, req.Loans.Loan.ApprovedBy, req.Loans.Loan.ApprovedDoc, req.Loans.Loan.ApprovedAt, req.Loans.Loan.ApprovedSign, int(req.Loans.Loan.ApprovedSignVersion),
 			req.Loans.Loan.LoanID, StateProposed, // required StateProposed
 		)
 	case StateInvested:
 		log.DebugContext(ctx, "req.Loans.Parties",
 			slog.Any("req.Loans.Parties", req.Loans.Parties),
 		)
+		x.signParties(now, &req.Loans.Loan)
+		var chain paymentChain
+		if chain, err = paymentHead(ctx, tx, req.Loans.Loan.LoanID); err != nil {
+			return
+		}
 		for i := range req.Loans.Parties {
-			req.Loans.Loan.Parties[i].CreatedAt = now
-			req.Loans.Loan.Parties[i].CreatedSign = sig
 			for j := range req.Loans.Loan.Parties[i].Payments {
-				req.Loans.Loan.Parties[i].Payments[j].CreatedAt = now
-				req.Loans.Loan.Parties[i].Payments[j].CreatedSign = sig
+				chain.link(req.Loans.Loan.LoanID, req.Loans.Loan.Parties[i].LoanPartyID, &req.Loans.Loan.Parties[i].Payments[j])
 				exec, err = tx.ExecContext(ctx, queries.LoanSvc.SQLite3.MutationLoanInvested(),
 					req.Loans.Loan.LoanState, req.Loans.Loan.LoanID, StateApproved, // required StateApproved
-					req.Loans.Loan.Parties[i].LoanPartyID, req.Loans.Loan.LoanID, req.Loans.Loan.Parties[i].UserID, int(req.Loans.Loan.Parties[i].LoanPartyRoleAs), req.Loans.Loan.Parties[i].CreatedAt, req.Loans.Loan.Parties[i].CreatedSign,
-					req.Loans.Loan.Parties[i].LoanPartyID, req.Loans.Loan.Parties[i].Payments[j].ISO4217, req.Loans.Loan.Parties[i].Payments[j].Amount, req.Loans.Loan.Parties[i].Payments[j].Time, req.Loans.Loan.Parties[i].Payments[j].Details, req.Loans.Loan.Parties[i].Payments[j].CreatedAt, req.Loans.Loan.Parties[i].Payments[j].CreatedSign,
+					req.Loans.Loan.Parties[i].LoanPartyID, req.Loans.Loan.LoanID, req.Loans.Loan.Parties[i].UserID, int(req.Loans.Loan.Parties[i].LoanPartyRoleAs), req.Loans.Loan.Parties[i].CreatedAt, req.Loans.Loan.Parties[i].CreatedSign, int(req.Loans.Loan.Parties[i].CreatedSignVersion),
+					req.Loans.Loan.Parties[i].LoanPartyID, req.Loans.Loan.Parties[i].Payments[j].PaymentID, int(req.Loans.Loan.Parties[i].Payments[j].PaymentType), req.Loans.Loan.Parties[i].Payments[j].ISO4217, req.Loans.Loan.Parties[i].Payments[j].Amount, req.Loans.Loan.Parties[i].Payments[j].Time, req.Loans.Loan.Parties[i].Payments[j].Details, req.Loans.Loan.Parties[i].Payments[j].CreatedAt, req.Loans.Loan.Parties[i].Payments[j].CreatedSign, int(req.Loans.Loan.Parties[i].Payments[j].CreatedSignVersion),
+					req.Loans.Loan.Parties[i].Payments[j].ChainSequence, req.Loans.Loan.Parties[i].Payments[j].PrevHash, req.Loans.Loan.Parties[i].Payments[j].PrevLoanHash, req.Loans.Loan.Parties[i].Payments[j].Hash,
 				)
 				if err != nil {
 					return res, err
 				}
+				if err = chain.advance(exec, &req.Loans.Loan.Parties[i].Payments[j]); err != nil {
+					return res, err
+				}
 			}
 		}
 	case StateDisbursed:
 		req.Loans.Loan.DisbursedAt = &now
-		req.Loans.Loan.DisbursedSign = sig
+		req.Loans.Loan.DisbursedSignVersion = SignCanonical
+		req.Loans.Loan.DisbursedSign = x.signContent(loanDisbursed(req.Loans.Loan))
 		exec, err = tx.ExecContext(ctx, queries.LoanSvc.SQLite3.MutationLoanDisbursed(),
-			req.Loans.Loan.LoanState, req.Loans.Loan.DisbursedBy, req.Loans.Loan.DisbursedDoc, req.Loans.Loan.DisbursedAt, req.Loans.Loan.DisbursedSign,
+			req.Loans.Loan.LoanState, req.Loans.Loan.DisbursedBy, req.Loans.Loan.DisbursedDoc, req.Loans.Loan.DisbursedAt, req.Loans.Loan.DisbursedSign, int(req.Loans.Loan.DisbursedSignVersion),
 			req.Loans.Loan.LoanID, StateInvested, // required StateInvested
 		)
 	}
 	return
 }
 
+// signParties will sign the content of each party & payment being inserted along with their parent IDs.
+func (x *datastore) signParties(now int64, l *Loan) {
+	for i := range l.Parties {
+		p := &l.Parties[i]
+		p.CreatedAt, p.CreatedSignVersion = now, SignCanonical
+		p.CreatedSign = x.signContent(loanParty(l.LoanID, *p))
+		for j := range p.Payments {
+			pp := &p.Payments[j]
+			pp.CreatedAt, pp.CreatedSignVersion = now, SignCanonical
+			pp.CreatedSign = x.signContent(loanPartyPayment(l.LoanID, p.LoanPartyID, *pp))
+		}
+	}
+}
+
 type MutationRequestLoans struct {
 	Loan
+	Journals []Journal           // posted in the same transaction of the loan
+	Wallets  []WalletEntry       // applied in the same transaction of the loan
+	Payouts  []PayoutInstruction // created as pending in the same transaction of the loan
+}
+
+// postJournals will write the journals & verify the balance of the written postings before commit.
+func (x *datastore) postJournals(ctx context.Context, tx *sql.Tx, journals []Journal) (err error) {
+	for _, j := range journals {
+		if _, err = tx.ExecContext(ctx, queries.LoanSvc.SQLite3.MutationLedgerJournal(),
+			j.JournalID, j.LoanID, j.Description, j.CreatedAt, j.CreatedSign,
+		); err != nil {
+			return
+		}
+		for _, p := range j.Postings {
+			if _, err = tx.ExecContext(ctx, queries.LoanSvc.SQLite3.MutationLedgerPosting(),
+				j.JournalID, int(p.LedgerAccount), p.UserID, p.ISO4217, p.Debit, p.Credit,
+			); err != nil {
+				return
+			}
+		}
+
+		var rows *sql.Rows
+		if rows, err = tx.QueryContext(ctx, queries.LoanSvc.SQLite3.QueryLedgerJournalBalance(), j.JournalID); err != nil {
+			return
+		}
+		for rows.Next() {
+			var iso4217 string
+			var debit, credit float64
+			if err = rows.Scan(&iso4217, &debit, &credit); err != nil {
+				break
+			}
+			if math.Abs(debit-credit) > balanceTolerance {
+				err = &UnbalancedJournalError{ISO4217: iso4217, Debit: debit, Credit: credit}
+				break
+			}
+		}
+		if err = errors.Join(err, rows.Err(), rows.Close()); err != nil {
+			return
+		}
+	}
+	return
 }
+
 type MutationResponseLoans struct {
 	Loan
 }
+
+func (x *datastore) mutationAccruals(ctx context.Context, req MutationRequest) (res MutationResponse, err error) {
+	log := pkg.Context.SlogLogger(ctx)
+	db := x.Dependency.DB.SQLite3
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	tx, err := conn.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return
+	}
+
+	var ra int64
+	defer func() {
+		if err == nil {
+			err = x.auditMutation(ctx, tx, "accruals", req.Accruals)
+		}
+		if err != nil {
+			log.ErrorContext(ctx, "repository/datastore.mutationAccruals",
+				slog.Any("err", err),
+			)
+			_ = tx.Rollback()
+			res.Accruals = nil
+			return
+		}
+		log.DebugContext(ctx, "repository/datastore.mutationAccruals",
+			slog.Int64("ra", ra),
+		)
+		if err = tx.Commit(); err == nil {
+			res.Accruals = &MutationResponseAccruals{List: req.Accruals.List}
+		}
+	}()
+
+	now := time.Now().Unix()
+	sig := x.sign(now)
+	for i := range req.Accruals.List {
+		req.Accruals.List[i].CreatedAt = now
+		req.Accruals.List[i].CreatedSign = sig
+		var exec sql.Result
+		exec, err = tx.ExecContext(ctx, queries.LoanSvc.SQLite3.MutationLoanAccrued(),
+			req.Accruals.List[i].LoanID, req.Accruals.List[i].AccrualDate, req.Accruals.List[i].DayCount,
+			req.Accruals.List[i].ISO4217, req.Accruals.List[i].Amount, req.Accruals.List[i].Accrued,
+			req.Accruals.List[i].CreatedAt, req.Accruals.List[i].CreatedSign,
+		)
+		if err != nil {
+			return
+		}
+		var n int64
+		if n, err = exec.RowsAffected(); err != nil {
+			return
+		}
+		ra += n
+	}
+	return
+}
+
+type MutationRequestAccruals struct {
+	List []LoanAccrual
+}
+type MutationResponseAccruals struct {
+	List []LoanAccrual
+}
+
+// mutationProvisions will write the snapshot once, the second snapshot of the same day will fail.
+func (x *datastore) mutationProvisions(ctx context.Context, req MutationRequest) (res MutationResponse, err error) {
+	log := pkg.Context.SlogLogger(ctx)
+	db := x.Dependency.DB.SQLite3
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	tx, err := conn.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return
+	}
+
+	defer func() {
+		if err == nil {
+			err = x.auditMutation(ctx, tx, "provisions", req.Provisions)
+		}
+		if err != nil {
+			log.ErrorContext(ctx, "repository/datastore.mutationProvisions",
+				slog.Any("err", err),
+			)
+			_ = tx.Rollback()
+			res.Provisions = nil
+			return
+		}
+		if err = tx.Commit(); err == nil {
+			res.Provisions = &MutationResponseProvisions{ProvisionSnapshot: req.Provisions.ProvisionSnapshot}
+		}
+	}()
+
+	now := time.Now().Unix()
+	snapshot := &req.Provisions.ProvisionSnapshot
+	snapshot.CreatedAt = now
+	snapshot.CreatedSign = x.sign(now)
+	if _, err = tx.ExecContext(ctx, queries.LoanSvc.SQLite3.MutationProvisionSnapshot(),
+		snapshot.SnapshotID, snapshot.AsOf, snapshot.CreatedAt, snapshot.CreatedSign,
+	); err != nil {
+		return
+	}
+	for _, l := range snapshot.Loans {
+		if _, err = tx.ExecContext(ctx, queries.LoanSvc.SQLite3.MutationProvisionSnapshotLoan(),
+			snapshot.SnapshotID, l.LoanID, l.ISO4217, l.DaysPastDue, l.Stage,
+			l.ProbabilityOfDefault, l.LossGivenDefault, l.ExposureAtDefault, l.ExpectedCreditLoss,
+		); err != nil {
+			return
+		}
+	}
+	for _, l := range snapshot.Lenders {
+		if _, err = tx.ExecContext(ctx, queries.LoanSvc.SQLite3.MutationProvisionSnapshotLender(),
+			snapshot.SnapshotID, l.UserID, l.LoanID, l.ISO4217, l.Share, l.ExposureAtDefault, l.ExpectedCreditLoss,
+		); err != nil {
+			return
+		}
+	}
+	return
+}
+
+type MutationRequestProvisions struct {
+	ProvisionSnapshot
+}
+type MutationResponseProvisions struct {
+	ProvisionSnapshot
+}
+
+// mutationWallets will apply the entries & post the journals in a single transaction, each entry is rejected
+// when the resulting balance is negative.
+func (x *datastore) mutationWallets(ctx context.Context, req MutationRequest) (res MutationResponse, err error) {
+	log := pkg.Context.SlogLogger(ctx)
+	db := x.Dependency.DB.SQLite3
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	tx, err := conn.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return
+	}
+
+	defer func() {
+		if err == nil {
+			err = x.auditMutation(ctx, tx, "wallets", req.Wallets)
+		}
+		if err != nil {
+			log.ErrorContext(ctx, "repository/datastore.mutationWallets",
+				slog.Any("err", err),
+			)
+			_ = tx.Rollback()
+			res.Wallets = nil
+			return
+		}
+		if err = tx.Commit(); err == nil {
+			res.Wallets = &MutationResponseWallets{}
+		}
+	}()
+
+	now := time.Now().Unix()
+	sig := x.sign(now)
+	for i := range req.Wallets.Journals {
+		req.Wallets.Journals[i].CreatedAt = now
+		req.Wallets.Journals[i].CreatedSign = sig
+		if req.Wallets.Journals[i], err = req.Wallets.Journals[i].Validate(ctx); err != nil {
+			return
+		}
+	}
+	if err = x.applyWallets(ctx, tx, now, sig, req.Wallets.List); err != nil {
+		return
+	}
+	if err = x.postJournals(ctx, tx, req.Wallets.Journals); err != nil {
+		return
+	}
+	for _, entry := range req.Wallets.List {
+		var w Wallet
+		if err = tx.QueryRowContext(ctx, queries.LoanSvc.SQLite3.QueryWallet(), entry.UserID, entry.ISO4217, entry.ISO4217).
+			Scan(&w.UserID, &w.ISO4217, &w.Available, &w.Reserved, &w.UpdatedAt, &w.UpdatedSign); err != nil {
+			return
+		}
+		if err = x.writeOutbox(ctx, tx, now, sig, walletEvents(entry, w)...); err != nil {
+			return
+		}
+		res.List = append(res.List, MutationResponse{Wallets: &MutationResponseWallets{Wallet: w}})
+	}
+	return
+}
+
+// applyWallets will add each entry into the wallet balance, creating the wallet on the first entry.
+func (x *datastore) applyWallets(ctx context.Context, tx *sql.Tx, now int64, sig []byte, entries []WalletEntry) (err error) {
+	for _, entry := range entries {
+		var available, reserved float64
+		err = tx.QueryRowContext(ctx, queries.LoanSvc.SQLite3.QueryWallet(), entry.UserID, entry.ISO4217, entry.ISO4217).
+			Scan(new([]byte), new(string), &available, &reserved, new(int64), new([]byte))
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		if available+entry.Available < -balanceTolerance {
+			return &InsufficientBalanceError{ISO4217: entry.ISO4217, Balance: available, Required: -entry.Available}
+		}
+		if reserved+entry.Reserved < -balanceTolerance {
+			return &InsufficientBalanceError{ISO4217: entry.ISO4217, Balance: reserved, Required: -entry.Reserved}
+		}
+		if _, err = tx.ExecContext(ctx, queries.LoanSvc.SQLite3.MutationWallet(),
+			entry.UserID, entry.ISO4217, now, sig,
+			entry.Available, entry.Reserved, now, sig,
+			entry.UserID, entry.ISO4217,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type MutationRequestWallets struct {
+	List     []WalletEntry
+	Journals []Journal // posted in the same transaction of the entries
+}
+type MutationResponseWallets struct {
+	Wallet
+}
+
+// mutationReconciliations will import the settled transactions, a transaction with known bank reference is ignored,
+// then record the matches while settling the matched payments.
+func (x *datastore) mutationReconciliations(ctx context.Context, req MutationRequest) (res MutationResponse, err error) {
+	log := pkg.Context.SlogLogger(ctx)
+	db := x.Dependency.DB.SQLite3
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	tx, err := conn.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return
+	}
+
+	var imported []SettledTransaction
+	var loans auditedLoans
+	defer func() {
+		if err == nil && len(imported) > 0 {
+			err = x.auditMutation(ctx, tx, "reconciliations.transactions", imported)
+		}
+		if err == nil {
+			err = loans.audit(ctx, x, tx, "reconciliations.matches")
+		}
+		if err != nil {
+			log.ErrorContext(ctx, "repository/datastore.mutationReconciliations",
+				slog.Any("err", err),
+			)
+			_ = tx.Rollback()
+			res.Reconciliations = nil
+			return
+		}
+		log.DebugContext(ctx, "repository/datastore.mutationReconciliations",
+			slog.Int("imported", len(imported)),
+			slog.Int("matches", len(req.Reconciliations.Matches)),
+		)
+		if err = tx.Commit(); err == nil {
+			res.Reconciliations = &MutationResponseReconciliations{
+				Imported:     int64(len(imported)),
+				Transactions: imported,
+				Matches:      req.Reconciliations.Matches,
+			}
+		}
+	}()
+
+	now := time.Now().Unix()
+	sig := x.sign(now)
+	for _, t := range req.Reconciliations.Transactions {
+		// the loan is kept only when exist, nothing is returned when the bank reference is known
+		if err = tx.QueryRowContext(ctx, queries.LoanSvc.SQLite3.MutationSettledTransaction(),
+			t.TransactionID, t.BankReference, t.Reference, t.ISO4217, t.Amount, t.ValueTime, t.Counterparty, t.Source,
+			t.LoanID, t.PaymentReference, now, sig,
+		).Scan(&t.LoanID); errors.Is(err, sql.ErrNoRows) {
+			err = nil
+			continue
+		} else if err != nil {
+			return
+		}
+		t.CreatedAt, t.CreatedSign = now, sig
+		imported = append(imported, t)
+	}
+	for i := range req.Reconciliations.Matches {
+		m := &req.Reconciliations.Matches[i]
+		m.CreatedAt, m.CreatedSign = now, sig
+		var loanID []byte
+		if err = tx.QueryRowContext(ctx, queries.LoanSvc.SQLite3.QueryPaymentLoan(), m.PaymentID).Scan(&loanID); err != nil {
+			return
+		}
+		if err = loans.touch(ctx, tx, loanID); err != nil {
+			return
+		}
+		if _, err = x.importLoan(ctx, tx, now, sig, loanID); err != nil {
+			return
+		}
+		if _, err = tx.ExecContext(ctx, queries.LoanSvc.SQLite3.MutationReconciliationMatch(),
+			m.TransactionID, m.PaymentID, int(m.MatchType), m.AmountDiff, m.DaysDiff, m.CreatedAt, m.CreatedSign,
+			m.Transaction.ValueTime, m.PaymentID,
+		); err != nil {
+			return
+		}
+		settledAt := m.Transaction.ValueTime
+		payload, _ := json.Marshal(LoanPartyPayment{PaymentID: m.PaymentID, SettledAt: &settledAt})
+		if err = x.appendLoanEvent(ctx, tx, now, sig, LoanEvent{
+			EventID:       xid.New().Bytes(),
+			LoanID:        loanID,
+			LoanEventType: LoanPaymentSettled,
+			Payload:       payload,
+		}); err != nil {
+			return
+		}
+		if err = x.writeOutbox(ctx, tx, now, sig, paymentSettledEvent(loanID, *m)); err != nil {
+			return
+		}
+		// the payout of the principal is confirmed once the exported instruction is found on the statement
+		p := PayoutInstruction{PaymentID: m.PaymentID, PayoutStatus: PayoutConfirmed}
+		if err = tx.QueryRowContext(ctx, queries.LoanSvc.SQLite3.MutationPayoutConfirmed(), now, sig, m.PaymentID).
+			Scan(&p.InstructionID, &p.LoanID, &p.BatchID); err == nil {
+			err = x.writeOutbox(ctx, tx, now, sig, payoutEvent(p))
+		} else if errors.Is(err, sql.ErrNoRows) {
+			err = nil
+		}
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+type MutationRequestReconciliations struct {
+	Transactions []SettledTransaction
+	Matches      []ReconciliationMatch // Transaction.ValueTime is used as the time of settlement
+}
+type MutationResponseReconciliations struct {
+	Imported     int64                // number of transactions imported, excluding the known bank reference
+	Transactions []SettledTransaction // imported, LoanID is nil when the loan is unknown
+	Matches      []ReconciliationMatch
+}
+
+// createPayouts will create the payout instructions as pending.
+func (x *datastore) createPayouts(ctx context.Context, tx *sql.Tx, now int64, sig []byte, payouts []PayoutInstruction) (err error) {
+	for _, p := range payouts {
+		if _, err = tx.ExecContext(ctx, queries.LoanSvc.SQLite3.MutationPayoutInstruction(),
+			p.InstructionID, p.LoanID, p.PaymentID, p.ISO4217, p.Amount,
+			p.AccountName, p.AccountNumber, p.BankCode, p.Reference, int(PayoutPending), now, sig, now, sig,
+		); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// payoutTransitions is the status required before moving into the status of the key.
+var payoutTransitions = map[PayoutStatus]PayoutStatus{
+	PayoutExported:  PayoutPending,
+	PayoutConfirmed: PayoutExported,
+	PayoutFailed:    PayoutExported,
+	PayoutPending:   PayoutFailed,
+}
+
+// mutationPayouts will move each payout instruction into its status, all or none are moved when any of the
+// instruction is not on the required status.
+func (x *datastore) mutationPayouts(ctx context.Context, req MutationRequest) (res MutationResponse, err error) {
+	log := pkg.Context.SlogLogger(ctx)
+	db := x.Dependency.DB.SQLite3
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	tx, err := conn.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return
+	}
+
+	defer func() {
+		if err == nil {
+			err = x.auditMutation(ctx, tx, "payouts", req.Payouts)
+		}
+		if err != nil {
+			log.ErrorContext(ctx, "repository/datastore.mutationPayouts",
+				slog.Any("err", err),
+			)
+			_ = tx.Rollback()
+			res.Payouts = nil
+			return
+		}
+		if err = tx.Commit(); err == nil {
+			res.Payouts = &MutationResponsePayouts{List: req.Payouts.List}
+		}
+	}()
+
+	now := time.Now().Unix()
+	sig := x.sign(now)
+	for i := range req.Payouts.List {
+		p := &req.Payouts.List[i]
+		required, ok := payoutTransitions[p.PayoutStatus]
+		if !ok {
+			err = fmt.Errorf("repository/datastore: unknown payout status %d", p.PayoutStatus)
+			return
+		}
+		if p.PayoutStatus == PayoutExported {
+			p.BatchID = req.Payouts.BatchID
+		}
+		if err = tx.QueryRowContext(ctx, queries.LoanSvc.SQLite3.MutationPayoutStatus(),
+			int(p.PayoutStatus), p.StatusReason, p.BatchID, now, sig,
+			p.InstructionID, int(required),
+		).Scan(&p.LoanID, &p.PaymentID, &p.BatchID); errors.Is(err, sql.ErrNoRows) {
+			err = fmt.Errorf("repository/datastore: payout [%s] is not %s", pkg.BtoA(p.InstructionID), required)
+			return
+		} else if err != nil {
+			return
+		}
+		p.UpdatedAt, p.UpdatedSign = now, sig
+		if err = x.writeOutbox(ctx, tx, now, sig, payoutEvent(*p)); err != nil {
+			return
+		}
+	}
+	return
+}
+
+type MutationRequestPayouts struct {
+	BatchID []byte              // recorded on the exported instructions
+	List    []PayoutInstruction // InstructionID, PayoutStatus & StatusReason are used, LoanID & PaymentID are returned
+}
+type MutationResponsePayouts struct {
+	List []PayoutInstruction
+}
+
+// mutationCallbacks will record the gateway callbacks, a callback with known gateway transaction ID & status is
+// ignored and left out of the response.
+func (x *datastore) mutationCallbacks(ctx context.Context, req MutationRequest) (res MutationResponse, err error) {
+	log := pkg.Context.SlogLogger(ctx)
+	db := x.Dependency.DB.SQLite3
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	tx, err := conn.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return
+	}
+
+	recorded := []GatewayCallback{}
+	defer func() {
+		if err == nil {
+			err = x.auditMutation(ctx, tx, "callbacks", req.Callbacks)
+		}
+		if err != nil {
+			log.ErrorContext(ctx, "repository/datastore.mutationCallbacks",
+				slog.Any("err", err),
+			)
+			_ = tx.Rollback()
+			res.Callbacks = nil
+			return
+		}
+		if err = tx.Commit(); err == nil {
+			res.Callbacks = &MutationResponseCallbacks{List: recorded}
+		}
+	}()
+
+	now := time.Now().Unix()
+	sig := x.sign(now)
+	for _, c := range req.Callbacks.List {
+		c.CreatedAt, c.CreatedSign = now, sig
+		var exec sql.Result
+		if exec, err = tx.ExecContext(ctx, queries.LoanSvc.SQLite3.MutationGatewayCallback(),
+			c.CallbackID, c.GatewayTransactionID, int(c.GatewayStatus), c.Reference, c.ISO4217, c.Amount,
+			c.Payload, c.Signature, c.CreatedAt, c.CreatedSign,
+		); err != nil {
+			return
+		}
+		var n int64
+		if n, err = exec.RowsAffected(); err != nil {
+			return
+		}
+		if n == 1 {
+			recorded = append(recorded, c)
+		}
+	}
+	return
+}
+
+type MutationRequestCallbacks struct {
+	List []GatewayCallback
+}
+type MutationResponseCallbacks struct {
+	List []GatewayCallback // only the newly recorded
+}
+
+// loanEvents will describe the transition of the loan as a domain event, the payload only carry what is changed by
+// the transition. Nothing is described for an unknown state.
+func loanEvents(l Loan) []OutboxEvent {
+	state := l.LoanState.String()
+	if state == "" {
+		return nil
+	}
+	type payment struct {
+		PaymentID   []byte  `json:"payment_id"`
+		PaymentType string  `json:"payment_type"`
+		ISO4217     string  `json:"iso4217"`
+		Amount      float64 `json:"amount"`
+		DueTime     int64   `json:"due_time"`
+	}
+	type party struct {
+		UserID   []byte    `json:"user_id"`
+		RoleAs   string    `json:"role_as"`
+		Payments []payment `json:"payments,omitempty"`
+	}
+	payload := struct {
+		LoanID           []byte   `json:"loan_id"`
+		LoanState        string   `json:"loan_state"`
+		Parties          []party  `json:"parties,omitempty"`
+		ApprovedBy       []byte   `json:"approved_by,omitempty"`
+		ApprovedDoc      *string  `json:"approved_doc,omitempty"`
+		DisbursedBy      []byte   `json:"disbursed_by,omitempty"`
+		DisbursedDoc     *string  `json:"disbursed_doc,omitempty"`
+		APR              *float64 `json:"apr,omitempty"`
+		EffectiveRate    *float64 `json:"effective_rate,omitempty"`
+		PaymentReference *string  `json:"payment_reference,omitempty"`
+	}{
+		LoanID:           l.LoanID,
+		LoanState:        state,
+		ApprovedBy:       l.ApprovedBy,
+		ApprovedDoc:      l.ApprovedDoc,
+		DisbursedBy:      l.DisbursedBy,
+		DisbursedDoc:     l.DisbursedDoc,
+		APR:              l.APR,
+		EffectiveRate:    l.EffectiveRate,
+		PaymentReference: l.PaymentReference,
+	}
+	for _, p := range l.Parties {
+		pp := party{UserID: p.UserID, RoleAs: p.LoanPartyRoleAs.String()}
+		for _, lpp := range p.Payments {
+			pp.Payments = append(pp.Payments, payment{
+				PaymentID:   lpp.PaymentID,
+				PaymentType: lpp.PaymentType.String(),
+				ISO4217:     lpp.ISO4217,
+				Amount:      lpp.Amount,
+				DueTime:     lpp.Time,
+			})
+		}
+		payload.Parties = append(payload.Parties, pp)
+	}
+	b, _ := json.Marshal(payload) // marshalling plain values never fails
+	return []OutboxEvent{{
+		EventID:     xid.New().Bytes(),
+		EventType:   "loan." + state,
+		AggregateID: l.LoanID,
+		Payload:     b,
+	}}
+}
+
+// paymentSettledEvent will describe the settlement of a payment of the loan by the matched transaction, settled at
+// the value date of the transaction.
+func paymentSettledEvent(loanID []byte, m ReconciliationMatch) OutboxEvent {
+	b, _ := json.Marshal(struct {
+		LoanID        []byte  `json:"loan_id"`
+		PaymentID     []byte  `json:"payment_id"`
+		TransactionID []byte  `json:"transaction_id"`
+		MatchType     string  `json:"match_type"`
+		ISO4217       string  `json:"iso4217"`
+		Amount        float64 `json:"amount"`
+		SettledAt     int64   `json:"settled_at"`
+	}{
+		LoanID:        loanID,
+		PaymentID:     m.PaymentID,
+		TransactionID: m.TransactionID,
+		MatchType:     m.MatchType.String(),
+		ISO4217:       m.Transaction.ISO4217,
+		Amount:        m.Transaction.Amount,
+		SettledAt:     m.Transaction.ValueTime,
+	})
+	return OutboxEvent{
+		EventID:     xid.New().Bytes(),
+		EventType:   "loan.payment_settled",
+		AggregateID: loanID,
+		Payload:     b,
+	}
+}
+
+// payoutEvent will describe the status of the payout instruction, the loan is the aggregate as it is paid once.
+func payoutEvent(p PayoutInstruction) OutboxEvent {
+	b, _ := json.Marshal(struct {
+		InstructionID []byte `json:"instruction_id"`
+		LoanID        []byte `json:"loan_id"`
+		PaymentID     []byte `json:"payment_id"`
+		PayoutStatus  string `json:"payout_status"`
+		StatusReason  string `json:"status_reason,omitempty"`
+		BatchID       []byte `json:"batch_id,omitempty"`
+	}{
+		InstructionID: p.InstructionID,
+		LoanID:        p.LoanID,
+		PaymentID:     p.PaymentID,
+		PayoutStatus:  p.PayoutStatus.String(),
+		StatusReason:  p.StatusReason,
+		BatchID:       p.BatchID,
+	})
+	return OutboxEvent{
+		EventID:     xid.New().Bytes(),
+		EventType:   "payout." + p.PayoutStatus.String(),
+		AggregateID: p.LoanID,
+		Payload:     b,
+	}
+}
+
+// walletEvents will describe the entry of the available balance as a deposit or a withdrawal along with the
+// balance after the entry. Nothing is described when the available balance is unchanged.
+func walletEvents(entry WalletEntry, w Wallet) []OutboxEvent {
+	eventType := "wallet.deposited"
+	switch {
+	case entry.Available == 0:
+		return nil
+	case entry.Available < 0:
+		eventType = "wallet.withdrawn"
+	}
+	b, _ := json.Marshal(struct {
+		UserID    []byte  `json:"user_id"`
+		ISO4217   string  `json:"iso4217"`
+		Amount    float64 `json:"amount"`
+		Available float64 `json:"available"`
+	}{
+		UserID:    entry.UserID,
+		ISO4217:   entry.ISO4217,
+		Amount:    math.Abs(entry.Available),
+		Available: w.Available,
+	})
+	return []OutboxEvent{{
+		EventID:     xid.New().Bytes(),
+		EventType:   eventType,
+		AggregateID: entry.UserID,
+		Payload:     b,
+	}}
+}
+
+// writeOutbox will write the events in the transaction of the mutation, so that an event exists if and only if
+// the mutation is committed.
+func (x *datastore) writeOutbox(ctx context.Context, tx *sql.Tx, now int64, sig []byte, events ...OutboxEvent) (err error) {
+	for _, e := range events {
+		if _, err = tx.ExecContext(ctx, queries.LoanSvc.SQLite3.MutationOutboxEvent(),
+			e.EventID, e.EventType, e.AggregateID, e.Payload, now, sig,
+		); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// mutationOutbox will mark the events as published, an event already published keep its first publish time.
+func (x *datastore) mutationOutbox(ctx context.Context, req MutationRequest) (res MutationResponse, err error) {
+	log := pkg.Context.SlogLogger(ctx)
+	db := x.Dependency.DB.SQLite3
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	tx, err := conn.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return
+	}
+
+	var published int64
+	defer func() {
+		if err == nil {
+			err = x.auditMutation(ctx, tx, "outbox", req.Outbox)
+		}
+		if err != nil {
+			log.ErrorContext(ctx, "repository/datastore.mutationOutbox",
+				slog.Any("err", err),
+			)
+			_ = tx.Rollback()
+			res.Outbox = nil
+			return
+		}
+		if err = tx.Commit(); err == nil {
+			res.Outbox = &MutationResponseOutbox{Published: published}
+		}
+	}()
+
+	now := time.Now().Unix()
+	for _, e := range req.Outbox.List {
+		var exec sql.Result
+		if exec, err = tx.ExecContext(ctx, queries.LoanSvc.SQLite3.MutationOutboxPublished(), now, e.EventID); err != nil {
+			return
+		}
+		var n int64
+		if n, err = exec.RowsAffected(); err != nil {
+			return
+		}
+		published += n
+	}
+	return
+}
+
+type MutationRequestOutbox struct {
+	List []OutboxEvent // EventID is used
+}
+type MutationResponseOutbox struct {
+	Published int64 // number of events marked as published, excluding the already published
+}
+
+// mutationWebhooks will save the subscriptions, create the deliveries of an event not yet known to the subscription,
+// record the attempts of pending deliveries & move the dead deliveries back to pending. All or none are saved when
+// any attempt is not on a pending delivery or any replay is not on a dead delivery.
+func (x *datastore) mutationWebhooks(ctx context.Context, req MutationRequest) (res MutationResponse, err error) {
+	log := pkg.Context.SlogLogger(ctx)
+	db := x.Dependency.DB.SQLite3
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	tx, err := conn.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return
+	}
+
+	r := &MutationResponseWebhooks{}
+	defer func() {
+		if err == nil {
+			err = x.auditMutation(ctx, tx, "webhooks", req.Webhooks.redacted())
+		}
+		if err != nil {
+			log.ErrorContext(ctx, "repository/datastore.mutationWebhooks",
+				slog.Any("err", err),
+			)
+			_ = tx.Rollback()
+			res.Webhooks = nil
+			return
+		}
+		if err = tx.Commit(); err == nil {
+			res.Webhooks = r
+		}
+	}()
+
+	now := time.Now().Unix()
+	sig := x.sign(now)
+	affected := func(exec sql.Result, err error) (int64, error) {
+		if err != nil {
+			return 0, err
+		}
+		return exec.RowsAffected()
+	}
+	var n int64
+	for _, s := range req.Webhooks.Subscriptions {
+		if s.CreatedAt == 0 { // kept on a known SubscriptionID
+			s.CreatedAt, s.CreatedSign = now, sig
+		}
+		s.UpdatedAt, s.UpdatedSign = now, sig
+		if _, err = tx.ExecContext(ctx, queries.LoanSvc.SQLite3.MutationWebhookSubscription(),
+			s.SubscriptionID, s.URL, s.EventTypes, s.Secret, s.Active, now, sig, now, sig,
+		); err != nil {
+			return
+		}
+		r.Subscriptions = append(r.Subscriptions, s)
+	}
+	for _, d := range req.Webhooks.Deliveries {
+		d.WebhookStatus, d.Attempts = WebhookPending, 0
+		d.NextAttemptAt = pkg.OrElse(d.NextAttemptAt > 0, d.NextAttemptAt, now)
+		d.CreatedAt, d.CreatedSign, d.UpdatedAt, d.UpdatedSign = now, sig, now, sig
+		if n, err = affected(tx.ExecContext(ctx, queries.LoanSvc.SQLite3.MutationWebhookDelivery(),
+			d.DeliveryID, d.SubscriptionID, d.EventID, d.EventType, d.Payload, d.NextAttemptAt, now, sig, now, sig,
+		)); err != nil {
+			return
+		}
+		if n == 1 {
+			r.Deliveries = append(r.Deliveries, d)
+		}
+	}
+	for _, a := range req.Webhooks.Attempts {
+		if a.WebhookStatus.String() == "" {
+			err = fmt.Errorf("repository/datastore: unknown webhook status %d", a.WebhookStatus)
+			return
+		}
+		a.CreatedAt, a.CreatedSign = now, sig
+		if n, err = affected(tx.ExecContext(ctx, queries.LoanSvc.SQLite3.MutationWebhookAttempt(),
+			a.DeliveryID, a.Attempt, int(a.WebhookStatus), a.StatusCode, a.Error, a.DurationMS, a.NextAttemptAt, now, sig,
+			int(a.WebhookStatus), a.Attempt, a.NextAttemptAt, now, sig, a.DeliveryID,
+		)); err != nil {
+			return
+		}
+		if n != 1 {
+			err = fmt.Errorf("repository/datastore: webhook delivery [%s] is not %s", pkg.BtoA(a.DeliveryID), WebhookPending)
+			return
+		}
+		r.Attempts = append(r.Attempts, a)
+	}
+	for _, d := range req.Webhooks.Replays {
+		if n, err = affected(tx.ExecContext(ctx, queries.LoanSvc.SQLite3.MutationWebhookReplay(),
+			now, now, sig, d.DeliveryID,
+		)); err != nil {
+			return
+		}
+		if n != 1 {
+			err = fmt.Errorf("repository/datastore: webhook delivery [%s] is not %s", pkg.BtoA(d.DeliveryID), WebhookDead)
+			return
+		}
+		d.WebhookStatus, d.Attempts, d.NextAttemptAt, d.UpdatedAt, d.UpdatedSign = WebhookPending, 0, now, now, sig
+		r.Replays = append(r.Replays, d)
+	}
+	return
+}
+
+type MutationRequestWebhooks struct {
+	Subscriptions []WebhookSubscription // created, only Active is updated on a known SubscriptionID
+	Deliveries    []WebhookDelivery     // created as pending, due immediately when NextAttemptAt is zero
+	Attempts      []WebhookAttempt      // recorded & applied on the pending delivery
+	Replays       []WebhookDelivery     // DeliveryID is used
+}
+
+// redacted will leave the secrets out of the audit.
+func (r MutationRequestWebhooks) redacted() MutationRequestWebhooks {
+	r.Subscriptions = slices.Clone(r.Subscriptions)
+	for i := range r.Subscriptions {
+		r.Subscriptions[i].Secret = nil
+	}
+	r.Deliveries = slices.Clone(r.Deliveries)
+	for i := range r.Deliveries {
+		r.Deliveries[i].Secret = nil
+	}
+	r.Replays = slices.Clone(r.Replays)
+	for i := range r.Replays {
+		r.Replays[i].Secret = nil
+	}
+	return r
+}
+
+type MutationResponseWebhooks struct {
+	Subscriptions []WebhookSubscription
+	Deliveries    []WebhookDelivery // only the newly created
+	Attempts      []WebhookAttempt
+	Replays       []WebhookDelivery
+}
+
+// loanEvent will describe the mutation of the loan as the event to be folded, the loan is kept as the payload as is.
+func loanEvent(l Loan) (_ LoanEvent, ok bool) {
+	eventType, ok := map[LoanState]LoanEventType{
+		StateProposed:  LoanProposed,
+		StateApproved:  LoanApproved,
+		StateInvested:  LoanInvested,
+		StateDisbursed: LoanDisbursed,
+	}[l.LoanState]
+	if !ok {
+		return LoanEvent{}, false
+	}
+	payload, _ := json.Marshal(l) // marshalling plain values never fails
+	return LoanEvent{
+		EventID:       xid.New().Bytes(),
+		LoanID:        l.LoanID,
+		LoanEventType: eventType,
+		Payload:       payload,
+	}, true
+}
+
+// appendLoanEvent will append the event as the next version of the loan, chained after the last event of every
+// loans & of the loan.
+func (x *datastore) appendLoanEvent(ctx context.Context, tx *sql.Tx, now int64, sig []byte, e LoanEvent) (err error) {
+	if err = tx.QueryRowContext(ctx, queries.LoanSvc.SQLite3.QueryLoanEventHead(), e.LoanID, e.LoanID).Scan(
+		&e.Version, &e.PrevLoanHash, &e.PrevHash,
+	); err != nil {
+		return
+	}
+	e.Version++
+	e.CreatedAt, e.CreatedSign = now, sig
+	e.Hash = eventHash(e)
+	_, err = tx.ExecContext(ctx, queries.LoanSvc.SQLite3.MutationLoanEvent(),
+		e.EventID, e.LoanID, e.Version, int(e.LoanEventType), e.Payload, e.CreatedAt, e.CreatedSign,
+		e.PrevHash, e.PrevLoanHash, e.Hash,
+	)
+	return
+}
+
+// importLoan will append the projection of a loan without any event as its first event, nothing is appended when
+// the loan has any event or does not exist.
+func (x *datastore) importLoan(ctx context.Context, tx *sql.Tx, now int64, sig []byte, loanID []byte) (imported bool, err error) {
+	var id []byte
+	if err = tx.QueryRowContext(ctx, queries.LoanSvc.SQLite3.QueryLoanUnevented(), loanID, loanID).Scan(&id); errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	} else if err != nil {
+		return
+	}
+	var loans []Loan
+	if loans, err = loadLoans(ctx, tx, QueryRequestLoans{ByLoanID: loanID}); err != nil || len(loans) != 1 {
+		return false, err
+	}
+	payload, _ := json.Marshal(loans[0])
+	err = x.appendLoanEvent(ctx, tx, now, sig, LoanEvent{
+		EventID:       xid.New().Bytes(),
+		LoanID:        loanID,
+		LoanEventType: LoanImported,
+		Payload:       payload,
+	})
+	return err == nil, err
+}
+
+// mutationLoanEvents will import the loans without any event, then replace the projection of each given loan.
+func (x *datastore) mutationLoanEvents(ctx context.Context, req MutationRequest) (res MutationResponse, err error) {
+	log := pkg.Context.SlogLogger(ctx)
+	db := x.Dependency.DB.SQLite3
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	tx, err := conn.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return
+	}
+
+	r := &MutationResponseLoanEvents{}
+	var imports, projections auditedLoans
+	defer func() {
+		if err == nil {
+			err = imports.audit(ctx, x, tx, "loan_events.import")
+		}
+		if err == nil {
+			err = projections.audit(ctx, x, tx, "loan_events.projection")
+		}
+		if err != nil {
+			log.ErrorContext(ctx, "repository/datastore.mutationLoanEvents",
+				slog.Any("err", err),
+			)
+			_ = tx.Rollback()
+			res.LoanEvents = nil
+			return
+		}
+		if err = tx.Commit(); err == nil {
+			res.LoanEvents = r
+		}
+	}()
+
+	now := time.Now().Unix()
+	sig := x.sign(now)
+	if req.LoanEvents.Import {
+		var rows *sql.Rows
+		if rows, err = tx.QueryContext(ctx, queries.LoanSvc.SQLite3.QueryLoanUnevented(),
+			orNull(req.LoanEvents.ByLoanID), orNull(req.LoanEvents.ByLoanID),
+		); err != nil {
+			return
+		}
+		var loanIDs [][]byte
+		if err = pkg.SQL.Scan(rows, func(i int, rx pkg.SQLRowsX) pkg.SQLScanFlow {
+			var loanID []byte
+			if err := rx.Scan(&loanID); err != nil {
+				return rx.Flow.Stop(err)
+			}
+			loanIDs = append(loanIDs, loanID)
+			return rx.Flow.Next()
+		}); err != nil {
+			return
+		}
+		for _, loanID := range loanIDs {
+			var imported bool
+			if imported, err = x.importLoan(ctx, tx, now, sig, loanID); err != nil {
+				return
+			}
+			if imported {
+				r.Imported++
+				if err = imports.touch(ctx, tx, loanID); err != nil {
+					return
+				}
+			}
+		}
+	}
+	for _, l := range req.LoanEvents.Projections {
+		if err = projections.touch(ctx, tx, l.LoanID); err != nil {
+			return
+		}
+		if _, err = tx.ExecContext(ctx, queries.LoanSvc.SQLite3.MutationLoanProjectionDeleted(),
+			l.LoanID, l.LoanID, l.LoanID,
+		); err != nil {
+			return
+		}
+		if _, err = tx.ExecContext(ctx, queries.LoanSvc.SQLite3.MutationLoanProjection(),
+			l.LoanID, int(l.LoanState),
+			l.ApprovedBy, l.ApprovedDoc, l.ApprovedAt, l.ApprovedSign, signVersion(l.ApprovedSignVersion),
+			l.DisbursedBy, l.DisbursedDoc, l.DisbursedAt, l.DisbursedSign, signVersion(l.DisbursedSignVersion),
+			l.APR, l.EffectiveRate, l.PaymentReference,
+			l.CreatedAt, l.CreatedSign, signVersion(l.CreatedSignVersion),
+		); err != nil {
+			return
+		}
+		for _, lp := range l.Parties {
+			if _, err = tx.ExecContext(ctx, queries.LoanSvc.SQLite3.MutationLoanProjectionParty(),
+				lp.LoanPartyID, l.LoanID, lp.UserID, int(lp.LoanPartyRoleAs), lp.CreatedAt, lp.CreatedSign, signVersion(lp.CreatedSignVersion),
+			); err != nil {
+				return
+			}
+			for _, lpp := range lp.Payments {
+				if _, err = tx.ExecContext(ctx, queries.LoanSvc.SQLite3.MutationLoanProjectionPayment(),
+					lp.LoanPartyID, lpp.PaymentID, int(lpp.PaymentType), lpp.ISO4217, lpp.Amount, lpp.Time, lpp.Details,
+					lpp.SettledAt, lpp.CreatedAt, lpp.CreatedSign, signVersion(lpp.CreatedSignVersion),
+					lpp.ChainSequence, lpp.PrevHash, lpp.PrevLoanHash, lpp.Hash, // the links are kept as folded
+				); err != nil {
+					return
+				}
+			}
+		}
+		r.Projected++
+	}
+	return
+}
+
+// signVersion will keep the version of a signature being projected as is, events recorded before the version is
+// recorded carry none and are signed by SignTimestamp.
+func signVersion(v SignVersion) int {
+	if v == 0 {
+		return int(SignTimestamp)
+	}
+	return int(v)
+}
+
+type MutationRequestLoanEvents struct {
+	Import      bool   // append the projection of the loans without any event as their first event
+	ByLoanID    []byte // scope of the import, nil for all loans
+	Projections []Loan // replace the loan tables of each loan, as folded from its events
+}
+type MutationResponseLoanEvents struct {
+	Imported  int64 // number of loans imported
+	Projected int64 // number of loans projected
+}
+
+// mutationCheckpoints will sign the head of each chain, a chain whose head is already signed by its last checkpoint
+// is skipped.
+func (x *datastore) mutationCheckpoints(ctx context.Context, req MutationRequest) (res MutationResponse, err error) {
+	log := pkg.Context.SlogLogger(ctx)
+	db := x.Dependency.DB.SQLite3
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	tx, err := conn.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return
+	}
+
+	r := &MutationResponseCheckpoints{}
+	defer func() {
+		if err == nil && len(r.List) > 0 {
+			err = x.auditMutation(ctx, tx, "checkpoints", r)
+		}
+		if err != nil {
+			log.ErrorContext(ctx, "repository/datastore.mutationCheckpoints",
+				slog.Any("err", err),
+			)
+			_ = tx.Rollback()
+			res.Checkpoints = nil
+			return
+		}
+		if err = tx.Commit(); err == nil {
+			res.Checkpoints = r
+		}
+	}()
+
+	var checkpoints []ChainCheckpoint
+	if checkpoints, err = loadCheckpoints(ctx, tx); err != nil {
+		return
+	}
+	last := map[string]ChainCheckpoint{}
+	for _, c := range checkpoints {
+		last[c.Chain] = c
+	}
+
+	var rows *sql.Rows
+	if rows, err = tx.QueryContext(ctx, queries.LoanSvc.SQLite3.QueryChainHeads()); err != nil {
+		return
+	}
+	var heads []ChainCheckpoint
+	if err = pkg.SQL.Scan(rows, func(i int, rx pkg.SQLRowsX) pkg.SQLScanFlow {
+		var c ChainCheckpoint
+		if err := rx.Scan(&c.Chain, &c.HeadSequence, &c.HeadHash); err != nil {
+			return rx.Flow.Stop(err)
+		}
+		heads = append(heads, c)
+		return rx.Flow.Next()
+	}); err != nil {
+		return
+	}
+
+	now := time.Now().Unix()
+	for _, c := range heads {
+		if l, ok := last[c.Chain]; ok && l.HeadSequence == c.HeadSequence && bytes.Equal(l.HeadHash, c.HeadHash) {
+			continue
+		}
+		c.CheckpointID = xid.New().Bytes()
+		c.CreatedAt = now
+		c.CreatedSign = x.signMessage(checkpointContent(c))
+		if _, err = tx.ExecContext(ctx, queries.LoanSvc.SQLite3.MutationChainCheckpoint(),
+			c.CheckpointID, c.Chain, c.HeadSequence, c.HeadHash, c.CreatedAt, c.CreatedSign,
+		); err != nil {
+			return
+		}
+		r.List = append(r.List, c)
+	}
+	return
+}
+
+type MutationRequestCheckpoints struct{}
+type MutationResponseCheckpoints struct {
+	List []ChainCheckpoint // written checkpoints, empty when no chain has moved since its last checkpoint
+}
+
+// mutationUserKeys will register the key of a user, or revoke it when Revoke is set. A revoked key is kept to tell
+// the signatures accepted before the revocation.
+func (x *datastore) mutationUserKeys(ctx context.Context, req MutationRequest) (res MutationResponse, err error) {
+	log := pkg.Context.SlogLogger(ctx)
+	db := x.Dependency.DB.SQLite3
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	tx, err := conn.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return
+	}
+
+	k := req.UserKeys.UserKey
+	defer func() {
+		if err == nil {
+			err = x.auditMutation(ctx, tx, pkg.OrElse(req.UserKeys.Revoke, "user_keys.revoked", "user_keys"), k)
+		}
+		if err != nil {
+			log.ErrorContext(ctx, "repository/datastore.mutationUserKeys",
+				slog.Any("err", err),
+			)
+			_ = tx.Rollback()
+			res.UserKeys = nil
+			return
+		}
+		if err = tx.Commit(); err == nil {
+			res.UserKeys = &MutationResponseUserKeys{UserKey: k}
+		}
+	}()
+
+	now := time.Now().Unix()
+	if req.UserKeys.Revoke {
+		k.RevokedAt = &now
+		var exec sql.Result
+		if exec, err = tx.ExecContext(ctx, queries.LoanSvc.SQLite3.MutationUserKeyRevoked(), k.RevokedAt, k.KeyID, k.UserID); err != nil {
+			return
+		}
+		if ra, _ := exec.RowsAffected(); ra < 1 {
+			err = fmt.Errorf("repository/datastore: key is not found or already revoked")
+			return
+		}
+		err = tx.QueryRowContext(ctx, queries.LoanSvc.SQLite3.QueryUserKeys(), nil, nil, k.KeyID, k.KeyID).
+			Scan(&k.KeyID, &k.UserID, &k.PublicKey, &k.CreatedAt, &k.CreatedSign, &k.RevokedAt)
+		return
+	}
+	k.CreatedAt, k.CreatedSign = now, x.sign(now)
+	_, err = tx.ExecContext(ctx, queries.LoanSvc.SQLite3.MutationUserKey(), k.KeyID, k.UserID, k.PublicKey, k.CreatedAt, k.CreatedSign)
+	return
+}
+
+type MutationRequestUserKeys struct {
+	UserKey
+	Revoke bool // revoke the key of the user instead of registering it
+}
+type MutationResponseUserKeys struct {
+	UserKey
+}
+
+// mutationContracts will write the rendered contract, or the signature of a contract verified beforehand.
+func (x *datastore) mutationContracts(ctx context.Context, req MutationRequest) (res MutationResponse, err error) {
+	log := pkg.Context.SlogLogger(ctx)
+	db := x.Dependency.DB.SQLite3
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	tx, err := conn.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return
+	}
+
+	r := &MutationResponseContracts{}
+	defer func() {
+		if err == nil {
+			err = x.auditMutation(ctx, tx, pkg.OrElse(r.Signature != nil, "contracts.signed", "contracts"), r)
+		}
+		if err != nil {
+			log.ErrorContext(ctx, "repository/datastore.mutationContracts",
+				slog.Any("err", err),
+			)
+			_ = tx.Rollback()
+			res.Contracts = nil
+			return
+		}
+		if err = tx.Commit(); err == nil {
+			res.Contracts = r
+		}
+	}()
+
+	now := time.Now().Unix()
+	sig := x.sign(now)
+	if c := req.Contracts.Contract; c != nil {
+		c.CreatedAt, c.CreatedSign = now, sig
+		if _, err = tx.ExecContext(ctx, queries.LoanSvc.SQLite3.MutationContract(),
+			c.ContractID, c.LoanID, c.UserID, int(c.LoanPartyRoleAs), c.Document, c.Hash, c.CreatedAt, c.CreatedSign,
+		); err != nil {
+			return
+		}
+		r.Contract = c
+	}
+	if s := req.Contracts.Signature; s != nil {
+		s.CreatedAt, s.CreatedSign = now, sig
+		if _, err = tx.ExecContext(ctx, queries.LoanSvc.SQLite3.MutationContractSignature(),
+			s.ContractID, s.KeyID, s.Signature, s.CreatedAt, s.CreatedSign,
+		); err != nil {
+			return
+		}
+		r.Signature = s
+	}
+	return
+}
+
+type MutationRequestContracts struct {
+	Contract  *Contract          // rendered for a party of the loan
+	Signature *ContractSignature // verified against the key of the party beforehand
+}
+type MutationResponseContracts struct {
+	Contract  *Contract
+	Signature *ContractSignature
+}
+
+// mutationDocuments will store each document by the hash of its content, a document already stored is kept as is.
+func (x *datastore) mutationDocuments(ctx context.Context, req MutationRequest) (res MutationResponse, err error) {
+	log := pkg.Context.SlogLogger(ctx)
+	db := x.Dependency.DB.SQLite3
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	tx, err := conn.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return
+	}
+
+	r, stored := &MutationResponseDocuments{}, []Document{}
+	defer func() {
+		if err == nil && len(stored) > 0 {
+			err = x.auditMutation(ctx, tx, "documents", stored)
+		}
+		if err != nil {
+			log.ErrorContext(ctx, "repository/datastore.mutationDocuments",
+				slog.Any("err", err),
+			)
+			_ = tx.Rollback()
+			res.Documents = nil
+			return
+		}
+		if err = tx.Commit(); err == nil {
+			res.Documents = r
+		}
+	}()
+
+	now := time.Now().Unix()
+	sig := x.sign(now)
+	for _, d := range req.Documents.List {
+		hash := sha256.Sum256(d.Content)
+		if !bytes.Equal(hash[:], d.Hash) {
+			err = fmt.Errorf("repository/datastore: document hash mismatch")
+			return
+		}
+		d.CreatedAt, d.CreatedSign = now, sig
+		var exec sql.Result
+		if exec, err = tx.ExecContext(ctx, queries.LoanSvc.SQLite3.MutationDocument(),
+			d.Hash, d.LoanID, d.UserID, d.Kind, d.TemplateVersion, d.MediaType, d.Content, d.CreatedAt, d.CreatedSign,
+		); err != nil {
+			return
+		}
+		if ra, _ := exec.RowsAffected(); ra > 0 {
+			stored = append(stored, d)
+		}
+		r.List = append(r.List, d)
+	}
+	return
+}
+
+type MutationRequestDocuments struct {
+	List []Document
+}
+type MutationResponseDocuments struct {
+	List []Document // CreatedAt is the time of the request for the documents already stored
+}
+
+// audit will write an immutable record of the mutation in its transaction, the actor, client IP & request ID are
+// taken from the context.
+func (x *datastore) audit(ctx context.Context, tx *sql.Tx, a AuditLog) (err error) {
+	a.AuditID = xid.New().Bytes()
+	a.Actor, a.ClientIP, a.RequestID = pkg.Context.Actor(ctx), pkg.Context.ClientIP(ctx), pkg.Context.RequestID(ctx)
+	a.CreatedAt = time.Now().Unix()
+	a.CreatedSign = x.sign(a.CreatedAt)
+	_, err = tx.ExecContext(ctx, queries.LoanSvc.SQLite3.MutationAuditLog(),
+		a.AuditID, a.Operation, a.LoanID, a.Actor, a.ClientIP, a.RequestID, a.Before, a.After, a.CreatedAt, a.CreatedSign,
+	)
+	return
+}
+
+// auditMutation will audit a mutation not scoped to a loan, the mutation as applied is kept as the snapshot after.
+func (x *datastore) auditMutation(ctx context.Context, tx *sql.Tx, operation string, mutation any) error {
+	after, err := json.Marshal(mutation)
+	if err != nil {
+		return err
+	}
+	return x.audit(ctx, tx, AuditLog{Operation: operation, After: after})
+}
+
+// snapshotLoan will marshal the loan as seen by the transaction, nil when the loan does not exist.
+func snapshotLoan(ctx context.Context, tx *sql.Tx, loanID []byte) ([]byte, error) {
+	if len(loanID) < 1 {
+		return nil, nil
+	}
+	loans, err := loadLoans(ctx, tx, QueryRequestLoans{ByLoanID: loanID})
+	if err != nil || len(loans) != 1 {
+		return nil, err
+	}
+	return json.Marshal(loans[0])
+}
+
+// auditedLoans will keep the snapshot of each loan before a mutation, in order of being touched.
+type auditedLoans struct {
+	loanIDs [][]byte
+	before  map[string][]byte
+}
+
+// touch will snapshot the loan before being mutated, only the first touch is kept.
+func (a *auditedLoans) touch(ctx context.Context, tx *sql.Tx, loanID []byte) (err error) {
+	if _, ok := a.before[string(loanID)]; ok {
+		return nil
+	}
+	if a.before == nil {
+		a.before = map[string][]byte{}
+	}
+	if a.before[string(loanID)], err = snapshotLoan(ctx, tx, loanID); err != nil {
+		return
+	}
+	a.loanIDs = append(a.loanIDs, loanID)
+	return
+}
+
+// audit will snapshot each touched loan after being mutated & write the records.
+func (a *auditedLoans) audit(ctx context.Context, x *datastore, tx *sql.Tx, operation string) (err error) {
+	for _, loanID := range a.loanIDs {
+		var after []byte
+		if after, err = snapshotLoan(ctx, tx, loanID); err != nil {
+			return
+		}
+		if err = x.audit(ctx, tx, AuditLog{
+			Operation: operation,
+			LoanID:    loanID,
+			Before:    a.before[string(loanID)],
+			After:     after,
+		}); err != nil {
+			return
+		}
+	}
+	return
+}
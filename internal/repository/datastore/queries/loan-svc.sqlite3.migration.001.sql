@@ -0,0 +1,2 @@
+ALTER TABLE loans ADD COLUMN apr            REAL        NULL; -- annual percentage rate disclosed upon proposed
+ALTER TABLE loans ADD COLUMN effective_rate REAL        NULL; -- effective annual rate disclosed upon proposed
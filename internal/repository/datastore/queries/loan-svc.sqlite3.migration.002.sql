@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS loan_accruals (
+    loan_id         BLOB    NOT NULL, -- FK to loans.loan_id
+    accrual_date    INTEGER NOT NULL, -- unix timestamp of the start of accrued day in UTC
+    day_count       TEXT    NOT NULL, -- day count convention, e.g. ACT/365, ACT/360, 30/360
+    iso4217         CHAR(3) NOT NULL,
+    amount          NUMERIC NOT NULL, -- interest accrued on said day
+    accrued         NUMERIC NOT NULL, -- cumulative interest accrued until the end of said day
+    created_at      INTEGER NOT NULL, -- unix timestamp
+    created_sign    BLOB    NOT NULL, -- signature contains of pk + signature of created_at
+    UNIQUE (loan_id, accrual_date, day_count)
+);
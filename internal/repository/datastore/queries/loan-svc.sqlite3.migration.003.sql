@@ -0,0 +1,30 @@
+ALTER TABLE loan_party_payments ADD COLUMN settled_at INTEGER NULL; -- unix timestamp of the payment being settled
+
+CREATE TABLE IF NOT EXISTS provision_snapshots (
+    snapshot_id     BLOB    NOT NULL UNIQUE,
+    as_of           INTEGER NOT NULL UNIQUE, -- unix timestamp of the start of snapshot day in UTC
+    created_at      INTEGER NOT NULL, -- unix timestamp
+    created_sign    BLOB    NOT NULL  -- signature contains of pk + signature of created_at
+);
+
+CREATE TABLE IF NOT EXISTS provision_snapshot_loans (
+    snapshot_id     BLOB    NOT NULL, -- FK to provision_snapshots.snapshot_id
+    loan_id         BLOB    NOT NULL, -- FK to loans.loan_id
+    iso4217         CHAR(3) NOT NULL,
+    days_past_due   INTEGER NOT NULL,
+    stage           INTEGER NOT NULL, -- 1 = performing; 2 = significant increase in credit risk; 3 = credit impaired
+    pd              NUMERIC NOT NULL, -- probability of default
+    lgd             NUMERIC NOT NULL, -- loss given default
+    ead             NUMERIC NOT NULL, -- exposure at default
+    ecl             NUMERIC NOT NULL  -- expected credit loss, pd * lgd * ead
+);
+
+CREATE TABLE IF NOT EXISTS provision_snapshot_lenders (
+    snapshot_id     BLOB    NOT NULL, -- FK to provision_snapshots.snapshot_id
+    user_id         BLOB    NOT NULL, -- FK to users.user_id
+    loan_id         BLOB    NOT NULL, -- FK to loans.loan_id
+    iso4217         CHAR(3) NOT NULL,
+    share           NUMERIC NOT NULL, -- portion of the loan funded by said lender
+    ead             NUMERIC NOT NULL, -- exposure at default
+    ecl             NUMERIC NOT NULL  -- expected credit loss
+);
@@ -0,0 +1,31 @@
+CREATE TABLE IF NOT EXISTS ledger_accounts (
+    account         INTEGER NOT NULL UNIQUE,
+    name            TEXT    NOT NULL,
+    normal_balance  INTEGER NOT NULL  -- 1 = debit; -1 = credit
+);
+
+INSERT OR IGNORE INTO ledger_accounts (account, name, normal_balance) VALUES
+    (1, 'borrower_receivable',   1),
+    (2, 'lender_payable',       -1),
+    (3, 'platform_fee_revenue', -1),
+    (4, 'cash_in_transit',       1);
+
+CREATE TABLE IF NOT EXISTS ledger_journals (
+    journal_id      BLOB    NOT NULL UNIQUE,
+    loan_id         BLOB        NULL, -- FK to loans.loan_id
+    description     TEXT    NOT NULL,
+    created_at      INTEGER NOT NULL, -- unix timestamp
+    created_sign    BLOB    NOT NULL  -- signature contains of pk + signature of created_at
+);
+
+CREATE TABLE IF NOT EXISTS ledger_postings (
+    journal_id      BLOB    NOT NULL, -- FK to ledger_journals.journal_id
+    account         INTEGER NOT NULL REFERENCES ledger_accounts (account),
+    user_id         BLOB        NULL, -- FK to users.user_id, owner of the sub-account
+    iso4217         CHAR(3) NOT NULL,
+    debit           NUMERIC NOT NULL DEFAULT 0,
+    credit          NUMERIC NOT NULL DEFAULT 0,
+    CHECK (debit >= 0 AND credit >= 0 AND (debit = 0 OR credit = 0))
+);
+
+CREATE INDEX IF NOT EXISTS ledger_postings_journal_id ON ledger_postings (journal_id);
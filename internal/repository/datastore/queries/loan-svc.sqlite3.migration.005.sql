@@ -0,0 +1,23 @@
+INSERT OR IGNORE INTO ledger_accounts (account, name, normal_balance) VALUES
+    (5, 'lender_wallet', -1),
+    (6, 'cash_at_bank',   1);
+
+CREATE TABLE IF NOT EXISTS wallets (
+    user_id         BLOB    NOT NULL, -- FK to users.user_id
+    iso4217         CHAR(3) NOT NULL,
+    available       NUMERIC NOT NULL DEFAULT 0, -- free to be invested or withdrawn
+    reserved        NUMERIC NOT NULL DEFAULT 0, -- invested on loans not yet disbursed
+    updated_at      INTEGER NOT NULL, -- unix timestamp
+    updated_sign    BLOB    NOT NULL, -- signature contains of pk + signature of updated_at
+    UNIQUE (user_id, iso4217),
+    CHECK (available >= 0 AND reserved >= 0)
+);
+
+-- lenders of loans invested before wallets exist have their investment reserved, released upon disbursement
+INSERT OR IGNORE INTO wallets (user_id, iso4217, available, reserved, updated_at, updated_sign)
+SELECT lp.user_id, lpp.iso4217, 0, TOTAL(lpp.amount), CAST(strftime('%s', 'now') AS INTEGER), X''
+FROM loans l
+JOIN loan_parties lp ON lp.loan_id = l.loan_id AND lp.role_as = 2 -- lender
+JOIN loan_party_payments lpp ON lpp.loan_party_id = lp.loan_party_id AND lpp.amount > 0
+WHERE l.loan_state = 3 -- invested
+GROUP BY lp.user_id, lpp.iso4217;
@@ -0,0 +1,13 @@
+ALTER TABLE loan_party_payments ADD COLUMN payment_type INTEGER NOT NULL DEFAULT 0; -- 1 = principal disbursement; 2 = installment; 3 = fee; 4 = interest; 5 = investment; 6 = repayment; 7 = refund; 8 = penalty
+
+-- rows written before payment_type exist only carry meaning by the sign of the amount & the role of the party
+UPDATE loan_party_payments
+SET payment_type = CASE
+    WHEN lp.role_as = 1 AND loan_party_payments.amount < 0 THEN 1 -- borrower: principal disbursement
+    WHEN lp.role_as = 1 AND loan_party_payments.amount > 0 THEN 2 -- borrower: installment
+    WHEN lp.role_as = 2 AND loan_party_payments.amount > 0 THEN 5 -- lender: investment
+    WHEN lp.role_as = 2 AND loan_party_payments.amount < 0 THEN 6 -- lender: repayment
+    ELSE 0
+END
+FROM loan_parties lp
+WHERE lp.loan_party_id = loan_party_payments.loan_party_id AND loan_party_payments.payment_type = 0;
@@ -0,0 +1,27 @@
+ALTER TABLE loan_party_payments ADD COLUMN payment_id BLOB NULL; -- ID referenced by reconciliation
+
+UPDATE loan_party_payments SET payment_id = randomblob(12) WHERE payment_id IS NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS loan_party_payments_payment_id ON loan_party_payments (payment_id);
+
+CREATE TABLE IF NOT EXISTS settled_transactions (
+    transaction_id  BLOB    NOT NULL UNIQUE,
+    bank_reference  TEXT    NOT NULL UNIQUE, -- unique reference given by the bank, imported once
+    reference       TEXT    NOT NULL, -- free text given by the payer, e.g. loan or payment reference
+    iso4217         CHAR(3) NOT NULL,
+    amount          NUMERIC NOT NULL, -- positive = money received; negative = money paid
+    value_time      INTEGER NOT NULL, -- unix timestamp of the money being settled
+    counterparty    TEXT    NOT NULL,
+    created_at      INTEGER NOT NULL, -- unix timestamp
+    created_sign    BLOB    NOT NULL  -- signature contains of pk + signature of created_at
+);
+
+CREATE TABLE IF NOT EXISTS reconciliation_matches (
+    transaction_id  BLOB    NOT NULL UNIQUE, -- FK to settled_transactions.transaction_id
+    payment_id      BLOB    NOT NULL UNIQUE, -- FK to loan_party_payments.payment_id
+    match_type      INTEGER NOT NULL, -- 1 = exact; 2 = reference; 3 = amount & date
+    amount_diff     NUMERIC NOT NULL, -- transaction amount - expected amount
+    days_diff       INTEGER NOT NULL, -- value date - due date
+    created_at      INTEGER NOT NULL, -- unix timestamp
+    created_sign    BLOB    NOT NULL  -- signature contains of pk + signature of created_at
+);
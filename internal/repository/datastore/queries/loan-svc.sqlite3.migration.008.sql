@@ -0,0 +1,5 @@
+ALTER TABLE settled_transactions ADD COLUMN source TEXT NOT NULL DEFAULT ''; -- statement format of the import, e.g. csv, mt940, camt053
+
+ALTER TABLE settled_transactions ADD COLUMN loan_id BLOB NULL; -- FK to loans.loan_id, candidate repayment of an incoming credit referencing the loan
+
+CREATE INDEX IF NOT EXISTS settled_transactions_loan_id ON settled_transactions (loan_id);
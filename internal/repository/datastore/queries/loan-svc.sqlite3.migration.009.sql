@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS payout_instructions (
+    instruction_id  BLOB    NOT NULL UNIQUE,
+    loan_id         BLOB    NOT NULL UNIQUE, -- FK to loans.loan_id, paid once upon disbursement
+    payment_id      BLOB    NOT NULL, -- FK to loan_party_payments.payment_id of the principal disbursement
+    iso4217         CHAR(3) NOT NULL,
+    amount          NUMERIC NOT NULL, -- net principal after upfront fees
+    fee             NUMERIC NOT NULL, -- upfront fees deducted from the principal
+    account_name    TEXT    NOT NULL, -- borrower bank details
+    account_number  TEXT    NOT NULL,
+    bank_code       TEXT    NOT NULL, -- BIC or domestic bank code
+    reference       TEXT    NOT NULL, -- remittance information, referenced by reconciliation
+    status          INTEGER NOT NULL, -- 1 = pending; 2 = exported; 3 = confirmed; 4 = failed
+    status_reason   TEXT    NOT NULL DEFAULT '',
+    batch_id        BLOB        NULL, -- ID of the latest exported payment file
+    created_at      INTEGER NOT NULL, -- unix timestamp
+    created_sign    BLOB    NOT NULL, -- signature contains of pk + signature of created_at
+    updated_at      INTEGER NOT NULL, -- unix timestamp
+    updated_sign    BLOB    NOT NULL, -- signature contains of pk + signature of updated_at
+    CHECK (amount > 0 AND fee >= 0)
+);
+
+CREATE INDEX IF NOT EXISTS payout_instructions_status ON payout_instructions (status);
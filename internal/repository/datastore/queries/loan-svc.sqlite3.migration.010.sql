@@ -0,0 +1,7 @@
+ALTER TABLE loans ADD COLUMN payment_reference TEXT NULL; -- numeric reference with ISO 7064 MOD 97-10 check digits given to the borrower for repayment
+
+-- loans proposed before the reference existed are given the rowid with check digits & without any bank prefix, such
+-- legacy references are rejected by the gateway & only reconciled from the bank statements
+UPDATE loans SET payment_reference = printf('%010d%02d', rowid, 98 - (rowid * 100 % 97)) WHERE payment_reference IS NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS loans_payment_reference ON loans (payment_reference);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS gateway_callbacks (
+    callback_id             BLOB    NOT NULL UNIQUE,
+    gateway_transaction_id  TEXT    NOT NULL, -- ID given by the payment gateway
+    status                  INTEGER NOT NULL, -- 1 = pending; 2 = succeeded; 3 = failed
+    reference               TEXT    NOT NULL, -- payment reference quoted by the payer
+    iso4217                 CHAR(3) NOT NULL,
+    amount                  NUMERIC NOT NULL,
+    payload                 BLOB    NOT NULL, -- raw notification as signed by the gateway
+    signature               TEXT    NOT NULL, -- signature header of the notification
+    created_at              INTEGER NOT NULL, -- unix timestamp
+    created_sign            BLOB    NOT NULL, -- signature contains of pk + signature of created_at
+    UNIQUE (gateway_transaction_id, status) -- a notification is recorded once, retries of the gateway are ignored
+);
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS outbox (
+    sequence        INTEGER PRIMARY KEY AUTOINCREMENT, -- order of the events, never reused
+    event_id        BLOB    NOT NULL UNIQUE,
+    event_type      TEXT    NOT NULL, -- e.g. loan.proposed
+    aggregate_id    BLOB    NOT NULL, -- ID of the entity the event is about, e.g. loans.loan_id
+    payload         BLOB    NOT NULL, -- JSON of the event
+    created_at      INTEGER NOT NULL, -- unix timestamp
+    created_sign    BLOB    NOT NULL, -- signature contains of pk + signature of created_at
+    published_at    INTEGER     NULL  -- unix timestamp of the first successful publish, NULL while unpublished
+);
+
+CREATE INDEX IF NOT EXISTS outbox_unpublished ON outbox (sequence) WHERE published_at IS NULL;
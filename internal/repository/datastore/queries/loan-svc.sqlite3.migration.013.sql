@@ -0,0 +1,43 @@
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    subscription_id BLOB    NOT NULL UNIQUE,
+    url             TEXT    NOT NULL, -- receiver of the HTTP POST
+    event_types     TEXT    NOT NULL DEFAULT '', -- comma separated e.g. loan.approved,loan.disbursed; empty for every event
+    secret          BLOB    NOT NULL, -- key of the HMAC-SHA256 signature of the payload
+    active          INTEGER NOT NULL DEFAULT 1, -- 0 once unsubscribed, kept for the delivery log
+    created_at      INTEGER NOT NULL, -- unix timestamp
+    created_sign    BLOB    NOT NULL, -- signature contains of pk + signature of created_at
+    updated_at      INTEGER NOT NULL, -- unix timestamp
+    updated_sign    BLOB    NOT NULL  -- signature contains of pk + signature of updated_at
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    delivery_id     BLOB    NOT NULL UNIQUE,
+    subscription_id BLOB    NOT NULL, -- FK to webhook_subscriptions.subscription_id
+    event_id        BLOB    NOT NULL, -- FK to outbox.event_id
+    event_type      TEXT    NOT NULL,
+    payload         BLOB    NOT NULL, -- body of the HTTP POST
+    status          INTEGER NOT NULL, -- 1 = pending; 2 = delivered; 3 = dead
+    attempts        INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at INTEGER NOT NULL, -- unix timestamp
+    created_at      INTEGER NOT NULL, -- unix timestamp
+    created_sign    BLOB    NOT NULL, -- signature contains of pk + signature of created_at
+    updated_at      INTEGER NOT NULL, -- unix timestamp
+    updated_sign    BLOB    NOT NULL, -- signature contains of pk + signature of updated_at
+    UNIQUE (subscription_id, event_id) -- the outbox publish at least once
+);
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_due ON webhook_deliveries (next_attempt_at) WHERE status = 1;
+
+CREATE TABLE IF NOT EXISTS webhook_attempts (
+    delivery_id     BLOB    NOT NULL, -- FK to webhook_deliveries.delivery_id
+    attempt         INTEGER NOT NULL, -- starting from 1, restarted by a replay
+    status          INTEGER NOT NULL, -- status of the delivery after the attempt
+    status_code     INTEGER NOT NULL, -- HTTP status code, 0 when no response
+    error           TEXT    NOT NULL DEFAULT '',
+    duration_ms     INTEGER NOT NULL,
+    next_attempt_at INTEGER NOT NULL, -- unix timestamp of the retry
+    created_at      INTEGER NOT NULL, -- unix timestamp
+    created_sign    BLOB    NOT NULL  -- signature contains of pk + signature of created_at
+);
+
+CREATE INDEX IF NOT EXISTS webhook_attempts_delivery ON webhook_attempts (delivery_id);
@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS loan_events (
+    sequence        INTEGER PRIMARY KEY AUTOINCREMENT, -- order of every events, never reused
+    event_id        BLOB    NOT NULL UNIQUE,
+    loan_id         BLOB    NOT NULL, -- FK to loans.loan_id, the aggregate being folded
+    version         INTEGER NOT NULL, -- order of the events of the loan, starting from 1
+    event_type      INTEGER NOT NULL, -- 1 = imported; 2 = proposed; 3 = approved; 4 = invested; 5 = disbursed; 6 = payment settled
+    payload         BLOB    NOT NULL, -- JSON of what is changed by the event
+    created_at      INTEGER NOT NULL, -- unix timestamp
+    created_sign    BLOB    NOT NULL, -- signature contains of pk + signature of created_at
+    UNIQUE (loan_id, version)
+);
+
+-- append-only, the loans, loan_parties & loan_party_payments are projections of the folded events
+CREATE TRIGGER IF NOT EXISTS loan_events_no_update BEFORE UPDATE ON loan_events
+BEGIN
+    SELECT RAISE(ABORT, 'loan_events is append-only');
+END;
+
+CREATE TRIGGER IF NOT EXISTS loan_events_no_delete BEFORE DELETE ON loan_events
+BEGIN
+    SELECT RAISE(ABORT, 'loan_events is append-only');
+END;
@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS audit_logs (
+    sequence        INTEGER PRIMARY KEY AUTOINCREMENT, -- order of every records, never reused
+    audit_id        BLOB    NOT NULL UNIQUE,
+    operation       TEXT    NOT NULL, -- mutation being audited, e.g. loans.approved, wallets, payouts
+    loan_id         BLOB,             -- FK to loans.loan_id, NULL when the mutation is not scoped to a loan
+    actor           TEXT    NOT NULL DEFAULT '', -- authenticated caller, empty for the service itself
+    client_ip       TEXT    NOT NULL DEFAULT '',
+    request_id      TEXT    NOT NULL DEFAULT '',
+    before          BLOB,             -- JSON snapshot of the loan before the mutation, NULL when not scoped to a loan
+    after           BLOB,             -- JSON snapshot of the loan after the mutation, or of the mutation itself
+    created_at      INTEGER NOT NULL, -- unix timestamp
+    created_sign    BLOB    NOT NULL  -- signature contains of pk + signature of created_at
+);
+
+CREATE INDEX IF NOT EXISTS audit_logs_loan_id ON audit_logs (loan_id, sequence) WHERE loan_id IS NOT NULL;
+
+-- immutable, written in the same transaction of the audited mutation
+CREATE TRIGGER IF NOT EXISTS audit_logs_no_update BEFORE UPDATE ON audit_logs
+BEGIN
+    SELECT RAISE(ABORT, 'audit_logs is immutable');
+END;
+
+CREATE TRIGGER IF NOT EXISTS audit_logs_no_delete BEFORE DELETE ON audit_logs
+BEGIN
+    SELECT RAISE(ABORT, 'audit_logs is immutable');
+END;
@@ -0,0 +1,6 @@
+-- format of the message being signed: 1 = unix timestamp only; 2 = canonical content of the row including its parent IDs
+ALTER TABLE loans               ADD COLUMN created_sign_version     INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE loans               ADD COLUMN approved_sign_version    INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE loans               ADD COLUMN disbursed_sign_version   INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE loan_parties        ADD COLUMN created_sign_version     INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE loan_party_payments ADD COLUMN created_sign_version     INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,33 @@
+-- hash chains of the payments & of the loan events, each row links the hash of the previous row of its table & of its
+-- loan; rows written beforehand are left unchained
+ALTER TABLE loan_party_payments ADD COLUMN chain_sequence  INTEGER NULL; -- position in the chain of the payments, starting from 1
+ALTER TABLE loan_party_payments ADD COLUMN prev_hash       BLOB    NULL; -- hash of the previous payment in the chain
+ALTER TABLE loan_party_payments ADD COLUMN prev_loan_hash  BLOB    NULL; -- hash of the previous payment of the same loan
+ALTER TABLE loan_party_payments ADD COLUMN hash            BLOB    NULL; -- SHA-256 of the content along with the previous hashes
+
+CREATE UNIQUE INDEX IF NOT EXISTS loan_party_payments_chain_sequence ON loan_party_payments (chain_sequence) WHERE chain_sequence IS NOT NULL;
+
+ALTER TABLE loan_events ADD COLUMN prev_hash      BLOB NULL; -- hash of the previous event by sequence
+ALTER TABLE loan_events ADD COLUMN prev_loan_hash BLOB NULL; -- hash of the previous event of the same loan
+ALTER TABLE loan_events ADD COLUMN hash           BLOB NULL; -- SHA-256 of the content along with the previous hashes
+
+CREATE TABLE IF NOT EXISTS chain_checkpoints (
+    sequence        INTEGER PRIMARY KEY AUTOINCREMENT, -- order of every checkpoints, never reused
+    checkpoint_id   BLOB    NOT NULL UNIQUE,
+    chain           TEXT    NOT NULL, -- loan_party_payments or loan_events
+    head_sequence   INTEGER NOT NULL, -- position of the head of the chain
+    head_hash       BLOB    NOT NULL, -- hash of the head of the chain
+    created_at      INTEGER NOT NULL, -- unix timestamp
+    created_sign    BLOB    NOT NULL  -- signature contains of key ID + signature of the chain, head & created_at
+);
+
+-- append-only, a checkpoint signs what the chain was at the time
+CREATE TRIGGER IF NOT EXISTS chain_checkpoints_no_update BEFORE UPDATE ON chain_checkpoints
+BEGIN
+    SELECT RAISE(ABORT, 'chain_checkpoints is append-only');
+END;
+
+CREATE TRIGGER IF NOT EXISTS chain_checkpoints_no_delete BEFORE DELETE ON chain_checkpoints
+BEGIN
+    SELECT RAISE(ABORT, 'chain_checkpoints is append-only');
+END;
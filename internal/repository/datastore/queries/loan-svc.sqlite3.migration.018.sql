@@ -0,0 +1,55 @@
+-- ed25519 public keys registered by the users, signing their contracts
+CREATE TABLE IF NOT EXISTS user_keys (
+    key_id          BLOB    NOT NULL UNIQUE, -- first 8 bytes of the SHA-256 of the public key
+    user_id         BLOB    NOT NULL, -- FK to users.user_id
+    public_key      BLOB    NOT NULL, -- ed25519
+    created_at      INTEGER NOT NULL, -- unix timestamp
+    created_sign    BLOB    NOT NULL, -- signature contains of key ID + signature of created_at
+    revoked_at      INTEGER NULL      -- unix timestamp, signatures accepted afterward are not valid
+);
+
+CREATE INDEX IF NOT EXISTS user_keys_user_id ON user_keys (user_id);
+
+-- contract rendered for a party of the loan, signed by the party over its hash
+CREATE TABLE IF NOT EXISTS contracts (
+    contract_id     BLOB    NOT NULL UNIQUE,
+    loan_id         BLOB    NOT NULL, -- FK to loans.loan_id
+    user_id         BLOB    NOT NULL, -- FK to loan_parties.user_id, the party expected to sign
+    role_as         INTEGER NOT NULL, -- 1 = borrower; 2 = lender
+    document        BLOB    NOT NULL, -- rendered contract as presented to the party
+    hash            BLOB    NOT NULL, -- SHA-256 of the document
+    created_at      INTEGER NOT NULL, -- unix timestamp
+    created_sign    BLOB    NOT NULL  -- signature contains of key ID + signature of created_at
+);
+
+CREATE INDEX IF NOT EXISTS contracts_loan_id ON contracts (loan_id);
+
+CREATE TABLE IF NOT EXISTS contract_signatures (
+    contract_id     BLOB    NOT NULL, -- FK to contracts.contract_id
+    key_id          BLOB    NOT NULL, -- FK to user_keys.key_id
+    signature       BLOB    NOT NULL, -- ed25519 signature of contracts.hash by the key of the party
+    created_at      INTEGER NOT NULL, -- unix timestamp of the signature being accepted
+    created_sign    BLOB    NOT NULL, -- signature contains of key ID + signature of created_at
+    UNIQUE (contract_id, key_id)
+);
+
+-- kept as the evidence of the parties agreeing
+CREATE TRIGGER IF NOT EXISTS contracts_no_update BEFORE UPDATE ON contracts
+BEGIN
+    SELECT RAISE(ABORT, 'contracts is immutable');
+END;
+
+CREATE TRIGGER IF NOT EXISTS contracts_no_delete BEFORE DELETE ON contracts
+BEGIN
+    SELECT RAISE(ABORT, 'contracts is immutable');
+END;
+
+CREATE TRIGGER IF NOT EXISTS contract_signatures_no_update BEFORE UPDATE ON contract_signatures
+BEGIN
+    SELECT RAISE(ABORT, 'contract_signatures is immutable');
+END;
+
+CREATE TRIGGER IF NOT EXISTS contract_signatures_no_delete BEFORE DELETE ON contract_signatures
+BEGIN
+    SELECT RAISE(ABORT, 'contract_signatures is immutable');
+END;
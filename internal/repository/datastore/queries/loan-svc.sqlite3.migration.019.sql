@@ -0,0 +1,24 @@
+-- documents generated from the versioned templates, stored by the SHA-256 of their content
+CREATE TABLE IF NOT EXISTS documents (
+    hash             BLOB    NOT NULL UNIQUE, -- SHA-256 of the content
+    loan_id          BLOB    NOT NULL, -- FK to loans.loan_id
+    user_id          BLOB    NULL,     -- FK to loan_parties.user_id, the party the document is addressed to
+    kind             TEXT    NOT NULL, -- borrower_agreement, lender_agreement or repayment_schedule
+    template_version INTEGER NOT NULL, -- version of the template being rendered
+    media_type       TEXT    NOT NULL, -- text/html or application/pdf
+    content          BLOB    NOT NULL,
+    created_at       INTEGER NOT NULL, -- unix timestamp
+    created_sign     BLOB    NOT NULL  -- signature contains of key ID + signature of created_at
+);
+
+CREATE INDEX IF NOT EXISTS documents_loan_id ON documents (loan_id);
+
+CREATE TRIGGER IF NOT EXISTS documents_no_update BEFORE UPDATE ON documents
+BEGIN
+    SELECT RAISE(ABORT, 'documents is immutable');
+END;
+
+CREATE TRIGGER IF NOT EXISTS documents_no_delete BEFORE DELETE ON documents
+BEGIN
+    SELECT RAISE(ABORT, 'documents is immutable');
+END;
@@ -0,0 +1,31 @@
+-- there are no upfront fees, the payout is the whole principal; the fee is part of the CHECK so the table is rebuilt
+-- keeping the rowid referenced by the signatures
+CREATE TABLE payout_instructions_020 (
+    instruction_id  BLOB    NOT NULL UNIQUE,
+    loan_id         BLOB    NOT NULL UNIQUE, -- FK to loans.loan_id, paid once upon disbursement
+    payment_id      BLOB    NOT NULL, -- FK to loan_party_payments.payment_id of the principal disbursement
+    iso4217         CHAR(3) NOT NULL,
+    amount          NUMERIC NOT NULL, -- principal disbursed to the borrower
+    account_name    TEXT    NOT NULL, -- borrower bank details
+    account_number  TEXT    NOT NULL,
+    bank_code       TEXT    NOT NULL, -- BIC or domestic bank code
+    reference       TEXT    NOT NULL, -- remittance information, referenced by reconciliation
+    status          INTEGER NOT NULL, -- 1 = pending; 2 = exported; 3 = confirmed; 4 = failed
+    status_reason   TEXT    NOT NULL DEFAULT '',
+    batch_id        BLOB        NULL, -- ID of the latest exported payment file
+    created_at      INTEGER NOT NULL, -- unix timestamp
+    created_sign    BLOB    NOT NULL, -- signature contains of pk + signature of created_at
+    updated_at      INTEGER NOT NULL, -- unix timestamp
+    updated_sign    BLOB    NOT NULL, -- signature contains of pk + signature of updated_at
+    CHECK (amount > 0)
+);
+
+INSERT INTO payout_instructions_020 (rowid, instruction_id, loan_id, payment_id, iso4217, amount, account_name, account_number, bank_code, reference, status, status_reason, batch_id, created_at, created_sign, updated_at, updated_sign)
+SELECT rowid, instruction_id, loan_id, payment_id, iso4217, amount + fee, account_name, account_number, bank_code, reference, status, status_reason, batch_id, created_at, created_sign, updated_at, updated_sign
+FROM payout_instructions;
+
+DROP TABLE payout_instructions;
+
+ALTER TABLE payout_instructions_020 RENAME TO payout_instructions;
+
+CREATE INDEX IF NOT EXISTS payout_instructions_status ON payout_instructions (status);
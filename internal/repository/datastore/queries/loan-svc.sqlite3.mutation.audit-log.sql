@@ -0,0 +1,13 @@
+INSERT INTO audit_logs (
+    audit_id,
+    operation,
+    loan_id,
+    actor,
+    client_ip,
+    request_id,
+    before,
+    after,
+    created_at,
+    created_sign
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+;
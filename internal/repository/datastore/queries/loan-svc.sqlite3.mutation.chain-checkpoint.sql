@@ -0,0 +1,2 @@
+INSERT INTO chain_checkpoints (checkpoint_id, chain, head_sequence, head_hash, created_at, created_sign)
+VALUES (?, ?, ?, ?, ?, ?);
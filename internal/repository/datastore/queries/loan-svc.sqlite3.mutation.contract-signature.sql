@@ -0,0 +1 @@
+INSERT INTO contract_signatures (contract_id, key_id, signature, created_at, created_sign) VALUES (?,?,?,?,?);
@@ -0,0 +1 @@
+INSERT INTO contracts (contract_id, loan_id, user_id, role_as, document, hash, created_at, created_sign) VALUES (?,?,?,?,?,?,?,?);
@@ -0,0 +1,3 @@
+-- the same content is stored once, rendered again from the same loan & template
+INSERT INTO documents (hash, loan_id, user_id, kind, template_version, media_type, content, created_at, created_sign) VALUES (?,?,?,?,?,?,?,?,?)
+ON CONFLICT (hash) DO NOTHING;
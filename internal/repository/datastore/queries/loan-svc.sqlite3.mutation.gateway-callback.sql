@@ -0,0 +1,2 @@
+INSERT OR IGNORE INTO gateway_callbacks (callback_id, gateway_transaction_id, status, reference, iso4217, amount, payload, signature, created_at, created_sign)
+VALUES (?,?,?,?,?,?,?,?,?,?);
@@ -0,0 +1 @@
+INSERT INTO ledger_journals (journal_id, loan_id, description, created_at, created_sign) VALUES (?,?,?,?,?);
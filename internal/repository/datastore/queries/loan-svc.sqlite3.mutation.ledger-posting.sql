@@ -0,0 +1 @@
+INSERT INTO ledger_postings (journal_id, account, user_id, iso4217, debit, credit) VALUES (?,?,?,?,?,?);
@@ -0,0 +1 @@
+INSERT OR IGNORE INTO loan_accruals (loan_id, accrual_date, day_count, iso4217, amount, accrued, created_at, created_sign) VALUES (?,?,?,?,?,?,?,?);
@@ -1,3 +1,3 @@
 UPDATE loans
-SET loan_state=?, approved_by=?, approved_doc=?, approved_at=?, approved_sign=?
+SET loan_state=?, approved_by=?, approved_doc=?, approved_at=?, approved_sign=?, approved_sign_version=?
 WHERE loan_id=? AND loan_state=?;
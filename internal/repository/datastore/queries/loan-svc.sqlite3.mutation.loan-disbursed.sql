@@ -1,3 +1,3 @@
 UPDATE loans
-SET loan_state=?, disbursed_by=?, disbursed_doc=?, disbursed_at=?, disbursed_sign=?
+SET loan_state=?, disbursed_by=?, disbursed_doc=?, disbursed_at=?, disbursed_sign=?, disbursed_sign_version=?
 WHERE loan_id=? AND loan_state=?;
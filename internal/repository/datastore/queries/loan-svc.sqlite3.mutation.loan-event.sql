@@ -0,0 +1,2 @@
+INSERT INTO loan_events (event_id, loan_id, version, event_type, payload, created_at, created_sign, prev_hash, prev_loan_hash, hash)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
@@ -0,0 +1,3 @@
+DELETE FROM loan_party_payments WHERE loan_party_id IN (SELECT lp.loan_party_id FROM loan_parties lp WHERE lp.loan_id = ?);
+DELETE FROM loan_parties WHERE loan_id = ?;
+DELETE FROM loans WHERE loan_id = ?;
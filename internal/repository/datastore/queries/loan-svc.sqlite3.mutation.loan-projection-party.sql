@@ -0,0 +1 @@
+INSERT INTO loan_parties (loan_party_id, loan_id, user_id, role_as, created_at, created_sign, created_sign_version) VALUES (?,?,?,?,?,?,?);
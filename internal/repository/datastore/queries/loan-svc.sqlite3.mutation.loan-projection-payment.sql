@@ -0,0 +1 @@
+INSERT INTO loan_party_payments (loan_party_id, payment_id, payment_type, iso4217, amount, due_time, details, settled_at, created_at, created_sign, created_sign_version, chain_sequence, prev_hash, prev_loan_hash, hash) VALUES (?,?,?,?,?,?,?,?,?,?,?,NULLIF(?,0),?,?,?);
@@ -0,0 +1,7 @@
+INSERT INTO loans (
+    loan_id, loan_state,
+    approved_by, approved_doc, approved_at, approved_sign, approved_sign_version,
+    disbursed_by, disbursed_doc, disbursed_at, disbursed_sign, disbursed_sign_version,
+    apr, effective_rate, payment_reference,
+    created_at, created_sign, created_sign_version
+) VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?);
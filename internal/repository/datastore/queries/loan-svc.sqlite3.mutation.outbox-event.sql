@@ -0,0 +1 @@
+INSERT INTO outbox (event_id, event_type, aggregate_id, payload, created_at, created_sign) VALUES (?,?,?,?,?,?);
@@ -0,0 +1 @@
+UPDATE outbox SET published_at = ? WHERE event_id = ? AND published_at IS NULL;
@@ -0,0 +1,2 @@
+UPDATE payout_instructions SET status = 3, updated_at = ?, updated_sign = ? WHERE payment_id = ? AND status = 2 -- confirmed once exported
+RETURNING instruction_id, loan_id, batch_id;
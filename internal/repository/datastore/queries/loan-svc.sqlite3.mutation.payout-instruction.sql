@@ -0,0 +1 @@
+INSERT INTO payout_instructions (instruction_id, loan_id, payment_id, iso4217, amount, account_name, account_number, bank_code, reference, status, created_at, created_sign, updated_at, updated_sign) VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?);
@@ -0,0 +1,8 @@
+UPDATE payout_instructions SET
+    status          = ?,
+    status_reason   = ?,
+    batch_id        = COALESCE(?, batch_id),
+    updated_at      = ?,
+    updated_sign    = ?
+WHERE instruction_id = ? AND status = ?
+RETURNING loan_id, payment_id, batch_id;
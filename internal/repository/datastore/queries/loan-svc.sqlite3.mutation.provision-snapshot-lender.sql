@@ -0,0 +1 @@
+INSERT INTO provision_snapshot_lenders (snapshot_id, user_id, loan_id, iso4217, share, ead, ecl) VALUES (?,?,?,?,?,?,?);
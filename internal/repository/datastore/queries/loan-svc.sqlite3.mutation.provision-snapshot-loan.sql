@@ -0,0 +1 @@
+INSERT INTO provision_snapshot_loans (snapshot_id, loan_id, iso4217, days_past_due, stage, pd, lgd, ead, ecl) VALUES (?,?,?,?,?,?,?,?,?);
@@ -0,0 +1 @@
+INSERT INTO provision_snapshots (snapshot_id, as_of, created_at, created_sign) VALUES (?,?,?,?);
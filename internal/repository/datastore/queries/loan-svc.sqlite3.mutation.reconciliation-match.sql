@@ -0,0 +1,2 @@
+INSERT INTO reconciliation_matches (transaction_id, payment_id, match_type, amount_diff, days_diff, created_at, created_sign) VALUES (?,?,?,?,?,?,?);
+UPDATE loan_party_payments SET settled_at = ? WHERE payment_id = ? AND settled_at IS NULL;
@@ -0,0 +1,3 @@
+INSERT OR IGNORE INTO settled_transactions (transaction_id, bank_reference, reference, iso4217, amount, value_time, counterparty, source, loan_id, created_at, created_sign)
+VALUES (?,?,?,?,?,?,?,?,(SELECT l.loan_id FROM loans l WHERE l.loan_id = ? OR l.payment_reference = ?),?,?)
+RETURNING loan_id;
@@ -0,0 +1 @@
+UPDATE user_keys SET revoked_at=? WHERE key_id=? AND user_id=? AND revoked_at IS NULL;
@@ -0,0 +1 @@
+INSERT INTO user_keys (key_id, user_id, public_key, created_at, created_sign) VALUES (?,?,?,?,?);
@@ -0,0 +1,4 @@
+INSERT OR IGNORE INTO wallets (user_id, iso4217, available, reserved, updated_at, updated_sign) VALUES (?,?,0,0,?,?);
+UPDATE wallets
+SET available = available + ?, reserved = reserved + ?, updated_at = ?, updated_sign = ?
+WHERE user_id = ? AND iso4217 = ?;
@@ -0,0 +1,2 @@
+INSERT INTO webhook_attempts (delivery_id, attempt, status, status_code, error, duration_ms, next_attempt_at, created_at, created_sign) VALUES (?,?,?,?,?,?,?,?,?);
+UPDATE webhook_deliveries SET status = ?, attempts = ?, next_attempt_at = ?, updated_at = ?, updated_sign = ? WHERE delivery_id = ? AND status = 1;
@@ -0,0 +1,2 @@
+INSERT OR IGNORE INTO webhook_deliveries (delivery_id, subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at, created_at, created_sign, updated_at, updated_sign)
+VALUES (?,?,?,?,?,1,0,?,?,?,?,?);
@@ -0,0 +1 @@
+UPDATE webhook_deliveries SET status = 1, attempts = 0, next_attempt_at = ?, updated_at = ?, updated_sign = ? WHERE delivery_id = ? AND status = 3;
@@ -0,0 +1,3 @@
+INSERT INTO webhook_subscriptions (subscription_id, url, event_types, secret, active, created_at, created_sign, updated_at, updated_sign)
+VALUES (?,?,?,?,?,?,?,?,?)
+ON CONFLICT (subscription_id) DO UPDATE SET active = excluded.active, updated_at = excluded.updated_at, updated_sign = excluded.updated_sign;
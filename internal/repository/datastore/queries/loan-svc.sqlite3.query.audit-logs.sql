@@ -0,0 +1,16 @@
+SELECT
+    al.sequence,
+    al.audit_id,
+    al.operation,
+    al.loan_id,
+    al.actor,
+    al.client_ip,
+    al.request_id,
+    al.before,
+    al.after,
+    al.created_at,
+    al.created_sign
+FROM audit_logs al
+WHERE al.loan_id = ?
+ORDER BY al.sequence
+;
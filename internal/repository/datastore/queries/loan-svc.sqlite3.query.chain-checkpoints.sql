@@ -0,0 +1,10 @@
+SELECT
+    cc.checkpoint_id,
+    cc.chain,
+    cc.head_sequence,
+    cc.head_hash,
+    cc.created_at,
+    cc.created_sign
+FROM chain_checkpoints cc
+ORDER BY cc.sequence
+;
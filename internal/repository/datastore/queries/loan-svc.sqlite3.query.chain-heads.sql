@@ -0,0 +1,15 @@
+-- head of each chain, a chain without any chained row is not listed
+SELECT * FROM (
+    SELECT 'loan_party_payments', lpp.chain_sequence, lpp.hash
+    FROM loan_party_payments lpp
+    WHERE lpp.chain_sequence IS NOT NULL
+    ORDER BY lpp.chain_sequence DESC LIMIT 1
+)
+UNION ALL
+SELECT * FROM (
+    SELECT 'loan_events', le.sequence, le.hash
+    FROM loan_events le
+    WHERE le.hash IS NOT NULL
+    ORDER BY le.sequence DESC LIMIT 1
+)
+;
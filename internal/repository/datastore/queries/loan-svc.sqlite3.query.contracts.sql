@@ -0,0 +1,24 @@
+-- contracts along with their signatures & the key of each signature, a contract without signature is listed once
+SELECT
+    c.contract_id,
+    c.loan_id,
+    c.user_id,
+    c.role_as,
+    c.document,
+    c.hash,
+    c.created_at,
+    c.created_sign,
+    cs.key_id,
+    cs.signature,
+    cs.created_at,
+    cs.created_sign,
+    uk.user_id,
+    uk.public_key,
+    uk.revoked_at
+FROM contracts c
+LEFT JOIN contract_signatures cs ON cs.contract_id = c.contract_id
+LEFT JOIN user_keys uk ON uk.key_id = cs.key_id
+WHERE   (c.contract_id = ? AND ? IS NOT NULL)
+    OR  (c.loan_id = ? AND ? IS NOT NULL)
+ORDER BY c.rowid, cs.rowid
+;
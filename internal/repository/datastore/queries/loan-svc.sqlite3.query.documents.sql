@@ -0,0 +1,15 @@
+SELECT
+    d.hash,
+    d.loan_id,
+    d.user_id,
+    d.kind,
+    d.template_version,
+    d.media_type,
+    d.content,
+    d.created_at,
+    d.created_sign
+FROM documents d
+WHERE   (d.hash = ? AND ? IS NOT NULL)
+    OR  (d.loan_id = ? AND ? IS NOT NULL)
+ORDER BY d.rowid
+;
@@ -0,0 +1,8 @@
+SELECT
+    lp.iso4217,
+    TOTAL(lp.debit),
+    TOTAL(lp.credit)
+FROM ledger_postings lp
+WHERE lp.journal_id = ?
+GROUP BY lp.iso4217
+;
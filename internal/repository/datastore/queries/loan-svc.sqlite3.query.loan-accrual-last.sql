@@ -0,0 +1,4 @@
+SELECT COALESCE(MAX(la.accrual_date), 0)
+FROM loan_accruals la
+WHERE la.day_count = ?
+;
@@ -0,0 +1,6 @@
+-- version & hash of the last event of the loan, then the hash of the last event of every loans
+SELECT
+    (SELECT COALESCE(MAX(le.version), 0) FROM loan_events le WHERE le.loan_id = ?),
+    (SELECT le.hash FROM loan_events le WHERE le.loan_id = ? ORDER BY le.version DESC LIMIT 1),
+    (SELECT le.hash FROM loan_events le ORDER BY le.sequence DESC LIMIT 1)
+;
@@ -0,0 +1,16 @@
+SELECT
+    le.sequence,
+    le.event_id,
+    le.loan_id,
+    le.version,
+    le.event_type,
+    le.payload,
+    le.created_at,
+    le.created_sign,
+    le.prev_hash,
+    le.prev_loan_hash,
+    le.hash
+FROM loan_events le
+WHERE (le.loan_id = ? OR ? IS NULL)
+ORDER BY le.sequence
+;
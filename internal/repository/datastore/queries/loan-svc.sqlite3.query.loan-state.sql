@@ -0,0 +1 @@
+SELECT l.loan_state FROM loans l WHERE l.loan_id = ?;
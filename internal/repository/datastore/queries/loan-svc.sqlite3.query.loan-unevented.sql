@@ -0,0 +1 @@
+SELECT l.loan_id FROM loans l WHERE NOT EXISTS (SELECT 1 FROM loan_events le WHERE le.loan_id = l.loan_id) AND (l.loan_id = ? OR ? IS NULL) ORDER BY l.rowid;
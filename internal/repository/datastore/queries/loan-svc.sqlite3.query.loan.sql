@@ -5,27 +5,47 @@ SELECT
     l.approved_doc,
     l.approved_at,
     l.approved_sign,
+    l.approved_sign_version,
     l.disbursed_by,
     l.disbursed_doc,
     l.disbursed_at,
     l.disbursed_sign,
+    l.disbursed_sign_version,
+    l.apr,
+    l.effective_rate,
+    l.payment_reference,
     l.created_at,
     l.created_sign,
+    l.created_sign_version,
     lp.loan_party_id,
     lp.user_id,
     lp.role_as,
     lp.created_at,
     lp.created_sign,
+    lp.created_sign_version,
+    lpp.payment_id,
+    lpp.payment_type,
     lpp.iso4217,
     lpp.amount,
     lpp.due_time,
     lpp.details,
+    lpp.settled_at,
+    rm.created_at, -- settlement recorded, the value date is lpp.settled_at
     lpp.created_at,
-    lpp.created_sign
+    lpp.created_sign,
+    lpp.created_sign_version,
+    COALESCE(lpp.chain_sequence, 0),
+    lpp.prev_hash,
+    lpp.prev_loan_hash,
+    lpp.hash
 FROM loans l
 JOIN loan_parties lp ON lp.loan_id = l.loan_id
 JOIN loan_party_payments lpp on lpp.loan_party_id = lp.loan_party_id
+LEFT JOIN reconciliation_matches rm ON rm.payment_id = lpp.payment_id
 WHERE   (l.loan_id = ? AND ? IS NOT NULL)
     OR  (lp.user_id = ? AND lp.role_as = 1 AND ? IS NOT NULL)
-    OR  (lp.user_id = ? AND lp.role_as = 2 AND ? IS NOT NULL)
+    OR  (l.loan_id IN (SELECT loan_id FROM loan_parties WHERE user_id = ? AND role_as = 2) AND ? IS NOT NULL)
+    OR  (l.loan_state = ? AND ? > 0)
+    OR  (l.payment_reference = ? AND ? != '')
+ORDER BY l.rowid, lp.rowid, lpp.rowid
 ;
\ No newline at end of file
@@ -0,0 +1,14 @@
+SELECT
+    o.sequence,
+    o.event_id,
+    o.event_type,
+    o.aggregate_id,
+    o.payload,
+    o.created_at,
+    o.created_sign,
+    o.published_at
+FROM outbox o
+WHERE o.published_at IS NULL
+ORDER BY o.sequence
+LIMIT ?
+;
@@ -0,0 +1,20 @@
+-- chained payments in order, the party is left joined so that a payment without party is still verified
+SELECT
+    lpp.chain_sequence,
+    lpp.payment_id,
+    lpp.loan_party_id,
+    lp.loan_id,
+    lpp.payment_type,
+    lpp.iso4217,
+    lpp.amount,
+    lpp.due_time,
+    lpp.details,
+    lpp.created_at,
+    lpp.prev_hash,
+    lpp.prev_loan_hash,
+    lpp.hash
+FROM loan_party_payments lpp
+LEFT JOIN loan_parties lp ON lp.loan_party_id = lpp.loan_party_id
+WHERE lpp.chain_sequence IS NOT NULL
+ORDER BY lpp.chain_sequence
+;
@@ -0,0 +1,12 @@
+-- position & hash of the last chained payment, then the hash of the last chained payment of the loan
+SELECT
+    (SELECT COALESCE(MAX(lpp.chain_sequence), 0) FROM loan_party_payments lpp),
+    (SELECT lpp.hash FROM loan_party_payments lpp WHERE lpp.chain_sequence IS NOT NULL ORDER BY lpp.chain_sequence DESC LIMIT 1),
+    (
+        SELECT lpp.hash
+        FROM loan_party_payments lpp
+        JOIN loan_parties lp ON lp.loan_party_id = lpp.loan_party_id
+        WHERE lp.loan_id = ? AND lpp.chain_sequence IS NOT NULL
+        ORDER BY lpp.chain_sequence DESC LIMIT 1
+    )
+;
@@ -0,0 +1 @@
+SELECT lp.loan_id FROM loan_party_payments lpp JOIN loan_parties lp ON lp.loan_party_id = lpp.loan_party_id WHERE lpp.payment_id = ?;
@@ -0,0 +1,21 @@
+SELECT
+    pi.instruction_id,
+    pi.loan_id,
+    pi.payment_id,
+    pi.iso4217,
+    pi.amount,
+    pi.account_name,
+    pi.account_number,
+    pi.bank_code,
+    pi.reference,
+    pi.status,
+    pi.status_reason,
+    pi.batch_id,
+    pi.created_at,
+    pi.created_sign,
+    pi.updated_at,
+    pi.updated_sign
+FROM payout_instructions pi
+WHERE (pi.status = ? OR ? = 0) AND (pi.loan_id = ? OR ? IS NULL) AND (pi.batch_id = ? OR ? IS NULL)
+ORDER BY pi.created_at, pi.rowid
+;
@@ -0,0 +1,11 @@
+SELECT
+    psl.user_id,
+    psl.loan_id,
+    psl.iso4217,
+    psl.share,
+    psl.ead,
+    psl.ecl
+FROM provision_snapshot_lenders psl
+WHERE psl.snapshot_id = ?
+ORDER BY psl.rowid
+;
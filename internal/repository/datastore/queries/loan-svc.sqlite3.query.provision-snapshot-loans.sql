@@ -0,0 +1,13 @@
+SELECT
+    psl.loan_id,
+    psl.iso4217,
+    psl.days_past_due,
+    psl.stage,
+    psl.pd,
+    psl.lgd,
+    psl.ead,
+    psl.ecl
+FROM provision_snapshot_loans psl
+WHERE psl.snapshot_id = ?
+ORDER BY psl.rowid
+;
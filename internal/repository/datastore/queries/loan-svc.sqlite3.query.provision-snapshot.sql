@@ -0,0 +1,8 @@
+SELECT
+    ps.snapshot_id,
+    ps.as_of,
+    ps.created_at,
+    ps.created_sign
+FROM provision_snapshots ps
+WHERE ps.as_of = ?
+;
@@ -0,0 +1,30 @@
+SELECT
+    rm.transaction_id,
+    rm.payment_id,
+    rm.match_type,
+    rm.amount_diff,
+    rm.days_diff,
+    rm.created_at,
+    rm.created_sign,
+    st.bank_reference,
+    st.reference,
+    st.iso4217,
+    st.amount,
+    st.value_time,
+    st.counterparty,
+    st.source,
+    st.loan_id,
+    lp.loan_id,
+    lp.user_id,
+    lpp.payment_type,
+    lpp.iso4217,
+    lpp.amount,
+    lpp.due_time,
+    lpp.details
+FROM reconciliation_matches rm
+JOIN settled_transactions st ON st.transaction_id = rm.transaction_id
+JOIN loan_party_payments lpp ON lpp.payment_id = rm.payment_id
+JOIN loan_parties lp ON lp.loan_party_id = lpp.loan_party_id
+WHERE st.value_time >= ? AND st.value_time < ?
+ORDER BY st.value_time, st.rowid
+;
@@ -0,0 +1,17 @@
+SELECT
+    l.loan_id,
+    lp.user_id,
+    COALESCE(l.payment_reference, ''),
+    lpp.payment_id,
+    lpp.payment_type,
+    lpp.iso4217,
+    lpp.amount,
+    lpp.due_time,
+    lpp.details
+FROM loans l
+JOIN loan_parties lp ON lp.loan_id = l.loan_id AND lp.role_as = 1 -- borrower
+JOIN loan_party_payments lpp ON lpp.loan_party_id = lp.loan_party_id
+WHERE l.loan_state = 4 -- disbursed
+    AND lpp.settled_at IS NULL
+ORDER BY lpp.due_time, lpp.rowid
+;
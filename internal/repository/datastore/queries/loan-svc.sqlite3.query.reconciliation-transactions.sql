@@ -0,0 +1,17 @@
+SELECT
+    st.transaction_id,
+    st.bank_reference,
+    st.reference,
+    st.iso4217,
+    st.amount,
+    st.value_time,
+    st.counterparty,
+    st.source,
+    st.loan_id,
+    st.created_at,
+    st.created_sign
+FROM settled_transactions st
+LEFT JOIN reconciliation_matches rm ON rm.transaction_id = st.transaction_id
+WHERE rm.transaction_id IS NULL
+ORDER BY st.value_time, st.rowid
+;
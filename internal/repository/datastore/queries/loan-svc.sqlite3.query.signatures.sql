@@ -0,0 +1,55 @@
+-- every signature stored, as pk + signature of the unix timestamp or of the canonical content of the loan tables since
+-- sign_version 2, verified along with the loan of loan_id
+SELECT 'loans' AS tbl, rowid AS row_id, 'created_sign' AS col, created_at AS signed_at, created_sign AS sign, loan_id, loan_id AS row_key, created_sign_version AS sign_version FROM loans
+UNION ALL
+SELECT 'loans' AS tbl, rowid AS row_id, 'approved_sign' AS col, approved_at AS signed_at, approved_sign AS sign, loan_id, loan_id AS row_key, approved_sign_version AS sign_version FROM loans WHERE approved_sign IS NOT NULL
+UNION ALL
+SELECT 'loans' AS tbl, rowid AS row_id, 'disbursed_sign' AS col, disbursed_at AS signed_at, disbursed_sign AS sign, loan_id, loan_id AS row_key, disbursed_sign_version AS sign_version FROM loans WHERE disbursed_sign IS NOT NULL
+UNION ALL
+SELECT 'loan_parties' AS tbl, rowid AS row_id, 'created_sign' AS col, created_at AS signed_at, created_sign AS sign, loan_id, loan_party_id AS row_key, created_sign_version AS sign_version FROM loan_parties
+UNION ALL
+SELECT 'loan_party_payments' AS tbl, lpp.rowid AS row_id, 'created_sign' AS col, lpp.created_at AS signed_at, lpp.created_sign AS sign, lp.loan_id, lpp.payment_id AS row_key, lpp.created_sign_version AS sign_version FROM loan_party_payments lpp JOIN loan_parties lp ON lp.loan_party_id = lpp.loan_party_id
+UNION ALL
+SELECT 'loan_accruals' AS tbl, rowid AS row_id, 'created_sign' AS col, created_at AS signed_at, created_sign AS sign, NULL AS loan_id, NULL AS row_key, 1 AS sign_version FROM loan_accruals
+UNION ALL
+SELECT 'provision_snapshots' AS tbl, rowid AS row_id, 'created_sign' AS col, created_at AS signed_at, created_sign AS sign, NULL AS loan_id, NULL AS row_key, 1 AS sign_version FROM provision_snapshots
+UNION ALL
+SELECT 'ledger_journals' AS tbl, rowid AS row_id, 'created_sign' AS col, created_at AS signed_at, created_sign AS sign, NULL AS loan_id, NULL AS row_key, 1 AS sign_version FROM ledger_journals
+UNION ALL
+SELECT 'wallets' AS tbl, rowid AS row_id, 'updated_sign' AS col, updated_at AS signed_at, updated_sign AS sign, NULL AS loan_id, NULL AS row_key, 1 AS sign_version FROM wallets
+UNION ALL
+SELECT 'settled_transactions' AS tbl, rowid AS row_id, 'created_sign' AS col, created_at AS signed_at, created_sign AS sign, NULL AS loan_id, NULL AS row_key, 1 AS sign_version FROM settled_transactions
+UNION ALL
+SELECT 'reconciliation_matches' AS tbl, rowid AS row_id, 'created_sign' AS col, created_at AS signed_at, created_sign AS sign, NULL AS loan_id, NULL AS row_key, 1 AS sign_version FROM reconciliation_matches
+UNION ALL
+SELECT 'payout_instructions' AS tbl, rowid AS row_id, 'created_sign' AS col, created_at AS signed_at, created_sign AS sign, NULL AS loan_id, NULL AS row_key, 1 AS sign_version FROM payout_instructions
+UNION ALL
+SELECT 'payout_instructions' AS tbl, rowid AS row_id, 'updated_sign' AS col, updated_at AS signed_at, updated_sign AS sign, NULL AS loan_id, NULL AS row_key, 1 AS sign_version FROM payout_instructions
+UNION ALL
+SELECT 'gateway_callbacks' AS tbl, rowid AS row_id, 'created_sign' AS col, created_at AS signed_at, created_sign AS sign, NULL AS loan_id, NULL AS row_key, 1 AS sign_version FROM gateway_callbacks
+UNION ALL
+SELECT 'outbox' AS tbl, rowid AS row_id, 'created_sign' AS col, created_at AS signed_at, created_sign AS sign, NULL AS loan_id, NULL AS row_key, 1 AS sign_version FROM outbox
+UNION ALL
+SELECT 'webhook_subscriptions' AS tbl, rowid AS row_id, 'created_sign' AS col, created_at AS signed_at, created_sign AS sign, NULL AS loan_id, NULL AS row_key, 1 AS sign_version FROM webhook_subscriptions
+UNION ALL
+SELECT 'webhook_subscriptions' AS tbl, rowid AS row_id, 'updated_sign' AS col, updated_at AS signed_at, updated_sign AS sign, NULL AS loan_id, NULL AS row_key, 1 AS sign_version FROM webhook_subscriptions
+UNION ALL
+SELECT 'webhook_deliveries' AS tbl, rowid AS row_id, 'created_sign' AS col, created_at AS signed_at, created_sign AS sign, NULL AS loan_id, NULL AS row_key, 1 AS sign_version FROM webhook_deliveries
+UNION ALL
+SELECT 'webhook_deliveries' AS tbl, rowid AS row_id, 'updated_sign' AS col, updated_at AS signed_at, updated_sign AS sign, NULL AS loan_id, NULL AS row_key, 1 AS sign_version FROM webhook_deliveries
+UNION ALL
+SELECT 'webhook_attempts' AS tbl, rowid AS row_id, 'created_sign' AS col, created_at AS signed_at, created_sign AS sign, NULL AS loan_id, NULL AS row_key, 1 AS sign_version FROM webhook_attempts
+UNION ALL
+SELECT 'loan_events' AS tbl, rowid AS row_id, 'created_sign' AS col, created_at AS signed_at, created_sign AS sign, NULL AS loan_id, NULL AS row_key, 1 AS sign_version FROM loan_events
+UNION ALL
+SELECT 'audit_logs' AS tbl, rowid AS row_id, 'created_sign' AS col, created_at AS signed_at, created_sign AS sign, NULL AS loan_id, NULL AS row_key, 1 AS sign_version FROM audit_logs
+UNION ALL
+SELECT 'user_keys' AS tbl, rowid AS row_id, 'created_sign' AS col, created_at AS signed_at, created_sign AS sign, NULL AS loan_id, NULL AS row_key, 1 AS sign_version FROM user_keys
+UNION ALL
+SELECT 'contracts' AS tbl, rowid AS row_id, 'created_sign' AS col, created_at AS signed_at, created_sign AS sign, NULL AS loan_id, NULL AS row_key, 1 AS sign_version FROM contracts
+UNION ALL
+SELECT 'contract_signatures' AS tbl, rowid AS row_id, 'created_sign' AS col, created_at AS signed_at, created_sign AS sign, NULL AS loan_id, NULL AS row_key, 1 AS sign_version FROM contract_signatures
+UNION ALL
+SELECT 'documents' AS tbl, rowid AS row_id, 'created_sign' AS col, created_at AS signed_at, created_sign AS sign, NULL AS loan_id, NULL AS row_key, 1 AS sign_version FROM documents
+ORDER BY tbl, row_id, col
+;
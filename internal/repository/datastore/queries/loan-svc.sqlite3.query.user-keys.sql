@@ -0,0 +1,12 @@
+SELECT
+    uk.key_id,
+    uk.user_id,
+    uk.public_key,
+    uk.created_at,
+    uk.created_sign,
+    uk.revoked_at
+FROM user_keys uk
+WHERE   (uk.user_id = ? AND ? IS NOT NULL)
+    OR  (uk.key_id = ? AND ? IS NOT NULL)
+ORDER BY uk.rowid
+;
@@ -0,0 +1,11 @@
+SELECT
+    w.user_id,
+    w.iso4217,
+    w.available,
+    w.reserved,
+    w.updated_at,
+    w.updated_sign
+FROM wallets w
+WHERE w.user_id = ? AND (w.iso4217 = ? OR ? = '')
+ORDER BY w.iso4217
+;
@@ -0,0 +1,15 @@
+SELECT
+    wa.delivery_id,
+    wa.attempt,
+    wa.status,
+    wa.status_code,
+    wa.error,
+    wa.duration_ms,
+    wa.next_attempt_at,
+    wa.created_at,
+    wa.created_sign
+FROM webhook_attempts wa
+JOIN webhook_deliveries wd ON wd.delivery_id = wa.delivery_id
+WHERE (wd.subscription_id = ? OR ? IS NULL) AND (wd.delivery_id = ? OR ? IS NULL) AND (wd.status = ? OR ? = 0)
+ORDER BY wa.created_at, wa.rowid
+;
@@ -0,0 +1,22 @@
+SELECT
+    wd.delivery_id,
+    wd.subscription_id,
+    wd.event_id,
+    wd.event_type,
+    wd.payload,
+    wd.status,
+    wd.attempts,
+    wd.next_attempt_at,
+    ws.url,
+    ws.secret,
+    wd.created_at,
+    wd.created_sign,
+    wd.updated_at,
+    wd.updated_sign
+FROM webhook_deliveries wd
+JOIN webhook_subscriptions ws ON ws.subscription_id = wd.subscription_id
+WHERE (wd.subscription_id = ? OR ? IS NULL) AND (wd.delivery_id = ? OR ? IS NULL) AND (wd.status = ? OR ? = 0)
+    AND (? = 0 OR (wd.status = 1 AND wd.next_attempt_at <= ? AND ws.active = 1)) -- due for delivery
+ORDER BY wd.next_attempt_at, wd.rowid
+LIMIT ?
+;
@@ -0,0 +1,14 @@
+SELECT
+    ws.subscription_id,
+    ws.url,
+    ws.event_types,
+    ws.secret,
+    ws.active,
+    ws.created_at,
+    ws.created_sign,
+    ws.updated_at,
+    ws.updated_sign
+FROM webhook_subscriptions ws
+WHERE (ws.subscription_id = ? OR ? IS NULL) AND (ws.active = 1 OR ? = 0)
+ORDER BY ws.created_at, ws.rowid
+;
@@ -7,16 +7,176 @@ var (
 
 	//go:embed loan-svc.sqlite3.migration.000.sql
 	lss3_migration_000 string
+	//go:embed loan-svc.sqlite3.migration.001.sql
+	lss3_migration_001 string
+	//go:embed loan-svc.sqlite3.migration.002.sql
+	lss3_migration_002 string
+	//go:embed loan-svc.sqlite3.migration.003.sql
+	lss3_migration_003 string
+	//go:embed loan-svc.sqlite3.migration.004.sql
+	lss3_migration_004 string
+	//go:embed loan-svc.sqlite3.migration.005.sql
+	lss3_migration_005 string
+	//go:embed loan-svc.sqlite3.migration.006.sql
+	lss3_migration_006 string
+	//go:embed loan-svc.sqlite3.migration.007.sql
+	lss3_migration_007 string
+	//go:embed loan-svc.sqlite3.migration.008.sql
+	lss3_migration_008 string
+	//go:embed loan-svc.sqlite3.migration.009.sql
+	lss3_migration_009 string
+	//go:embed loan-svc.sqlite3.migration.010.sql
+	lss3_migration_010 string
+	//go:embed loan-svc.sqlite3.migration.011.sql
+	lss3_migration_011 string
+	//go:embed loan-svc.sqlite3.migration.012.sql
+	lss3_migration_012 string
+	//go:embed loan-svc.sqlite3.migration.013.sql
+	lss3_migration_013 string
+	//go:embed loan-svc.sqlite3.migration.014.sql
+	lss3_migration_014 string
+	//go:embed loan-svc.sqlite3.migration.015.sql
+	lss3_migration_015 string
+	//go:embed loan-svc.sqlite3.migration.016.sql
+	lss3_migration_016 string
+	//go:embed loan-svc.sqlite3.migration.017.sql
+	lss3_migration_017 string
+	//go:embed loan-svc.sqlite3.migration.018.sql
+	lss3_migration_018 string
+	//go:embed loan-svc.sqlite3.migration.019.sql
+	lss3_migration_019 string
+	//go:embed loan-svc.sqlite3.migration.020.sql
+	lss3_migration_020 string
+	//go:embed loan-svc.sqlite3.mutation.audit-log.sql
+	lss3_mut_audit_log string
+	//go:embed loan-svc.sqlite3.mutation.chain-checkpoint.sql
+	lss3_mut_chain_checkpoint string
+	//go:embed loan-svc.sqlite3.mutation.contract-signature.sql
+	lss3_mut_contract_signature string
+	//go:embed loan-svc.sqlite3.mutation.contract.sql
+	lss3_mut_contract string
+	//go:embed loan-svc.sqlite3.mutation.document.sql
+	lss3_mut_document string
+	//go:embed loan-svc.sqlite3.mutation.gateway-callback.sql
+	lss3_mut_gateway_callback string
+	//go:embed loan-svc.sqlite3.mutation.ledger-journal.sql
+	lss3_mut_ledger_journal string
+	//go:embed loan-svc.sqlite3.mutation.ledger-posting.sql
+	lss3_mut_ledger_posting string
+	//go:embed loan-svc.sqlite3.mutation.loan-accrued.sql
+	lss3_mut_loan_accrued string
 	//go:embed loan-svc.sqlite3.mutation.loan-approved.sql
 	lss3_mut_loan_approved string
 	//go:embed loan-svc.sqlite3.mutation.loan-disbursed.sql
 	lss3_mut_loan_disbursed string
+	//go:embed loan-svc.sqlite3.mutation.loan-event.sql
+	lss3_mut_loan_event string
 	//go:embed loan-svc.sqlite3.mutation.loan-invested.sql
 	lss3_mut_loan_invested string
+	//go:embed loan-svc.sqlite3.mutation.loan-projection-deleted.sql
+	lss3_mut_loan_projection_deleted string
+	//go:embed loan-svc.sqlite3.mutation.loan-projection-party.sql
+	lss3_mut_loan_projection_party string
+	//go:embed loan-svc.sqlite3.mutation.loan-projection-payment.sql
+	lss3_mut_loan_projection_payment string
+	//go:embed loan-svc.sqlite3.mutation.loan-projection.sql
+	lss3_mut_loan_projection string
+	//go:embed loan-svc.sqlite3.mutation.loan-proposed-payment.sql
+	lss3_mut_loan_proposed_payment string
 	//go:embed loan-svc.sqlite3.mutation.loan-proposed.sql
 	lss3_mut_loan_proposed string
+	//go:embed loan-svc.sqlite3.mutation.outbox-event.sql
+	lss3_mut_outbox_event string
+	//go:embed loan-svc.sqlite3.mutation.outbox-published.sql
+	lss3_mut_outbox_published string
+	//go:embed loan-svc.sqlite3.mutation.payout-confirmed.sql
+	lss3_mut_payout_confirmed string
+	//go:embed loan-svc.sqlite3.mutation.payout-instruction.sql
+	lss3_mut_payout_instruction string
+	//go:embed loan-svc.sqlite3.mutation.payout-status.sql
+	lss3_mut_payout_status string
+	//go:embed loan-svc.sqlite3.mutation.provision-snapshot-lender.sql
+	lss3_mut_provision_snapshot_lender string
+	//go:embed loan-svc.sqlite3.mutation.provision-snapshot-loan.sql
+	lss3_mut_provision_snapshot_loan string
+	//go:embed loan-svc.sqlite3.mutation.provision-snapshot.sql
+	lss3_mut_provision_snapshot string
+	//go:embed loan-svc.sqlite3.mutation.reconciliation-match.sql
+	lss3_mut_reconciliation_match string
+	//go:embed loan-svc.sqlite3.mutation.settled-transaction.sql
+	lss3_mut_settled_transaction string
+	//go:embed loan-svc.sqlite3.mutation.user-key-revoked.sql
+	lss3_mut_user_key_revoked string
+	//go:embed loan-svc.sqlite3.mutation.user-key.sql
+	lss3_mut_user_key string
+	//go:embed loan-svc.sqlite3.mutation.wallet.sql
+	lss3_mut_wallet string
+	//go:embed loan-svc.sqlite3.mutation.webhook-attempt.sql
+	lss3_mut_webhook_attempt string
+	//go:embed loan-svc.sqlite3.mutation.webhook-delivery.sql
+	lss3_mut_webhook_delivery string
+	//go:embed loan-svc.sqlite3.mutation.webhook-replay.sql
+	lss3_mut_webhook_replay string
+	//go:embed loan-svc.sqlite3.mutation.webhook-subscription.sql
+	lss3_mut_webhook_subscription string
+	//go:embed loan-svc.sqlite3.query.audit-logs.sql
+	lss3_qry_audit_logs string
+	//go:embed loan-svc.sqlite3.query.chain-checkpoints.sql
+	lss3_qry_chain_checkpoints string
+	//go:embed loan-svc.sqlite3.query.chain-heads.sql
+	lss3_qry_chain_heads string
+	//go:embed loan-svc.sqlite3.query.contracts.sql
+	lss3_qry_contracts string
+	//go:embed loan-svc.sqlite3.query.documents.sql
+	lss3_qry_documents string
+	//go:embed loan-svc.sqlite3.query.ledger-journal-balance.sql
+	lss3_qry_ledger_journal_balance string
+	//go:embed loan-svc.sqlite3.query.loan-accrual-last.sql
+	lss3_qry_loan_accrual_last string
+	//go:embed loan-svc.sqlite3.query.loan-event-head.sql
+	lss3_qry_loan_event_head string
+	//go:embed loan-svc.sqlite3.query.loan-events.sql
+	lss3_qry_loan_events string
+	//go:embed loan-svc.sqlite3.query.loan-state.sql
+	lss3_qry_loan_state string
+	//go:embed loan-svc.sqlite3.query.loan-unevented.sql
+	lss3_qry_loan_unevented string
 	//go:embed loan-svc.sqlite3.query.loan.sql
 	lss3_qry_loan string
+	//go:embed loan-svc.sqlite3.query.outbox.sql
+	lss3_qry_outbox string
+	//go:embed loan-svc.sqlite3.query.payment-chain.sql
+	lss3_qry_payment_chain string
+	//go:embed loan-svc.sqlite3.query.payment-head.sql
+	lss3_qry_payment_head string
+	//go:embed loan-svc.sqlite3.query.payment-loan.sql
+	lss3_qry_payment_loan string
+	//go:embed loan-svc.sqlite3.query.payout-instructions.sql
+	lss3_qry_payout_instructions string
+	//go:embed loan-svc.sqlite3.query.provision-snapshot-lenders.sql
+	lss3_qry_provision_snapshot_lenders string
+	//go:embed loan-svc.sqlite3.query.provision-snapshot-loans.sql
+	lss3_qry_provision_snapshot_loans string
+	//go:embed loan-svc.sqlite3.query.provision-snapshot.sql
+	lss3_qry_provision_snapshot string
+	//go:embed loan-svc.sqlite3.query.reconciliation-matches.sql
+	lss3_qry_reconciliation_matches string
+	//go:embed loan-svc.sqlite3.query.reconciliation-payments.sql
+	lss3_qry_reconciliation_payments string
+	//go:embed loan-svc.sqlite3.query.reconciliation-transactions.sql
+	lss3_qry_reconciliation_transactions string
+	//go:embed loan-svc.sqlite3.query.signatures.sql
+	lss3_qry_signatures string
+	//go:embed loan-svc.sqlite3.query.user-keys.sql
+	lss3_qry_user_keys string
+	//go:embed loan-svc.sqlite3.query.wallet.sql
+	lss3_qry_wallet string
+	//go:embed loan-svc.sqlite3.query.webhook-attempts.sql
+	lss3_qry_webhook_attempts string
+	//go:embed loan-svc.sqlite3.query.webhook-deliveries.sql
+	lss3_qry_webhook_deliveries string
+	//go:embed loan-svc.sqlite3.query.webhook-subscriptions.sql
+	lss3_qry_webhook_subscriptions string
 
 	LoanSvc loan_svc
 )
@@ -24,9 +184,116 @@ var (
 type loan_svc struct{ SQLite3 lss3 }
 type lss3 struct{}
 
-func (lss3) Migration000() string          { return lss3_migration_000 }
-func (lss3) MutationLoanApproved() string  { return lss3_mut_loan_approved }
-func (lss3) MutationLoanDisbursed() string { return lss3_mut_loan_disbursed }
-func (lss3) MutationLoanInvested() string  { return lss3_mut_loan_invested }
-func (lss3) MutationLoanProposed() string  { return lss3_mut_loan_proposed }
-func (lss3) QueryLoan() string             { return lss3_qry_loan }
+func (lss3) Migration000() string                    { return lss3_migration_000 }
+func (lss3) Migration001() string                    { return lss3_migration_001 }
+func (lss3) Migration002() string                    { return lss3_migration_002 }
+func (lss3) Migration003() string                    { return lss3_migration_003 }
+func (lss3) Migration004() string                    { return lss3_migration_004 }
+func (lss3) Migration005() string                    { return lss3_migration_005 }
+func (lss3) Migration006() string                    { return lss3_migration_006 }
+func (lss3) Migration007() string                    { return lss3_migration_007 }
+func (lss3) Migration008() string                    { return lss3_migration_008 }
+func (lss3) Migration009() string                    { return lss3_migration_009 }
+func (lss3) Migration010() string                    { return lss3_migration_010 }
+func (lss3) Migration011() string                    { return lss3_migration_011 }
+func (lss3) Migration012() string                    { return lss3_migration_012 }
+func (lss3) Migration013() string                    { return lss3_migration_013 }
+func (lss3) Migration014() string                    { return lss3_migration_014 }
+func (lss3) Migration015() string                    { return lss3_migration_015 }
+func (lss3) Migration016() string                    { return lss3_migration_016 }
+func (lss3) Migration017() string                    { return lss3_migration_017 }
+func (lss3) Migration018() string                    { return lss3_migration_018 }
+func (lss3) Migration019() string                    { return lss3_migration_019 }
+func (lss3) Migration020() string                    { return lss3_migration_020 }
+func (lss3) MutationAuditLog() string                { return lss3_mut_audit_log }
+func (lss3) MutationChainCheckpoint() string         { return lss3_mut_chain_checkpoint }
+func (lss3) MutationContract() string                { return lss3_mut_contract }
+func (lss3) MutationContractSignature() string       { return lss3_mut_contract_signature }
+func (lss3) MutationDocument() string                { return lss3_mut_document }
+func (lss3) MutationGatewayCallback() string         { return lss3_mut_gateway_callback }
+func (lss3) MutationLedgerJournal() string           { return lss3_mut_ledger_journal }
+func (lss3) MutationLedgerPosting() string           { return lss3_mut_ledger_posting }
+func (lss3) MutationLoanAccrued() string             { return lss3_mut_loan_accrued }
+func (lss3) MutationLoanApproved() string            { return lss3_mut_loan_approved }
+func (lss3) MutationLoanDisbursed() string           { return lss3_mut_loan_disbursed }
+func (lss3) MutationLoanEvent() string               { return lss3_mut_loan_event }
+func (lss3) MutationLoanInvested() string            { return lss3_mut_loan_invested }
+func (lss3) MutationLoanProjection() string          { return lss3_mut_loan_projection }
+func (lss3) MutationLoanProjectionDeleted() string   { return lss3_mut_loan_projection_deleted }
+func (lss3) MutationLoanProjectionParty() string     { return lss3_mut_loan_projection_party }
+func (lss3) MutationLoanProjectionPayment() string   { return lss3_mut_loan_projection_payment }
+func (lss3) MutationLoanProposed() string            { return lss3_mut_loan_proposed }
+func (lss3) MutationLoanProposedPayment() string     { return lss3_mut_loan_proposed_payment }
+func (lss3) MutationOutboxEvent() string             { return lss3_mut_outbox_event }
+func (lss3) MutationOutboxPublished() string         { return lss3_mut_outbox_published }
+func (lss3) MutationPayoutConfirmed() string         { return lss3_mut_payout_confirmed }
+func (lss3) MutationPayoutInstruction() string       { return lss3_mut_payout_instruction }
+func (lss3) MutationPayoutStatus() string            { return lss3_mut_payout_status }
+func (lss3) MutationProvisionSnapshot() string       { return lss3_mut_provision_snapshot }
+func (lss3) MutationProvisionSnapshotLender() string { return lss3_mut_provision_snapshot_lender }
+func (lss3) MutationProvisionSnapshotLoan() string   { return lss3_mut_provision_snapshot_loan }
+func (lss3) MutationReconciliationMatch() string     { return lss3_mut_reconciliation_match }
+func (lss3) MutationSettledTransaction() string      { return lss3_mut_settled_transaction }
+func (lss3) MutationUserKey() string                 { return lss3_mut_user_key }
+func (lss3) MutationUserKeyRevoked() string          { return lss3_mut_user_key_revoked }
+func (lss3) MutationWallet() string                  { return lss3_mut_wallet }
+func (lss3) MutationWebhookAttempt() string          { return lss3_mut_webhook_attempt }
+func (lss3) MutationWebhookDelivery() string         { return lss3_mut_webhook_delivery }
+func (lss3) MutationWebhookReplay() string           { return lss3_mut_webhook_replay }
+func (lss3) MutationWebhookSubscription() string     { return lss3_mut_webhook_subscription }
+func (lss3) QueryAuditLogs() string                  { return lss3_qry_audit_logs }
+func (lss3) QueryChainCheckpoints() string           { return lss3_qry_chain_checkpoints }
+func (lss3) QueryChainHeads() string                 { return lss3_qry_chain_heads }
+func (lss3) QueryContracts() string                  { return lss3_qry_contracts }
+func (lss3) QueryDocuments() string                  { return lss3_qry_documents }
+func (lss3) QueryLedgerJournalBalance() string       { return lss3_qry_ledger_journal_balance }
+func (lss3) QueryLoan() string                       { return lss3_qry_loan }
+func (lss3) QueryLoanAccrualLast() string            { return lss3_qry_loan_accrual_last }
+func (lss3) QueryLoanEventHead() string              { return lss3_qry_loan_event_head }
+func (lss3) QueryLoanEvents() string                 { return lss3_qry_loan_events }
+func (lss3) QueryLoanState() string                  { return lss3_qry_loan_state }
+func (lss3) QueryLoanUnevented() string              { return lss3_qry_loan_unevented }
+func (lss3) QueryOutbox() string                     { return lss3_qry_outbox }
+func (lss3) QueryPaymentChain() string               { return lss3_qry_payment_chain }
+func (lss3) QueryPaymentHead() string                { return lss3_qry_payment_head }
+func (lss3) QueryPaymentLoan() string                { return lss3_qry_payment_loan }
+func (lss3) QueryPayoutInstructions() string         { return lss3_qry_payout_instructions }
+func (lss3) QueryProvisionSnapshot() string          { return lss3_qry_provision_snapshot }
+func (lss3) QueryProvisionSnapshotLenders() string   { return lss3_qry_provision_snapshot_lenders }
+func (lss3) QueryProvisionSnapshotLoans() string     { return lss3_qry_provision_snapshot_loans }
+func (lss3) QueryReconciliationMatches() string      { return lss3_qry_reconciliation_matches }
+func (lss3) QueryReconciliationPayments() string     { return lss3_qry_reconciliation_payments }
+func (lss3) QueryReconciliationTransactions() string { return lss3_qry_reconciliation_transactions }
+func (lss3) QuerySignatures() string                 { return lss3_qry_signatures }
+func (lss3) QueryUserKeys() string                   { return lss3_qry_user_keys }
+func (lss3) QueryWallet() string                     { return lss3_qry_wallet }
+func (lss3) QueryWebhookAttempts() string            { return lss3_qry_webhook_attempts }
+func (lss3) QueryWebhookDeliveries() string          { return lss3_qry_webhook_deliveries }
+func (lss3) QueryWebhookSubscriptions() string       { return lss3_qry_webhook_subscriptions }
+
+// Migrations will return all migration in order, the index is the version recorded as `PRAGMA user_version`.
+func (x lss3) Migrations() []string {
+	return []string{
+		x.Migration000(),
+		x.Migration001(),
+		x.Migration002(),
+		x.Migration003(),
+		x.Migration004(),
+		x.Migration005(),
+		x.Migration006(),
+		x.Migration007(),
+		x.Migration008(),
+		x.Migration009(),
+		x.Migration010(),
+		x.Migration011(),
+		x.Migration012(),
+		x.Migration013(),
+		x.Migration014(),
+		x.Migration015(),
+		x.Migration016(),
+		x.Migration017(),
+		x.Migration018(),
+		x.Migration019(),
+		x.Migration020(),
+	}
+}
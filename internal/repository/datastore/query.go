@@ -3,6 +3,10 @@ package datastore
 import (
 	"bytes"
 	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"database/sql"
+	"errors"
 
 	"github.com/gunawanwijaya/loan-svc/internal/repository/datastore/queries"
 	"github.com/gunawanwijaya/loan-svc/pkg"
@@ -11,19 +15,94 @@ import (
 type QueryRequest struct {
 	List []QueryRequest
 
-	Loans *QueryRequestLoans
+	Loans           *QueryRequestLoans
+	Accruals        *QueryRequestAccruals
+	Provisions      *QueryRequestProvisions
+	Wallets         *QueryRequestWallets
+	Reconciliations *QueryRequestReconciliations
+	Payouts         *QueryRequestPayouts
+	Outbox          *QueryRequestOutbox
+	Webhooks        *QueryRequestWebhooks
+	Deliveries      *QueryRequestDeliveries
+	LoanEvents      *QueryRequestLoanEvents
+	AuditLogs       *QueryRequestAuditLogs
+	Signatures      *QueryRequestSignatures
+	Chains          *QueryRequestChains
+	UserKeys        *QueryRequestUserKeys
+	Contracts       *QueryRequestContracts
+	Documents       *QueryRequestDocuments
 }
 
 type QueryResponse struct {
 	List []QueryResponse
 
-	Loans *QueryResponseLoans
+	Loans           *QueryResponseLoans
+	Accruals        *QueryResponseAccruals
+	Provisions      *QueryResponseProvisions
+	Wallets         *QueryResponseWallets
+	Reconciliations *QueryResponseReconciliations
+	Payouts         *QueryResponsePayouts
+	Outbox          *QueryResponseOutbox
+	Webhooks        *QueryResponseWebhooks
+	Deliveries      *QueryResponseDeliveries
+	LoanEvents      *QueryResponseLoanEvents
+	AuditLogs       *QueryResponseAuditLogs
+	Signatures      *QueryResponseSignatures
+	Chains          *QueryResponseChains
+	UserKeys        *QueryResponseUserKeys
+	Contracts       *QueryResponseContracts
+	Documents       *QueryResponseDocuments
 }
 
 func (x *datastore) Query(ctx context.Context, req QueryRequest) (res QueryResponse, err error) {
 	if req.Loans != nil {
 		return x.queryLoans(ctx, req)
 	}
+	if req.Accruals != nil {
+		return x.queryAccruals(ctx, req)
+	}
+	if req.Provisions != nil {
+		return x.queryProvisions(ctx, req)
+	}
+	if req.Wallets != nil {
+		return x.queryWallets(ctx, req)
+	}
+	if req.Reconciliations != nil {
+		return x.queryReconciliations(ctx, req)
+	}
+	if req.Payouts != nil {
+		return x.queryPayouts(ctx, req)
+	}
+	if req.Outbox != nil {
+		return x.queryOutbox(ctx, req)
+	}
+	if req.Webhooks != nil {
+		return x.queryWebhooks(ctx, req)
+	}
+	if req.Deliveries != nil {
+		return x.queryDeliveries(ctx, req)
+	}
+	if req.LoanEvents != nil {
+		return x.queryLoanEvents(ctx, req)
+	}
+	if req.AuditLogs != nil {
+		return x.queryAuditLogs(ctx, req)
+	}
+	if req.Signatures != nil {
+		return x.querySignatures(ctx, req)
+	}
+	if req.Chains != nil {
+		return x.queryChains(ctx, req)
+	}
+	if req.UserKeys != nil {
+		return x.queryUserKeys(ctx, req)
+	}
+	if req.Contracts != nil {
+		return x.queryContracts(ctx, req)
+	}
+	if req.Documents != nil {
+		return x.queryDocuments(ctx, req)
+	}
 	return
 }
 
@@ -33,32 +112,64 @@ func (x *datastore) queryLoans(ctx context.Context, req QueryRequest) (res Query
 	if err != nil {
 		return
 	}
-	rows, err := conn.QueryContext(ctx, queries.LoanSvc.SQLite3.QueryLoan(),
-		req.Loans.ByLoanID, req.Loans.ByLoanID,
-		req.Loans.ByBorrowerID, req.Loans.ByBorrowerID,
-		req.Loans.ByLenderID, req.Loans.ByLenderID,
+	defer conn.Close()
+
+	res.Loans = &QueryResponseLoans{}
+	var loans []Loan
+	if loans, err = loadLoans(ctx, conn, *req.Loans); err != nil {
+		return
+	}
+	for _, l := range loans {
+		x.verifyLoan(&l)
+		res.List = append(res.List, QueryResponse{Loans: &QueryResponseLoans{Loan: l}})
+	}
+	return
+}
+
+// verifyLoan will flag each row of the loan whose every signature is valid against its content.
+func (x *datastore) verifyLoan(l *Loan) {
+	l.Verified = x.verifyContent(loanCreated(*l)) == SignatureValid
+	if l.ApprovedAt != nil {
+		l.Verified = l.Verified && x.verifyContent(loanApproved(*l)) == SignatureValid
+	}
+	if l.DisbursedAt != nil {
+		l.Verified = l.Verified && x.verifyContent(loanDisbursed(*l)) == SignatureValid
+	}
+	for i := range l.Parties {
+		p := &l.Parties[i]
+		p.Verified = x.verifyContent(loanParty(l.LoanID, *p)) == SignatureValid
+		for j := range p.Payments {
+			p.Payments[j].Verified = x.verifyContent(loanPartyPayment(l.LoanID, p.LoanPartyID, p.Payments[j])) == SignatureValid
+		}
+	}
+}
+
+// querier is either a connection or a transaction.
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// loadLoans will group the joined rows into loans along with their parties & payments.
+func loadLoans(ctx context.Context, q querier, r QueryRequestLoans) (loans []Loan, err error) {
+	rows, err := q.QueryContext(ctx, queries.LoanSvc.SQLite3.QueryLoan(),
+		r.ByLoanID, r.ByLoanID,
+		r.ByBorrowerID, r.ByBorrowerID,
+		r.ByLenderID, r.ByLenderID,
+		r.ByLoanState, r.ByLoanState,
+		r.ByPaymentReference, r.ByPaymentReference,
 	)
 	if err != nil {
 		return
 	}
-	// if rows.NextResultSet() {
-	res.Loans = &QueryResponseLoans{}
 	var prevLoanID, prevLoanPartyID []byte
 	err = pkg.SQL.Scan(rows, func(i int, rx pkg.SQLRowsX) pkg.SQLScanFlow {
-		log := pkg.Context.SlogLogger(ctx)
-		_ = log
-		// log.DebugContext(ctx, "",
-		// 	slog.Int("i", i),
-		// 	slog.Any("res", res),
-		// )
-
 		var l Loan
 		var lp LoanParty
 		var lpp LoanPartyPayment
-		if err = rx.Err(); err != nil {
+		if err := rx.Err(); err != nil {
 			return rx.Flow.Stop(err)
 		}
-		if err = rx.Scan(
+		if err := rx.Scan(
 			&l.LoanID,
 			&l.LoanState,
 			//
@@ -66,81 +177,1054 @@ func (x *datastore) queryLoans(ctx context.Context, req QueryRequest) (res Query
 			&l.ApprovedDoc,
 			&l.ApprovedAt,
 			&l.ApprovedSign,
+			&l.ApprovedSignVersion,
 			//
 			&l.DisbursedBy,
 			&l.DisbursedDoc,
 			&l.DisbursedAt,
 			&l.DisbursedSign,
+			&l.DisbursedSignVersion,
+			//
+			&l.APR,
+			&l.EffectiveRate,
+			&l.PaymentReference,
 			//
 			&l.CreatedAt,
 			&l.CreatedSign,
+			&l.CreatedSignVersion,
 			//
 			&lp.LoanPartyID,
 			&lp.UserID,
 			&lp.LoanPartyRoleAs,
 			&lp.CreatedAt,
 			&lp.CreatedSign,
+			&lp.CreatedSignVersion,
 			//
+			&lpp.PaymentID,
+			&lpp.PaymentType,
 			&lpp.ISO4217,
 			&lpp.Amount,
 			&lpp.Time,
 			&lpp.Details,
+			&lpp.SettledAt,
+			&lpp.SettlementRecordedAt,
 			&lpp.CreatedAt,
 			&lpp.CreatedSign,
+			&lpp.CreatedSignVersion,
+			&lpp.ChainSequence,
+			&lpp.PrevHash,
+			&lpp.PrevLoanHash,
+			&lpp.Hash,
 		); err != nil {
 			return rx.Flow.Stop(err)
 		}
 
-		if len(res.List) < 1 {
-			lp.Payments = append(lp.Payments, lpp)
-			l.Parties = append(l.Parties, lp)
-			res.List = append(
-				res.List,
-				QueryResponse{Loans: &QueryResponseLoans{Loan: l}},
-			)
-
-			prevLoanID = l.LoanID
-			prevLoanPartyID = lp.LoanPartyID
-			return rx.Flow.Next()
-		}
-
-		// log.DebugContext(ctx, "",
-		// 	slog.Bool("eq l", bytes.Equal(prevLoanID, l.LoanID)),
-		// 	slog.Bool("eq lp", bytes.Equal(prevLoanPartyID, lp.LoanPartyID)),
-		// )
-
-		if !bytes.Equal(prevLoanID, l.LoanID) {
-			res.List = append(
-				res.List,
-				QueryResponse{Loans: &QueryResponseLoans{Loan: l}},
-			)
+		if len(loans) < 1 || !bytes.Equal(prevLoanID, l.LoanID) {
+			loans = append(loans, l)
+			prevLoanPartyID = nil
 		}
-		var lIdx int = len(res.List) - 1
+		last := &loans[len(loans)-1]
 		if !bytes.Equal(prevLoanPartyID, lp.LoanPartyID) {
-			res.List[lIdx].Loans.Loan.Parties = append(
-				res.List[lIdx].Loans.Loan.Parties,
-				lp,
-			)
-		}
-		var lpIdx int = len(res.List[lIdx].Loans.Loan.Parties) - 1
-		res.List[lIdx].Loans.Loan.Parties[lpIdx].Payments = append(
-			res.List[lIdx].Loans.Loan.Parties[lpIdx].Payments,
-			lpp,
-		)
+			last.Parties = append(last.Parties, lp)
+		}
+		party := &last.Parties[len(last.Parties)-1]
+		party.Payments = append(party.Payments, lpp)
 
 		prevLoanID = l.LoanID
 		prevLoanPartyID = lp.LoanPartyID
 		return rx.Flow.Next()
 	})
-	// }
 	return
 }
 
 type QueryRequestLoans struct {
 	ByLoanID     []byte
-	ByLenderID   []byte
+	ByLenderID   []byte // every party of the loans invested by the lender, not only the lender
 	ByBorrowerID []byte
+	ByLoanState  LoanState
+
+	ByPaymentReference string
 }
 type QueryResponseLoans struct {
 	Loan
 }
+
+// queryAccruals will return the last recorded accrual date of the day count, zero when nothing is recorded.
+func (x *datastore) queryAccruals(ctx context.Context, req QueryRequest) (res QueryResponse, err error) {
+	db := x.Dependency.DB.SQLite3
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	res.Accruals = &QueryResponseAccruals{}
+	err = conn.QueryRowContext(ctx, queries.LoanSvc.SQLite3.QueryLoanAccrualLast(), req.Accruals.ByDayCount).
+		Scan(&res.Accruals.LastAccrualDate)
+	return
+}
+
+type QueryRequestAccruals struct {
+	ByDayCount string
+}
+type QueryResponseAccruals struct {
+	LastAccrualDate int64 // Unix timestamp of the start of the last accrued day in UTC
+}
+
+// queryProvisions will leave Provisions as nil when there is no snapshot on said day.
+func (x *datastore) queryProvisions(ctx context.Context, req QueryRequest) (res QueryResponse, err error) {
+	db := x.Dependency.DB.SQLite3
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	var snapshot ProvisionSnapshot
+	err = conn.QueryRowContext(ctx, queries.LoanSvc.SQLite3.QueryProvisionSnapshot(), req.Provisions.ByAsOf).
+		Scan(&snapshot.SnapshotID, &snapshot.AsOf, &snapshot.CreatedAt, &snapshot.CreatedSign)
+	if errors.Is(err, sql.ErrNoRows) {
+		return res, nil
+	} else if err != nil {
+		return
+	}
+
+	rows, err := conn.QueryContext(ctx, queries.LoanSvc.SQLite3.QueryProvisionSnapshotLoans(), snapshot.SnapshotID)
+	if err != nil {
+		return
+	}
+	if err = pkg.SQL.Scan(rows, func(i int, rx pkg.SQLRowsX) pkg.SQLScanFlow {
+		var l ProvisionLoan
+		if err := rx.Scan(
+			&l.LoanID,
+			&l.ISO4217,
+			&l.DaysPastDue,
+			&l.Stage,
+			&l.ProbabilityOfDefault,
+			&l.LossGivenDefault,
+			&l.ExposureAtDefault,
+			&l.ExpectedCreditLoss,
+		); err != nil {
+			return rx.Flow.Stop(err)
+		}
+		snapshot.Loans = append(snapshot.Loans, l)
+		return rx.Flow.Next()
+	}); err != nil {
+		return
+	}
+
+	rows, err = conn.QueryContext(ctx, queries.LoanSvc.SQLite3.QueryProvisionSnapshotLenders(), snapshot.SnapshotID)
+	if err != nil {
+		return
+	}
+	if err = pkg.SQL.Scan(rows, func(i int, rx pkg.SQLRowsX) pkg.SQLScanFlow {
+		var l ProvisionLender
+		if err := rx.Scan(
+			&l.UserID,
+			&l.LoanID,
+			&l.ISO4217,
+			&l.Share,
+			&l.ExposureAtDefault,
+			&l.ExpectedCreditLoss,
+		); err != nil {
+			return rx.Flow.Stop(err)
+		}
+		snapshot.Lenders = append(snapshot.Lenders, l)
+		return rx.Flow.Next()
+	}); err != nil {
+		return
+	}
+
+	res.Provisions = &QueryResponseProvisions{ProvisionSnapshot: snapshot}
+	return
+}
+
+type QueryRequestProvisions struct {
+	ByAsOf int64
+}
+type QueryResponseProvisions struct {
+	ProvisionSnapshot
+}
+
+// queryWallets will list the wallet of each currency held by the user.
+func (x *datastore) queryWallets(ctx context.Context, req QueryRequest) (res QueryResponse, err error) {
+	db := x.Dependency.DB.SQLite3
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	rows, err := conn.QueryContext(ctx, queries.LoanSvc.SQLite3.QueryWallet(),
+		req.Wallets.ByUserID, req.Wallets.ByISO4217, req.Wallets.ByISO4217,
+	)
+	if err != nil {
+		return
+	}
+	err = pkg.SQL.Scan(rows, func(i int, rx pkg.SQLRowsX) pkg.SQLScanFlow {
+		var w Wallet
+		if err := rx.Scan(
+			&w.UserID,
+			&w.ISO4217,
+			&w.Available,
+			&w.Reserved,
+			&w.UpdatedAt,
+			&w.UpdatedSign,
+		); err != nil {
+			return rx.Flow.Stop(err)
+		}
+		res.List = append(res.List, QueryResponse{Wallets: &QueryResponseWallets{Wallet: w}})
+		return rx.Flow.Next()
+	})
+	return
+}
+
+type QueryRequestWallets struct {
+	ByUserID  []byte
+	ByISO4217 string // empty for all currencies
+}
+type QueryResponseWallets struct {
+	Wallet
+}
+
+// queryReconciliations will list all unmatched transactions & expected payments, while the matches are limited to
+// the transactions settled within the range.
+func (x *datastore) queryReconciliations(ctx context.Context, req QueryRequest) (res QueryResponse, err error) {
+	db := x.Dependency.DB.SQLite3
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	r := &QueryResponseReconciliations{}
+	rows, err := conn.QueryContext(ctx, queries.LoanSvc.SQLite3.QueryReconciliationTransactions())
+	if err != nil {
+		return
+	}
+	if err = pkg.SQL.Scan(rows, func(i int, rx pkg.SQLRowsX) pkg.SQLScanFlow {
+		var t SettledTransaction
+		if err := rx.Scan(
+			&t.TransactionID,
+			&t.BankReference,
+			&t.Reference,
+			&t.ISO4217,
+			&t.Amount,
+			&t.ValueTime,
+			&t.Counterparty,
+			&t.Source,
+			&t.LoanID,
+			&t.CreatedAt,
+			&t.CreatedSign,
+		); err != nil {
+			return rx.Flow.Stop(err)
+		}
+		r.Transactions = append(r.Transactions, t)
+		return rx.Flow.Next()
+	}); err != nil {
+		return
+	}
+
+	rows, err = conn.QueryContext(ctx, queries.LoanSvc.SQLite3.QueryReconciliationPayments())
+	if err != nil {
+		return
+	}
+	if err = pkg.SQL.Scan(rows, func(i int, rx pkg.SQLRowsX) pkg.SQLScanFlow {
+		var p ExpectedPayment
+		if err := rx.Scan(
+			&p.LoanID,
+			&p.UserID,
+			&p.PaymentReference,
+			&p.PaymentID,
+			&p.PaymentType,
+			&p.ISO4217,
+			&p.Amount,
+			&p.Time,
+			&p.Details,
+		); err != nil {
+			return rx.Flow.Stop(err)
+		}
+		r.Payments = append(r.Payments, p)
+		return rx.Flow.Next()
+	}); err != nil {
+		return
+	}
+
+	rows, err = conn.QueryContext(ctx, queries.LoanSvc.SQLite3.QueryReconciliationMatches(),
+		req.Reconciliations.ByFrom, req.Reconciliations.ByUntil,
+	)
+	if err != nil {
+		return
+	}
+	if err = pkg.SQL.Scan(rows, func(i int, rx pkg.SQLRowsX) pkg.SQLScanFlow {
+		var m ReconciliationMatch
+		if err := rx.Scan(
+			&m.TransactionID,
+			&m.PaymentID,
+			&m.MatchType,
+			&m.AmountDiff,
+			&m.DaysDiff,
+			&m.CreatedAt,
+			&m.CreatedSign,
+			&m.Transaction.BankReference,
+			&m.Transaction.Reference,
+			&m.Transaction.ISO4217,
+			&m.Transaction.Amount,
+			&m.Transaction.ValueTime,
+			&m.Transaction.Counterparty,
+			&m.Transaction.Source,
+			&m.Transaction.LoanID,
+			&m.Payment.LoanID,
+			&m.Payment.UserID,
+			&m.Payment.PaymentType,
+			&m.Payment.ISO4217,
+			&m.Payment.Amount,
+			&m.Payment.Time,
+			&m.Payment.Details,
+		); err != nil {
+			return rx.Flow.Stop(err)
+		}
+		m.Transaction.TransactionID, m.Payment.PaymentID = m.TransactionID, m.PaymentID
+		r.Matches = append(r.Matches, m)
+		return rx.Flow.Next()
+	}); err != nil {
+		return
+	}
+
+	res.Reconciliations = r
+	return
+}
+
+type QueryRequestReconciliations struct {
+	ByFrom  int64 // Unix timestamp, inclusive
+	ByUntil int64 // Unix timestamp, exclusive
+}
+type QueryResponseReconciliations struct {
+	Transactions []SettledTransaction // not yet matched
+	Payments     []ExpectedPayment    // not yet settled
+	Matches      []ReconciliationMatch
+}
+
+func (x *datastore) queryPayouts(ctx context.Context, req QueryRequest) (res QueryResponse, err error) {
+	db := x.Dependency.DB.SQLite3
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	r := req.Payouts
+	rows, err := conn.QueryContext(ctx, queries.LoanSvc.SQLite3.QueryPayoutInstructions(),
+		int(r.ByPayoutStatus), int(r.ByPayoutStatus), orNull(r.ByLoanID), orNull(r.ByLoanID), orNull(r.ByBatchID), orNull(r.ByBatchID),
+	)
+	if err != nil {
+		return
+	}
+	err = pkg.SQL.Scan(rows, func(i int, rx pkg.SQLRowsX) pkg.SQLScanFlow {
+		var p PayoutInstruction
+		if err := rx.Scan(
+			&p.InstructionID,
+			&p.LoanID,
+			&p.PaymentID,
+			&p.ISO4217,
+			&p.Amount,
+			&p.AccountName,
+			&p.AccountNumber,
+			&p.BankCode,
+			&p.Reference,
+			&p.PayoutStatus,
+			&p.StatusReason,
+			&p.BatchID,
+			&p.CreatedAt,
+			&p.CreatedSign,
+			&p.UpdatedAt,
+			&p.UpdatedSign,
+		); err != nil {
+			return rx.Flow.Stop(err)
+		}
+		res.List = append(res.List, QueryResponse{Payouts: &QueryResponsePayouts{PayoutInstruction: p}})
+		return rx.Flow.Next()
+	})
+	return
+}
+
+type QueryRequestPayouts struct {
+	ByPayoutStatus PayoutStatus // zero for all status
+	ByLoanID       []byte       // nil for all loans
+	ByBatchID      []byte       // nil for all batches
+}
+type QueryResponsePayouts struct {
+	PayoutInstruction
+}
+
+// orNull will bind an empty ID as NULL, an empty but non-nil slice is otherwise bound as an empty blob.
+func orNull(id []byte) []byte {
+	if len(id) < 1 {
+		return nil
+	}
+	return id
+}
+
+// queryOutbox will list the unpublished events in order.
+func (x *datastore) queryOutbox(ctx context.Context, req QueryRequest) (res QueryResponse, err error) {
+	db := x.Dependency.DB.SQLite3
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	rows, err := conn.QueryContext(ctx, queries.LoanSvc.SQLite3.QueryOutbox(), req.Outbox.ByLimit)
+	if err != nil {
+		return
+	}
+	err = pkg.SQL.Scan(rows, func(i int, rx pkg.SQLRowsX) pkg.SQLScanFlow {
+		var e OutboxEvent
+		if err := rx.Scan(
+			&e.Sequence,
+			&e.EventID,
+			&e.EventType,
+			&e.AggregateID,
+			&e.Payload,
+			&e.CreatedAt,
+			&e.CreatedSign,
+			&e.PublishedAt,
+		); err != nil {
+			return rx.Flow.Stop(err)
+		}
+		res.List = append(res.List, QueryResponse{Outbox: &QueryResponseOutbox{OutboxEvent: e}})
+		return rx.Flow.Next()
+	})
+	return
+}
+
+type QueryRequestOutbox struct {
+	ByLimit int // maximum number of unpublished events
+}
+type QueryResponseOutbox struct {
+	OutboxEvent
+}
+
+func (x *datastore) queryWebhooks(ctx context.Context, req QueryRequest) (res QueryResponse, err error) {
+	db := x.Dependency.DB.SQLite3
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	r := req.Webhooks
+	rows, err := conn.QueryContext(ctx, queries.LoanSvc.SQLite3.QueryWebhookSubscriptions(),
+		orNull(r.BySubscriptionID), orNull(r.BySubscriptionID), r.ByActive,
+	)
+	if err != nil {
+		return
+	}
+	err = pkg.SQL.Scan(rows, func(i int, rx pkg.SQLRowsX) pkg.SQLScanFlow {
+		var s WebhookSubscription
+		if err := rx.Scan(
+			&s.SubscriptionID,
+			&s.URL,
+			&s.EventTypes,
+			&s.Secret,
+			&s.Active,
+			&s.CreatedAt,
+			&s.CreatedSign,
+			&s.UpdatedAt,
+			&s.UpdatedSign,
+		); err != nil {
+			return rx.Flow.Stop(err)
+		}
+		res.List = append(res.List, QueryResponse{Webhooks: &QueryResponseWebhooks{WebhookSubscription: s}})
+		return rx.Flow.Next()
+	})
+	return
+}
+
+type QueryRequestWebhooks struct {
+	BySubscriptionID []byte // nil for all subscriptions
+	ByActive         bool   // false for both active & unsubscribed
+}
+type QueryResponseWebhooks struct {
+	WebhookSubscription
+}
+
+// queryDeliveries will list the deliveries in order of their next attempt, along with the URL & secret of the
+// subscription. The delivery log is populated when requested.
+func (x *datastore) queryDeliveries(ctx context.Context, req QueryRequest) (res QueryResponse, err error) {
+	db := x.Dependency.DB.SQLite3
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	r := req.Deliveries
+	rows, err := conn.QueryContext(ctx, queries.LoanSvc.SQLite3.QueryWebhookDeliveries(),
+		orNull(r.BySubscriptionID), orNull(r.BySubscriptionID), orNull(r.ByDeliveryID), orNull(r.ByDeliveryID),
+		int(r.ByWebhookStatus), int(r.ByWebhookStatus), r.ByDueAt, r.ByDueAt,
+		pkg.OrElse(r.ByLimit > 0, r.ByLimit, -1),
+	)
+	if err != nil {
+		return
+	}
+	index := map[string]int{}
+	if err = pkg.SQL.Scan(rows, func(i int, rx pkg.SQLRowsX) pkg.SQLScanFlow {
+		var d WebhookDelivery
+		if err := rx.Scan(
+			&d.DeliveryID,
+			&d.SubscriptionID,
+			&d.EventID,
+			&d.EventType,
+			&d.Payload,
+			&d.WebhookStatus,
+			&d.Attempts,
+			&d.NextAttemptAt,
+			&d.URL,
+			&d.Secret,
+			&d.CreatedAt,
+			&d.CreatedSign,
+			&d.UpdatedAt,
+			&d.UpdatedSign,
+		); err != nil {
+			return rx.Flow.Stop(err)
+		}
+		index[string(d.DeliveryID)] = len(res.List)
+		res.List = append(res.List, QueryResponse{Deliveries: &QueryResponseDeliveries{WebhookDelivery: d}})
+		return rx.Flow.Next()
+	}); err != nil || !r.WithAttempts {
+		return
+	}
+
+	rows, err = conn.QueryContext(ctx, queries.LoanSvc.SQLite3.QueryWebhookAttempts(),
+		orNull(r.BySubscriptionID), orNull(r.BySubscriptionID), orNull(r.ByDeliveryID), orNull(r.ByDeliveryID),
+		int(r.ByWebhookStatus), int(r.ByWebhookStatus),
+	)
+	if err != nil {
+		return
+	}
+	err = pkg.SQL.Scan(rows, func(i int, rx pkg.SQLRowsX) pkg.SQLScanFlow {
+		var a WebhookAttempt
+		if err := rx.Scan(
+			&a.DeliveryID,
+			&a.Attempt,
+			&a.WebhookStatus,
+			&a.StatusCode,
+			&a.Error,
+			&a.DurationMS,
+			&a.NextAttemptAt,
+			&a.CreatedAt,
+			&a.CreatedSign,
+		); err != nil {
+			return rx.Flow.Stop(err)
+		}
+		if i, ok := index[string(a.DeliveryID)]; ok { // left out by the limit otherwise
+			d := res.List[i].Deliveries
+			d.Log = append(d.Log, a)
+		}
+		return rx.Flow.Next()
+	})
+	return
+}
+
+type QueryRequestDeliveries struct {
+	BySubscriptionID []byte        // nil for all subscriptions
+	ByDeliveryID     []byte        // nil for all deliveries
+	ByWebhookStatus  WebhookStatus // zero for all status
+	ByDueAt          int64         // Unix timestamp, non-zero for only the pending deliveries of active subscriptions due by then
+	ByLimit          int           // zero for no limit
+	WithAttempts     bool          // populate the delivery log
+}
+type QueryResponseDeliveries struct {
+	WebhookDelivery
+	Log []WebhookAttempt // in order of attempt, populated when requested
+}
+
+// queryLoanEvents will list the events in order, to be folded by loan.
+func (x *datastore) queryLoanEvents(ctx context.Context, req QueryRequest) (res QueryResponse, err error) {
+	db := x.Dependency.DB.SQLite3
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	r := req.LoanEvents
+	rows, err := conn.QueryContext(ctx, queries.LoanSvc.SQLite3.QueryLoanEvents(), orNull(r.ByLoanID), orNull(r.ByLoanID))
+	if err != nil {
+		return
+	}
+	err = pkg.SQL.Scan(rows, func(i int, rx pkg.SQLRowsX) pkg.SQLScanFlow {
+		var e LoanEvent
+		if err := rx.Scan(
+			&e.Sequence,
+			&e.EventID,
+			&e.LoanID,
+			&e.Version,
+			&e.LoanEventType,
+			&e.Payload,
+			&e.CreatedAt,
+			&e.CreatedSign,
+			&e.PrevHash,
+			&e.PrevLoanHash,
+			&e.Hash,
+		); err != nil {
+			return rx.Flow.Stop(err)
+		}
+		res.List = append(res.List, QueryResponse{LoanEvents: &QueryResponseLoanEvents{LoanEvent: e}})
+		return rx.Flow.Next()
+	})
+	return
+}
+
+type QueryRequestLoanEvents struct {
+	ByLoanID []byte // nil for all loans
+}
+type QueryResponseLoanEvents struct {
+	LoanEvent
+}
+
+// queryAuditLogs will list the audit records of a loan in order.
+func (x *datastore) queryAuditLogs(ctx context.Context, req QueryRequest) (res QueryResponse, err error) {
+	db := x.Dependency.DB.SQLite3
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	rows, err := conn.QueryContext(ctx, queries.LoanSvc.SQLite3.QueryAuditLogs(), req.AuditLogs.ByLoanID)
+	if err != nil {
+		return
+	}
+	err = pkg.SQL.Scan(rows, func(i int, rx pkg.SQLRowsX) pkg.SQLScanFlow {
+		var a AuditLog
+		if err := rx.Scan(
+			&a.Sequence,
+			&a.AuditID,
+			&a.Operation,
+			&a.LoanID,
+			&a.Actor,
+			&a.ClientIP,
+			&a.RequestID,
+			&a.Before,
+			&a.After,
+			&a.CreatedAt,
+			&a.CreatedSign,
+		); err != nil {
+			return rx.Flow.Stop(err)
+		}
+		res.List = append(res.List, QueryResponse{AuditLogs: &QueryResponseAuditLogs{AuditLog: a}})
+		return rx.Flow.Next()
+	})
+	return
+}
+
+type QueryRequestAuditLogs struct {
+	ByLoanID []byte
+}
+type QueryResponseAuditLogs struct {
+	AuditLog
+}
+
+// querySignatures will verify every signature stored in the datastore, only those not valid are listed unless
+// WithValid is set.
+func (x *datastore) querySignatures(ctx context.Context, req QueryRequest) (res QueryResponse, err error) {
+	db := x.Dependency.DB.SQLite3
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	rows, err := conn.QueryContext(ctx, queries.LoanSvc.SQLite3.QuerySignatures())
+	if err != nil {
+		return
+	}
+	type scanned struct {
+		SignedRow
+		sig, loanID, key []byte
+	}
+	var signedRows []scanned
+	if err = pkg.SQL.Scan(rows, func(i int, rx pkg.SQLRowsX) pkg.SQLScanFlow {
+		var s scanned
+		if err := rx.Scan(&s.Table, &s.RowID, &s.Column, &s.At, &s.sig, &s.loanID, &s.key, &s.SignVersion); err != nil {
+			return rx.Flow.Stop(err)
+		}
+		signedRows = append(signedRows, s)
+		return rx.Flow.Next()
+	}); err != nil {
+		return
+	}
+
+	// content of the loan tables is loaded once per loan, after the rows are scanned as the connection is shared
+	contents := map[string][]signedContent{}
+	r := &QueryResponseSignatures{}
+	for _, s := range signedRows {
+		row := s.SignedRow
+		if s.loanID == nil {
+			row.Verification = x.verify(row.At, s.sig)
+		} else {
+			cs, ok := contents[string(s.loanID)]
+			if !ok {
+				var loans []Loan
+				if loans, err = loadLoans(ctx, conn, QueryRequestLoans{ByLoanID: s.loanID}); err != nil {
+					return
+				}
+				for _, l := range loans {
+					cs = append(cs, loanContents(l)...)
+				}
+				contents[string(s.loanID)] = cs
+			}
+			row.Verification = SignatureInvalid // the row is not found along with its loan
+			for _, c := range cs {
+				if c.Table == row.Table && c.Column == row.Column && bytes.Equal(c.Key, s.key) {
+					row.Verification = x.verifyContent(c)
+					break
+				}
+			}
+		}
+		row.KeyID, row.PublicKey = x.signer(s.sig)
+		r.Scanned++
+		if row.Verification != SignatureValid || req.Signatures.WithValid {
+			res.List = append(res.List, QueryResponse{Signatures: &QueryResponseSignatures{SignedRow: row}})
+		}
+	}
+	res.Signatures = r
+	return
+}
+
+type QueryRequestSignatures struct {
+	WithValid bool // list the valid signatures as well
+}
+type QueryResponseSignatures struct {
+	SignedRow
+	Scanned int64 // number of signatures verified, only set on the top level response
+}
+
+// queryChains will walk the chain of the payments & of the loan events, then check each checkpoint against the head
+// it signs. Only the first broken link of each chain is listed.
+func (x *datastore) queryChains(ctx context.Context, req QueryRequest) (res QueryResponse, err error) {
+	db := x.Dependency.DB.SQLite3
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	r := &QueryResponseChains{}
+	broken := map[string]bool{}
+	report := func(b BrokenLink) {
+		if !broken[b.Chain] {
+			broken[b.Chain] = true
+			res.List = append(res.List, QueryResponse{Chains: &QueryResponseChains{BrokenLink: b}})
+		}
+	}
+
+	// checkpoints are loaded first, so that only the signed heads are kept while walking the chains
+	var checkpoints []ChainCheckpoint
+	if checkpoints, err = loadCheckpoints(ctx, conn); err != nil {
+		return
+	}
+	heads := map[string]map[int64][]byte{ChainPayments: {}, ChainLoanEvents: {}}
+	for _, c := range checkpoints {
+		if _, ok := heads[c.Chain]; ok {
+			heads[c.Chain][c.HeadSequence] = nil
+		}
+	}
+	keep := func(chain string, sequence int64, hash []byte) {
+		if _, ok := heads[chain][sequence]; ok {
+			heads[chain][sequence] = hash
+		}
+	}
+
+	rows, err := conn.QueryContext(ctx, queries.LoanSvc.SQLite3.QueryPaymentChain())
+	if err != nil {
+		return
+	}
+	var prev []byte
+	var sequence int64
+	loanHashes := map[string][]byte{}
+	if err = pkg.SQL.Scan(rows, func(i int, rx pkg.SQLRowsX) pkg.SQLScanFlow {
+		var p LoanPartyPayment
+		var loanPartyID, loanID []byte
+		if err := rx.Scan(
+			&p.ChainSequence,
+			&p.PaymentID,
+			&loanPartyID,
+			&loanID,
+			&p.PaymentType,
+			&p.ISO4217,
+			&p.Amount,
+			&p.Time,
+			&p.Details,
+			&p.CreatedAt,
+			&p.PrevHash,
+			&p.PrevLoanHash,
+			&p.Hash,
+		); err != nil {
+			return rx.Flow.Stop(err)
+		}
+		r.Scanned++
+		link := BrokenLink{Chain: ChainPayments, Sequence: p.ChainSequence, ID: p.PaymentID}
+		switch {
+		case p.ChainSequence != sequence+1:
+			link.Reason = "sequence"
+		case !bytes.Equal(p.PrevHash, prev):
+			link.Reason = "prev_hash"
+		case !bytes.Equal(p.PrevLoanHash, loanHashes[string(loanID)]):
+			link.Reason = "prev_loan_hash"
+		case !bytes.Equal(p.Hash, paymentHash(loanID, loanPartyID, p)):
+			link.Reason = "hash"
+		}
+		if link.Reason != "" {
+			report(link)
+		}
+		sequence, prev, loanHashes[string(loanID)] = p.ChainSequence, p.Hash, p.Hash
+		keep(ChainPayments, p.ChainSequence, p.Hash)
+		return rx.Flow.Next()
+	}); err != nil {
+		return
+	}
+
+	if rows, err = conn.QueryContext(ctx, queries.LoanSvc.SQLite3.QueryLoanEvents(), nil, nil); err != nil {
+		return
+	}
+	prev, loanHashes = nil, map[string][]byte{}
+	var chained bool // events written before the chain are not linked
+	if err = pkg.SQL.Scan(rows, func(i int, rx pkg.SQLRowsX) pkg.SQLScanFlow {
+		var e LoanEvent
+		if err := rx.Scan(
+			&e.Sequence,
+			&e.EventID,
+			&e.LoanID,
+			&e.Version,
+			&e.LoanEventType,
+			&e.Payload,
+			&e.CreatedAt,
+			&e.CreatedSign,
+			&e.PrevHash,
+			&e.PrevLoanHash,
+			&e.Hash,
+		); err != nil {
+			return rx.Flow.Stop(err)
+		}
+		if chained = chained || e.Hash != nil; chained {
+			r.Scanned++
+		}
+		link := BrokenLink{Chain: ChainLoanEvents, Sequence: e.Sequence, ID: e.EventID}
+		switch {
+		case !chained:
+		case e.Hash == nil:
+			link.Reason = "unchained"
+		case !bytes.Equal(e.PrevHash, prev):
+			link.Reason = "prev_hash"
+		case !bytes.Equal(e.PrevLoanHash, loanHashes[string(e.LoanID)]):
+			link.Reason = "prev_loan_hash"
+		case !bytes.Equal(e.Hash, eventHash(e)):
+			link.Reason = "hash"
+		}
+		if link.Reason != "" {
+			report(link)
+		}
+		prev, loanHashes[string(e.LoanID)] = e.Hash, e.Hash
+		keep(ChainLoanEvents, e.Sequence, e.Hash)
+		return rx.Flow.Next()
+	}); err != nil {
+		return
+	}
+
+	for _, c := range checkpoints {
+		r.Checkpoints++
+		if v := x.verifyMessage(checkpointContent(c), c.CreatedSign); v != SignatureValid {
+			report(BrokenLink{Chain: ChainCheckpoints, Sequence: c.HeadSequence, ID: c.CheckpointID, Reason: "created_sign " + v.String()})
+			continue
+		}
+		hash, ok := heads[c.Chain][c.HeadSequence]
+		switch {
+		case !ok:
+			report(BrokenLink{Chain: ChainCheckpoints, Sequence: c.HeadSequence, ID: c.CheckpointID, Reason: "chain"})
+		case hash == nil:
+			report(BrokenLink{Chain: c.Chain, Sequence: c.HeadSequence, ID: c.CheckpointID, Reason: "checkpoint_missing"})
+		case !bytes.Equal(hash, c.HeadHash):
+			report(BrokenLink{Chain: c.Chain, Sequence: c.HeadSequence, ID: c.CheckpointID, Reason: "checkpoint_hash"})
+		}
+	}
+	res.Chains = r
+	return
+}
+
+// loadCheckpoints will list every checkpoint in order.
+func loadCheckpoints(ctx context.Context, q querier) (checkpoints []ChainCheckpoint, err error) {
+	rows, err := q.QueryContext(ctx, queries.LoanSvc.SQLite3.QueryChainCheckpoints())
+	if err != nil {
+		return
+	}
+	err = pkg.SQL.Scan(rows, func(i int, rx pkg.SQLRowsX) pkg.SQLScanFlow {
+		var c ChainCheckpoint
+		if err := rx.Scan(&c.CheckpointID, &c.Chain, &c.HeadSequence, &c.HeadHash, &c.CreatedAt, &c.CreatedSign); err != nil {
+			return rx.Flow.Stop(err)
+		}
+		checkpoints = append(checkpoints, c)
+		return rx.Flow.Next()
+	})
+	return
+}
+
+type QueryRequestChains struct{}
+type QueryResponseChains struct {
+	BrokenLink
+	Scanned     int64 // number of chained rows verified, only set on the top level response
+	Checkpoints int64 // number of checkpoints verified, only set on the top level response
+}
+
+// queryUserKeys will list the keys registered by the user, or the key of the ID, revoked keys included.
+func (x *datastore) queryUserKeys(ctx context.Context, req QueryRequest) (res QueryResponse, err error) {
+	db := x.Dependency.DB.SQLite3
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	rows, err := conn.QueryContext(ctx, queries.LoanSvc.SQLite3.QueryUserKeys(),
+		req.UserKeys.ByUserID, req.UserKeys.ByUserID, req.UserKeys.ByKeyID, req.UserKeys.ByKeyID,
+	)
+	if err != nil {
+		return
+	}
+	err = pkg.SQL.Scan(rows, func(i int, rx pkg.SQLRowsX) pkg.SQLScanFlow {
+		var k UserKey
+		if err := rx.Scan(&k.KeyID, &k.UserID, &k.PublicKey, &k.CreatedAt, &k.CreatedSign, &k.RevokedAt); err != nil {
+			return rx.Flow.Stop(err)
+		}
+		res.List = append(res.List, QueryResponse{UserKeys: &QueryResponseUserKeys{UserKey: k}})
+		return rx.Flow.Next()
+	})
+	return
+}
+
+type QueryRequestUserKeys struct {
+	ByUserID []byte
+	ByKeyID  []byte
+}
+type QueryResponseUserKeys struct {
+	UserKey
+}
+
+// queryContracts will list the contracts along with their signatures, each signature is verified against the key
+// of the party expected to sign & the document as stored.
+func (x *datastore) queryContracts(ctx context.Context, req QueryRequest) (res QueryResponse, err error) {
+	db := x.Dependency.DB.SQLite3
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	rows, err := conn.QueryContext(ctx, queries.LoanSvc.SQLite3.QueryContracts(),
+		req.Contracts.ByContractID, req.Contracts.ByContractID, req.Contracts.ByLoanID, req.Contracts.ByLoanID,
+	)
+	if err != nil {
+		return
+	}
+	var last *Contract
+	err = pkg.SQL.Scan(rows, func(i int, rx pkg.SQLRowsX) pkg.SQLScanFlow {
+		var (
+			c        Contract
+			s        ContractSignature
+			keyUser  []byte
+			keyPub   []byte
+			signedAt sql.NullInt64
+			revoked  sql.NullInt64
+		)
+		if err := rx.Scan(
+			&c.ContractID,
+			&c.LoanID,
+			&c.UserID,
+			&c.LoanPartyRoleAs,
+			&c.Document,
+			&c.Hash,
+			&c.CreatedAt,
+			&c.CreatedSign,
+			&s.KeyID,
+			&s.Signature,
+			&signedAt,
+			&s.CreatedSign,
+			&keyUser,
+			&keyPub,
+			&revoked,
+		); err != nil {
+			return rx.Flow.Stop(err)
+		}
+		if last == nil || !bytes.Equal(last.ContractID, c.ContractID) {
+			res.List = append(res.List, QueryResponse{Contracts: &QueryResponseContracts{Contract: c}})
+			last = &res.List[len(res.List)-1].Contracts.Contract
+		}
+		if s.KeyID == nil {
+			return rx.Flow.Next()
+		}
+		s.ContractID, s.CreatedAt = last.ContractID, signedAt.Int64
+		hash := sha256.Sum256(last.Document)
+		s.Verified = bytes.Equal(keyUser, last.UserID) &&
+			(!revoked.Valid || revoked.Int64 > s.CreatedAt) &&
+			bytes.Equal(hash[:], last.Hash) &&
+			len(keyPub) == ed25519.PublicKeySize &&
+			ed25519.Verify(keyPub, last.Hash, s.Signature)
+		last.Signatures = append(last.Signatures, s)
+		return rx.Flow.Next()
+	})
+	return
+}
+
+type QueryRequestContracts struct {
+	ByContractID []byte
+	ByLoanID     []byte
+}
+type QueryResponseContracts struct {
+	Contract
+}
+
+// queryDocuments will list the documents of the loan, or the document of the hash.
+func (x *datastore) queryDocuments(ctx context.Context, req QueryRequest) (res QueryResponse, err error) {
+	db := x.Dependency.DB.SQLite3
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	rows, err := conn.QueryContext(ctx, queries.LoanSvc.SQLite3.QueryDocuments(),
+		req.Documents.ByHash, req.Documents.ByHash, req.Documents.ByLoanID, req.Documents.ByLoanID,
+	)
+	if err != nil {
+		return
+	}
+	err = pkg.SQL.Scan(rows, func(i int, rx pkg.SQLRowsX) pkg.SQLScanFlow {
+		var d Document
+		if err := rx.Scan(
+			&d.Hash,
+			&d.LoanID,
+			&d.UserID,
+			&d.Kind,
+			&d.TemplateVersion,
+			&d.MediaType,
+			&d.Content,
+			&d.CreatedAt,
+			&d.CreatedSign,
+		); err != nil {
+			return rx.Flow.Stop(err)
+		}
+		res.List = append(res.List, QueryResponse{Documents: &QueryResponseDocuments{Document: d}})
+		return rx.Flow.Next()
+	})
+	return
+}
+
+type QueryRequestDocuments struct {
+	ByHash   []byte
+	ByLoanID []byte
+}
+type QueryResponseDocuments struct {
+	Document
+}
@@ -0,0 +1,161 @@
+package datastore
+
+import (
+	"crypto/ed25519"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+
+	"github.com/gunawanwijaya/loan-svc/internal/repository/keyring"
+)
+
+// signedContent is a signature of the loan tables along with the content it is signed over.
+type signedContent struct {
+	Table       string // e.g. loans, loan_parties
+	Column      string // e.g. created_sign, approved_sign
+	Key         []byte // loan_id, loan_party_id or payment_id of the row
+	At          int64  // Unix timestamp being signed
+	Sign        []byte //
+	SignVersion        //
+	Fields      []any  // content of the row signed since SignCanonical
+}
+
+// message will return what is signed by the version of the signature, nil on unknown version.
+func (c signedContent) message() []byte {
+	switch c.SignVersion {
+	case 0, SignTimestamp: // rows written before the version & the keyring are recorded
+		return []byte(fmt.Sprint(c.At))
+	case SignCanonical:
+		return canonical(c.Table+"."+c.Column, c.Fields...)
+	}
+	return nil
+}
+
+// signContent will sign the content with SignCanonical, the row should already be set with the version.
+func (x *datastore) signContent(c signedContent) []byte {
+	return x.signMessage(c.message())
+}
+
+// verifyContent will check the signature against the content by the version it is signed with.
+func (x *datastore) verifyContent(c signedContent) Verification {
+	msg := c.message()
+	if msg == nil && len(c.Sign) > 0 {
+		return SignatureInvalid
+	} else if c.SignVersion < SignCanonical && signedByKeyID(c.Sign) {
+		return SignatureInvalid
+	}
+	return x.verifyMessage(msg, c.Sign)
+}
+
+// signedByKeyID will report whether the signature carries a key ID of the keyring, which signs the loan tables since
+// SignCanonical only. The version is not covered by the signature, so a loan row claiming SignTimestamp along with a
+// key ID is downgraded to be verified over its timestamp & is never valid.
+func signedByKeyID(sig []byte) bool {
+	return len(sig) == keyring.KeyIDSize+ed25519.SignatureSize
+}
+
+// canonical will serialize the label & the fields of a row as signed by SignCanonical, every field is written as
+// 0x00 when null or 0x01 followed by the uvarint length & the value so that no two contents share the same message.
+// Integers & floats are written as their shortest decimal, which round-trip through the columns as is.
+func canonical(label string, fields ...any) []byte {
+	b := appendCanonical([]byte{byte(SignCanonical)}, label)
+	for _, f := range fields {
+		b = appendCanonical(b, f)
+	}
+	return b
+}
+
+func appendCanonical(b []byte, field any) []byte {
+	var v []byte
+	switch f := field.(type) {
+	case nil:
+		return append(b, 0x00)
+	case []byte:
+		v = f
+	case string:
+		v = []byte(f)
+	case int64:
+		v = strconv.AppendInt(nil, f, 10)
+	case float64:
+		v = strconv.AppendFloat(nil, f, 'g', -1, 64)
+	case *string:
+		if f == nil {
+			return append(b, 0x00)
+		}
+		return appendCanonical(b, *f)
+	case *int64:
+		if f == nil {
+			return append(b, 0x00)
+		}
+		return appendCanonical(b, *f)
+	case *float64:
+		if f == nil {
+			return append(b, 0x00)
+		}
+		return appendCanonical(b, *f)
+	default:
+		panic(fmt.Sprintf("repository/datastore: canonical of %T", field))
+	}
+	b = binary.AppendUvarint(append(b, 0x01), uint64(len(v)))
+	return append(b, v...)
+}
+
+// loanCreated will return the proposal of the loan, LoanState is excluded as it is updated on each transition.
+func loanCreated(l Loan) signedContent {
+	return signedContent{"loans", "created_sign", l.LoanID, l.CreatedAt, l.CreatedSign, l.CreatedSignVersion, []any{
+		l.LoanID, l.APR, l.EffectiveRate, l.PaymentReference, l.CreatedAt,
+	}}
+}
+
+// loanApproved will return the approval of the loan.
+func loanApproved(l Loan) signedContent {
+	return signedContent{"loans", "approved_sign", l.LoanID, orZero(l.ApprovedAt), l.ApprovedSign, l.ApprovedSignVersion, []any{
+		l.LoanID, l.ApprovedBy, l.ApprovedDoc, l.ApprovedAt,
+	}}
+}
+
+// loanDisbursed will return the disbursement of the loan.
+func loanDisbursed(l Loan) signedContent {
+	return signedContent{"loans", "disbursed_sign", l.LoanID, orZero(l.DisbursedAt), l.DisbursedSign, l.DisbursedSignVersion, []any{
+		l.LoanID, l.DisbursedBy, l.DisbursedDoc, l.DisbursedAt,
+	}}
+}
+
+// loanParty will return the party of the loan.
+func loanParty(loanID []byte, p LoanParty) signedContent {
+	return signedContent{"loan_parties", "created_sign", p.LoanPartyID, p.CreatedAt, p.CreatedSign, p.CreatedSignVersion, []any{
+		p.LoanPartyID, loanID, p.UserID, int64(p.LoanPartyRoleAs), p.CreatedAt,
+	}}
+}
+
+// loanPartyPayment will return the payment of the party, SettledAt is excluded as it is set by reconciliation.
+func loanPartyPayment(loanID, loanPartyID []byte, p LoanPartyPayment) signedContent {
+	return signedContent{"loan_party_payments", "created_sign", p.PaymentID, p.CreatedAt, p.CreatedSign, p.CreatedSignVersion, []any{
+		p.PaymentID, loanPartyID, loanID, int64(p.PaymentType), p.ISO4217, p.Amount, p.Time, p.Details, p.CreatedAt,
+	}}
+}
+
+// loanContents will return every signature of the loan along with its content.
+func loanContents(l Loan) (contents []signedContent) {
+	contents = append(contents, loanCreated(l))
+	if l.ApprovedAt != nil {
+		contents = append(contents, loanApproved(l))
+	}
+	if l.DisbursedAt != nil {
+		contents = append(contents, loanDisbursed(l))
+	}
+	for _, p := range l.Parties {
+		contents = append(contents, loanParty(l.LoanID, p))
+		for _, pp := range p.Payments {
+			contents = append(contents, loanPartyPayment(l.LoanID, p.LoanPartyID, pp))
+		}
+	}
+	return
+}
+
+func orZero[T any](v *T) (z T) {
+	if v != nil {
+		z = *v
+	}
+	return
+}
@@ -1,39 +1,492 @@
 package datastore
 
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
 type Loan struct {
-	LoanID        []byte // ID
-	LoanState            //
-	Parties       []LoanParty
-	ApprovedBy    []byte  // ID of field officer doing the approval
-	ApprovedDoc   *string // url of document pointing to the approval
-	ApprovedAt    *int64  // Unix timestamp
-	ApprovedSign  []byte  // signature of ApprovedAt
-	DisbursedBy   []byte  // ID of field officer doing the disbursement
-	DisbursedDoc  *string // url of document pointing to the disbursement
-	DisbursedAt   *int64  // Unix timestamp
-	DisbursedSign []byte  // signature of DisbursedAt
-	CreatedAt     int64   // Unix timestamp
-	CreatedSign   []byte  // signature of CreatedAt
+	LoanID               []byte // ID
+	LoanState                   //
+	Parties              []LoanParty
+	ApprovedBy           []byte      // ID of field officer doing the approval
+	ApprovedDoc          *string     // url of document pointing to the approval
+	ApprovedAt           *int64      // Unix timestamp
+	ApprovedSign         []byte      // signature of the approval
+	ApprovedSignVersion  SignVersion // format of ApprovedSign
+	DisbursedBy          []byte      // ID of field officer doing the disbursement
+	DisbursedDoc         *string     // url of document pointing to the disbursement
+	DisbursedAt          *int64      // Unix timestamp
+	DisbursedSign        []byte      // signature of the disbursement
+	DisbursedSignVersion SignVersion // format of DisbursedSign
+	APR                  *float64    // annual percentage rate disclosed upon proposed
+	EffectiveRate        *float64    // effective annual rate disclosed upon proposed
+	PaymentReference     *string     // numeric reference with check digits given to the borrower for repayment
+	CreatedAt            int64       // Unix timestamp
+	CreatedSign          []byte      // signature of the proposal
+	CreatedSignVersion   SignVersion // format of CreatedSign
+	Verified             bool        `json:"-"` // every signature of the row is valid, set on query
+}
+
+// LoanEvent is an append-only fact of a loan, written in the same transaction of the projection it is applied on.
+type LoanEvent struct {
+	Sequence      int64  // order of every events
+	EventID       []byte // ID
+	LoanID        []byte // FK to Loan, the aggregate being folded
+	Version       int    // order of the events of the loan, starting from 1
+	LoanEventType        //
+	Payload       []byte // JSON of Loan carrying only what is changed, or of LoanPartyPayment once settled
+	CreatedAt     int64  // Unix timestamp
+	CreatedSign   []byte // signature of CreatedAt
+	ChainLink            // nil for events written before the chain
 }
 
+// ChainLink is the position of a row in the hash chain of its table & of its loan, a row being removed, reordered or
+// altered breaks the link of the next row.
+type ChainLink struct {
+	PrevHash     []byte // hash of the previous row of the table, nil for the first row
+	PrevLoanHash []byte // hash of the previous row of the same loan, nil for the first row of the loan
+	Hash         []byte // SHA-256 of the content of the row along with both previous hashes
+}
+
+// ChainCheckpoint is a signed head of a hash chain, rows removed from the tail are found against the checkpoint.
+type ChainCheckpoint struct {
+	CheckpointID []byte // ID
+	Chain        string // loan_party_payments or loan_events
+	HeadSequence int64  // chain_sequence of the payment or sequence of the event being the head
+	HeadHash     []byte // hash of the head
+	CreatedAt    int64  // Unix timestamp
+	CreatedSign  []byte // signature of the chain, the head & CreatedAt
+}
+
+// BrokenLink is the first row of a hash chain failing its verification.
+type BrokenLink struct {
+	Chain    string // loan_party_payments, loan_events or chain_checkpoints
+	Sequence int64  // position of the row in its chain, or of the head signed by the checkpoint
+	ID       []byte // payment_id, event_id or checkpoint_id of the row
+	Reason   string // sequence, prev_hash, prev_loan_hash, hash, unchained, checkpoint_missing, checkpoint_hash or created_sign
+}
+
+// AuditLog is an immutable record of a mutation, written in the same transaction of the mutation.
+type AuditLog struct {
+	Sequence    int64  // order of every records
+	AuditID     []byte // ID
+	Operation   string // mutation being audited, e.g. loans.approved, wallets, payouts
+	LoanID      []byte // FK to Loan, nil when the mutation is not scoped to a loan
+	Actor       string // authenticated caller taken from the context, empty for the service itself
+	ClientIP    string // taken from the context
+	RequestID   string // taken from the context
+	Before      []byte // JSON of Loan before the mutation, nil when not scoped to a loan or not yet created
+	After       []byte // JSON of Loan after the mutation, or of the mutation itself when not scoped to a loan
+	CreatedAt   int64  // Unix timestamp
+	CreatedSign []byte // signature of CreatedAt
+}
+
+type LoanEventType int
+
+func (x LoanEventType) String() string {
+	return map[LoanEventType]string{
+		LoanImported:       "imported",
+		LoanProposed:       "proposed",
+		LoanApproved:       "approved",
+		LoanInvested:       "invested",
+		LoanDisbursed:      "disbursed",
+		LoanPaymentSettled: "payment_settled",
+	}[x]
+}
+
+const (
+	_                  LoanEventType = iota
+	LoanImported                     // snapshot of a loan projected before the events were recorded, the first event
+	LoanProposed                     // the first event
+	LoanApproved                     //
+	LoanInvested                     // lenders are added as parties
+	LoanDisbursed                    //
+	LoanPaymentSettled               // SettledAt of a payment is set by reconciliation
+)
+
 type LoanParty struct {
-	LoanPartyID     []byte // ID
-	UserID          []byte // ID of said party defined by RoleAs
-	LoanPartyRoleAs        // role of said user, either borrower or lender
-	Payments        []LoanPartyPayment
-	CreatedAt       int64  // Unix timestamp
-	CreatedSign     []byte // signature of CreatedAt
+	LoanPartyID        []byte // ID
+	UserID             []byte // ID of said party defined by RoleAs
+	LoanPartyRoleAs           // role of said user, either borrower or lender
+	Payments           []LoanPartyPayment
+	CreatedAt          int64       // Unix timestamp
+	CreatedSign        []byte      // signature of the row
+	CreatedSignVersion SignVersion // format of CreatedSign
+	Verified           bool        `json:"-"` // signature of the row is valid, set on query
 }
 
 type LoanPartyPayment struct {
-	ISO4217     string
-	Amount      float64
-	Time        int64  // Unix timestamp
-	Details     string // signature of DueTime
+	PaymentID          []byte      // ID
+	PaymentType                    // meaning of said payment, the sign of Amount is kept for the direction
+	ISO4217            string      //
+	Amount             float64     //
+	Time               int64       // Unix timestamp
+	Details            string      // signature of DueTime
+	SettledAt          *int64      // Unix timestamp of the payment being settled
+	CreatedAt          int64       // Unix timestamp
+	CreatedSign        []byte      // signature of the row, SettledAt excluded
+	CreatedSignVersion SignVersion // format of CreatedSign
+	ChainSequence      int64       // position in the chain of the payments starting from 1, 0 for payments written before the chain
+	ChainLink                      // SettledAt excluded
+	Verified           bool        `json:"-"` // signature of the row is valid, set on query
+
+	SettlementRecordedAt *int64 `json:"-"` // Unix timestamp of the reconciliation match settling the payment, set on query
+}
+
+type SettledTransaction struct {
+	TransactionID []byte  // ID
+	BankReference string  // unique reference given by the bank, imported once
+	Reference     string  // free text given by the payer, e.g. loan or payment reference
+	ISO4217       string  //
+	Amount        float64 // positive when money is received, negative when money is paid
+	ValueTime     int64   // Unix timestamp of the money being settled
+	Counterparty  string  //
+	Source        string  // statement format of the import, e.g. csv, mt940, camt053
+	LoanID        []byte  // FK to Loan, candidate repayment of an incoming credit referencing the loan
+	CreatedAt     int64   // Unix timestamp
+	CreatedSign   []byte  // signature of CreatedAt
+
+	PaymentReference string // payment reference found within Reference to resolve LoanID, not stored
+}
+
+// ExpectedPayment is a borrower payment of a disbursed loan not yet settled.
+type ExpectedPayment struct {
+	LoanID           []byte // FK to Loan
+	UserID           []byte // FK to users.user_id
+	PaymentReference string // payment reference of the loan
+	LoanPartyPayment
+}
+
+type ReconciliationMatch struct {
+	TransactionID []byte  // FK to SettledTransaction
+	PaymentID     []byte  // FK to LoanPartyPayment
+	MatchType             //
+	AmountDiff    float64 // transaction amount - expected amount
+	DaysDiff      int     // value date - due date
+	CreatedAt     int64   // Unix timestamp
+	CreatedSign   []byte  // signature of CreatedAt
+
+	Transaction SettledTransaction // populated on query
+	Payment     ExpectedPayment    // populated on query
+}
+
+type MatchType int
+
+func (x MatchType) String() string {
+	return map[MatchType]string{
+		MatchExact:      "exact",
+		MatchReference:  "reference",
+		MatchAmountDate: "amount_date",
+	}[x]
+}
+
+const (
+	_               MatchType = iota
+	MatchExact                // reference, amount & date within tolerance
+	MatchReference            // reference & amount within tolerance, date outside of tolerance
+	MatchAmountDate           // no reference, the only payment with amount & date within tolerance
+)
+
+// PayoutInstruction is the payment of the principal to the borrower bank account created upon disbursement.
+type PayoutInstruction struct {
+	InstructionID []byte  // ID
+	LoanID        []byte  // FK to Loan
+	PaymentID     []byte  // FK to LoanPartyPayment of the principal disbursement
+	ISO4217       string  //
+	Amount        float64 // the whole principal, there are no upfront fees
+	BankAccount           //
+	Reference     string  // remittance information
+	PayoutStatus          //
+	StatusReason  string  // e.g. the rejection reason of the bank
+	BatchID       []byte  // ID of the latest exported payment file
+	CreatedAt     int64   // Unix timestamp
+	CreatedSign   []byte  // signature of CreatedAt
+	UpdatedAt     int64   // Unix timestamp
+	UpdatedSign   []byte  // signature of UpdatedAt
+}
+
+type BankAccount struct {
+	AccountName   string // holder name
+	AccountNumber string // IBAN or domestic account number
+	BankCode      string // BIC or domestic bank code
+}
+
+type PayoutStatus int
+
+func (x PayoutStatus) String() string {
+	return map[PayoutStatus]string{
+		PayoutPending:   "pending",
+		PayoutExported:  "exported",
+		PayoutConfirmed: "confirmed",
+		PayoutFailed:    "failed",
+	}[x]
+}
+
+const (
+	_               PayoutStatus = iota
+	PayoutPending                // created upon disbursement, waiting to be exported
+	PayoutExported               // included in a payment file sent to the bank
+	PayoutConfirmed              // paid by the bank, either confirmed manually or by reconciliation
+	PayoutFailed                 // rejected by the bank, set back to pending to be exported again
+)
+
+// GatewayCallback is an asynchronous notification of the payment gateway, verified before being recorded.
+type GatewayCallback struct {
+	CallbackID           []byte  // ID
+	GatewayTransactionID string  // ID given by the payment gateway
+	GatewayStatus                //
+	Reference            string  // payment reference quoted by the payer
+	ISO4217              string  //
+	Amount               float64 //
+	Payload              []byte  // raw notification as signed by the gateway
+	Signature            string  // signature header of the notification
+	CreatedAt            int64   // Unix timestamp
+	CreatedSign          []byte  // signature of CreatedAt
+}
+
+type GatewayStatus int
+
+func (x GatewayStatus) String() string {
+	return map[GatewayStatus]string{
+		GatewayPending:   "pending",
+		GatewaySucceeded: "succeeded",
+		GatewayFailed:    "failed",
+	}[x]
+}
+
+const (
+	_                GatewayStatus = iota
+	GatewayPending                 // accepted by the gateway, waiting for the payer
+	GatewaySucceeded               // paid, turned into a borrower repayment
+	GatewayFailed                  // cancelled or expired
+)
+
+// OutboxEvent is a domain event written in the same transaction of the mutation it describes, published afterwards
+// by the relay at least once.
+type OutboxEvent struct {
+	Sequence    int64  // order of the events
+	EventID     []byte // ID
+	EventType   string // e.g. loan.proposed
+	AggregateID []byte // ID of the entity the event is about, e.g. LoanID
+	Payload     []byte // JSON of the event
 	CreatedAt   int64  // Unix timestamp
 	CreatedSign []byte // signature of CreatedAt
+	PublishedAt *int64 // Unix timestamp of the first successful publish, nil while unpublished
+}
+
+// WebhookSubscription is a partner receiving the domain events as signed HTTP POST.
+type WebhookSubscription struct {
+	SubscriptionID []byte // ID
+	URL            string // receiver of the HTTP POST
+	EventTypes     string // comma separated e.g. loan.approved,loan.disbursed, empty for every event
+	Secret         []byte // key of the HMAC-SHA256 signature of the payload
+	Active         bool   // false once unsubscribed
+	CreatedAt      int64  // Unix timestamp
+	CreatedSign    []byte // signature of CreatedAt
+	UpdatedAt      int64  // Unix timestamp
+	UpdatedSign    []byte // signature of UpdatedAt
 }
 
+// WebhookDelivery is a domain event to be posted to a subscription, retried until delivered or dead.
+type WebhookDelivery struct {
+	DeliveryID     []byte // ID
+	SubscriptionID []byte // FK to WebhookSubscription
+	EventID        []byte // FK to OutboxEvent
+	EventType      string //
+	Payload        []byte // body of the HTTP POST
+	WebhookStatus         //
+	Attempts       int    // number of attempts since created or replayed
+	NextAttemptAt  int64  // Unix timestamp
+	URL            string // populated on query from the subscription
+	Secret         []byte // populated on query from the subscription
+	CreatedAt      int64  // Unix timestamp
+	CreatedSign    []byte // signature of CreatedAt
+	UpdatedAt      int64  // Unix timestamp
+	UpdatedSign    []byte // signature of UpdatedAt
+}
+
+// WebhookAttempt is an entry of the delivery log, recorded along with the resulting status of the delivery.
+type WebhookAttempt struct {
+	DeliveryID    []byte // FK to WebhookDelivery
+	Attempt       int    // starting from 1, restarted by a replay
+	WebhookStatus        // status of the delivery after the attempt
+	StatusCode    int    // HTTP status code, zero when no response
+	Error         string //
+	DurationMS    int64  // duration of the HTTP POST in milliseconds
+	NextAttemptAt int64  // Unix timestamp of the retry
+	CreatedAt     int64  // Unix timestamp
+	CreatedSign   []byte // signature of CreatedAt
+}
+
+type WebhookStatus int
+
+func (x WebhookStatus) String() string {
+	return map[WebhookStatus]string{
+		WebhookPending:   "pending",
+		WebhookDelivered: "delivered",
+		WebhookDead:      "dead",
+	}[x]
+}
+
+const (
+	_                WebhookStatus = iota
+	WebhookPending                 // waiting for the next attempt
+	WebhookDelivered               // acknowledged by the receiver with 2xx
+	WebhookDead                    // failed the maximum attempts, waiting to be replayed
+)
+
+type LoanAccrual struct {
+	LoanID      []byte  // FK to Loan
+	AccrualDate int64   // Unix timestamp of the start of accrued day in UTC
+	DayCount    string  // day count convention
+	ISO4217     string  //
+	Amount      float64 // interest accrued on said day
+	Accrued     float64 // cumulative interest accrued until the end of said day
+	CreatedAt   int64   // Unix timestamp
+	CreatedSign []byte  // signature of CreatedAt
+}
+
+type ProvisionSnapshot struct {
+	SnapshotID  []byte // ID
+	AsOf        int64  // Unix timestamp of the start of snapshot day in UTC
+	Loans       []ProvisionLoan
+	Lenders     []ProvisionLender
+	CreatedAt   int64  // Unix timestamp
+	CreatedSign []byte // signature of CreatedAt
+}
+
+type ProvisionLoan struct {
+	LoanID               []byte  // FK to Loan
+	ISO4217              string  //
+	DaysPastDue          int     //
+	Stage                int     // 1 = performing; 2 = significant increase in credit risk; 3 = credit impaired
+	ProbabilityOfDefault float64 //
+	LossGivenDefault     float64 //
+	ExposureAtDefault    float64 //
+	ExpectedCreditLoss   float64 // ProbabilityOfDefault * LossGivenDefault * ExposureAtDefault
+}
+
+type ProvisionLender struct {
+	UserID             []byte  // ID of the lender
+	LoanID             []byte  // FK to Loan
+	ISO4217            string  //
+	Share              float64 // portion of the loan funded by said lender
+	ExposureAtDefault  float64 //
+	ExpectedCreditLoss float64 //
+}
+
+type Journal struct {
+	JournalID   []byte // ID
+	LoanID      []byte // FK to Loan, nil when not related to any loan
+	Description string //
+	Postings    []Posting
+	CreatedAt   int64  // Unix timestamp
+	CreatedSign []byte // signature of CreatedAt
+}
+
+type Posting struct {
+	LedgerAccount         // account being debited or credited
+	UserID        []byte  // owner of the sub-account, nil for platform account
+	ISO4217       string  //
+	Debit         float64 // either Debit or Credit should be zero
+	Credit        float64 //
+}
+
+type Wallet struct {
+	UserID      []byte  // FK to users.user_id
+	ISO4217     string  //
+	Available   float64 // free to be invested or withdrawn
+	Reserved    float64 // invested on loans not yet disbursed
+	UpdatedAt   int64   // Unix timestamp
+	UpdatedSign []byte  // signature of UpdatedAt
+}
+
+// WalletEntry is the change of a wallet balance, negative amount is a debit.
+type WalletEntry struct {
+	UserID    []byte
+	ISO4217   string
+	Available float64
+	Reserved  float64
+}
+
+type InsufficientBalanceError struct {
+	ISO4217           string
+	Balance, Required float64
+}
+
+func (x *InsufficientBalanceError) Error() string {
+	return fmt.Sprintf("repository/datastore: insufficient balance of %s, balance [%f] & required [%f]", x.ISO4217, x.Balance, x.Required)
+}
+
+type PaymentReferenceConflictError struct {
+	PaymentReference string
+}
+
+func (x *PaymentReferenceConflictError) Error() string {
+	return fmt.Sprintf("repository/datastore: payment reference [%s] is taken by another loan", x.PaymentReference)
+}
+
+type UnbalancedJournalError struct {
+	ISO4217       string
+	Debit, Credit float64
+}
+
+func (x *UnbalancedJournalError) Error() string {
+	return fmt.Sprintf("repository/datastore: unbalanced journal of %s, debit [%f] & credit [%f]", x.ISO4217, x.Debit, x.Credit)
+}
+
+// Validate will enforce the double-entry invariant, sum of debit equal to sum of credit for each currency.
+func (x Journal) Validate(ctx context.Context) (_ Journal, err error) {
+	if len(x.JournalID) < 1 {
+		return x, fmt.Errorf("repository/datastore: invalid journal_id")
+	}
+	debit, credit := map[string]float64{}, map[string]float64{}
+	for _, p := range x.Postings {
+		if p.LedgerAccount.String() == "" {
+			return x, fmt.Errorf("repository/datastore: unknown ledger account [%d]", p.LedgerAccount)
+		}
+		if p.Debit < 0 || p.Credit < 0 || (p.Debit != 0 && p.Credit != 0) {
+			return x, fmt.Errorf("repository/datastore: posting should either be a positive debit or credit")
+		}
+		debit[p.ISO4217] += p.Debit
+		credit[p.ISO4217] += p.Credit
+	}
+	for iso4217 := range debit {
+		if math.Abs(debit[iso4217]-credit[iso4217]) > balanceTolerance {
+			return x, &UnbalancedJournalError{ISO4217: iso4217, Debit: debit[iso4217], Credit: credit[iso4217]}
+		}
+	}
+	return x, nil
+}
+
+// balanceTolerance absorb floating point error of summing amounts, far below the smallest currency unit.
+const balanceTolerance = 1e-6
+
+type LedgerAccount int
+
+func (x LedgerAccount) String() string {
+	return map[LedgerAccount]string{
+		AccountBorrowerReceivable: "borrower_receivable",
+		AccountLenderPayable:      "lender_payable",
+		AccountPlatformFeeRevenue: "platform_fee_revenue",
+		AccountCashInTransit:      "cash_in_transit",
+		AccountLenderWallet:       "lender_wallet",
+		AccountCashAtBank:         "cash_at_bank",
+	}[x]
+}
+
+const (
+	_ LedgerAccount = iota
+	AccountBorrowerReceivable
+	AccountLenderPayable
+	AccountPlatformFeeRevenue
+	AccountCashInTransit
+	AccountLenderWallet
+	AccountCashAtBank
+)
+
 type LoanState int
 
 func (x LoanState) String() string {
@@ -53,6 +506,33 @@ const (
 	StateDisbursed
 )
 
+type PaymentType int
+
+func (x PaymentType) String() string {
+	return map[PaymentType]string{
+		PaymentPrincipalDisbursement: "principal_disbursement",
+		PaymentInstallment:           "installment",
+		PaymentFee:                   "fee",
+		PaymentInterest:              "interest",
+		PaymentInvestment:            "investment",
+		PaymentRepayment:             "repayment",
+		PaymentRefund:                "refund",
+		PaymentPenalty:               "penalty",
+	}[x]
+}
+
+const (
+	_                            PaymentType = iota
+	PaymentPrincipalDisbursement             // borrower receive the principal, negative
+	PaymentInstallment                       // borrower repay the principal, interest & fee, positive
+	PaymentFee                               // borrower pay a standalone fee, positive
+	PaymentInterest                          // borrower pay a standalone interest, positive
+	PaymentInvestment                        // lender fund the principal, positive
+	PaymentRepayment                         // lender receive the investment & interest, negative
+	PaymentRefund                            // party receive back an overpayment, negative
+	PaymentPenalty                           // borrower pay a late penalty, positive
+)
+
 type LoanPartyRoleAs int
 
 func (x LoanPartyRoleAs) String() string {
@@ -67,3 +547,97 @@ const (
 	RoleAsBorrower
 	RoleAsLender
 )
+
+// UserKey is an ed25519 public key registered by a user, signing the contracts of the user.
+type UserKey struct {
+	KeyID       []byte // first bytes of the SHA-256 of PublicKey, as keyring.KeyID
+	UserID      []byte // FK to users.user_id
+	PublicKey   []byte // ed25519
+	CreatedAt   int64  // Unix timestamp
+	CreatedSign []byte // signature of CreatedAt
+	RevokedAt   *int64 // Unix timestamp, signatures accepted afterward are not valid
+}
+
+// Contract is the document rendered for a party of the loan, signed by the party over its hash.
+type Contract struct {
+	ContractID      []byte // ID
+	LoanID          []byte // FK to Loan
+	UserID          []byte // FK to LoanParty, the party expected to sign
+	LoanPartyRoleAs        //
+	Document        []byte // rendered contract as presented to the party
+	Hash            []byte // SHA-256 of Document
+	CreatedAt       int64  // Unix timestamp
+	CreatedSign     []byte // signature of CreatedAt
+	Signatures      []ContractSignature
+}
+
+// ContractSignature is a signature of the hash of a contract by a key of the party.
+type ContractSignature struct {
+	ContractID  []byte // FK to Contract
+	KeyID       []byte // FK to UserKey
+	Signature   []byte // ed25519 signature of Contract.Hash
+	CreatedAt   int64  // Unix timestamp of the signature being accepted
+	CreatedSign []byte // signature of CreatedAt
+	Verified    bool   `json:"-"` // signed by an unrevoked key of the party over the hash of the document, set on query
+}
+
+// Document is rendered from a versioned template for a party of the loan, stored by the hash of its content.
+type Document struct {
+	Hash            []byte // SHA-256 of Content
+	LoanID          []byte // FK to Loan
+	UserID          []byte // FK to LoanParty, the party the document is addressed to
+	Kind            string // e.g. borrower_agreement
+	TemplateVersion int    // version of the template being rendered
+	MediaType       string // e.g. application/pdf
+	Content         []byte `json:"-"` // left out of the audit log, stored once by its hash
+	CreatedAt       int64  // Unix timestamp of the content being first stored
+	CreatedSign     []byte // signature of CreatedAt
+}
+
+// SignedRow is a signature stored on a row, as found by scanning the datastore.
+type SignedRow struct {
+	Table        string // e.g. loans, loan_parties
+	RowID        int64  // rowid of the table
+	Column       string // e.g. created_sign, approved_sign
+	At           int64  // Unix timestamp being signed
+	SignVersion         // format of the message being signed
+	KeyID        []byte // nil when the key is not found in the keyring
+	PublicKey    []byte // nil when the key is unknown or the signature is malformed
+	Verification        //
+}
+
+// SignVersion is the format of the message being signed on the loan tables, the other tables are always signed as
+// SignTimestamp. Rows keep the version they are signed with so that older rows can still be verified.
+type SignVersion int
+
+func (x SignVersion) String() string {
+	return map[SignVersion]string{
+		SignTimestamp: "timestamp",
+		SignCanonical: "canonical",
+	}[x]
+}
+
+const (
+	_             SignVersion = iota
+	SignTimestamp             // the Unix timestamp only, shared by every row written in the same second
+	SignCanonical             // canonical content of the row including its parent IDs, see canonical
+)
+
+type Verification int
+
+func (x Verification) String() string {
+	return map[Verification]string{
+		SignatureValid:      "valid",
+		SignatureUnsigned:   "unsigned",
+		SignatureUnknownKey: "unknown_key",
+		SignatureInvalid:    "invalid",
+	}[x]
+}
+
+const (
+	_                   Verification = iota
+	SignatureValid                   // signed by a key of the keyring or a trusted key
+	SignatureUnsigned                // empty, e.g. backfilled by a migration
+	SignatureUnknownKey              // signed by a key neither in the keyring nor trusted
+	SignatureInvalid                 // malformed or not the signature of the message
+)
@@ -0,0 +1,187 @@
+//go:generate mockgen -destination keyring_mock.go -package keyring . Keyring
+package keyring
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/gunawanwijaya/loan-svc/pkg"
+)
+
+type Configuration struct {
+	File     string        `json:"file,omitempty"`     // encrypted key file, created on first start; the seeds of the secret are used when empty
+	Rotation time.Duration `json:"rotation,omitempty"` // age of the signing key before a new one is generated, never rotated when 0
+	Interval time.Duration `json:"interval,omitempty"` // pause between each check of the rotation, default to 1h
+}
+type Dependency struct {
+	Secret []byte   // AES-256 key encrypting the key file
+	Seeds  [][]byte // ed25519 seeds used when there is no key file, the last one signs while the others are retired
+}
+type Keyring interface {
+	Sign(msg []byte) []byte
+	PublicKey(keyID []byte) (ed25519.PublicKey, bool)
+	Keys() []Key
+	Rotate(ctx context.Context, req RotateRequest) (res RotateResponse, err error)
+	Run(ctx context.Context)
+}
+
+func New(ctx context.Context, cfg Configuration, dep Dependency) (_ Keyring, err error) {
+	cfg, err = pkg.AsValidator(cfg).Validate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	dep, err = pkg.AsValidator(dep).Validate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.File != "" && len(dep.Secret) != 32 {
+		return nil, fmt.Errorf("repository/keyring: secret of the key file should be 32 bytes")
+	}
+	if cfg.File == "" && len(dep.Seeds) > 0 && cfg.Rotation > 0 {
+		return nil, fmt.Errorf("repository/keyring: seeds of the secret are rotated by replacing the secret")
+	}
+
+	// the signing key is only rotated by Rotate & Run, so that tools sharing the key file never rotate it
+	x := &keyring{Configuration: cfg, Dependency: dep}
+	if err = x.load(ctx); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type keyring struct {
+	Configuration
+	Dependency
+
+	mu   sync.RWMutex
+	keys []key // in order of creation, the last one signs
+}
+
+func (cfg Configuration) Validate(ctx context.Context) (_ Configuration, err error) {
+	if cfg.Rotation < 0 || cfg.Interval < 0 {
+		return cfg, fmt.Errorf("repository/keyring: negative rotation or interval")
+	}
+	if cfg.Interval == 0 {
+		cfg.Interval = time.Hour
+	}
+	return cfg, nil
+}
+
+func (dep Dependency) Validate(ctx context.Context) (_ Dependency, err error) {
+	for _, seed := range dep.Seeds {
+		if len(seed) != ed25519.SeedSize {
+			return dep, fmt.Errorf("repository/keyring: invalid seed")
+		}
+	}
+	return dep, nil
+}
+
+// KeyIDSize is the length of a key ID, stored in front of each signature instead of the public key.
+const KeyIDSize = 8
+
+// KeyID will identify the public key by the first bytes of its SHA-256.
+func KeyID(pub ed25519.PublicKey) []byte {
+	h := sha256.Sum256(pub)
+	return h[:KeyIDSize]
+}
+
+// Key is a public key of the keyring, retired keys are kept to verify what they signed.
+type Key struct {
+	KeyID     []byte
+	PublicKey ed25519.PublicKey
+	CreatedAt int64  // Unix timestamp
+	RetiredAt *int64 // Unix timestamp, nil for the signing key
+}
+
+type key struct {
+	Key
+	ed25519.PrivateKey // nil once retired
+}
+
+func newKey(now int64, seed []byte) key {
+	priv := ed25519.NewKeyFromSeed(seed)
+	pub := priv.Public().(ed25519.PublicKey)
+	return key{Key{KeyID: KeyID(pub), PublicKey: pub, CreatedAt: now}, priv}
+}
+
+// load will read the key file, creating it on first start, or take the seeds of the secret. Without both, an
+// ephemeral key signs what cannot be verified once the process is restarted.
+func (x *keyring) load(ctx context.Context) (err error) {
+	log := pkg.Context.SlogLogger(ctx)
+	switch {
+	case x.Configuration.File != "":
+		var unlock func()
+		if unlock, err = lockFile(x.Configuration.File + ".lock"); err != nil {
+			return err
+		}
+		defer unlock()
+		var created bool
+		if x.keys, created, err = x.read(); err != nil {
+			return err
+		}
+		if created {
+			log.InfoContext(ctx, "repository/keyring: key file is created", slog.String("file", x.Configuration.File))
+		}
+	case len(x.Dependency.Seeds) > 0:
+		for i, seed := range x.Dependency.Seeds {
+			k := newKey(0, seed)
+			if i < len(x.Dependency.Seeds)-1 {
+				k.RetiredAt, k.PrivateKey = pkg.Ptr(int64(0)), nil
+			}
+			x.keys = append(x.keys, k)
+		}
+	default:
+		var seed []byte
+		if seed, err = randomSeed(); err != nil {
+			return err
+		}
+		x.keys = []key{newKey(time.Now().Unix(), seed)}
+		log.WarnContext(ctx, "repository/keyring: ephemeral key, signatures are not verified once restarted")
+	}
+	if len(x.keys) < 1 || x.keys[len(x.keys)-1].PrivateKey == nil {
+		return fmt.Errorf("repository/keyring: no signing key")
+	}
+	signing := x.keys[len(x.keys)-1]
+	log.InfoContext(ctx, "repository/keyring",
+		slog.String("key_id", pkg.BtoA(signing.KeyID)),
+		slog.String("public_key", pkg.BtoA(signing.PublicKey)),
+		slog.Int("retired", len(x.keys)-1),
+	)
+	return nil
+}
+
+// Sign will return the key ID of the signing key followed by the signature of `msg`.
+func (x *keyring) Sign(msg []byte) []byte {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	k := x.keys[len(x.keys)-1]
+	return append(append([]byte{}, k.KeyID...), ed25519.Sign(k.PrivateKey, msg)...)
+}
+
+// PublicKey will find the public key of the key ID, either signing or retired.
+func (x *keyring) PublicKey(keyID []byte) (ed25519.PublicKey, bool) {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	for _, k := range x.keys {
+		if string(k.KeyID) == string(keyID) {
+			return k.PublicKey, true
+		}
+	}
+	return nil, false
+}
+
+// Keys will list every public key in order of creation, the last one signs.
+func (x *keyring) Keys() []Key {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	keys := make([]Key, len(x.keys))
+	for i, k := range x.keys {
+		keys[i] = k.Key
+	}
+	return keys
+}
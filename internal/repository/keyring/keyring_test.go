@@ -0,0 +1,168 @@
+package keyring_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gunawanwijaya/loan-svc/internal/repository/keyring"
+	"github.com/gunawanwijaya/loan-svc/pkg"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyring(t *testing.T) {
+	ctx := pkg.Context.PutSlogLogger(context.Background(), slog.Default())
+	secret := make([]byte, 32)
+	_, err := rand.Read(secret)
+	require.NoError(t, err)
+	msg := []byte("loan-svc")
+
+	verify := func(k keyring.Keyring, sig []byte) bool {
+		require.Len(t, sig, keyring.KeyIDSize+ed25519.SignatureSize)
+		pub, ok := k.PublicKey(sig[:keyring.KeyIDSize])
+		return ok && ed25519.Verify(pub, msg, sig[keyring.KeyIDSize:])
+	}
+
+	t.Run("key file", func(t *testing.T) {
+		cfg := keyring.Configuration{File: filepath.Join(t.TempDir(), "loan-svc.keyring")}
+		k1, err := keyring.New(ctx, cfg, keyring.Dependency{Secret: secret})
+		require.NoError(t, err)
+		sig1 := k1.Sign(msg)
+		require.True(t, verify(k1, sig1))
+		require.Len(t, k1.Keys(), 1)
+		require.Equal(t, keyring.KeyID(k1.Keys()[0].PublicKey), k1.Keys()[0].KeyID)
+
+		// the same key is loaded once restarted
+		k2, err := keyring.New(ctx, cfg, keyring.Dependency{Secret: secret})
+		require.NoError(t, err)
+		require.Equal(t, k1.Keys(), k2.Keys())
+		require.True(t, verify(k2, sig1))
+		require.Equal(t, sig1[:keyring.KeyIDSize], k2.Sign(msg)[:keyring.KeyIDSize])
+
+		// sealed, the seed is not found as is
+		b, err := os.ReadFile(cfg.File)
+		require.NoError(t, err)
+		require.NotContains(t, string(b), "seed")
+		_, err = keyring.New(ctx, cfg, keyring.Dependency{Secret: make([]byte, 32)})
+		require.Error(t, err)
+		_, err = keyring.New(ctx, cfg, keyring.Dependency{})
+		require.Error(t, err)
+	})
+
+	t.Run("rotation", func(t *testing.T) {
+		cfg := keyring.Configuration{File: filepath.Join(t.TempDir(), "loan-svc.keyring"), Rotation: time.Hour}
+		k, err := keyring.New(ctx, cfg, keyring.Dependency{Secret: secret})
+		require.NoError(t, err)
+		sig1 := k.Sign(msg)
+
+		res, err := k.Rotate(ctx, keyring.RotateRequest{})
+		require.NoError(t, err)
+		require.False(t, res.Rotated) // not yet due
+		require.Equal(t, sig1[:keyring.KeyIDSize], res.KeyID)
+
+		res, err = k.Rotate(ctx, keyring.RotateRequest{Force: true})
+		require.NoError(t, err)
+		require.True(t, res.Rotated)
+		sig2 := k.Sign(msg)
+		require.Equal(t, res.KeyID, sig2[:keyring.KeyIDSize])
+		require.NotEqual(t, sig1[:keyring.KeyIDSize], sig2[:keyring.KeyIDSize])
+		// the retired key still verifies what it signed
+		require.True(t, verify(k, sig1))
+		require.True(t, verify(k, sig2))
+
+		keys := k.Keys()
+		require.Len(t, keys, 2)
+		require.NotNil(t, keys[0].RetiredAt)
+		require.Nil(t, keys[1].RetiredAt)
+
+		// rotated keys are kept in the key file
+		k, err = keyring.New(ctx, cfg, keyring.Dependency{Secret: secret})
+		require.NoError(t, err)
+		require.Equal(t, keys, k.Keys())
+		require.True(t, verify(k, sig1))
+		require.Equal(t, sig2[:keyring.KeyIDSize], k.Sign(msg)[:keyring.KeyIDSize])
+	})
+
+	t.Run("shared key file", func(t *testing.T) {
+		cfg := keyring.Configuration{File: filepath.Join(t.TempDir(), "loan-svc.keyring"), Rotation: time.Nanosecond}
+		k1, err := keyring.New(ctx, cfg, keyring.Dependency{Secret: secret})
+		require.NoError(t, err)
+		// never rotated once constructed, even when due, as by the tools sharing the key file
+		k2, err := keyring.New(ctx, cfg, keyring.Dependency{Secret: secret})
+		require.NoError(t, err)
+		require.Equal(t, k1.Keys(), k2.Keys())
+
+		// the key rotated by one is taken by the other instead of being overwritten
+		res1, err := k1.Rotate(ctx, keyring.RotateRequest{Force: true})
+		require.NoError(t, err)
+		cfg.Rotation = time.Hour
+		k2, err = keyring.New(ctx, cfg, keyring.Dependency{Secret: secret})
+		require.NoError(t, err)
+		res2, err := k2.Rotate(ctx, keyring.RotateRequest{})
+		require.NoError(t, err)
+		require.False(t, res2.Rotated)
+		require.Equal(t, res1.KeyID, res2.KeyID)
+
+		// rotated concurrently, every key is kept in the key file & a single one signs
+		var wg sync.WaitGroup
+		for range 8 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				k, err := keyring.New(ctx, cfg, keyring.Dependency{Secret: secret})
+				if err == nil {
+					_, err = k.Rotate(ctx, keyring.RotateRequest{Force: true})
+				}
+				require.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+		k, err := keyring.New(ctx, cfg, keyring.Dependency{Secret: secret})
+		require.NoError(t, err)
+		keys := k.Keys()
+		require.Len(t, keys, 2+8)
+		for _, key := range keys[:len(keys)-1] {
+			require.NotNil(t, key.RetiredAt)
+		}
+		require.Nil(t, keys[len(keys)-1].RetiredAt)
+		require.True(t, verify(k, k1.Sign(msg)))
+	})
+
+	t.Run("seeds", func(t *testing.T) {
+		seed1, seed2 := make([]byte, ed25519.SeedSize), make([]byte, ed25519.SeedSize)
+		_, _ = rand.Read(seed1)
+		_, _ = rand.Read(seed2)
+		k, err := keyring.New(ctx, keyring.Configuration{}, keyring.Dependency{Seeds: [][]byte{seed1, seed2}})
+		require.NoError(t, err)
+		pub2 := ed25519.NewKeyFromSeed(seed2).Public().(ed25519.PublicKey)
+		sig := k.Sign(msg)
+		require.Equal(t, keyring.KeyID(pub2), sig[:keyring.KeyIDSize])
+		require.True(t, verify(k, sig))
+
+		pub1 := ed25519.NewKeyFromSeed(seed1).Public().(ed25519.PublicKey)
+		pub, ok := k.PublicKey(keyring.KeyID(pub1))
+		require.True(t, ok)
+		require.Equal(t, pub1, pub)
+
+		_, err = k.Rotate(ctx, keyring.RotateRequest{Force: true})
+		require.Error(t, err)
+		_, err = keyring.New(ctx, keyring.Configuration{Rotation: time.Hour}, keyring.Dependency{Seeds: [][]byte{seed1}})
+		require.Error(t, err)
+		_, err = keyring.New(ctx, keyring.Configuration{}, keyring.Dependency{Seeds: [][]byte{seed1[1:]}})
+		require.Error(t, err)
+	})
+
+	t.Run("ephemeral", func(t *testing.T) {
+		k, err := keyring.New(ctx, keyring.Configuration{}, keyring.Dependency{})
+		require.NoError(t, err)
+		require.True(t, verify(k, k.Sign(msg)))
+		_, ok := k.PublicKey(make([]byte, keyring.KeyIDSize))
+		require.False(t, ok)
+	})
+}
@@ -0,0 +1,27 @@
+//go:build !unix
+
+package keyring
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+)
+
+// lockFile will hold an exclusive lock on `name` until unlocked, by creating it exclusively. A lock left behind by a
+// process exiting without unlocking should be removed by hand.
+func lockFile(name string) (unlock func(), err error) {
+	for deadline := time.Now().Add(30 * time.Second); ; time.Sleep(50 * time.Millisecond) {
+		var f *os.File
+		if f, err = os.OpenFile(name, os.O_CREATE|os.O_EXCL|os.O_RDWR, 0o600); err == nil {
+			_ = f.Close()
+			return func() { _ = os.Remove(name) }, nil
+		} else if !errors.Is(err, fs.ErrExist) {
+			return nil, err
+		} else if time.Now().After(deadline) {
+			return nil, fmt.Errorf("repository/keyring: key file is locked by %s", name)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+//go:build unix
+
+package keyring
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockFile will hold an exclusive lock on `name` until unlocked, shared by every process of the key file.
+func lockFile(name string) (unlock func(), err error) {
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	if err = syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return func() {
+		_ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		_ = f.Close()
+	}, nil
+}
@@ -0,0 +1,243 @@
+package keyring
+
+import (
+	"cmp"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/gunawanwijaya/loan-svc/pkg"
+)
+
+type RotateRequest struct {
+	Force bool // rotate before the signing key is due, e.g. once compromised
+}
+
+type RotateResponse struct {
+	Rotated bool
+	KeyID   []byte // of the signing key
+}
+
+// Rotate will generate a new signing key once the current one is older than the rotation, the current one is
+// retired keeping only its public key. The key file is locked & read again beforehand, so that keys rotated by other
+// processes sharing it are merged instead of overwritten, & written before the new key signs anything.
+func (x *keyring) Rotate(ctx context.Context, req RotateRequest) (res RotateResponse, err error) {
+	log := pkg.Context.SlogLogger(ctx)
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	keys := x.keys
+	var stored []key
+	if x.Configuration.File != "" {
+		var unlock func()
+		if unlock, err = lockFile(x.Configuration.File + ".lock"); err != nil {
+			return
+		}
+		defer unlock()
+		if stored, _, err = x.read(); err != nil {
+			return
+		}
+		if keys, err = merge(keys, stored); err != nil {
+			return
+		}
+		if signing := keys[len(keys)-1]; string(signing.KeyID) != string(x.keys[len(x.keys)-1].KeyID) {
+			log.InfoContext(ctx, "repository/keyring.Rotate: signing key is rotated by another process",
+				slog.String("key_id", pkg.BtoA(signing.KeyID)),
+			)
+		}
+	}
+
+	now := time.Now()
+	signing := keys[len(keys)-1]
+	res.KeyID = signing.KeyID
+	if !req.Force && (x.Configuration.Rotation == 0 || now.Before(time.Unix(signing.CreatedAt, 0).Add(x.Configuration.Rotation))) {
+		if x.Configuration.File != "" && len(keys) != len(stored) {
+			err = x.write(keys) // keys of this process missing from the key file
+		}
+		if err == nil {
+			x.keys = keys
+		}
+		return
+	}
+	if x.Configuration.File == "" && len(x.Dependency.Seeds) > 0 {
+		err = fmt.Errorf("repository/keyring: seeds of the secret are rotated by replacing the secret")
+		return
+	}
+
+	var seed []byte
+	if seed, err = randomSeed(); err != nil {
+		return
+	}
+	keys = append([]key{}, keys...)
+	keys[len(keys)-1].RetiredAt, keys[len(keys)-1].PrivateKey = pkg.Ptr(now.Unix()), nil
+	keys = append(keys, newKey(now.Unix(), seed))
+	if x.Configuration.File != "" {
+		if err = x.write(keys); err != nil {
+			return
+		}
+	}
+	x.keys = keys
+	res.Rotated, res.KeyID = true, keys[len(keys)-1].KeyID
+
+	log.InfoContext(ctx, "repository/keyring.Rotate",
+		slog.String("retired", pkg.BtoA(signing.KeyID)),
+		slog.String("key_id", pkg.BtoA(res.KeyID)),
+		slog.String("public_key", pkg.BtoA(keys[len(keys)-1].PublicKey)),
+	)
+	return
+}
+
+// merge will add the keys of `stored` missing from `keys`, a key retired by either one stays retired. Keys are kept
+// in order of creation & every key but the last one is retired, so that a single key signs.
+func merge(keys, stored []key) ([]key, error) {
+	merged := append([]key{}, keys...)
+	for _, s := range stored {
+		i := slices.IndexFunc(merged, func(k key) bool { return string(k.KeyID) == string(s.KeyID) })
+		if i < 0 {
+			merged = append(merged, s)
+		} else if s.RetiredAt != nil && merged[i].RetiredAt == nil {
+			merged[i].RetiredAt, merged[i].PrivateKey = s.RetiredAt, nil
+		}
+	}
+	slices.SortStableFunc(merged, func(a, b key) int { return cmp.Compare(a.CreatedAt, b.CreatedAt) })
+	for i := range merged[:len(merged)-1] {
+		if merged[i].RetiredAt == nil {
+			merged[i].RetiredAt, merged[i].PrivateKey = pkg.Ptr(merged[i+1].CreatedAt), nil
+		}
+	}
+	if merged[len(merged)-1].PrivateKey == nil {
+		return nil, fmt.Errorf("repository/keyring: no signing key")
+	}
+	return merged, nil
+}
+
+// Run will rotate the signing key on schedule until the context is done.
+func (x *keyring) Run(ctx context.Context) {
+	log := pkg.Context.SlogLogger(ctx)
+	for {
+		if _, err := x.Rotate(ctx, RotateRequest{}); err != nil {
+			log.ErrorContext(ctx, "repository/keyring.Run", slog.Any("err", err))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(x.Configuration.Interval):
+		}
+	}
+}
+
+// keyFile is the plaintext of the key file, sealed by AES-256-GCM as nonce followed by the ciphertext.
+type keyFile struct {
+	Keys []sealedKey `json:"keys"`
+}
+type sealedKey struct {
+	Key
+	Seed []byte `json:"seed,omitempty"` // nil once retired
+}
+
+// read will open the key file, a new one is written with a single signing key when it does not exist. The lock of
+// the key file should be held.
+func (x *keyring) read() (keys []key, created bool, err error) {
+	b, err := os.ReadFile(x.Configuration.File)
+	if errors.Is(err, fs.ErrNotExist) {
+		var seed []byte
+		if seed, err = randomSeed(); err != nil {
+			return
+		}
+		keys = []key{newKey(time.Now().Unix(), seed)}
+		return keys, true, x.write(keys)
+	} else if err != nil {
+		return
+	}
+
+	aead, err := x.aead()
+	if err != nil {
+		return
+	}
+	if len(b) < aead.NonceSize() {
+		return nil, false, fmt.Errorf("repository/keyring: malformed key file")
+	}
+	if b, err = aead.Open(nil, b[:aead.NonceSize()], b[aead.NonceSize():], nil); err != nil {
+		return nil, false, fmt.Errorf("repository/keyring: key file is not sealed by the secret")
+	}
+	var f keyFile
+	if err = json.Unmarshal(b, &f); err != nil {
+		return
+	}
+	for _, k := range f.Keys {
+		if len(k.Seed) < 1 {
+			keys = append(keys, key{Key: k.Key})
+			continue
+		}
+		if len(k.Seed) != ed25519.SeedSize {
+			return nil, false, fmt.Errorf("repository/keyring: invalid seed of %s", pkg.BtoA(k.KeyID))
+		}
+		keys = append(keys, key{k.Key, ed25519.NewKeyFromSeed(k.Seed)})
+	}
+	return
+}
+
+// write will seal the keys into the key file, replacing it at once by renaming a temporary file. The lock of the key
+// file should be held.
+func (x *keyring) write(keys []key) (err error) {
+	var f keyFile
+	for _, k := range keys {
+		s := sealedKey{Key: k.Key}
+		if k.PrivateKey != nil {
+			s.Seed = k.PrivateKey.Seed()
+		}
+		f.Keys = append(f.Keys, s)
+	}
+	b, err := json.Marshal(f)
+	if err != nil {
+		return
+	}
+	aead, err := x.aead()
+	if err != nil {
+		return
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(x.Configuration.File), filepath.Base(x.Configuration.File)+".*.tmp")
+	if err != nil {
+		return
+	}
+	defer os.Remove(tmp.Name()) // once renamed, nothing is removed
+	if _, err = tmp.Write(aead.Seal(nonce, nonce, b, nil)); err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return
+	}
+	return os.Rename(tmp.Name(), x.Configuration.File)
+}
+
+func (x *keyring) aead() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(x.Dependency.Secret)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func randomSeed() ([]byte, error) {
+	seed := make([]byte, ed25519.SeedSize)
+	_, err := rand.Read(seed)
+	return seed, err
+}
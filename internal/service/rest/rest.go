@@ -2,21 +2,52 @@ package rest
 
 import (
 	"context"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"strings"
+	"time"
 
+	"github.com/gunawanwijaya/loan-svc/internal/feature/contract"
+	"github.com/gunawanwijaya/loan-svc/internal/feature/document"
+	"github.com/gunawanwijaya/loan-svc/internal/feature/gateway"
 	"github.com/gunawanwijaya/loan-svc/internal/feature/loan"
+	"github.com/gunawanwijaya/loan-svc/internal/feature/payout"
+	"github.com/gunawanwijaya/loan-svc/internal/feature/provision"
+	"github.com/gunawanwijaya/loan-svc/internal/feature/reconciliation"
+	"github.com/gunawanwijaya/loan-svc/internal/feature/wallet"
+	"github.com/gunawanwijaya/loan-svc/internal/feature/webhook"
 	"github.com/gunawanwijaya/loan-svc/pkg"
 	"github.com/rs/cors"
+	"github.com/rs/xid"
 )
 
 type Configuration struct {
-	//
+	TrustProxy bool `json:"trust_proxy,omitempty"` // take the actor & client IP from the headers set by the proxy in front
 }
+
+const (
+	// ActorHeader is the authenticated caller, set by the authenticating proxy in front of the service & only read
+	// when the proxy is trusted, the actor is otherwise left empty. A user is identified by the base64 of its user_id.
+	ActorHeader = "X-Actor-ID"
+	// RequestIDHeader is echoed on the response, generated when not given.
+	RequestIDHeader = "X-Request-ID"
+)
+
 type Dependency struct {
 	loan.Loan
+	provision.Provision
+	wallet.Wallet
+	reconciliation.Reconciliation
+	payout.Payout
+	gateway.Gateway
+	webhook.Webhook
+	contract.Contract
+	document.Document
 }
 
 type REST interface {
@@ -66,6 +97,139 @@ func (x *rest) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 	}))
 
+	mux.Handle("POST /loan/quote", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		req := loan.QuoteRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"errors": []string{err.Error()},
+			}))
+			return
+		}
+		res, err := x.Loan.Quote(ctx, req)
+		if err != nil {
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"errors": []string{err.Error()},
+			}))
+		} else {
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"data": obj{
+					"req": req,
+					"res": res,
+				},
+			}))
+		}
+	}))
+
+	mux.Handle("POST /loan/accrual", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		req := loan.AccrualRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"errors": []string{err.Error()},
+			}))
+			return
+		}
+		res, err := x.Loan.Accrual(ctx, req)
+		if err != nil {
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"errors": []string{err.Error()},
+			}))
+		} else {
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"data": obj{
+					"req": req,
+					"res": res,
+				},
+			}))
+		}
+	}))
+
+	mux.Handle("GET /loan/audit/{id...}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		req := loan.AuditRequest{LoanID: pkg.AtoB(r.PathValue("id"))}
+		res, err := x.Loan.Audit(ctx, req)
+		if err != nil {
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"errors": []string{err.Error()},
+			}))
+		} else {
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"data": obj{
+					"req": req,
+					"res": res,
+				},
+			}))
+		}
+	}))
+
+	mux.Handle("GET /loan/history/{id...}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		req := loan.HistoryRequest{LoanID: pkg.AtoB(r.PathValue("id"))}
+		res, err := x.Loan.History(ctx, req)
+		if err != nil {
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"errors": []string{err.Error()},
+			}))
+		} else {
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"data": obj{
+					"req": req,
+					"res": res,
+				},
+			}))
+		}
+	}))
+
+	mux.Handle("GET /loan/contract/{id...}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		req := contract.ViewRequest{LoanID: pkg.AtoB(r.PathValue("id"))}
+		res, err := x.Contract.View(ctx, req)
+		if err != nil {
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"errors": []string{err.Error()},
+			}))
+		} else {
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"data": obj{
+					"req": req,
+					"res": res,
+				},
+			}))
+		}
+	}))
+
+	mux.Handle("GET /loan/document/{id...}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		req := document.ListRequest{LoanID: pkg.AtoB(r.PathValue("id"))}
+		res, err := x.Document.List(ctx, req)
+		if err != nil {
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"errors": []string{err.Error()},
+			}))
+		} else {
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"data": obj{
+					"req": req,
+					"res": res,
+				},
+			}))
+		}
+	}))
+
+	// published as is for the JWKS clients, verifying the receipts of the mutations offline
+	mux.Handle("GET /.well-known/jwks.json", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		res, err := x.Loan.JWKS(ctx, loan.JWKSRequest{})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/jwk-set+json")
+		w.Header().Set("Cache-Control", "max-age=3600")
+		pkg.Must(json.NewEncoder(w).Encode(res))
+	}))
+
 	mux.Handle("GET /loan/{id...}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 		req := loan.ViewRequest{}
@@ -97,6 +261,16 @@ func (x *rest) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 				}
 			}
 		}
+		if asOf := r.URL.Query().Get("as_of"); asOf != "" {
+			t, err := time.Parse(time.RFC3339, asOf)
+			if err != nil {
+				pkg.Must(json.NewEncoder(w).Encode(obj{
+					"errors": []string{err.Error()},
+				}))
+				return
+			}
+			req.AsOf = &t
+		}
 		res, err := x.Loan.View(ctx, req)
 		if err != nil {
 			pkg.Must(json.NewEncoder(w).Encode(obj{
@@ -112,23 +286,721 @@ func (x *rest) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 	}))
 
-	handler := mwcors(mux)
-	handler.ServeHTTP(w, r)
-}
+	mux.Handle("GET /portfolio/{id...}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		req := loan.PortfolioRequest{LenderID: pkg.AtoB(r.PathValue("id"))}
+		if asOf := r.URL.Query().Get("as_of"); asOf != "" {
+			t, err := time.Parse(time.RFC3339, asOf)
+			if err != nil {
+				pkg.Must(json.NewEncoder(w).Encode(obj{
+					"errors": []string{err.Error()},
+				}))
+				return
+			}
+			req.AsOf = &t
+		}
+		res, err := x.Loan.Portfolio(ctx, req)
+		if err != nil {
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"errors": []string{err.Error()},
+			}))
+		} else {
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"data": obj{
+					"req": req,
+					"res": res,
+				},
+			}))
+		}
+	}))
 
-func (cfg Configuration) Validate(ctx context.Context) (_ Configuration, err error) {
-	return cfg, nil
-}
+	mux.Handle("POST /provision", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		req := provision.SnapshotRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"errors": []string{err.Error()},
+			}))
+			return
+		}
+		res, err := x.Provision.Snapshot(ctx, req)
+		if err != nil {
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"errors": []string{err.Error()},
+			}))
+		} else {
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"data": obj{
+					"req": req,
+					"res": res,
+				},
+			}))
+		}
+	}))
 
-func (dep Dependency) Validate(ctx context.Context) (_ Dependency, err error) {
-	errFmt := "service/rest: uninitialized %s"
-	if dep.Loan == nil {
-		return Dependency{}, fmt.Errorf(errFmt, "feature/loan")
-	}
-	return dep, nil
-}
+	mux.Handle("GET /provision/{date}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		req := provision.SnapshotRequest{}
+		asOf, err := time.Parse(time.DateOnly, r.PathValue("date"))
+		if err != nil {
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"errors": []string{err.Error()},
+			}))
+			return
+		}
+		req.AsOf = &asOf
+		res, err := x.Provision.Snapshot(ctx, req)
+		if err != nil {
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"errors": []string{err.Error()},
+			}))
+		} else {
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"data": obj{
+					"req": req,
+					"res": res,
+				},
+			}))
+		}
+	}))
 
-type MW func(next http.Handler) http.Handler
+	mux.Handle("POST /wallet/deposit", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		req := wallet.DepositRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"errors": []string{err.Error()},
+			}))
+			return
+		}
+		res, err := x.Wallet.Deposit(ctx, req)
+		if err != nil {
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"errors": []string{err.Error()},
+			}))
+		} else {
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"data": obj{
+					"req": req,
+					"res": res,
+				},
+			}))
+		}
+	}))
+
+	mux.Handle("POST /wallet/withdraw", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		req := wallet.WithdrawRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"errors": []string{err.Error()},
+			}))
+			return
+		}
+		res, err := x.Wallet.Withdraw(ctx, req)
+		if err != nil {
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"errors": []string{err.Error()},
+			}))
+		} else {
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"data": obj{
+					"req": req,
+					"res": res,
+				},
+			}))
+		}
+	}))
+
+	mux.Handle("GET /wallet/{id...}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		req := wallet.BalanceRequest{UserID: pkg.AtoB(r.PathValue("id"))}
+		res, err := x.Wallet.Balance(ctx, req)
+		if err != nil {
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"errors": []string{err.Error()},
+			}))
+		} else {
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"data": obj{
+					"req": req,
+					"res": res,
+				},
+			}))
+		}
+	}))
+
+	mux.Handle("POST /reconciliation", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		req := reconciliation.ReconcileRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"errors": []string{err.Error()},
+			}))
+			return
+		}
+		res, err := x.Reconciliation.Reconcile(ctx, req)
+		if err != nil {
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"errors": []string{err.Error()},
+			}))
+		} else {
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"data": obj{
+					"req": req,
+					"res": res,
+				},
+			}))
+		}
+	}))
+
+	mux.Handle("POST /reconciliation/statement", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		req := reconciliation.ImportRequest{Format: r.URL.Query().Get("format")}
+		statement, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxStatementSize))
+		if err != nil {
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"errors": []string{err.Error()},
+			}))
+			return
+		}
+		req.Statement = statement
+		res, err := x.Reconciliation.Import(ctx, req)
+		req.Statement = nil // the uploaded statement is not echoed back
+		if err != nil {
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"errors": []string{err.Error()},
+			}))
+		} else {
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"data": obj{
+					"req": req,
+					"res": res,
+				},
+			}))
+		}
+	}))
+
+	mux.Handle("GET /reconciliation", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		req, err := reportRequest(r)
+		if err != nil {
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"errors": []string{err.Error()},
+			}))
+			return
+		}
+		res, err := x.Reconciliation.Report(ctx, req)
+		if err != nil {
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"errors": []string{err.Error()},
+			}))
+		} else {
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"data": obj{
+					"req": req,
+					"res": res,
+				},
+			}))
+		}
+	}))
+
+	mux.Handle("GET /reconciliation.csv", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		req, err := reportRequest(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		res, err := x.Reconciliation.Report(ctx, req)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=reconciliation-%s.csv", res.AsOf.UTC().Format(time.DateOnly)))
+		pkg.Must(res.WriteCSV(w))
+	}))
+
+	mux.Handle("GET /payout", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		req := payout.ListRequest{
+			Status:  r.URL.Query().Get("status"),
+			LoanID:  pkg.AtoB(r.URL.Query().Get("loan_id")),
+			BatchID: pkg.AtoB(r.URL.Query().Get("batch_id")),
+		}
+		res, err := x.Payout.List(ctx, req)
+		if err != nil {
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"errors": []string{err.Error()},
+			}))
+		} else {
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"data": obj{
+					"req": req,
+					"res": res,
+				},
+			}))
+		}
+	}))
+
+	mux.Handle("POST /payout/export", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		req := payout.ExportRequest{Format: r.URL.Query().Get("format")}
+		if s := r.URL.Query().Get("execution_date"); s != "" {
+			t, err := time.Parse(time.DateOnly, s)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			req.ExecutionDate = &t
+		}
+		res, err := x.Payout.Export(ctx, req)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+		w.Header().Set("Content-Type", res.ContentType)
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", res.Filename))
+		_, _ = w.Write(res.File)
+	}))
+
+	mux.Handle("POST /payout/status", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		req := payout.UpdateRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"errors": []string{err.Error()},
+			}))
+			return
+		}
+		res, err := x.Payout.Update(ctx, req)
+		if err != nil {
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"errors": []string{err.Error()},
+			}))
+		} else {
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"data": obj{
+					"req": req,
+					"res": res,
+				},
+			}))
+		}
+	}))
+
+	// the gateway retries the notification until it is answered with 2xx, so the outcome is given as status code,
+	// 4xx for a notification that never succeed & 5xx for the one worth retrying
+	mux.Handle("POST /gateway/callback", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		payload, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxCallbackSize))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"errors": []string{err.Error()},
+			}))
+			return
+		}
+		res, err := x.Gateway.Callback(ctx, gateway.CallbackRequest{
+			Payload:   payload,
+			Signature: r.Header.Get(gateway.SignatureHeader),
+		})
+		if err != nil {
+			switch {
+			case errors.Is(err, gateway.ErrInvalidSignature):
+				w.WriteHeader(http.StatusUnauthorized)
+			case errors.Is(err, gateway.ErrInvalidNotification):
+				w.WriteHeader(http.StatusBadRequest)
+			case errors.Is(err, gateway.ErrUnknownReference):
+				w.WriteHeader(http.StatusUnprocessableEntity)
+			default:
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"errors": []string{err.Error()},
+			}))
+			return
+		}
+		pkg.Must(json.NewEncoder(w).Encode(obj{
+			"data": obj{
+				"res": res,
+			},
+		}))
+	}))
+
+	mux.Handle("POST /webhook", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		req := webhook.SubscribeRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"errors": []string{err.Error()},
+			}))
+			return
+		}
+		res, err := x.Webhook.Subscribe(ctx, req)
+		if err != nil {
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"errors": []string{err.Error()},
+			}))
+		} else {
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"data": obj{
+					"req": req,
+					"res": res,
+				},
+			}))
+		}
+	}))
+
+	mux.Handle("GET /webhook", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		req := webhook.ListRequest{
+			SubscriptionID: pkg.AtoB(r.URL.Query().Get("subscription_id")),
+			Active:         r.URL.Query().Get("active") == "true",
+		}
+		res, err := x.Webhook.List(ctx, req)
+		if err != nil {
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"errors": []string{err.Error()},
+			}))
+		} else {
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"data": obj{
+					"req": req,
+					"res": res,
+				},
+			}))
+		}
+	}))
+
+	mux.Handle("POST /webhook/unsubscribe", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		req := webhook.UnsubscribeRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"errors": []string{err.Error()},
+			}))
+			return
+		}
+		res, err := x.Webhook.Unsubscribe(ctx, req)
+		if err != nil {
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"errors": []string{err.Error()},
+			}))
+		} else {
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"data": obj{
+					"req": req,
+					"res": res,
+				},
+			}))
+		}
+	}))
+
+	// the dead-letter list is given by ?status=dead
+	mux.Handle("GET /webhook/delivery", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		req := webhook.DeliveriesRequest{
+			SubscriptionID: pkg.AtoB(r.URL.Query().Get("subscription_id")),
+			DeliveryID:     pkg.AtoB(r.URL.Query().Get("delivery_id")),
+			Status:         r.URL.Query().Get("status"),
+		}
+		res, err := x.Webhook.Deliveries(ctx, req)
+		if err != nil {
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"errors": []string{err.Error()},
+			}))
+		} else {
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"data": obj{
+					"req": req,
+					"res": res,
+				},
+			}))
+		}
+	}))
+
+	mux.Handle("POST /webhook/replay", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		req := webhook.ReplayRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"errors": []string{err.Error()},
+			}))
+			return
+		}
+		res, err := x.Webhook.Replay(ctx, req)
+		if err != nil {
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"errors": []string{err.Error()},
+			}))
+		} else {
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"data": obj{
+					"req": req,
+					"res": res,
+				},
+			}))
+		}
+	}))
+
+	mux.Handle("POST /contract", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		req := contract.RenderRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"errors": []string{err.Error()},
+			}))
+			return
+		}
+		res, err := x.Contract.Render(ctx, req)
+		if err != nil {
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"errors": []string{err.Error()},
+			}))
+		} else {
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"data": obj{
+					"req": req,
+					"res": res,
+				},
+			}))
+		}
+	}))
+
+	mux.Handle("POST /contract/sign", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		req := contract.SignRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"errors": []string{err.Error()},
+			}))
+			return
+		}
+		res, err := x.Contract.Sign(ctx, req)
+		if err != nil {
+			if errors.Is(err, contract.ErrForbidden) {
+				w.WriteHeader(http.StatusForbidden)
+			}
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"errors": []string{err.Error()},
+			}))
+		} else {
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"data": obj{
+					"req": req,
+					"res": res,
+				},
+			}))
+		}
+	}))
+
+	mux.Handle("GET /contract/{id...}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		req := contract.ViewRequest{ContractID: pkg.AtoB(r.PathValue("id"))}
+		res, err := x.Contract.View(ctx, req)
+		if err != nil {
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"errors": []string{err.Error()},
+			}))
+		} else {
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"data": obj{
+					"req": req,
+					"res": res,
+				},
+			}))
+		}
+	}))
+
+	mux.Handle("POST /user/key", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		req := contract.RegisterKeyRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"errors": []string{err.Error()},
+			}))
+			return
+		}
+		res, err := x.Contract.RegisterKey(ctx, req)
+		if err != nil {
+			if errors.Is(err, contract.ErrForbidden) {
+				w.WriteHeader(http.StatusForbidden)
+			}
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"errors": []string{err.Error()},
+			}))
+		} else {
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"data": obj{
+					"req": req,
+					"res": res,
+				},
+			}))
+		}
+	}))
+
+	mux.Handle("POST /user/key/revoke", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		req := contract.RevokeKeyRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"errors": []string{err.Error()},
+			}))
+			return
+		}
+		res, err := x.Contract.RevokeKey(ctx, req)
+		if err != nil {
+			if errors.Is(err, contract.ErrForbidden) {
+				w.WriteHeader(http.StatusForbidden)
+			}
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"errors": []string{err.Error()},
+			}))
+		} else {
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"data": obj{
+					"req": req,
+					"res": res,
+				},
+			}))
+		}
+	}))
+
+	mux.Handle("POST /document", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		req := document.GenerateRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"errors": []string{err.Error()},
+			}))
+			return
+		}
+		res, err := x.Document.Generate(ctx, req)
+		if err != nil {
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"errors": []string{err.Error()},
+			}))
+		} else {
+			pkg.Must(json.NewEncoder(w).Encode(obj{
+				"data": obj{
+					"req": req,
+					"res": res,
+				},
+			}))
+		}
+	}))
+
+	// served as is by the link on the loan, the content never changes as it is stored by its hash
+	mux.Handle("GET /document/{hash}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		hash, err := hex.DecodeString(r.PathValue("hash"))
+		if err != nil {
+			http.Error(w, "invalid hash", http.StatusBadRequest)
+			return
+		}
+		res, err := x.Document.Get(ctx, document.GetRequest{Hash: hash})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", res.MediaType)
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+		w.Header().Set("ETag", `"`+r.PathValue("hash")+`"`)
+		_, _ = w.Write(res.Content)
+	}))
+
+	handler := mwcors(x.mwaudit(mux))
+	handler.ServeHTTP(w, r)
+}
+
+func (cfg Configuration) Validate(ctx context.Context) (_ Configuration, err error) {
+	return cfg, nil
+}
+
+func (dep Dependency) Validate(ctx context.Context) (_ Dependency, err error) {
+	errFmt := "service/rest: uninitialized %s"
+	if dep.Loan == nil {
+		return Dependency{}, fmt.Errorf(errFmt, "feature/loan")
+	}
+	if dep.Provision == nil {
+		return Dependency{}, fmt.Errorf(errFmt, "feature/provision")
+	}
+	if dep.Wallet == nil {
+		return Dependency{}, fmt.Errorf(errFmt, "feature/wallet")
+	}
+	if dep.Reconciliation == nil {
+		return Dependency{}, fmt.Errorf(errFmt, "feature/reconciliation")
+	}
+	if dep.Payout == nil {
+		return Dependency{}, fmt.Errorf(errFmt, "feature/payout")
+	}
+	if dep.Gateway == nil {
+		return Dependency{}, fmt.Errorf(errFmt, "feature/gateway")
+	}
+	if dep.Webhook == nil {
+		return Dependency{}, fmt.Errorf(errFmt, "feature/webhook")
+	}
+	if dep.Contract == nil {
+		return Dependency{}, fmt.Errorf(errFmt, "feature/contract")
+	}
+	if dep.Document == nil {
+		return Dependency{}, fmt.Errorf(errFmt, "feature/document")
+	}
+	return dep, nil
+}
+
+// reportRequest will parse the optional `from` & `as_of` query in RFC3339.
+func reportRequest(r *http.Request) (req reconciliation.ReportRequest, err error) {
+	for key, v := range map[string]**time.Time{"from": &req.From, "as_of": &req.AsOf} {
+		if s := r.URL.Query().Get(key); s != "" {
+			t, err := time.Parse(time.RFC3339, s)
+			if err != nil {
+				return req, err
+			}
+			*v = &t
+		}
+	}
+	return req, nil
+}
+
+// maxStatementSize is the limit of an uploaded bank statement.
+const maxStatementSize = 16 << 20
+
+// maxCallbackSize is the limit of a notification posted by the payment gateway.
+const maxCallbackSize = 64 << 10
+
+type MW func(next http.Handler) http.Handler
+
+// mwaudit will put the actor, client IP & request ID into the context, to be recorded on every mutation.
+func (x *rest) mwaudit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(RequestIDHeader)
+		if requestID == "" {
+			requestID = xid.New().String()
+		}
+		w.Header().Set(RequestIDHeader, requestID)
+
+		clientIP, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			clientIP = r.RemoteAddr
+		}
+		var actor string
+		if x.TrustProxy {
+			actor = r.Header.Get(ActorHeader)
+			if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+				clientIP, _, _ = strings.Cut(forwarded, ",")
+				clientIP = strings.TrimSpace(clientIP)
+			}
+		}
+
+		ctx := r.Context()
+		ctx = pkg.Context.PutActor(ctx, actor)
+		ctx = pkg.Context.PutClientIP(ctx, clientIP)
+		ctx = pkg.Context.PutRequestID(ctx, requestID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
 
 type obj = map[string]any
 
@@ -4,13 +4,23 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/gunawanwijaya/loan-svc/internal/feature/contract"
+	"github.com/gunawanwijaya/loan-svc/internal/feature/document"
+	"github.com/gunawanwijaya/loan-svc/internal/feature/gateway"
 	"github.com/gunawanwijaya/loan-svc/internal/feature/loan"
+	"github.com/gunawanwijaya/loan-svc/internal/feature/payout"
+	"github.com/gunawanwijaya/loan-svc/internal/feature/provision"
+	"github.com/gunawanwijaya/loan-svc/internal/feature/reconciliation"
+	"github.com/gunawanwijaya/loan-svc/internal/feature/wallet"
+	"github.com/gunawanwijaya/loan-svc/internal/feature/webhook"
 	"github.com/gunawanwijaya/loan-svc/internal/repository/datastore"
 	"github.com/gunawanwijaya/loan-svc/internal/service/rest"
 	"github.com/gunawanwijaya/loan-svc/pkg"
@@ -23,13 +33,34 @@ func TestRest(t *testing.T) {
 	ctx := pkg.Context.PutSlogLogger(context.Background(), slog.Default())
 	ctrl := gomock.NewController(t)
 	mockLoan := loan.NewMockLoan(ctrl)
+	mockProvision := provision.NewMockProvision(ctrl)
+	mockWallet := wallet.NewMockWallet(ctrl)
+	mockReconciliation := reconciliation.NewMockReconciliation(ctrl)
+	mockPayout := payout.NewMockPayout(ctrl)
+	mockGateway := gateway.NewMockGateway(ctrl)
+	mockWebhook := webhook.NewMockWebhook(ctrl)
+	mockContract := contract.NewMockContract(ctrl)
+	mockDocument := document.NewMockDocument(ctrl)
 
 	type obj = map[string]any
 
+	// the context of the request is derived with the actor, client IP & request ID
+	reqCtx := gomock.Cond(func(c context.Context) bool {
+		return pkg.Context.SlogLogger(c) == pkg.Context.SlogLogger(ctx) && pkg.Context.RequestID(c) != ""
+	})
+
 	svcRest, err := rest.New(ctx, rest.Configuration{
-		//
+		TrustProxy: true,
 	}, rest.Dependency{
-		Loan: mockLoan,
+		Loan:           mockLoan,
+		Provision:      mockProvision,
+		Wallet:         mockWallet,
+		Reconciliation: mockReconciliation,
+		Payout:         mockPayout,
+		Gateway:        mockGateway,
+		Webhook:        mockWebhook,
+		Contract:       mockContract,
+		Document:       mockDocument,
 	})
 	require.NoError(t, err)
 
@@ -51,7 +82,7 @@ func TestRest(t *testing.T) {
 	w, r := httptest.NewRecorder(), httptest.NewRequestWithContext(ctx, "POST", "/loan", buf)
 	{
 		mockLoan.EXPECT().
-			Upsert(ctx, loan.UpsertRequest{Proposed: &loan.ProposedRequest{
+			Upsert(reqCtx, loan.UpsertRequest{Proposed: &loan.ProposedRequest{
 				BorrowerID: []byte("123"),
 				Principal: &pkg.Money{
 					ISO4217: "IDR",
@@ -90,7 +121,7 @@ func TestRest(t *testing.T) {
 	w, r = httptest.NewRecorder(), httptest.NewRequestWithContext(ctx, "GET", "/loan/"+pkg.BtoA(loanID), buf)
 	{
 		mockLoan.EXPECT().
-			View(ctx, loan.ViewRequest{LoanID: loanID}).
+			View(reqCtx, loan.ViewRequest{LoanID: loanID}).
 			Return(loan.ViewResponse{
 				LoanID:     loanID,
 				LoanState:  datastore.StateProposed.String(),
@@ -111,4 +142,294 @@ func TestRest(t *testing.T) {
         }
     }
 }`, w.Body.String())
+
+	asOf := time.Date(2024, 10, 31, 0, 0, 0, 0, time.UTC)
+	w, r = httptest.NewRecorder(), httptest.NewRequestWithContext(ctx, "GET", "/loan/"+pkg.BtoA(loanID)+"?as_of=2024-10-31T00:00:00Z", buf)
+	{
+		mockLoan.EXPECT().
+			View(reqCtx, loan.ViewRequest{LoanID: loanID, AsOf: &asOf}).
+			Return(loan.ViewResponse{
+				LoanID:    loanID,
+				LoanState: datastore.StateProposed.String(),
+			}, nil)
+	}
+	svcRest.ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `{
+    "data": {
+        "req": {
+            "loan_id": "`+pkg.BtoA(loanID)+`",
+            "as_of": "2024-10-31T00:00:00Z"
+        },
+        "res": {
+            "loan_id": "`+pkg.BtoA(loanID)+`",
+            "loan_state": "proposed"
+        }
+    }
+}`, w.Body.String())
+
+	w, r = httptest.NewRecorder(), httptest.NewRequestWithContext(ctx, "GET", "/loan/audit/"+pkg.BtoA(loanID), buf)
+	r.Header.Set(rest.ActorHeader, "officer-7")
+	r.Header.Set(rest.RequestIDHeader, "req-1")
+	{
+		mockLoan.EXPECT().
+			Audit(gomock.Cond(func(c context.Context) bool {
+				return pkg.Context.Actor(c) == "officer-7" &&
+					pkg.Context.RequestID(c) == "req-1" &&
+					pkg.Context.ClientIP(c) == "192.0.2.1" // of httptest
+			}), loan.AuditRequest{LoanID: loanID}).
+			Return(loan.AuditResponse{List: []loan.AuditResponse{{
+				Operation:  "loans.proposed",
+				Actor:      "officer-7",
+				ClientIP:   "192.0.2.1",
+				RequestID:  "req-0",
+				After:      &loan.ViewResponse{LoanID: loanID, LoanState: datastore.StateProposed.String()},
+				OccurredAt: pkg.Ptr(time.Date(2024, 10, 30, 18, 0, 0, 0, time.UTC)),
+			}}}, nil)
+	}
+	svcRest.ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "req-1", w.Header().Get(rest.RequestIDHeader))
+	require.JSONEq(t, `{
+    "data": {
+        "req": {
+            "loan_id": "`+pkg.BtoA(loanID)+`"
+        },
+        "res": {
+            "list": [{
+                "operation": "loans.proposed",
+                "actor": "officer-7",
+                "client_ip": "192.0.2.1",
+                "request_id": "req-0",
+                "after": {
+                    "loan_id": "`+pkg.BtoA(loanID)+`",
+                    "loan_state": "proposed"
+                },
+                "occurred_at": "2024-10-30T18:00:00Z"
+            }]
+        }
+    }
+}`, w.Body.String())
+
+	// the actor & client IP are never taken from the headers without a trusted proxy in front
+	untrusted, err := rest.New(ctx, rest.Configuration{
+		TrustProxy: false,
+	}, rest.Dependency{
+		Loan:           mockLoan,
+		Provision:      mockProvision,
+		Wallet:         mockWallet,
+		Reconciliation: mockReconciliation,
+		Payout:         mockPayout,
+		Gateway:        mockGateway,
+		Webhook:        mockWebhook,
+		Contract:       mockContract,
+		Document:       mockDocument,
+	})
+	require.NoError(t, err)
+	w, r = httptest.NewRecorder(), httptest.NewRequestWithContext(ctx, "GET", "/loan/audit/"+pkg.BtoA(loanID), buf)
+	r.Header.Set(rest.ActorHeader, "officer-7")
+	r.Header.Set("X-Forwarded-For", "203.0.113.9")
+	{
+		mockLoan.EXPECT().
+			Audit(gomock.Cond(func(c context.Context) bool {
+				return pkg.Context.Actor(c) == "" &&
+					pkg.Context.ClientIP(c) == "192.0.2.1" // of httptest
+			}), loan.AuditRequest{LoanID: loanID}).
+			Return(loan.AuditResponse{}, nil)
+	}
+	untrusted.ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w, r = httptest.NewRecorder(), httptest.NewRequestWithContext(ctx, "GET", "/loan/history/"+pkg.BtoA(loanID), buf)
+	{
+		mockLoan.EXPECT().
+			History(reqCtx, loan.HistoryRequest{LoanID: loanID}).
+			Return(loan.HistoryResponse{
+				LoanID:  loanID,
+				Version: 1,
+				Events: []loan.HistoryEvent{{
+					Version:    1,
+					EventType:  datastore.LoanProposed.String(),
+					LoanState:  datastore.StateProposed.String(),
+					OccurredAt: time.Date(2024, 10, 30, 18, 0, 0, 0, time.UTC),
+				}},
+				Loan: loan.ViewResponse{LoanID: loanID, LoanState: datastore.StateProposed.String()},
+			}, nil)
+	}
+	svcRest.ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `{
+    "data": {
+        "req": {
+            "loan_id": "`+pkg.BtoA(loanID)+`"
+        },
+        "res": {
+            "loan_id": "`+pkg.BtoA(loanID)+`",
+            "version": 1,
+            "events": [{
+                "version": 1,
+                "event_type": "proposed",
+                "loan_state": "proposed",
+                "occurred_at": "2024-10-30T18:00:00Z"
+            }],
+            "loan": {
+                "loan_id": "`+pkg.BtoA(loanID)+`",
+                "loan_state": "proposed"
+            }
+        }
+    }
+}`, w.Body.String())
+
+	fake := gateway.Fake{Secret: []byte("local-gateway-secret")}
+	notification := gateway.Notification{TransactionID: "GW-0001", Status: "pending", Currency: "IDR", Amount: 100_000}
+	r, err = fake.Request(ctx, "/gateway/callback", notification)
+	require.NoError(t, err)
+	payload, err := json.Marshal(notification)
+	require.NoError(t, err)
+	w = httptest.NewRecorder()
+	{
+		mockGateway.EXPECT().
+			Callback(reqCtx, gateway.CallbackRequest{Payload: payload, Signature: fake.Sign(payload)}).
+			Return(gateway.CallbackResponse{Notification: notification, Duplicate: true}, nil)
+	}
+	svcRest.ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Body.String(), `"duplicate":true`)
+
+	r, err = fake.Request(ctx, "/gateway/callback", notification)
+	require.NoError(t, err)
+	w = httptest.NewRecorder()
+	{
+		mockGateway.EXPECT().
+			Callback(reqCtx, gomock.Any()).
+			Return(gateway.CallbackResponse{}, gateway.ErrInvalidSignature)
+	}
+	svcRest.ServeHTTP(w, r)
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+	require.Contains(t, w.Body.String(), `"errors":["`+gateway.ErrInvalidSignature.Error()+`"]`)
+
+	// only a failure worth retrying is answered with 5xx
+	for cause, code := range map[error]int{
+		fmt.Errorf("%w: invalid paid_at", gateway.ErrInvalidNotification): http.StatusBadRequest,
+		fmt.Errorf("%w [8808000000000175]", gateway.ErrUnknownReference):  http.StatusUnprocessableEntity,
+		fmt.Errorf("database is locked"):                                  http.StatusInternalServerError,
+	} {
+		r, err = fake.Request(ctx, "/gateway/callback", notification)
+		require.NoError(t, err)
+		w = httptest.NewRecorder()
+		{
+			mockGateway.EXPECT().
+				Callback(reqCtx, gomock.Any()).
+				Return(gateway.CallbackResponse{}, cause)
+		}
+		svcRest.ServeHTTP(w, r)
+		require.Equal(t, code, w.Code, cause.Error())
+		require.Contains(t, w.Body.String(), `"errors":["`+cause.Error()+`"]`)
+	}
+
+	buf.Reset()
+	require.NoError(t, json.NewEncoder(buf).Encode(obj{"url": "https://example.com/hook", "event_types": []string{"loan.approved"}}))
+	w, r = httptest.NewRecorder(), httptest.NewRequestWithContext(ctx, "POST", "/webhook", buf)
+	{
+		mockWebhook.EXPECT().
+			Subscribe(reqCtx, webhook.SubscribeRequest{URL: "https://example.com/hook", EventTypes: []string{"loan.approved"}}).
+			Return(webhook.Subscription{SubscriptionID: []byte("123"), URL: "https://example.com/hook", Secret: []byte("secret"), Active: true}, nil)
+	}
+	svcRest.ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Body.String(), `"secret":"`+pkg.BtoA([]byte("secret"))+`"`)
+
+	w, r = httptest.NewRecorder(), httptest.NewRequestWithContext(ctx, "GET", "/webhook/delivery?status=dead", nil)
+	{
+		mockWebhook.EXPECT().
+			Deliveries(reqCtx, webhook.DeliveriesRequest{SubscriptionID: []byte{}, DeliveryID: []byte{}, Status: "dead"}).
+			Return(webhook.DeliveriesResponse{List: []webhook.Delivery{{DeliveryID: []byte("456"), Status: "dead", Attempts: 8}}}, nil)
+	}
+	svcRest.ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Body.String(), `"status":"dead"`)
+
+	buf.Reset()
+	require.NoError(t, json.NewEncoder(buf).Encode(obj{"delivery_id": pkg.BtoA([]byte("456"))}))
+	w, r = httptest.NewRecorder(), httptest.NewRequestWithContext(ctx, "POST", "/webhook/replay", buf)
+	{
+		mockWebhook.EXPECT().
+			Replay(reqCtx, webhook.ReplayRequest{DeliveryID: []byte("456")}).
+			Return(webhook.DeliveriesResponse{List: []webhook.Delivery{{DeliveryID: []byte("456"), Status: "pending"}}}, nil)
+	}
+	svcRest.ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Body.String(), `"status":"pending"`)
+
+	w, r = httptest.NewRecorder(), httptest.NewRequestWithContext(ctx, "GET", "/.well-known/jwks.json", nil)
+	jwk := pkg.JWK{Kty: "OKP", Crv: "Ed25519", Kid: "a2V5LWlk", X: "cHVibGljLWtleQ", Use: "sig", Alg: "EdDSA"}
+	{
+		mockLoan.EXPECT().
+			JWKS(reqCtx, loan.JWKSRequest{}).
+			Return(loan.JWKSResponse{JWKS: pkg.JWKS{Keys: []pkg.JWK{jwk}}}, nil)
+	}
+	svcRest.ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "application/jwk-set+json", w.Header().Get("Content-Type"))
+	var jwks pkg.JWKS
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&jwks))
+	require.Equal(t, []pkg.JWK{jwk}, jwks.Keys)
+
+	buf.Reset()
+	require.NoError(t, json.NewEncoder(buf).Encode(obj{"contract_id": pkg.BtoA([]byte("789")), "user_id": "MTIz", "key_id": pkg.BtoA([]byte("key-id")), "signature": pkg.BtoA([]byte("sig"))}))
+	w, r = httptest.NewRecorder(), httptest.NewRequestWithContext(ctx, "POST", "/contract/sign", buf)
+	{
+		mockContract.EXPECT().
+			Sign(reqCtx, contract.SignRequest{ContractID: []byte("789"), UserID: []byte("123"), KeyID: []byte("key-id"), Signature: []byte("sig")}).
+			Return(contract.ContractResponse{}, fmt.Errorf("invalid signature"))
+	}
+	svcRest.ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Body.String(), `"errors":["invalid signature"]`)
+
+	// the actor is not the user of the request
+	for path, call := range map[string]func() *gomock.Call{
+		"/contract/sign": func() *gomock.Call {
+			return mockContract.EXPECT().Sign(reqCtx, gomock.Any()).Return(contract.ContractResponse{}, contract.ErrForbidden)
+		},
+		"/user/key": func() *gomock.Call {
+			return mockContract.EXPECT().RegisterKey(reqCtx, gomock.Any()).Return(contract.KeyResponse{}, contract.ErrForbidden)
+		},
+		"/user/key/revoke": func() *gomock.Call {
+			return mockContract.EXPECT().RevokeKey(reqCtx, gomock.Any()).Return(contract.KeyResponse{}, contract.ErrForbidden)
+		},
+	} {
+		w, r = httptest.NewRecorder(), httptest.NewRequestWithContext(ctx, "POST", path, strings.NewReader(`{"user_id":"MTIz"}`))
+		r.Header.Set(rest.ActorHeader, "OTk5")
+		call()
+		svcRest.ServeHTTP(w, r)
+		require.Equal(t, http.StatusForbidden, w.Code, path)
+		require.Contains(t, w.Body.String(), contract.ErrForbidden.Error(), path)
+	}
+
+	w, r = httptest.NewRecorder(), httptest.NewRequestWithContext(ctx, "GET", "/loan/contract/"+pkg.BtoA(loanID), nil)
+	{
+		mockContract.EXPECT().
+			View(reqCtx, contract.ViewRequest{LoanID: loanID}).
+			Return(contract.ContractResponse{ContractID: []byte("789"), RoleAs: "borrower", Signatures: []contract.SignatureResponse{{Verified: true}}}, nil)
+	}
+	svcRest.ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Body.String(), `"verified":true`)
+
+	hash := make([]byte, 32)
+	w, r = httptest.NewRecorder(), httptest.NewRequestWithContext(ctx, "GET", document.Link(hash), nil)
+	{
+		mockDocument.EXPECT().
+			Get(reqCtx, document.GetRequest{Hash: hash}).
+			Return(document.DocumentResponse{MediaType: document.MediaTypePDF, Content: []byte("%PDF-1.4")}, nil)
+	}
+	svcRest.ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, document.MediaTypePDF, w.Header().Get("Content-Type"))
+	require.Equal(t, "%PDF-1.4", w.Body.String())
+
+	w, r = httptest.NewRecorder(), httptest.NewRequestWithContext(ctx, "GET", "/document/not-hex", nil)
+	svcRest.ServeHTTP(w, r)
+	require.Equal(t, http.StatusBadRequest, w.Code)
 }
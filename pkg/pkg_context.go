@@ -8,8 +8,17 @@ import (
 var Context interface {
 	PutSlogLogger(ctx context.Context, logger *slog.Logger) context.Context
 	SlogLogger(ctx context.Context) *slog.Logger
+	PutActor(ctx context.Context, actor string) context.Context
+	Actor(ctx context.Context) string
+	PutClientIP(ctx context.Context, clientIP string) context.Context
+	ClientIP(ctx context.Context) string
+	PutRequestID(ctx context.Context, requestID string) context.Context
+	RequestID(ctx context.Context) string
 } = struct {
 	ctxKeySlogLogger
+	ctxKeyActor
+	ctxKeyClientIP
+	ctxKeyRequestID
 }{}
 
 type ctxKeySlogLogger struct{}
@@ -25,3 +34,34 @@ func (key ctxKeySlogLogger) SlogLogger(ctx context.Context) *slog.Logger {
 	v, _ := ctx.Value(key).(*slog.Logger)
 	return v
 }
+
+// ctxKeyActor is the authenticated caller, empty for the service itself.
+type ctxKeyActor struct{}
+
+func (key ctxKeyActor) PutActor(ctx context.Context, val string) context.Context {
+	return context.WithValue(ctx, key, val)
+}
+func (key ctxKeyActor) Actor(ctx context.Context) string {
+	v, _ := ctx.Value(key).(string)
+	return v
+}
+
+type ctxKeyClientIP struct{}
+
+func (key ctxKeyClientIP) PutClientIP(ctx context.Context, val string) context.Context {
+	return context.WithValue(ctx, key, val)
+}
+func (key ctxKeyClientIP) ClientIP(ctx context.Context) string {
+	v, _ := ctx.Value(key).(string)
+	return v
+}
+
+type ctxKeyRequestID struct{}
+
+func (key ctxKeyRequestID) PutRequestID(ctx context.Context, val string) context.Context {
+	return context.WithValue(ctx, key, val)
+}
+func (key ctxKeyRequestID) RequestID(ctx context.Context) string {
+	v, _ := ctx.Value(key).(string)
+	return v
+}
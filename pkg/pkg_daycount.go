@@ -0,0 +1,65 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+type ValidateDayCountError struct {
+	Unknown DayCount
+}
+
+func (x *ValidateDayCountError) Error() string {
+	return fmt.Sprintf("daycount: validate: unknown convention [%s]", string(x.Unknown))
+}
+
+// DayCount is the convention of counting the fraction of year between 2 dates - https://en.wikipedia.org/wiki/Day_count_convention
+type DayCount string
+
+const (
+	DayCountACT365 DayCount = "ACT/365" // actual days over 365 days year, a.k.a. ACT/365 Fixed
+	DayCountACT360 DayCount = "ACT/360" // actual days over 360 days year
+	DayCount30360  DayCount = "30/360"  // 30 days month over 360 days year, a.k.a. bond basis
+)
+
+// Validate will default an empty convention to ACT/365.
+func (x DayCount) Validate(ctx context.Context) (_ DayCount, err error) {
+	switch x {
+	case "":
+		return DayCountACT365, nil
+	case DayCountACT365, DayCountACT360, DayCount30360:
+		return x, nil
+	}
+	return x, &ValidateDayCountError{Unknown: x}
+}
+
+// YearFraction will return the fraction of year from `from` until `to` by ignoring the time of day,
+// negative when `to` is before `from`.
+func (x DayCount) YearFraction(from, to time.Time) float64 {
+	if to.Before(from) {
+		return -x.YearFraction(to, from)
+	}
+	y1, m1, d1 := from.Date()
+	y2, m2, d2 := to.Date()
+	switch x {
+	default: // DayCountACT365
+		return float64(days(from, to)) / 365
+	case DayCountACT360:
+		return float64(days(from, to)) / 360
+	case DayCount30360:
+		d1 = min(d1, 30)
+		if d1 == 30 {
+			d2 = min(d2, 30)
+		}
+		return float64(360*(y2-y1)+30*(int(m2)-int(m1))+(d2-d1)) / 360
+	}
+}
+
+func days(from, to time.Time) int {
+	y1, m1, d1 := from.Date()
+	y2, m2, d2 := to.Date()
+	t1 := time.Date(y1, m1, d1, 0, 0, 0, 0, time.UTC)
+	t2 := time.Date(y2, m2, d2, 0, 0, 0, 0, time.UTC)
+	return int(t2.Sub(t1).Hours() / 24)
+}
@@ -0,0 +1,34 @@
+package pkg_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gunawanwijaya/loan-svc/pkg"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDayCount(t *testing.T) {
+	ctx := context.Background()
+	var errValidateDayCount *pkg.ValidateDayCountError
+
+	dc, err := pkg.DayCount("").Validate(ctx)
+	require.NoError(t, err)
+	require.Equal(t, pkg.DayCountACT365, dc)
+
+	_, err = pkg.DayCount("ACT/ACT").Validate(ctx)
+	_ = err.Error()
+	require.ErrorAs(t, err, &errValidateDayCount)
+	require.Equal(t, pkg.DayCount("ACT/ACT"), errValidateDayCount.Unknown)
+
+	date := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, time.UTC) }
+	from, to := date(2024, 1, 31), date(2024, 3, 31) // 60 days in leap year
+
+	require.InDelta(t, 60.0/365, pkg.DayCountACT365.YearFraction(from, to), 1e-12)
+	require.InDelta(t, 60.0/360, pkg.DayCountACT360.YearFraction(from, to), 1e-12)
+	require.InDelta(t, 60.0/360, pkg.DayCount30360.YearFraction(from, to), 1e-12)
+	require.InDelta(t, 29.0/360, pkg.DayCount30360.YearFraction(date(2024, 1, 31), date(2024, 2, 29)), 1e-12)
+	require.InDelta(t, 1.0, pkg.DayCount30360.YearFraction(date(2023, 3, 15), date(2024, 3, 15)), 1e-12)
+	require.InDelta(t, -60.0/365, pkg.DayCountACT365.YearFraction(to, from), 1e-12)
+}
@@ -0,0 +1,130 @@
+package pkg
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+type SolveIRRError struct {
+	NoSignChange bool
+	Iteration    int
+}
+
+func (x *SolveIRRError) Error() string {
+	switch {
+	default:
+		return ""
+	case x.NoSignChange:
+		return "irr: solve: cash flows should contain at least one positive & one negative value"
+	case x.Iteration > 0:
+		return fmt.Sprintf("irr: solve: not converging after [%d] iteration", x.Iteration)
+	}
+}
+
+// CashFlow is a single dated amount, negative for money going out & positive for money coming in.
+type CashFlow struct {
+	Amount float64
+	Time   time.Time
+}
+
+const (
+	irrTolerance     = 1e-10
+	irrMaxIteration  = 256
+	irrDaysInYear    = 365.0
+	irrLowerBoundary = -1 + 1e-9
+)
+
+// IRR will return the internal rate of return per period of evenly spaced cash flows, the first value is at t=0.
+func IRR(values ...float64) (rate float64, err error) {
+	return solveIRR(func(r float64) (npv, d float64) {
+		for i, v := range values {
+			n := float64(i)
+			npv += v / math.Pow(1+r, n)
+			d += -n * v / math.Pow(1+r, n+1)
+		}
+		return npv, d
+	}, signChanged(values...))
+}
+
+// XIRR will return the annualised internal rate of return of dated cash flows, using the actual number of days
+// over a 365 days year from the earliest cash flow.
+func XIRR(flows ...CashFlow) (rate float64, err error) {
+	if len(flows) < 1 {
+		return 0, &SolveIRRError{NoSignChange: true}
+	}
+	t0 := flows[0].Time
+	values := make([]float64, len(flows))
+	for i, flow := range flows {
+		if flow.Time.Before(t0) {
+			t0 = flow.Time
+		}
+		values[i] = flow.Amount
+	}
+	return solveIRR(func(r float64) (npv, d float64) {
+		for _, flow := range flows {
+			n := flow.Time.Sub(t0).Hours() / 24 / irrDaysInYear
+			npv += flow.Amount / math.Pow(1+r, n)
+			d += -n * flow.Amount / math.Pow(1+r, n+1)
+		}
+		return npv, d
+	}, signChanged(values...))
+}
+
+func signChanged(values ...float64) bool {
+	var pos, neg bool
+	for _, v := range values {
+		pos, neg = pos || v > 0, neg || v < 0
+	}
+	return pos && neg
+}
+
+// solveIRR will try Newton-Raphson first and fallback to bisection when Newton-Raphson is diverging.
+func solveIRR(f func(r float64) (npv, d float64), signChanged bool) (rate float64, err error) {
+	if !signChanged {
+		return 0, &SolveIRRError{NoSignChange: true}
+	}
+
+	rate = 0.1
+	for i := 0; i < irrMaxIteration; i++ {
+		npv, d := f(rate)
+		if math.Abs(npv) < irrTolerance {
+			return rate, nil
+		}
+		if d == 0 || math.IsNaN(d) || math.IsInf(d, 0) {
+			break
+		}
+		next := rate - npv/d
+		if next <= irrLowerBoundary || math.IsNaN(next) || math.IsInf(next, 0) {
+			break
+		}
+		if math.Abs(next-rate) < irrTolerance {
+			return next, nil
+		}
+		rate = next
+	}
+
+	lo, hi := irrLowerBoundary, 1.0
+	npvLo, _ := f(lo)
+	npvHi, _ := f(hi)
+	for i := 0; npvLo*npvHi > 0; i++ {
+		if i >= irrMaxIteration {
+			return 0, &SolveIRRError{Iteration: i}
+		}
+		hi *= 2
+		npvHi, _ = f(hi)
+	}
+	for i := 0; i < irrMaxIteration; i++ {
+		rate = (lo + hi) / 2
+		npv, _ := f(rate)
+		if math.Abs(npv) < irrTolerance || (hi-lo)/2 < irrTolerance {
+			return rate, nil
+		}
+		if npv*npvLo > 0 {
+			lo, npvLo = rate, npv
+		} else {
+			hi = rate
+		}
+	}
+	return 0, &SolveIRRError{Iteration: irrMaxIteration}
+}
@@ -0,0 +1,40 @@
+package pkg_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gunawanwijaya/loan-svc/pkg"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIRR(t *testing.T) {
+	var errSolveIRR *pkg.SolveIRRError
+
+	r, err := pkg.IRR(-100, 110)
+	require.NoError(t, err)
+	require.InDelta(t, 0.10, r, 1e-9)
+
+	r, err = pkg.IRR(-70_000, 12_000, 15_000, 18_000, 21_000, 26_000)
+	require.NoError(t, err)
+	require.InDelta(t, 0.086631, r, 1e-6)
+
+	_, err = pkg.IRR(100, 110)
+	_ = err.Error()
+	require.ErrorAs(t, err, &errSolveIRR)
+	require.True(t, errSolveIRR.NoSignChange)
+
+	date := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, time.UTC) }
+	r, err = pkg.XIRR(
+		pkg.CashFlow{Amount: -10_000, Time: date(2008, 1, 1)},
+		pkg.CashFlow{Amount: 2_750, Time: date(2008, 3, 1)},
+		pkg.CashFlow{Amount: 4_250, Time: date(2008, 10, 30)},
+		pkg.CashFlow{Amount: 3_250, Time: date(2009, 2, 15)},
+		pkg.CashFlow{Amount: 2_750, Time: date(2009, 4, 1)},
+	)
+	require.NoError(t, err)
+	require.InDelta(t, 0.373362535, r, 1e-6)
+
+	_, err = pkg.XIRR()
+	require.ErrorAs(t, err, &errSolveIRR)
+}
@@ -0,0 +1,106 @@
+package pkg
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+type VerifyJWSError struct {
+	Reason string
+}
+
+func (x *VerifyJWSError) Error() string {
+	return fmt.Sprintf("jws: verify: %s", x.Reason)
+}
+
+// JWK is an ed25519 public key as published in a JWK Set - https://www.rfc-editor.org/rfc/rfc8037
+type JWK struct {
+	Kty string `json:"kty"`           // OKP
+	Crv string `json:"crv"`           // Ed25519
+	Kid string `json:"kid"`           // base64url key ID, as found in the header of the JWS
+	X   string `json:"x"`             // base64url public key
+	Use string `json:"use,omitempty"` // sig
+	Alg string `json:"alg,omitempty"` // EdDSA
+}
+
+// JWKS is the set of public keys verifying the JWS, retired keys are kept to verify what they signed.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// NewJWK will describe the ed25519 public key of the key ID.
+func NewJWK(keyID []byte, pub ed25519.PublicKey) JWK {
+	return JWK{
+		Kty: "OKP",
+		Crv: "Ed25519",
+		Kid: base64.RawURLEncoding.EncodeToString(keyID),
+		X:   base64.RawURLEncoding.EncodeToString(pub),
+		Use: "sig",
+		Alg: "EdDSA",
+	}
+}
+
+// PublicKey will find the ed25519 public key of the kid.
+func (x JWKS) PublicKey(kid string) (ed25519.PublicKey, bool) {
+	for _, k := range x.Keys {
+		if k.Kid != kid || k.Kty != "OKP" || k.Crv != "Ed25519" {
+			continue
+		}
+		pub, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(pub) != ed25519.PublicKeySize {
+			return nil, false
+		}
+		return pub, true
+	}
+	return nil, false
+}
+
+type jwsHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// DetachedJWS will sign the payload as a compact JWS of EdDSA whose payload is left out, i.e. header..signature, the
+// payload is sent along as is - https://www.rfc-editor.org/rfc/rfc7515#appendix-F. `sign` should sign the input by the
+// key of the kid.
+func DetachedJWS(kid string, payload []byte, sign func(input []byte) []byte) string {
+	header, _ := json.Marshal(jwsHeader{Alg: "EdDSA", Kid: kid}) // marshalling plain values never fails
+	h := base64.RawURLEncoding.EncodeToString(header)
+	sig := sign([]byte(h + "." + base64.RawURLEncoding.EncodeToString(payload)))
+	return h + ".." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+// VerifyDetachedJWS will check the detached JWS against the payload by the key of its kid, offline once the JWK Set
+// is fetched.
+func VerifyDetachedJWS(jws string, payload []byte, jwks JWKS) error {
+	parts := strings.Split(jws, ".")
+	if len(parts) != 3 || parts[1] != "" {
+		return &VerifyJWSError{Reason: "not a detached compact JWS"}
+	}
+	b, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return &VerifyJWSError{Reason: "malformed header"}
+	}
+	var header jwsHeader
+	if err = json.Unmarshal(b, &header); err != nil {
+		return &VerifyJWSError{Reason: "malformed header"}
+	}
+	if header.Alg != "EdDSA" {
+		return &VerifyJWSError{Reason: "unsupported alg " + header.Alg}
+	}
+	pub, ok := jwks.PublicKey(header.Kid)
+	if !ok {
+		return &VerifyJWSError{Reason: "unknown kid " + header.Kid}
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return &VerifyJWSError{Reason: "malformed signature"}
+	}
+	if !ed25519.Verify(pub, []byte(parts[0]+"."+base64.RawURLEncoding.EncodeToString(payload)), sig) {
+		return &VerifyJWSError{Reason: "invalid signature"}
+	}
+	return nil
+}
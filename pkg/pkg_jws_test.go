@@ -0,0 +1,44 @@
+package pkg_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"strings"
+	"testing"
+
+	"github.com/gunawanwijaya/loan-svc/pkg"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetachedJWS(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	other, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	jwks := pkg.JWKS{Keys: []pkg.JWK{pkg.NewJWK([]byte("retired!"), other), pkg.NewJWK([]byte("signing!"), pub)}}
+	kid := jwks.Keys[1].Kid
+	var errVerify *pkg.VerifyJWSError
+
+	payload := []byte(`{"loan_state":"proposed"}`)
+	jws := pkg.DetachedJWS(kid, payload, func(input []byte) []byte { return ed25519.Sign(priv, input) })
+	require.Contains(t, jws, "..")
+	require.NoError(t, pkg.VerifyDetachedJWS(jws, payload, jwks))
+
+	err = pkg.VerifyDetachedJWS(jws, []byte(`{"loan_state":"approved"}`), jwks)
+	_ = err.Error()
+	require.ErrorAs(t, err, &errVerify)
+	require.Equal(t, "invalid signature", errVerify.Reason)
+
+	err = pkg.VerifyDetachedJWS(jws, payload, pkg.JWKS{Keys: jwks.Keys[:1]})
+	require.ErrorAs(t, err, &errVerify)
+	require.Equal(t, "unknown kid "+kid, errVerify.Reason)
+
+	// signed by another key than the kid
+	forged := pkg.DetachedJWS(jwks.Keys[0].Kid, payload, func(input []byte) []byte { return ed25519.Sign(priv, input) })
+	require.ErrorAs(t, pkg.VerifyDetachedJWS(forged, payload, jwks), &errVerify)
+
+	// the payload is not embedded
+	parts := strings.Split(jws, ".")
+	require.ErrorAs(t, pkg.VerifyDetachedJWS(parts[0]+".e30."+parts[2], payload, jwks), &errVerify)
+	require.ErrorAs(t, pkg.VerifyDetachedJWS("e30", payload, jwks), &errVerify)
+}
@@ -48,23 +48,24 @@ type Money struct {
 }
 
 func (x *Money) Validate(ctx context.Context) (_ *Money, err error) {
-	l, ok := moneyLookup[x.ISO4217]
-	if !ok {
+	if _, ok := moneyLookup[x.ISO4217]; !ok {
 		return nil, &ValidateMoneyError{UnknownISO4217: x.ISO4217}
 	}
-
-	amount := x.Amount
-	if l.Precision == 0 {
-		amount = math.Round(x.Amount)
-	} else {
-		ratio := math.Pow(10, float64(l.Precision))
-		amount = math.Round(x.Amount*ratio) / ratio
+	m := x.Round()
+	if m.Amount != x.Amount {
+		err = &ValidateMoneyError{RawValue: x.Amount, Value: m.Amount}
 	}
+	return m, err
+}
 
-	if amount != x.Amount {
-		err = &ValidateMoneyError{RawValue: x.Amount, Value: amount}
+// Round will round the amount to the precision of the currency, the amount of an unknown currency is kept as is.
+func (x *Money) Round() *Money {
+	m := &Money{ISO4217: x.ISO4217, Amount: x.Amount, Time: x.Time, Details: x.Details}
+	if l, ok := moneyLookup[x.ISO4217]; ok {
+		ratio := math.Pow(10, float64(l.Precision))
+		m.Amount = math.Round(x.Amount*ratio) / ratio
 	}
-	return &Money{ISO4217: x.ISO4217, Amount: amount, Time: x.Time, Details: x.Details}, err
+	return m
 }
 
 func (x *Money) String() string {
@@ -96,10 +97,7 @@ func (x *Money) Take(portion float64) (take, remainder *Money, err error) {
 	if portion < 0 || portion > 1 {
 		return nil, nil, &TakeMoneyError{Portion: portion}
 	}
-	ctx := context.Background()
-
-	take = &Money{ISO4217: x.ISO4217, Time: x.Time, Details: x.Details, Amount: x.Amount * portion}
-	take, _ = take.Validate(ctx) // error validation should be ignored
+	take = (&Money{ISO4217: x.ISO4217, Time: x.Time, Details: x.Details, Amount: x.Amount * portion}).Round()
 
 	remainder = &Money{ISO4217: x.ISO4217, Time: x.Time, Details: x.Details, Amount: x.Amount - take.Amount}
 	return take, remainder, err
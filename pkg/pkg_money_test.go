@@ -35,6 +35,11 @@ func TestMoney(t *testing.T) {
 	require.Equal(t, 10000.0, m.Amount)
 	require.Equal(t, "JPY", m.ISO4217)
 
+	// rounded to the precision of the currency, unknown currency kept as is
+	require.Equal(t, 10000.23, (&pkg.Money{ISO4217: "IDR", Amount: 10000.234}).Round().Amount)
+	require.Equal(t, 10000.0, (&pkg.Money{ISO4217: "JPY", Amount: 10000.234}).Round().Amount)
+	require.Equal(t, 10000.234, (&pkg.Money{ISO4217: "ABC", Amount: 10000.234}).Round().Amount)
+
 	tk, rm, err := m.Take(3)
 	_ = err.Error()
 	require.ErrorAs(t, err, &errTakeMoney)
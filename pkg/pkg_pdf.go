@@ -0,0 +1,84 @@
+package pkg
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// A4 pages in Courier, as the monospaced text keeps the columns of the plain text aligned.
+const (
+	pdfWidth, pdfHeight = 595, 842 // A4 in points
+	pdfMargin           = 50
+	pdfFontSize         = 9
+	pdfLeading          = 12
+	pdfLineLength       = (pdfWidth - 2*pdfMargin) * 10 / (pdfFontSize * 6) // Courier glyph is 0.6 of the font size
+	pdfLinesPerPage     = (pdfHeight - 2*pdfMargin) / pdfLeading
+)
+
+// PDF will typeset the plain text as a PDF of A4 pages, longer lines are wrapped & characters outside of
+// Windows-1252 are replaced. The same text always gives the same bytes, no creation date is written.
+// - https://opensource.adobe.com/dc-acrobat-sdk-docs/pdfstandards/PDF32000_2008.pdf
+func PDF(title string, text string) []byte {
+	lines := []string{}
+	for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		r := []rune(strings.TrimRight(line, " \r\t"))
+		for len(r) > pdfLineLength {
+			lines, r = append(lines, string(r[:pdfLineLength])), r[pdfLineLength:]
+		}
+		lines = append(lines, string(r))
+	}
+	pages := [][]string{}
+	for len(lines) > pdfLinesPerPage {
+		pages, lines = append(pages, lines[:pdfLinesPerPage]), lines[pdfLinesPerPage:]
+	}
+	pages = append(pages, lines)
+
+	// 1 catalog, 2 pages, 3 font, 4 info, followed by a page & its content for each page
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>",
+		fmt.Sprintf("<< /Title (%s) /Producer (loan-svc) >>", pdfString(title)),
+	}
+	kids := []string{}
+	for _, page := range pages {
+		n := len(objects) + 1
+		kids = append(kids, fmt.Sprintf("%d 0 R", n))
+		var content strings.Builder
+		fmt.Fprintf(&content, "BT\n/F1 %d Tf\n%d TL\n%d %d Td\n", pdfFontSize, pdfLeading, pdfMargin, pdfHeight-pdfMargin)
+		for _, line := range page {
+			fmt.Fprintf(&content, "(%s) '\n", pdfString(line))
+		}
+		content.WriteString("ET")
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>", pdfWidth, pdfHeight, n+1),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", content.Len(), content.String()),
+		)
+	}
+	objects[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(kids))
+
+	b := &bytes.Buffer{}
+	b.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n") // binary comment, the file is not taken as text
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = b.Len()
+		fmt.Fprintf(b, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := b.Len()
+	fmt.Fprintf(b, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(b, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(b, "trailer\n<< /Size %d /Root 1 0 R /Info 4 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return b.Bytes()
+}
+
+// pdfString will encode the text in Windows-1252 as a literal string of PDF, escaping the delimiters.
+func pdfString(s string) string {
+	s, _ = encoding.ReplaceUnsupported(charmap.Windows1252.NewEncoder()).String(s) // unsupported runes become SUB
+	return strings.NewReplacer(`\`, `\\`, `(`, `\(`, `)`, `\)`, "\x1a", "?").Replace(s)
+}
@@ -0,0 +1,39 @@
+package pkg_test
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gunawanwijaya/loan-svc/pkg"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPDF(t *testing.T) {
+	text := "LOAN AGREEMENT (BORROWER)\nAPR : 12.00%\n"
+	b := pkg.PDF("agreement", text)
+	require.True(t, bytes.HasPrefix(b, []byte("%PDF-1.4\n")))
+	require.True(t, bytes.HasSuffix(b, []byte("%%EOF\n")))
+	require.Equal(t, b, pkg.PDF("agreement", text)) // deterministic
+	require.Contains(t, string(b), `(LOAN AGREEMENT \(BORROWER\)) '`)
+	require.Contains(t, string(b), "/Count 1")
+
+	// each object is found at its offset of the cross-reference table
+	xref := bytes.LastIndex(b, []byte("startxref\n"))
+	offset, err := strconv.Atoi(strings.Fields(string(b[xref:]))[1])
+	require.NoError(t, err)
+	entries := strings.Split(string(b[offset:]), "\n")[3:]
+	for i := 1; i <= 6; i++ {
+		at, err := strconv.Atoi(strings.Fields(entries[i-1])[0])
+		require.NoError(t, err)
+		require.True(t, bytes.HasPrefix(b[at:], []byte(strconv.Itoa(i)+" 0 obj\n")))
+	}
+
+	// wrapped & paginated
+	long := strings.Repeat(strings.Repeat("x", 100)+"\n", 70)
+	require.Contains(t, string(pkg.PDF("schedule", long)), "/Count 3")
+
+	// outside of Windows-1252
+	require.Contains(t, string(pkg.PDF("", "Rp 1.000 — €5 ✓")), "(Rp 1.000 \x97 \x805 ?) '")
+}
@@ -0,0 +1,87 @@
+package pkg
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+type ValidatePaymentReferenceError struct {
+	Prefix string
+	Length int
+}
+
+func (x *ValidatePaymentReferenceError) Error() string {
+	return fmt.Sprintf("reference: validate: invalid prefix [%s] of length [%d]", x.Prefix, x.Length)
+}
+
+// PaymentReference is the generator of numeric payment references, a.k.a. virtual account numbers, made of the bank
+// prefix, a random body & 2 check digits of ISO 7064 MOD 97-10 - https://en.wikipedia.org/wiki/ISO_7064
+type PaymentReference struct {
+	Prefix string `json:"prefix,omitempty"` // digits assigned by the bank, may be empty
+	Length int    `json:"length,omitempty"` // total digits including prefix & check digits, default to 16
+}
+
+const minPaymentReferenceBody = 8
+
+// Validate will default an empty length to 16 digits, the random body should be at least 8 digits.
+func (x PaymentReference) Validate(ctx context.Context) (_ PaymentReference, err error) {
+	if x.Length == 0 {
+		x.Length = 16
+	}
+	if !isDigits(x.Prefix) || x.Length-len(x.Prefix)-2 < minPaymentReferenceBody {
+		return x, &ValidatePaymentReferenceError{Prefix: x.Prefix, Length: x.Length}
+	}
+	return x, nil
+}
+
+// Generate will return a new reference, uniqueness is not guaranteed, a reference taken by another loan is rejected
+// by the storage & should be generated again.
+func (x PaymentReference) Generate() (string, error) {
+	n := x.Length - len(x.Prefix) - 2
+	body, err := rand.Int(rand.Reader, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil))
+	if err != nil {
+		return "", err
+	}
+	s := x.Prefix + fmt.Sprintf("%0*d", n, body)
+	return s + fmt.Sprintf("%02d", 98-mod97(s+"00")), nil
+}
+
+// Issued will check the reference could have been generated by x, i.e. of the length & prefix besides its check
+// digits, rejecting the legacy references given before the generator was configured.
+func (x PaymentReference) Issued(reference string) bool {
+	return len(reference) == x.Length && strings.HasPrefix(reference, x.Prefix) && ValidPaymentReference(reference)
+}
+
+// ValidPaymentReference will check the digits of reference against its check digits.
+func ValidPaymentReference(reference string) bool {
+	return len(reference) > 2 && isDigits(reference) && mod97(reference) == 1
+}
+
+// FindPaymentReference will find the first valid reference of at least minLength digits within the free text.
+func FindPaymentReference(text string, minLength int) string {
+	for _, token := range strings.FieldsFunc(text, func(r rune) bool { return r < '0' || r > '9' }) {
+		if len(token) >= minLength && ValidPaymentReference(token) {
+			return token
+		}
+	}
+	return ""
+}
+
+func mod97(digits string) (r int) {
+	for _, c := range digits {
+		r = (r*10 + int(c-'0')) % 97
+	}
+	return r
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
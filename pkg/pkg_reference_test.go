@@ -0,0 +1,51 @@
+package pkg_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gunawanwijaya/loan-svc/pkg"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPaymentReference(t *testing.T) {
+	ctx := context.Background()
+	var errValidate *pkg.ValidatePaymentReferenceError
+
+	_, err := pkg.PaymentReference{Prefix: "88a"}.Validate(ctx)
+	_ = err.Error()
+	require.ErrorAs(t, err, &errValidate)
+	_, err = pkg.PaymentReference{Prefix: "8808", Length: 12}.Validate(ctx)
+	require.ErrorAs(t, err, &errValidate)
+
+	ref, err := pkg.PaymentReference{Prefix: "8808"}.Validate(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 16, ref.Length)
+
+	for range 100 {
+		s, err := ref.Generate()
+		require.NoError(t, err)
+		require.Len(t, s, 16)
+		require.Equal(t, "8808", s[:4])
+		require.True(t, pkg.ValidPaymentReference(s))
+		require.True(t, ref.Issued(s))
+
+		// every single digit error is detected
+		for i := range s {
+			b := []byte(s)
+			b[i] = '0' + (b[i]-'0'+1)%10
+			require.False(t, pkg.ValidPaymentReference(string(b)))
+		}
+		require.Equal(t, s, pkg.FindPaymentReference("Repayment 1000 VA "+s+" thanks", 10))
+	}
+
+	require.True(t, pkg.ValidPaymentReference("8808123456789072"))
+	require.False(t, pkg.ValidPaymentReference("8808123456789027")) // transposed check digits
+	require.Equal(t, "", pkg.FindPaymentReference("Payment #3 of 12", 10))
+
+	// legacy reference of the migration, valid check digits without the prefix
+	require.True(t, pkg.ValidPaymentReference("000000000195"))
+	require.False(t, ref.Issued("000000000195"))
+	require.True(t, pkg.ValidPaymentReference("9909123456789021"))
+	require.False(t, ref.Issued("9909123456789021")) // prefix of another bank
+}